@@ -17,7 +17,8 @@ extern "C"
 * the specific board setting are set a the end of this file
 ********************************************************************************/
 
-    #define BOARD_YARDFORCE500 1
+    #define BOARD_YARDFORCE500_VARIANT_ORIG 1
+
 
 
 
@@ -29,43 +30,58 @@ extern "C"
 /* Publish Mowgli Topics */
 //#define ROS_PUBLISH_MOWGLI
 
+#define MAX_MPS 0.6          // Allow maximum speed of 1.0 m/s
+#define PWM_PER_MPS 300.0 // PWM value of 300 means 1 m/s bot speed so we divide by 4 to have correct robot speed but still progressive speed
+#define TICKS_PER_M 300 // Motor Encoder ticks per meter
+#define WHEEL_BASE  0.325        // The distance between the center of the wheels in meters
+
 /* different type of panel are possible */
 #define PANEL_TYPE_NONE 0
 #define PANEL_TYPE_YARDFORCE_500_CLASSIC 1
 #define PANEL_TYPE_YARDFORCE_LUV1000RI 2
 #define PANEL_TYPE_YARDFORCE_900_ECO 3
 
-#if defined(BOARD_YARDFORCE500)
-
+#if BOARD_YARDFORCE500_VARIANT_ORIG
+///////////////////////////
+// Yardforce 500 CLASSIC //
+///////////////////////////
+#define BLADEMOTOR_USART_INSTANCE USART3
+#define VALID_BOARD_DEFINED 1
 #define PANEL_TYPE PANEL_TYPE_YARDFORCE_500_CLASSIC
 #define BLADEMOTOR_LENGTH_RECEIVED_MSG 16
 #define DEBUG_TYPE DEBUG_TYPE_UART
-
-#define MAX_MPS 0.6          // Allow maximum speed of 1.0 m/s
-#define PWM_PER_MPS 300.0 // PWM value of 300 means 1 m/s bot speed so we divide by 4 to have correct robot speed but still progressive speed
-#define TICKS_PER_M 300.0 // Motor Encoder ticks per meter
-#define WHEEL_BASE  0.325        // The distance between the center of the wheels in meters
-
 #define OPTION_ULTRASONIC 0
 #define OPTION_BUMPER 0
+#define BOARD_HAS_MASTER_USART 1
+
+#elif BOARD_YARDFORCE500_VARIANT_B
+/////////////////////
+// Yardforce 500 B //
+/////////////////////
+// TODO: Are those options valid?
+#define BLADEMOTOR_USART_INSTANCE USART6
+#define VALID_BOARD_DEFINED 1
+#define PANEL_TYPE PANEL_TYPE_YARDFORCE_500_CLASSIC
+#define BLADEMOTOR_LENGTH_RECEIVED_MSG 16
+#define DEBUG_TYPE DEBUG_TYPE_SWO
+#define OPTION_ULTRASONIC 0
+#define OPTION_BUMPER 0
+#define BOARD_HAS_MASTER_USART 1
+
+/////////////////////////
+// Yardforce LUV1000Ri //
+/////////////////////////
 #elif defined(BOARD_LUV1000RI)
+#define VALID_BOARD_DEFINED 1
 #define PANEL_TYPE PANEL_TYPE_YARDFORCE_500_CLASSIC
 #define BLADEMOTOR_LENGTH_RECEIVED_MSG 14
-
 #define DEBUG_TYPE 0
-
 #define OPTION_ULTRASONIC 1
 #define OPTION_BUMPER 0
+#define BOARD_HAS_MASTER_USART 0
+#endif
 
-#define MAX_MPS 0.6          // Allow maximum speed of 1.0 m/s
-#define PWM_PER_MPS 300.0 // PWM value of 300 means 1 m/s bot speed so we divide by 4 to have correct robot speed but still progressive speed
-#define TICKS_PER_M 300.0 // Motor Encoder ticks per meter
-#define WHEEL_BASE 0.285   // The distance between the center of the wheels in meters
-
-#else
 
-#error "No board selection"
-#endif
 
 
 
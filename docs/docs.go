@@ -16,6 +16,156 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/alerts": {
+            "get": {
+                "description": "list the active alerts, an alert stays active until its rule no longer holds even if it is acknowledged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "list the active alerts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.Alert"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts/rules": {
+            "get": {
+                "description": "list the alert rules",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "list the alert rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.AlertRule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "validate and save the alert rules, the alerts of removed rules are cleared",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "replace the alert rules",
+                "parameters": [
+                    {
+                        "description": "alert rules",
+                        "name": "rules",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.AlertRule"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/alerts/stream": {
+            "get": {
+                "description": "websocket sending an event each time an alert is raised, acknowledged or cleared, messages are base64 encoded JSON types.AlertEvent",
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "stream the alert events",
+                "responses": {}
+            }
+        },
+        "/alerts/{id}/ack": {
+            "post": {
+                "description": "acknowledge an active alert",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "acknowledge an alert",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "alert id, the id of its rule",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/config/envs": {
             "get": {
                 "description": "get config env from backend",
@@ -45,7 +195,7 @@ const docTemplate = `{
         },
         "/config/keys/get": {
             "post": {
-                "description": "get config from backend",
+                "description": "get config from backend, the system.* keys that are not on the settings page require the admin token",
                 "produces": [
                     "application/json"
                 ],
@@ -77,6 +227,18 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -88,7 +250,7 @@ const docTemplate = `{
         },
         "/config/keys/set": {
             "post": {
-                "description": "set config to backend",
+                "description": "set config to backend, the system.* keys that are not on the settings page require the admin token",
                 "produces": [
                     "application/json"
                 ],
@@ -120,6 +282,18 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -155,38 +329,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/containers/{containerId}/logs": {
+        "/containers/stats": {
             "get": {
-                "description": "get container logs",
+                "description": "get the CPU, memory, network and block I/O usage, the health and the uptime of the openmower and gui containers",
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
                     "containers"
                 ],
-                "summary": "get container logs",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "container id",
-                        "name": "containerId",
-                        "in": "path",
-                        "required": true
+                "summary": "get the resource usage of the OpenMower containers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ContainerStatsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
+                }
+            }
+        },
+        "/containers/stats/stream": {
+            "get": {
+                "description": "websocket sending the usage of the openmower and gui containers every second, messages are base64 encoded JSON ContainerStats",
+                "tags": [
+                    "containers"
                 ],
+                "summary": "stream the resource usage of the OpenMower containers",
                 "responses": {}
             }
         },
-        "/containers/{containerId}/{command}": {
-            "post": {
-                "description": "execute a command on a container",
-                "produces": [
-                    "application/json"
-                ],
+        "/containers/{containerId}/exec": {
+            "get": {
+                "description": "websocket running a command with a TTY in the container, /bin/bash by default. The messages sent are the base64 encoded output, the messages received are JSON objects: {\"type\":\"stdin\",\"data\":\"\u003cbase64\u003e\"} or {\"type\":\"resize\",\"rows\":24,\"cols\":80}. The connection is closed with the reason \"exit \u003ccode\u003e\" when the command exits. Restricted to the admin token, sent as a bearer token or, from a browser, in the subprotocols \"bearer\" and the token.",
                 "tags": [
                     "containers"
                 ],
-                "summary": "execute a command on a container",
+                "summary": "open a shell in a container",
                 "parameters": [
                     {
                         "type": "string",
@@ -196,18 +381,39 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "command to execute (start/stop/restart)",
-                        "name": "command",
-                        "in": "path",
-                        "required": true
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "command and arguments",
+                        "name": "cmd",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "initial rows of the TTY",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "initial columns of the TTY",
+                        "name": "cols",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/api.OkResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -219,99 +425,197 @@ const docTemplate = `{
                 }
             }
         },
-        "/openmower/call/{command}": {
-            "post": {
-                "description": "call a service",
-                "consumes": [
-                    "application/json"
-                ],
+        "/containers/{containerId}/logs": {
+            "get": {
+                "description": "websocket sending the last lines of the container logs then the new ones, messages are base64 encoded lines",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "openmower"
+                    "containers"
                 ],
-                "summary": "call a service",
+                "summary": "get container logs",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "command to call, could be: mower_start, mower_home, mower_s1, mower_s2, emergency, mow",
-                        "name": "command",
+                        "description": "container id",
+                        "name": "containerId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "request body",
-                        "name": "CallReq",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                        "type": "string",
+                        "description": "only the lines since this RFC 3339 date, unix timestamp or duration, e.g. 10m",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the lines until this RFC 3339 date, unix timestamp or duration",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "number of lines sent first or all, 100 by default",
+                        "name": "tail",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "stdout or stderr, both by default",
+                        "name": "stream",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "prefix the lines with their timestamp",
+                        "name": "timestamps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "regular expression the lines must match",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum level of the lines, e.g. warning",
+                        "name": "level",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/api.OkResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/openmower/map": {
-            "delete": {
-                "description": "clear the map",
-                "consumes": [
-                    "application/json"
-                ],
+        "/containers/{containerId}/logs/download": {
+            "get": {
+                "description": "download the container logs matching the query as a text or gzip file",
                 "produces": [
-                    "application/json"
+                    "text/plain",
+                    "application/gzip"
                 ],
                 "tags": [
-                    "openmower"
+                    "containers"
                 ],
-                "summary": "clear the map",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/api.OkResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
+                "summary": "download container logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "container id",
+                        "name": "containerId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the lines since this RFC 3339 date, unix timestamp or duration, e.g. 10m",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the lines until this RFC 3339 date, unix timestamp or duration",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "number of last lines or all, all by default",
+                        "name": "tail",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "stdout or stderr, both by default",
+                        "name": "stream",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "prefix the lines with their timestamp",
+                        "name": "timestamps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "regular expression the lines must match",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum level of the lines, e.g. warning",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "text or gzip, text by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "clear the map and insert multiple areas",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/containers/{containerId}/update": {
+            "get": {
+                "description": "compare the digest of the image tag of the container in its registry with the digest of the local image",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "openmower"
+                    "containers"
+                ],
+                "summary": "check if the image of a container has an update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "container id",
+                        "name": "containerId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "clear the map and insert areas",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.OkResponse"
+                            "$ref": "#/definitions/types.ImageUpdateStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -321,37 +625,30 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/openmower/map/area/add": {
+            },
             "post": {
-                "description": "add a map area",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "pull the image tag of the container and recreate it, the previous container is restored if the new one does not become healthy. The progress events are JSON types.ImageUpdateEvent, the end event is a JSON types.ImageUpdateResult. The GUI container cannot be updated this way.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "openmower"
+                    "containers"
                 ],
-                "summary": "add a map area",
+                "summary": "update the image of a container",
                 "parameters": [
                     {
-                        "description": "request body",
-                        "name": "CallReq",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/mower_map.AddMowingAreaSrvReq"
-                        }
+                        "type": "string",
+                        "description": "container id",
+                        "name": "containerId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.OkResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -363,28 +660,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/openmower/map/docking": {
+        "/containers/{containerId}/{command}": {
             "post": {
-                "description": "set the docking point",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "execute a command on a container",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "openmower"
+                    "containers"
                 ],
-                "summary": "set the docking point",
+                "summary": "execute a command on a container",
                 "parameters": [
                     {
-                        "description": "request body",
-                        "name": "CallReq",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/mower_map.SetDockingPointSrvReq"
-                        }
+                        "type": "string",
+                        "description": "container id",
+                        "name": "containerId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "command to execute (start/stop/restart)",
+                        "name": "command",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -394,6 +693,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.OkResponse"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -403,59 +714,127 @@ const docTemplate = `{
                 }
             }
         },
-        "/openmower/publish/{topic}": {
+        "/monitoring/actions": {
             "get": {
-                "description": "publish to a topic",
+                "description": "list the actions registered by the nodes, only enabled actions can be triggered. The list is empty unless\nsystem.monitoring.registerActions is enabled, the GUI then provides /xbot/register_actions in place of\nxbot_monitoring and only knows the actions registered after it started",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "openmower"
+                    "monitoring"
                 ],
-                "summary": "publish to a topic",
+                "summary": "list the actions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.Action"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/monitoring/actions/{id}": {
+            "post": {
+                "description": "trigger an enabled action by its id, e.g. mower_logic:idle/start_mowing",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "monitoring"
+                ],
+                "summary": "trigger an action",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "topic to publish to, could be: joy",
-                        "name": "topic",
+                        "description": "action id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
-                "responses": {}
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
             }
         },
-        "/openmower/subscribe/{topic}": {
+        "/monitoring/sensors": {
             "get": {
-                "description": "subscribe to a topic",
+                "description": "list the sensors published by xbot_monitoring with their unit, critical thresholds and last value",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "openmower"
+                    "monitoring"
                 ],
-                "summary": "subscribe to a topic",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "topic to subscribe to, could be: diagnostics, status, gps, imu, ticks, highLevelStatus",
-                        "name": "topic",
-                        "in": "path",
-                        "required": true
+                "summary": "list the sensors",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.Sensor"
+                            }
+                        }
                     }
+                }
+            }
+        },
+        "/monitoring/sensors/stream": {
+            "get": {
+                "description": "websocket sending the current sensors then each sensor update, messages are base64 encoded JSON types.Sensor",
+                "tags": [
+                    "monitoring"
                 ],
+                "summary": "stream the sensor values",
                 "responses": {}
             }
         },
-        "/settings": {
+        "/notifications/channels": {
             "get": {
-                "description": "returns a JSON object with the settings",
+                "description": "list the webhook, smtp, ntfy and gotify channels with the events routed to them, the passwords and tokens are replaced with ********",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "settings"
+                    "notifications"
                 ],
-                "summary": "returns a JSON object with the settings",
+                "summary": "list the notification channels",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.GetSettingsResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.NotificationChannel"
+                            }
                         }
                     },
                     "500": {
@@ -466,8 +845,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "description": "saves the settings to the mower_config.sh file",
+            "put": {
+                "description": "validate and save the notification channels. Events routed to a channel are alert.raised, alert.cleared, state.changed, mowing.started, mowing.finished and test, all events are routed when none is set. A password or token set to ******** keeps the saved one. Restricted to the admin token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -475,18 +854,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "settings"
+                    "notifications"
                 ],
-                "summary": "saves the settings to the mower_config.sh file",
+                "summary": "replace the notification channels",
                 "parameters": [
                     {
-                        "description": "settings",
-                        "name": "settings",
+                        "description": "notification channels",
+                        "name": "channels",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.NotificationChannel"
+                            }
                         }
                     }
                 ],
@@ -497,6 +878,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.OkResponse"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -506,59 +905,108 @@ const docTemplate = `{
                 }
             }
         },
-        "/setup/flashBoard": {
+        "/notifications/channels/{id}/test": {
             "post": {
-                "description": "flash the mower board with the given config",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "send a test event to the channel, even if it is disabled, and return the delivery. Restricted to the admin token.",
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "setup"
+                    "notifications"
                 ],
-                "summary": "flash the mower board with the given config",
+                "summary": "send a test notification",
                 "parameters": [
                     {
-                        "description": "config",
-                        "name": "settings",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.FirmwareConfig"
-                        }
+                        "type": "string",
+                        "description": "channel id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.OkResponse"
+                            "$ref": "#/definitions/types.NotificationDelivery"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    }
-                }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
             }
         },
-        "/setup/flashGPS": {
+        "/notifications/deliveries": {
+            "get": {
+                "description": "list the last notification deliveries, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "list the last deliveries",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.NotificationDelivery"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/openmower/call/{command}": {
             "post": {
-                "description": "flash the gps configuration",
+                "description": "call a service",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "setup"
+                    "openmower"
+                ],
+                "summary": "call a service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "command to call, could be: mower_start, mower_home, mower_s1, mower_s2, emergency, mow",
+                        "name": "command",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "CallReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
                 ],
-                "summary": "flash the gps configuration",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -574,276 +1022,2510 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.Container": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "labels": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+        },
+        "/openmower/map": {
+            "put": {
+                "description": "clear the map and insert areas",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "openmower"
+                ],
+                "summary": "clear the map and insert areas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
-                },
-                "names": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+                }
+            },
+            "delete": {
+                "description": "clear the map",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "openmower"
+                ],
+                "summary": "clear the map",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
-                },
-                "state": {
-                    "type": "string"
                 }
             }
         },
-        "api.ContainerListResponse": {
-            "type": "object",
-            "properties": {
-                "containers": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.Container"
+        "/openmower/map/area/add": {
+            "post": {
+                "description": "add a map area",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "openmower"
+                ],
+                "summary": "add a map area",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "CallReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mower_map.AddMowingAreaSrvReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/openmower/map/docking": {
+            "post": {
+                "description": "set the docking point",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "openmower"
+                ],
+                "summary": "set the docking point",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "CallReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mower_map.SetDockingPointSrvReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/openmower/publish/{topic}": {
+            "get": {
+                "description": "publish joystick velocities to the mower. Only one driver is allowed at a time and only in the configured states, velocities are clamped to the configured limits and the mower is stopped if no message is received before the watchdog timeout. The driver is pinged every watchdog timeout and disconnected when it answers neither with a message nor with a pong for two timeouts.",
+                "tags": [
+                    "openmower"
+                ],
+                "summary": "publish to a topic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "topic to publish to, could be: joy",
+                        "name": "topic",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "name of the driver, defaults to the client IP",
+                        "name": "driver",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "api.ErrorResponse": {
+        "/openmower/subscribe/{topic}": {
+            "get": {
+                "description": "subscribe to a topic",
+                "tags": [
+                    "openmower"
+                ],
+                "summary": "subscribe to a topic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "topic to subscribe to, could be: diagnostics, status, gps, imu, ticks, highLevelStatus, robotState",
+                        "name": "topic",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/openmower/teleop": {
+            "get": {
+                "description": "get the active driver, the mower state and the configured speed limits and watchdog timeout",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "openmower"
+                ],
+                "summary": "get the teleop status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.TeleopStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/recordings": {
+            "get": {
+                "description": "list the recorded bag files, including the running one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recordings"
+                ],
+                "summary": "list the recordings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.Recording"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recordings/start": {
+            "post": {
+                "description": "record the given topics (or the configured ones if empty) into a bag file",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recordings"
+                ],
+                "summary": "start a recording",
+                "parameters": [
+                    {
+                        "description": "recording name and topics",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.StartRecordingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.Recording"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recordings/stop": {
+            "post": {
+                "description": "stop the running recording",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recordings"
+                ],
+                "summary": "stop the running recording",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.Recording"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recordings/{name}": {
+            "get": {
+                "description": "download a recording as a bag file",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "recordings"
+                ],
+                "summary": "download a recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "recording name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete a recording",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recordings"
+                ],
+                "summary": "delete a recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "recording name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/replay": {
+            "get": {
+                "description": "get the loaded recording, playback position and speed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "replay"
+                ],
+                "summary": "get the replay status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ReplayStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/replay/{command}": {
+            "post": {
+                "description": "load a recording and replay it through the subscribe endpoints instead of live data. load needs a name, seek a position in seconds and speed a speed factor. stop switches back to live data.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "replay"
+                ],
+                "summary": "control the replay",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "command to execute (load/play/pause/seek/speed/stop)",
+                        "name": "command",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "command parameters",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReplayCommandRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ReplayStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/call": {
+            "get": {
+                "description": "list the services of the service registry, they can be called with /ros/call/{service}",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "list the services that can be called",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/call/{service}": {
+            "post": {
+                "description": "decode the JSON body into the request type of the service, call it and return the response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "call a ROS service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "service name, e.g. /mower_map_service/get_docking_point",
+                        "name": "service",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "service request",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/graph": {
+            "get": {
+                "description": "get nodes (with ping latency), topics and services known by the ROS master, and the expected nodes that are missing",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "get the ROS graph",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.RosGraphResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/graph/nodes": {
+            "get": {
+                "description": "list ROS nodes with their ping latency, expected nodes that are not registered are returned with missing=true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "list ROS nodes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.RosNodeStatus"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/graph/services": {
+            "get": {
+                "description": "list ROS services with their type and providers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "list ROS services",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.RosService"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/graph/topics": {
+            "get": {
+                "description": "list ROS topics with their type, publishers and subscribers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "list ROS topics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.RosTopic"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/parameters/{node}": {
+            "get": {
+                "description": "get the schema (type, min, max, default, enum values) and current value of each parameter of a node",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "get the dynamic_reconfigure parameters of a node",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "node name, e.g. mower_logic",
+                        "name": "node",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ReconfigureParameter"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "validate the values against the parameters schema and set them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "set dynamic_reconfigure parameters of a node",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "node name, e.g. mower_logic",
+                        "name": "node",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "parameter values",
+                        "name": "values",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ReconfigureParameter"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/publish/{topic}": {
+            "get": {
+                "description": "websocket receiving JSON messages, each message is decoded into the message type of the topic and published. Messages exceeding the rate limit of the topic are dropped.",
+                "tags": [
+                    "ros"
+                ],
+                "summary": "publish a stream of messages to a topic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "topic, e.g. /mower/status",
+                        "name": "topic",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "decode the JSON message into the message type of the topic and publish it, the topic must be whitelisted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "publish a message to a topic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "topic, e.g. /mower/status",
+                        "name": "topic",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ros/publishers": {
+            "get": {
+                "description": "list the whitelisted topics with their message type and maximum publish rate in messages per second (0 if unlimited)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ros"
+                ],
+                "summary": "list the topics that can be published to",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.PublishTopic"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/settings": {
+            "get": {
+                "description": "returns a JSON object with the settings",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "returns a JSON object with the settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.GetSettingsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "saves the settings to the mower_config.sh file, the changed known settings and the settings depending on them are checked against the settings schema and only the changed keys are rewritten and the previous content is saved in the settings history and the comments, order and quoting of the file are kept. Booleans are written True or False and a null value removes the key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "saves the settings to the mower_config.sh file",
+                "parameters": [
+                    {
+                        "description": "settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "author of the change saved in the settings history, defaults to the client IP",
+                        "name": "author",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/settings/revisions": {
+            "get": {
+                "description": "list the previous contents of the mower_config.sh file saved by each change, newest first, with the author, the time and the keys of the change",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "list the previous revisions of the settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SettingsRevisionsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/settings/revisions/diff": {
+            "get": {
+                "description": "returns the settings added, removed or changed from a revision to another",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "diff two revisions of the settings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "revision id or current",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "revision id or current, defaults to current",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SettingsDiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/settings/revisions/{id}/restore": {
+            "post": {
+                "description": "writes a previous content of the mower_config.sh file, the replaced content is saved as a new revision. The openmower container is restarted when restart is true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "restore a revision of the settings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "revision id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "restart the openmower container",
+                        "name": "restart",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "author of the change saved in the settings history, defaults to the client IP",
+                        "name": "author",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/settings/schema": {
+            "get": {
+                "description": "returns the type, range, options and dependencies of the known settings of the mower_config.sh file, the settings are checked against it when they are saved",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "settings"
+                ],
+                "summary": "returns the schema of the known settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SettingsSchemaResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/setup/flashBoard": {
+            "post": {
+                "description": "flash the mower board with the given config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "setup"
+                ],
+                "summary": "flash the mower board with the given config",
+                "parameters": [
+                    {
+                        "description": "config",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.FirmwareConfig"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/setup/flashGPS": {
+            "post": {
+                "description": "flash the gps configuration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "setup"
+                ],
+                "summary": "flash the gps configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/backup": {
+            "post": {
+                "description": "download a tar.gz archive of the GUI DB keys, including the firmware config and the HomeKit identity, the mower_config.sh file and the map. The archive is encrypted when a passphrase is given. Requires the admin token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "download a backup of the GUI",
+                "parameters": [
+                    {
+                        "description": "passphrase encrypting the archive",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.BackupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/logs": {
+            "get": {
+                "description": "list the entries kept in the log buffer, from the oldest to the most recent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "list the recent log entries of the GUI",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only the entries of this subsystem",
+                        "name": "subsystem",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum level, e.g. warning",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum number of most recent entries",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.LogEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/logs/levels": {
+            "get": {
+                "description": "list the log level of each subsystem of the GUI",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "list the log level of each subsystem",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "validate, save and apply the log level overrides, the subsystems without an override use the system.logs.level setting",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "override the log level of subsystems",
+                "parameters": [
+                    {
+                        "description": "level of each overridden subsystem",
+                        "name": "levels",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.OkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/logs/stream": {
+            "get": {
+                "description": "websocket sending the most recent entries (100 unless limit is set) then each new entry, the new entries are dropped when the client doesn't keep up. Messages are base64 encoded JSON types.LogEntry. Restricted to the admin token, sent as a bearer token or, from a browser, in the subprotocols \"bearer\" and the token.",
+                "tags": [
+                    "system"
+                ],
+                "summary": "stream the log entries of the GUI",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only the entries of this subsystem",
+                        "name": "subsystem",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum level, e.g. warning",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of recent entries sent first",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/restore": {
+            "post": {
+                "description": "validates then applies an archive of the backup route: the DB keys of the archive are set, the mower_config.sh file is replaced, its previous content is kept in the settings history, and the map is replaced. If a part fails, the parts already restored are rolled back and the error tells which ones. The GUI must be restarted to use the restored HomeKit identity. Requires the admin token.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "restore a backup of the GUI",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "backup archive",
+                        "name": "archive",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "passphrase of an encrypted archive",
+                        "name": "passphrase",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.BackupManifest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BackupRequest": {
+            "type": "object",
+            "properties": {
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Container": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ContainerListResponse": {
+            "type": "object",
+            "properties": {
+                "containers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Container"
+                    }
+                }
+            }
+        },
+        "api.ContainerStats": {
+            "type": "object",
+            "properties": {
+                "app": {
+                    "type": "string"
+                },
+                "blockRead": {
+                    "type": "integer"
+                },
+                "blockWrite": {
+                    "type": "integer"
+                },
+                "cpuPercent": {
+                    "type": "number"
+                },
+                "health": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "memoryLimit": {
+                    "type": "integer"
+                },
+                "memoryPercent": {
+                    "type": "number"
+                },
+                "memoryUsage": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "networkRx": {
+                    "type": "integer"
+                },
+                "networkTx": {
+                    "type": "integer"
+                },
+                "restartCount": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "uptimeSeconds": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.ContainerStatsResponse": {
+            "type": "object",
+            "properties": {
+                "stats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ContainerStats"
+                    }
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.GetConfigResponse": {
+            "type": "object",
+            "properties": {
+                "tileUri": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.GetSettingsResponse": {
+            "type": "object",
+            "properties": {
+                "settings": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.OkResponse": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ReplayCommandRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "number"
+                },
+                "speed": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.RosGraphResponse": {
+            "type": "object",
+            "properties": {
+                "missingNodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RosNodeStatus"
+                    }
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.RosService"
+                    }
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.RosTopic"
+                    }
+                }
+            }
+        },
+        "api.RosNodeStatus": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "alive": {
+                    "type": "boolean"
+                },
+                "missing": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pingMs": {
+                    "type": "number"
+                },
+                "publications": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.SettingsDiffResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SettingChange"
+                    }
+                }
+            }
+        },
+        "api.SettingsRevisionsResponse": {
+            "type": "object",
+            "properties": {
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SettingsRevision"
+                    }
+                }
+            }
+        },
+        "api.SettingsSchemaResponse": {
+            "type": "object",
+            "properties": {
+                "settings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SettingSchema"
+                    }
+                }
+            }
+        },
+        "api.StartRecordingRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "geometry_msgs.Point": {
+            "type": "object",
+            "properties": {
+                "msg.Package": {
+                    "type": "integer"
+                },
+                "x": {
+                    "type": "number"
+                },
+                "y": {
+                    "type": "number"
+                },
+                "z": {
+                    "type": "number"
+                }
+            }
+        },
+        "geometry_msgs.Point32": {
+            "type": "object",
+            "properties": {
+                "msg.Package": {
+                    "type": "integer"
+                },
+                "x": {
+                    "type": "number"
+                },
+                "y": {
+                    "type": "number"
+                },
+                "z": {
+                    "type": "number"
+                }
+            }
+        },
+        "geometry_msgs.Polygon": {
+            "type": "object",
+            "properties": {
+                "msg.Package": {
+                    "type": "integer"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/geometry_msgs.Point32"
+                    }
+                }
+            }
+        },
+        "geometry_msgs.Pose": {
+            "type": "object",
+            "properties": {
+                "msg.Package": {
+                    "type": "integer"
+                },
+                "orientation": {
+                    "$ref": "#/definitions/geometry_msgs.Quaternion"
+                },
+                "position": {
+                    "$ref": "#/definitions/geometry_msgs.Point"
+                }
+            }
+        },
+        "geometry_msgs.Quaternion": {
+            "type": "object",
+            "properties": {
+                "msg.Package": {
+                    "type": "integer"
+                },
+                "w": {
+                    "type": "number"
+                },
+                "x": {
+                    "type": "number"
+                },
+                "y": {
+                    "type": "number"
+                },
+                "z": {
+                    "type": "number"
+                }
+            }
+        },
+        "mower_map.AddMowingAreaSrvReq": {
+            "type": "object",
+            "properties": {
+                "area": {
+                    "$ref": "#/definitions/mower_map.MapArea"
+                },
+                "isNavigationArea": {
+                    "type": "boolean"
+                },
+                "msg.Package": {
+                    "type": "integer"
+                }
+            }
+        },
+        "mower_map.MapArea": {
+            "type": "object",
+            "properties": {
+                "area": {
+                    "$ref": "#/definitions/geometry_msgs.Polygon"
+                },
+                "msg.Package": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "obstacles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/geometry_msgs.Polygon"
+                    }
+                }
+            }
+        },
+        "mower_map.SetDockingPointSrvReq": {
+            "type": "object",
+            "properties": {
+                "dockingPose": {
+                    "$ref": "#/definitions/geometry_msgs.Pose"
+                },
+                "msg.Package": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.Action": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "node": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.Alert": {
+            "type": "object",
+            "properties": {
+                "acknowledged": {
+                    "type": "boolean"
+                },
+                "acknowledgedAt": {
+                    "type": "string"
+                },
+                "clearedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "raisedAt": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "value": {}
+            }
+        },
+        "types.AlertRule": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "for": {
+                    "type": "number"
+                },
+                "hysteresis": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "states": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "value": {}
+            }
+        },
+        "types.BackupManifest": {
+            "type": "object",
+            "properties": {
+                "contents": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "encrypted": {
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.FirmwareConfig": {
+            "type": "object",
+            "properties": {
+                "batChargeCutoffVoltage": {
+                    "type": "number"
+                },
+                "boardType": {
+                    "type": "string"
+                },
+                "bothWheelsLiftEmergencyMillis": {
+                    "type": "integer"
+                },
+                "branch": {
+                    "type": "string"
+                },
+                "debugType": {
+                    "type": "string"
+                },
+                "disableEmergency": {
+                    "type": "boolean"
+                },
+                "externalImuAcceleration": {
+                    "type": "boolean"
+                },
+                "externalImuAngular": {
+                    "type": "boolean"
+                },
+                "file": {
+                    "type": "string"
+                },
+                "limitVoltage150MA": {
+                    "type": "number"
+                },
+                "masterJ18": {
+                    "type": "boolean"
+                },
+                "maxChargeCurrent": {
+                    "type": "number"
+                },
+                "maxChargeVoltage": {
+                    "type": "number"
+                },
+                "maxMps": {
+                    "type": "number"
+                },
+                "oneWheelLiftEmergencyMillis": {
+                    "type": "integer"
+                },
+                "panelType": {
+                    "type": "string"
+                },
+                "perimeterWire": {
+                    "type": "boolean"
+                },
+                "playButtonClearEmergencyMillis": {
+                    "type": "integer"
+                },
+                "repository": {
+                    "type": "string"
+                },
+                "stopButtonEmergencyMillis": {
+                    "type": "integer"
+                },
+                "tickPerM": {
+                    "type": "number"
+                },
+                "tiltEmergencyMillis": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "wheelBase": {
+                    "type": "number"
+                }
+            }
+        },
+        "types.ImageUpdateStatus": {
+            "type": "object",
+            "properties": {
+                "containerId": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "localDigest": {
+                    "type": "string"
+                },
+                "remoteDigest": {
+                    "type": "string"
+                },
+                "updateAvailable": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.LogEntry": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "level": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "subsystem": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.NotificationChannel": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body is a text/template rendered with the event, defaults to the event as JSON",
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "host": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "Method of the webhook, defaults to POST",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "description": "Token is the Gotify application token or the ntfy access token",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL of the webhook, the ntfy topic or the Gotify server",
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.NotificationDelivery": {
             "type": "object",
             "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "channel": {
+                    "type": "string"
+                },
                 "error": {
                     "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "time": {
+                    "type": "string"
                 }
             }
         },
-        "api.GetConfigResponse": {
+        "types.PublishTopic": {
             "type": "object",
             "properties": {
-                "tileUri": {
+                "rate": {
+                    "type": "number"
+                },
+                "topic": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "api.GetSettingsResponse": {
+        "types.ReconfigureEnumValue": {
             "type": "object",
             "properties": {
-                "settings": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
-                }
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "value": {}
             }
         },
-        "api.OkResponse": {
+        "types.ReconfigureParameter": {
             "type": "object",
             "properties": {
-                "ok": {
+                "default": {},
+                "description": {
                     "type": "string"
-                }
+                },
+                "enum": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ReconfigureEnumValue"
+                    }
+                },
+                "group": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "max": {},
+                "min": {},
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {}
             }
         },
-        "geometry_msgs.Point": {
+        "types.Recording": {
             "type": "object",
             "properties": {
-                "msg.Package": {
-                    "type": "integer"
+                "active": {
+                    "type": "boolean"
                 },
-                "x": {
+                "duration": {
                     "type": "number"
                 },
-                "y": {
-                    "type": "number"
+                "name": {
+                    "type": "string"
                 },
-                "z": {
-                    "type": "number"
+                "size": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trigger": {
+                    "type": "string"
                 }
             }
         },
-        "geometry_msgs.Point32": {
+        "types.ReplayStatus": {
             "type": "object",
             "properties": {
-                "msg.Package": {
-                    "type": "integer"
+                "active": {
+                    "type": "boolean"
                 },
-                "x": {
+                "duration": {
                     "type": "number"
                 },
-                "y": {
+                "name": {
+                    "type": "string"
+                },
+                "playing": {
+                    "type": "boolean"
+                },
+                "position": {
                     "type": "number"
                 },
-                "z": {
+                "speed": {
                     "type": "number"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "geometry_msgs.Polygon": {
+        "types.RosService": {
             "type": "object",
             "properties": {
-                "msg.Package": {
-                    "type": "integer"
+                "address": {
+                    "type": "string"
                 },
-                "points": {
+                "name": {
+                    "type": "string"
+                },
+                "providers": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/geometry_msgs.Point32"
+                        "type": "string"
                     }
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "geometry_msgs.Pose": {
+        "types.RosTopic": {
             "type": "object",
             "properties": {
-                "msg.Package": {
-                    "type": "integer"
+                "name": {
+                    "type": "string"
                 },
-                "orientation": {
-                    "$ref": "#/definitions/geometry_msgs.Quaternion"
+                "publishers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "position": {
-                    "$ref": "#/definitions/geometry_msgs.Point"
+                "subscribers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "geometry_msgs.Quaternion": {
+        "types.Sensor": {
             "type": "object",
             "properties": {
-                "msg.Package": {
-                    "type": "integer"
+                "critical": {
+                    "type": "boolean"
                 },
-                "w": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lowerCritical": {
                     "type": "number"
                 },
-                "x": {
+                "max": {
                     "type": "number"
                 },
-                "y": {
+                "min": {
                     "type": "number"
                 },
-                "z": {
+                "name": {
+                    "type": "string"
+                },
+                "stamp": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "upperCritical": {
                     "type": "number"
-                }
+                },
+                "value": {}
             }
         },
-        "mower_map.AddMowingAreaSrvReq": {
+        "types.SettingChange": {
             "type": "object",
             "properties": {
-                "area": {
-                    "$ref": "#/definitions/mower_map.MapArea"
+                "change": {
+                    "type": "string"
                 },
-                "isNavigationArea": {
-                    "type": "boolean"
+                "key": {
+                    "type": "string"
                 },
-                "msg.Package": {
-                    "type": "integer"
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
                 }
             }
         },
-        "mower_map.MapArea": {
+        "types.SettingCondition": {
             "type": "object",
             "properties": {
-                "area": {
-                    "$ref": "#/definitions/geometry_msgs.Polygon"
-                },
-                "msg.Package": {
-                    "type": "integer"
-                },
-                "name": {
+                "key": {
                     "type": "string"
                 },
-                "obstacles": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/geometry_msgs.Polygon"
-                    }
+                "value": {
+                    "type": "string"
                 }
             }
         },
-        "mower_map.SetDockingPointSrvReq": {
+        "types.SettingOption": {
             "type": "object",
             "properties": {
-                "dockingPose": {
-                    "$ref": "#/definitions/geometry_msgs.Pose"
+                "id": {
+                    "type": "string"
                 },
-                "msg.Package": {
-                    "type": "integer"
+                "label": {
+                    "type": "string"
                 }
             }
         },
-        "types.FirmwareConfig": {
+        "types.SettingSchema": {
             "type": "object",
             "properties": {
-                "batChargeCutoffVoltage": {
-                    "type": "number"
-                },
-                "boardType": {
+                "default": {
                     "type": "string"
                 },
-                "bothWheelsLiftEmergencyMillis": {
-                    "type": "integer"
-                },
-                "branch": {
+                "description": {
                     "type": "string"
                 },
-                "debugType": {
+                "greaterThan": {
                     "type": "string"
                 },
-                "disableEmergency": {
-                    "type": "boolean"
-                },
-                "externalImuAcceleration": {
-                    "type": "boolean"
-                },
-                "externalImuAngular": {
-                    "type": "boolean"
+                "help": {
+                    "type": "string"
                 },
-                "file": {
+                "key": {
                     "type": "string"
                 },
-                "limitVoltage150MA": {
+                "max": {
                     "type": "number"
                 },
-                "masterJ18": {
-                    "type": "boolean"
-                },
-                "maxChargeCurrent": {
+                "min": {
                     "type": "number"
                 },
-                "maxChargeVoltage": {
-                    "type": "number"
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SettingOption"
+                    }
                 },
-                "maxMps": {
-                    "type": "number"
+                "requiredWhen": {
+                    "$ref": "#/definitions/types.SettingCondition"
                 },
-                "oneWheelLiftEmergencyMillis": {
-                    "type": "integer"
+                "section": {
+                    "type": "string"
                 },
-                "panelType": {
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.SettingsRevision": {
+            "type": "object",
+            "properties": {
+                "author": {
                     "type": "string"
                 },
-                "playButtonClearEmergencyMillis": {
-                    "type": "integer"
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "repository": {
+                "id": {
                     "type": "string"
                 },
-                "stopButtonEmergencyMillis": {
-                    "type": "integer"
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.TeleopStatus": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
                 },
-                "tickPerM": {
-                    "type": "number"
+                "allowed": {
+                    "type": "boolean"
                 },
-                "tiltEmergencyMillis": {
-                    "type": "integer"
+                "allowedStates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "version": {
+                "driver": {
                     "type": "string"
                 },
-                "wheelBase": {
+                "maxAngular": {
+                    "type": "number"
+                },
+                "maxLinear": {
                     "type": "number"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "type": "integer"
                 }
             }
         }
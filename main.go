@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/cedbossneo/openmower-gui/pkg/api"
 	"github.com/cedbossneo/openmower-gui/pkg/providers"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
 	"github.com/joho/godotenv"
 )
 
@@ -10,10 +11,32 @@ func main() {
 	_ = godotenv.Load()
 
 	dbProvider := providers.NewDBProvider()
-	dockerProvider := providers.NewDockerProvider()
-	rosProvider := providers.NewRosProvider(dbProvider)
+	logProvider := providers.NewLogProvider(dbProvider)
+	dockerProvider := providers.NewContainerProvider(dbProvider)
+	simulation, err := dbProvider.Get("system.ros.simulation")
+	if err != nil {
+		panic(err)
+	}
+	var rosProvider types.IRosProvider
+	if string(simulation) == "true" {
+		rosProvider = providers.NewSimRosProvider(dbProvider)
+	} else {
+		rosProvider = providers.NewRosProvider(dbProvider)
+	}
 	firmwareProvider := providers.NewFirmwareProvider(dbProvider)
 	ubloxProvider := providers.NewUbloxProvider()
+	reconfigureProvider := providers.NewReconfigureProvider(rosProvider)
+	recorderProvider := providers.NewRecorderProvider(rosProvider, dbProvider)
+	replayProvider := providers.NewReplayProvider(rosProvider, recorderProvider)
+	teleopProvider := providers.NewTeleopProvider(rosProvider, dbProvider)
+	publishProvider := providers.NewPublishProvider(rosProvider, dbProvider)
+	monitoringProvider := providers.NewMonitoringProvider(rosProvider, dbProvider)
+	alertProvider := providers.NewAlertProvider(rosProvider, dbProvider)
+	notificationProvider := providers.NewNotificationProvider(rosProvider, dbProvider, alertProvider)
+	metricsProvider := providers.NewMetricsProvider(rosProvider)
+	healthProvider := providers.NewHealthProvider(rosProvider, dbProvider, dockerProvider)
+	settingsHistory := providers.NewSettingsHistory(dbProvider)
+	backupProvider := providers.NewBackupProvider(dbProvider, rosProvider, settingsHistory)
 	homekitEnabled, err := dbProvider.Get("system.homekit.enabled")
 	if err != nil {
 		panic(err)
@@ -28,5 +51,24 @@ func main() {
 	if string(mqttEnabled) == "true" {
 		providers.NewMqttProvider(rosProvider, dbProvider)
 	}
-	api.NewAPI(dbProvider, dockerProvider, rosProvider, firmwareProvider, ubloxProvider)
+	api.NewAPI(api.Providers{
+		DB:              dbProvider,
+		Docker:          dockerProvider,
+		Ros:             rosProvider,
+		Firmware:        firmwareProvider,
+		Gps:             ubloxProvider,
+		Reconfigure:     reconfigureProvider,
+		Recorder:        recorderProvider,
+		Replay:          replayProvider,
+		Teleop:          teleopProvider,
+		Publish:         publishProvider,
+		Monitoring:      monitoringProvider,
+		Alert:           alertProvider,
+		Notification:    notificationProvider,
+		Metrics:         metricsProvider,
+		Health:          healthProvider,
+		Log:             logProvider,
+		Backup:          backupProvider,
+		SettingsHistory: settingsHistory,
+	})
 }
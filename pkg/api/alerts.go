@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/distribution/uuid"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func AlertsRoutes(r *gin.RouterGroup, provider types.IAlertProvider) {
+	group := r.Group("/alerts")
+	ActiveAlertsRoute(group, provider)
+	AlertsStreamRoute(group, provider)
+	AcknowledgeAlertRoute(group, provider)
+	AlertRulesRoute(group, provider)
+	SetAlertRulesRoute(group, provider)
+}
+
+// ActiveAlertsRoute list the active alerts
+//
+// @Summary list the active alerts
+// @Description list the active alerts, an alert stays active until its rule no longer holds even if it is acknowledged
+// @Tags alerts
+// @Produce  json
+// @Success 200 {array} types.Alert
+// @Router /alerts [get]
+func ActiveAlertsRoute(group *gin.RouterGroup, provider types.IAlertProvider) {
+	group.GET("", func(c *gin.Context) {
+		c.JSON(200, provider.Active())
+	})
+}
+
+// AlertsStreamRoute stream the alert events
+//
+// @Summary stream the alert events
+// @Description websocket sending an event each time an alert is raised, acknowledged or cleared, messages are base64 encoded JSON types.AlertEvent
+// @Tags alerts
+// @Router /alerts/stream [get]
+func AlertsStreamRoute(group *gin.RouterGroup, provider types.IAlertProvider) {
+	group.GET("/stream", func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var mtx sync.Mutex
+		id := uuid.Generate().String()
+		provider.SubscribeAlerts(id, func(event types.AlertEvent) {
+			data, err := json.Marshal(event)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			mtx.Lock()
+			defer mtx.Unlock()
+			err = conn.WriteMessage(websocket.TextMessage, []byte(base64.StdEncoding.EncodeToString(data)))
+			if err != nil {
+				c.Error(err)
+			}
+		})
+		defer provider.UnSubscribeAlerts(id)
+		_, _, err = conn.ReadMessage()
+		if err != nil {
+			c.Error(err)
+		}
+	})
+}
+
+// AcknowledgeAlertRoute acknowledge an alert
+//
+// @Summary acknowledge an alert
+// @Description acknowledge an active alert
+// @Tags alerts
+// @Produce  json
+// @Param id path string true "alert id, the id of its rule"
+// @Success 200 {object} OkResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /alerts/{id}/ack [post]
+func AcknowledgeAlertRoute(group *gin.RouterGroup, provider types.IAlertProvider) {
+	group.POST("/:id/ack", func(c *gin.Context) {
+		err := provider.Acknowledge(c.Param("id"))
+		switch {
+		case errors.Is(err, types.ErrUnknownAlert):
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+		case err != nil:
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(200, OkResponse{})
+		}
+	})
+}
+
+// AlertRulesRoute list the alert rules
+//
+// @Summary list the alert rules
+// @Description list the alert rules
+// @Tags alerts
+// @Produce  json
+// @Success 200 {array} types.AlertRule
+// @Failure 500 {object} ErrorResponse
+// @Router /alerts/rules [get]
+func AlertRulesRoute(group *gin.RouterGroup, provider types.IAlertProvider) {
+	group.GET("/rules", func(c *gin.Context) {
+		rules, err := provider.Rules()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, rules)
+	})
+}
+
+// SetAlertRulesRoute replace the alert rules
+//
+// @Summary replace the alert rules
+// @Description validate and save the alert rules, the alerts of removed rules are cleared
+// @Tags alerts
+// @Accept  json
+// @Produce  json
+// @Param rules body []types.AlertRule true "alert rules"
+// @Success 200 {object} OkResponse
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /alerts/rules [put]
+func SetAlertRulesRoute(group *gin.RouterGroup, provider types.IAlertProvider) {
+	group.PUT("/rules", func(c *gin.Context) {
+		var rules []types.AlertRule
+		err := c.BindJSON(&rules)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		err = provider.SetRules(rules)
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, OkResponse{})
+	})
+}
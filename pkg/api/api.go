@@ -7,45 +7,91 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"log"
 )
 
+var apiLog = providers.Logs.Logger("api")
+
 // gin-swagger middleware
 // swagger embed files
 
-func NewAPI(dbProvider types.IDBProvider, dockerProvider types.IDockerProvider, rosProvider types.IRosProvider, firmwareProvider *providers.FirmwareProvider, ubloxProvider *providers.UbloxProvider) {
-	httpAddr, err := dbProvider.Get("system.api.addr")
+// Providers are the providers serving the API routes
+type Providers struct {
+	DB              types.IDBProvider
+	Docker          types.IDockerProvider
+	Ros             types.IRosProvider
+	Firmware        types.IFirmwareProvider
+	Gps             types.IGpsProvider
+	Reconfigure     types.IReconfigureProvider
+	Recorder        types.IRecorderProvider
+	Replay          types.IReplayProvider
+	Teleop          types.ITeleopProvider
+	Publish         types.IPublishProvider
+	Monitoring      types.IMonitoringProvider
+	Alert           types.IAlertProvider
+	Notification    types.INotificationProvider
+	Metrics         types.IMetricsProvider
+	Health          types.IHealthProvider
+	Log             types.ILogProvider
+	Backup          types.IBackupProvider
+	SettingsHistory *providers.SettingsHistory
+}
+
+func NewAPI(p Providers) {
+	httpAddr, err := p.DB.Get("system.api.addr")
 	if err != nil {
-		log.Fatal(err)
+		apiLog.Fatal(err)
 	}
-
 	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = apiLog.Writer()
+	gin.DefaultErrorWriter = apiLog.WriterLevel(logrus.ErrorLevel)
+	r, err := NewRouter(p)
+	if err != nil {
+		apiLog.Fatal(err)
+	}
+	r.Run(string(httpAddr))
+}
+
+// NewRouter builds the gin engine serving the API, the web application and the tiles proxy
+func NewRouter(p Providers) (*gin.Engine, error) {
 	docs.SwaggerInfo.BasePath = "/api"
 	r := gin.Default()
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowWebSockets = true
 	r.Use(cors.New(config))
-	webDirectory, err := dbProvider.Get("system.api.webDirectory")
+	webDirectory, err := p.DB.Get("system.api.webDirectory")
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	r.Use(static.Serve("/", static.LocalFile(string(webDirectory), false)))
 	apiGroup := r.Group("/api")
-	ConfigRoute(apiGroup, dbProvider)
-	SettingsRoutes(apiGroup, dbProvider)
-	ContainersRoutes(apiGroup, dockerProvider)
-	OpenMowerRoutes(apiGroup, rosProvider)
-	SetupRoutes(apiGroup, firmwareProvider, ubloxProvider)
-	tileServer, err := dbProvider.Get("system.map.enabled")
+	ConfigRoute(apiGroup, p.DB)
+	SettingsRoutes(apiGroup, p.DB, p.SettingsHistory, p.Docker)
+	ContainersRoutes(apiGroup, p.Docker, p.DB)
+	OpenMowerRoutes(apiGroup, p.Ros, p.Teleop)
+	RosRoutes(apiGroup, p.Ros, p.DB)
+	PublishRoutes(apiGroup, p.Publish)
+	MonitoringRoutes(apiGroup, p.Monitoring)
+	AlertsRoutes(apiGroup, p.Alert)
+	NotificationsRoutes(apiGroup, p.Notification, p.DB)
+	ReconfigureRoutes(apiGroup, p.Reconfigure)
+	RecorderRoutes(apiGroup, p.Recorder)
+	ReplayRoutes(apiGroup, p.Replay)
+	LogsRoutes(apiGroup, p.Log, p.DB)
+	BackupRoutes(apiGroup, p.Backup, p.DB)
+	SetupRoutes(apiGroup, p.Firmware, p.Gps)
+	tileServer, err := p.DB.Get("system.map.enabled")
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	if string(tileServer) == "true" {
-		TilesProxy(r, dbProvider)
+		TilesProxy(r, p.DB)
 	}
+	MetricsRoute(r, p.Metrics)
+	HealthRoutes(r, p.Health)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
-	r.Run(string(httpAddr))
+	return r, nil
 }
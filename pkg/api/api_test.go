@@ -0,0 +1,1097 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/std_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/providers"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testServer struct {
+	*httptest.Server
+	db          *fakes.DBProvider
+	docker      *fakes.DockerProvider
+	ros         *fakes.RosProvider
+	firmware    *fakes.FirmwareProvider
+	gps         *fakes.GpsProvider
+	reconfigure *fakes.ReconfigureProvider
+	configFile  string
+	// token is sent as the admin token by do and dial
+	token string
+}
+
+// newTestServer serves the API backed by fake providers, the recorder and replay providers are the real ones
+// writing to a temporary directory
+func newTestServer(t *testing.T, config map[string]string) *testServer {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	values := map[string]string{}
+	for key, value := range providers.Defaults {
+		values[key] = value
+	}
+	values["system.api.webDirectory"] = dir
+	values["system.mower.configFile"] = filepath.Join(dir, "mower_config.sh")
+	values["system.recorder.directory"] = filepath.Join(dir, "bags")
+	for key, value := range config {
+		values[key] = value
+	}
+	s := &testServer{
+		db: fakes.NewDBProvider(values),
+		docker: fakes.NewDockerProvider(
+			dockertypes.Container{ID: "om", Names: []string{"/openmower"}, State: "running"},
+			dockertypes.Container{ID: "gui", Names: []string{"/openmower-gui"}, State: "running"},
+		),
+		ros:      fakes.NewRosProvider(),
+		firmware: &fakes.FirmwareProvider{Output: []string{"building", "flashing"}},
+		gps:      &fakes.GpsProvider{Output: []string{"configuring"}},
+		reconfigure: &fakes.ReconfigureProvider{Nodes: map[string][]types.ReconfigureParameter{
+			"/mower_logic": {{Name: "automatic_mode", Type: "int", Value: 0}},
+		}},
+		configFile: values["system.mower.configFile"],
+		token:      values["system.api.adminToken"],
+	}
+	recorderProvider := providers.NewRecorderProvider(s.ros, s.db)
+	alertProvider := providers.NewAlertProvider(s.ros, s.db)
+	settingsHistory := providers.NewSettingsHistory(s.db)
+	r, err := NewRouter(Providers{
+		DB:              s.db,
+		Docker:          s.docker,
+		Ros:             s.ros,
+		Firmware:        s.firmware,
+		Gps:             s.gps,
+		Reconfigure:     s.reconfigure,
+		Recorder:        recorderProvider,
+		Replay:          providers.NewReplayProvider(s.ros, recorderProvider),
+		Teleop:          providers.NewTeleopProvider(s.ros, s.db),
+		Publish:         providers.NewPublishProvider(s.ros, s.db),
+		Monitoring:      providers.NewMonitoringProvider(s.ros, s.db),
+		Alert:           alertProvider,
+		Notification:    providers.NewNotificationProvider(s.ros, s.db, alertProvider),
+		Metrics:         providers.NewMetricsProvider(s.ros),
+		Health:          providers.NewHealthProvider(s.ros, s.db, s.docker),
+		Log:             providers.NewLogProvider(s.db),
+		Backup:          providers.NewBackupProvider(s.db, s.ros, settingsHistory),
+		SettingsHistory: settingsHistory,
+	})
+	require.NoError(t, err)
+	s.Server = httptest.NewServer(r)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testServer) do(t *testing.T, method string, path string, body any) (int, []byte) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	content, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, content
+}
+
+func (s *testServer) dial(t *testing.T, path string) *websocket.Conn {
+	dialer := *websocket.DefaultDialer
+	if s.token != "" {
+		dialer.Subprotocols = []string{adminTokenProtocol, s.token}
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+path, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readBase64Message(t *testing.T, conn *websocket.Conn) []byte {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(string(msg))
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestConfigRoutes(t *testing.T) {
+	s := newTestServer(t, map[string]string{"system.map.tileUri": "/tiles/{z}/{x}/{y}"})
+
+	code, body := s.do(t, "GET", "/api/config/envs", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"tileUri":"/tiles/{z}/{x}/{y}"}`, string(body))
+
+	code, _ = s.do(t, "POST", "/api/config/keys/set", map[string]string{"gui.test": "value"})
+	assert.Equal(t, 200, code)
+	code, body = s.do(t, "POST", "/api/config/keys/get", map[string]string{"gui.test": "", "gui.missing": ""})
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"gui.test":"value","gui.missing":""}`, string(body))
+
+	// the keys of the settings page don't need the admin token
+	code, _ = s.do(t, "POST", "/api/config/keys/set", map[string]string{"system.mqtt.host": ":1884"})
+	assert.Equal(t, 200, code)
+	code, body = s.do(t, "POST", "/api/config/keys/get", map[string]string{"system.mqtt.host": ""})
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"system.mqtt.host":":1884"}`, string(body))
+
+	// the other system keys require the admin token to be read and written
+	code, _ = s.do(t, "POST", "/api/config/keys/set", map[string]string{"gui.test": "other", "system.containers.names": "*"})
+	assert.Equal(t, 403, code)
+	code, _ = s.do(t, "POST", "/api/config/keys/get", map[string]string{"system.notifications.channels": ""})
+	assert.Equal(t, 403, code)
+	require.NoError(t, s.db.Set("system.api.adminToken", []byte("secret")))
+	code, _ = s.do(t, "POST", "/api/config/keys/set", map[string]string{"system.containers.names": "*"})
+	assert.Equal(t, 401, code)
+	value, err := s.db.Get("system.containers.names")
+	require.NoError(t, err)
+	assert.Equal(t, "openmower*", string(value))
+	value, err = s.db.Get("gui.test")
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+	s.token = "secret"
+	code, _ = s.do(t, "POST", "/api/config/keys/set", map[string]string{"system.containers.names": "openmower"})
+	assert.Equal(t, 200, code)
+	value, err = s.db.Get("system.containers.names")
+	require.NoError(t, err)
+	assert.Equal(t, "openmower", string(value))
+	code, body = s.do(t, "POST", "/api/config/keys/get", map[string]string{"system.containers.names": ""})
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"system.containers.names":"openmower"}`, string(body))
+
+	// the admin token cannot be read nor replaced
+	s.do(t, "POST", "/api/config/keys/set", map[string]string{"system.api.adminToken": "guessed"})
+	code, body = s.do(t, "POST", "/api/config/keys/get", map[string]string{"system.api.adminToken": ""})
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{}`, string(body))
+	value, err = s.db.Get("system.api.adminToken")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(value))
+}
+
+func TestSettingsRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	code, _ := s.do(t, "GET", "/api/settings", nil)
+	assert.Equal(t, 500, code)
+
+	require.NoError(t, os.WriteFile(s.configFile, []byte("export OM_DATUM_LAT=\"48.1\"\n"), 0644))
+	code, _ = s.do(t, "POST", "/api/settings", map[string]any{"OM_ENABLE_MOWER": true, "OM_TOOL_WIDTH": "0.13"})
+	assert.Equal(t, 200, code)
+
+	code, body := s.do(t, "GET", "/api/settings", nil)
+	assert.Equal(t, 200, code)
+	var response GetSettingsResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, map[string]string{
+		"OM_DATUM_LAT":    "48.1",
+		"OM_ENABLE_MOWER": "True",
+		"OM_TOOL_WIDTH":   "0.13",
+	}, response.Settings)
+
+	require.NoError(t, os.WriteFile(s.configFile, []byte("# datum\nexport OM_DATUM_LAT=48.1 # north\nexport OM_TOOL_WIDTH='0.13'\n"), 0644))
+	code, _ = s.do(t, "POST", "/api/settings", map[string]any{"OM_DATUM_LAT": 48.2, "OM_TOOL_WIDTH": nil, "OM_OUTLINE_COUNT": 4})
+	assert.Equal(t, 200, code)
+	content, err := os.ReadFile(s.configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "# datum\nexport OM_DATUM_LAT=48.2 # north\nexport OM_OUTLINE_COUNT=\"4\"\n", string(content))
+
+	code, body = s.do(t, "POST", "/api/settings", map[string]any{"OM MOWER": "x", "OM_AREAS": []int{1}})
+	assert.Equal(t, 400, code)
+	var validation ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(body, &validation))
+	assert.ElementsMatch(t, []string{"OM MOWER", "OM_AREAS"}, lo.Keys(validation.Fields))
+
+	code, body = s.do(t, "POST", "/api/settings", map[string]any{"OM_USE_NTRIP": true, "OM_GPS_PROTOCOL": "RTCM", "OM_MQTT_PORT": 70000})
+	assert.Equal(t, 400, code)
+	validation = ValidationErrorResponse{}
+	require.NoError(t, json.Unmarshal(body, &validation))
+	assert.ElementsMatch(t, []string{"OM_NTRIP_HOSTNAME", "OM_NTRIP_PORT", "OM_NTRIP_ENDPOINT", "OM_GPS_PROTOCOL", "OM_MQTT_PORT"}, lo.Keys(validation.Fields))
+	content, err = os.ReadFile(s.configFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "OM_USE_NTRIP")
+
+	// an invalid value already in the file doesn't prevent saving the other settings
+	require.NoError(t, os.WriteFile(s.configFile, []byte("export OM_GPS_PROTOCOL=RTCM\n"), 0644))
+	code, _ = s.do(t, "POST", "/api/settings", map[string]any{"OM_TOOL_WIDTH": 0.2})
+	assert.Equal(t, 200, code)
+
+	code, body = s.do(t, "GET", "/api/settings/schema", nil)
+	assert.Equal(t, 200, code)
+	var schema SettingsSchemaResponse
+	require.NoError(t, json.Unmarshal(body, &schema))
+	protocol, ok := lo.Find(schema.Settings, func(setting types.SettingSchema) bool {
+		return setting.Key == "OM_GPS_PROTOCOL"
+	})
+	require.True(t, ok)
+	assert.Equal(t, types.SettingSelect, protocol.Type)
+	assert.Len(t, protocol.Options, 2)
+}
+
+func TestSettingsRevisionsRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	require.NoError(t, os.WriteFile(s.configFile, []byte("export OM_TOOL_WIDTH=0.13\n"), 0644))
+	code, _ := s.do(t, "POST", "/api/settings?author=alice", map[string]any{"OM_TOOL_WIDTH": 0.2})
+	assert.Equal(t, 200, code)
+
+	code, body := s.do(t, "GET", "/api/settings/revisions", nil)
+	assert.Equal(t, 200, code)
+	var revisions SettingsRevisionsResponse
+	require.NoError(t, json.Unmarshal(body, &revisions))
+	require.Len(t, revisions.Revisions, 1)
+	assert.Equal(t, "alice", revisions.Revisions[0].Author)
+	assert.Equal(t, []string{"OM_TOOL_WIDTH"}, revisions.Revisions[0].Changes)
+	id := revisions.Revisions[0].ID
+
+	code, body = s.do(t, "GET", "/api/settings/revisions/diff?from="+id, nil)
+	assert.Equal(t, 200, code)
+	var diff SettingsDiffResponse
+	require.NoError(t, json.Unmarshal(body, &diff))
+	assert.Equal(t, []types.SettingChange{{Key: "OM_TOOL_WIDTH", Change: "changed", Old: "0.13", New: "0.2"}}, diff.Changes)
+	code, _ = s.do(t, "GET", "/api/settings/revisions/diff", nil)
+	assert.Equal(t, 400, code)
+	code, _ = s.do(t, "GET", "/api/settings/revisions/diff?from=1", nil)
+	assert.Equal(t, 404, code)
+
+	code, _ = s.do(t, "POST", "/api/settings/revisions/1/restore", nil)
+	assert.Equal(t, 404, code)
+	code, _ = s.do(t, "POST", "/api/settings/revisions/"+id+"/restore?restart=true", nil)
+	assert.Equal(t, 200, code)
+	content, err := os.ReadFile(s.configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "export OM_TOOL_WIDTH=0.13\n", string(content))
+	assert.Equal(t, []string{"restart om"}, s.docker.History())
+
+	code, body = s.do(t, "GET", "/api/settings/revisions", nil)
+	assert.Equal(t, 200, code)
+	require.NoError(t, json.Unmarshal(body, &revisions))
+	assert.Len(t, revisions.Revisions, 2)
+}
+
+func TestContainersRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.docker.Logs["om"] = []types.ContainerLogLine{
+		{Stream: "stdout", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Message: "[ INFO] [1714564800.0]: first line"},
+		{Stream: "stderr", Timestamp: time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC), Message: "[ WARN] [1714564801.0]: second line"},
+		{Stream: "stderr", Timestamp: time.Date(2024, 5, 1, 12, 0, 2, 0, time.UTC), Message: "  at continuation"},
+		{Stream: "stdout", Timestamp: time.Date(2024, 5, 1, 12, 0, 3, 0, time.UTC), Message: "[ERROR] [1714564803.0]: third line"},
+	}
+
+	code, body := s.do(t, "GET", "/api/containers/", nil)
+	assert.Equal(t, 200, code)
+	var list ContainerListResponse
+	require.NoError(t, json.Unmarshal(body, &list))
+	require.Len(t, list.Containers, 2)
+	assert.Equal(t, "openmower", list.Containers[0].Labels["app"])
+	assert.Equal(t, "gui", list.Containers[1].Labels["app"])
+
+	for _, command := range []string{"stop", "start", "restart"} {
+		code, _ = s.do(t, "POST", "/api/containers/om/"+command, nil)
+		assert.Equal(t, 200, code)
+	}
+	assert.Equal(t, []string{"stop om", "start om", "restart om"}, s.docker.History())
+	code, _ = s.do(t, "POST", "/api/containers/unknown/restart", nil)
+	assert.Equal(t, 404, code)
+	code, body = s.do(t, "POST", "/api/containers/om/remove", nil)
+	assert.Equal(t, 400, code)
+	assert.JSONEq(t, `{"error":"invalid values: command: must be start, stop or restart","fields":{"command":"must be start, stop or restart"}}`, string(body))
+	assert.Len(t, s.docker.History(), 3)
+
+	conn := s.dial(t, "/api/containers/om/logs?tail=3&level=warning")
+	assert.Equal(t, "[ WARN] [1714564801.0]: second line", string(readBase64Message(t, conn)))
+	assert.Equal(t, "  at continuation", string(readBase64Message(t, conn)))
+	assert.Equal(t, "[ERROR] [1714564803.0]: third line", string(readBase64Message(t, conn)))
+	conn = s.dial(t, "/api/containers/om/logs?stream=stderr&timestamps=true&filter=line$")
+	assert.Equal(t, "2024-05-01T12:00:01Z [ WARN] [1714564801.0]: second line", string(readBase64Message(t, conn)))
+	assert.Equal(t, types.ContainerLogsQuery{Tail: "100", Stream: "stderr", Follow: true}, s.docker.LogsQueries[1])
+
+	code, body = s.do(t, "GET", "/api/containers/om/logs/download?since=10m&stream=stdout", nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "[ INFO] [1714564800.0]: first line\n[ERROR] [1714564803.0]: third line\n", string(body))
+	assert.Equal(t, types.ContainerLogsQuery{Since: "10m", Tail: "all", Stream: "stdout"}, s.docker.LogsQueries[2])
+	code, body = s.do(t, "GET", "/api/containers/om/logs/download?format=gzip&filter=third", nil)
+	assert.Equal(t, 200, code)
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	text, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "[ERROR] [1714564803.0]: third line\n", string(text))
+	code, _ = s.do(t, "GET", "/api/containers/unknown/logs/download", nil)
+	assert.Equal(t, 500, code)
+
+	s.docker.Stats["om"] = []types.ContainerStats{{ID: "om", Name: "openmower", CPUPercent: 95.5}, {ID: "om", Name: "openmower", CPUPercent: 12}}
+	s.docker.Stats["gui"] = []types.ContainerStats{{ID: "gui", Name: "openmower-gui", MemoryUsage: 1024}}
+	code, body = s.do(t, "GET", "/api/containers/stats", nil)
+	assert.Equal(t, 200, code)
+	var stats ContainerStatsResponse
+	require.NoError(t, json.Unmarshal(body, &stats))
+	require.Len(t, stats.Stats, 2)
+	assert.Equal(t, "openmower", stats.Stats[0].App)
+	assert.Equal(t, 95.5, stats.Stats[0].CPUPercent)
+	assert.Equal(t, "gui", stats.Stats[1].App)
+	assert.Equal(t, uint64(1024), stats.Stats[1].MemoryUsage)
+	conn = s.dial(t, "/api/containers/stats/stream")
+	var cpu []float64
+	for i := 0; i < 3; i++ {
+		var sample ContainerStats
+		require.NoError(t, json.Unmarshal(readBase64Message(t, conn), &sample))
+		if sample.App == "openmower" {
+			cpu = append(cpu, sample.CPUPercent)
+		}
+	}
+	assert.Equal(t, []float64{95.5, 12}, cpu)
+
+	s.docker.Updates["om"] = types.ImageUpdateStatus{ContainerID: "om", Image: "openmower/ros:stable", UpdateAvailable: true}
+	code, body = s.do(t, "GET", "/api/containers/om/update", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"containerId":"om","image":"openmower/ros:stable","localDigest":"","remoteDigest":"","updateAvailable":true}`, string(body))
+	s.docker.UpdateEvents = []types.ImageUpdateEvent{{Step: "pull", Status: "Downloading", Layer: "a1b2", Current: 1, Total: 2}}
+	s.docker.UpdateResult = types.ImageUpdateResult{ContainerID: "new", Updated: true}
+	code, body = s.do(t, "POST", "/api/containers/om/update", nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "event:progress\ndata:{\"step\":\"pull\",\"status\":\"Downloading\",\"layer\":\"a1b2\",\"current\":1,\"total\":2}\n\n"+
+		"event:end\ndata:{\"containerId\":\"new\",\"image\":\"\",\"previousImageId\":\"\",\"imageId\":\"\",\"updated\":true,\"rolledBack\":false}\n\n", string(body))
+	s.docker.UpdateError = errors.New("rolled back: the container is unhealthy")
+	_, body = s.do(t, "POST", "/api/containers/om/update", nil)
+	assert.Contains(t, string(body), "event:error\ndata:rolled back: the container is unhealthy\n\n")
+	code, _ = s.do(t, "POST", "/api/containers/gui/update", nil)
+	assert.Equal(t, 400, code)
+
+	code, body = s.do(t, "GET", "/api/containers/om/logs/download?since=yesterday&stream=both&filter=(&level=loud&tail=last&format=zip", nil)
+	assert.Equal(t, 400, code)
+	var validation ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(body, &validation))
+	assert.ElementsMatch(t, []string{"since", "stream", "filter", "level", "tail", "format"}, lo.Keys(validation.Fields))
+}
+
+func TestContainerExecRoute(t *testing.T) {
+	s := newTestServer(t, nil)
+	code, _ := s.do(t, "GET", "/api/containers/om/exec", nil)
+	assert.Equal(t, 403, code)
+
+	s = newTestServer(t, map[string]string{"system.api.adminToken": "secret"})
+	s.docker.ExecExitCode = 3
+	// the token is not read from the query string, which is logged
+	s.token = ""
+	code, _ = s.do(t, "GET", "/api/containers/om/exec?token=secret", nil)
+	assert.Equal(t, 401, code)
+	s.token = "wrong"
+	code, _ = s.do(t, "GET", "/api/containers/om/exec", nil)
+	assert.Equal(t, 401, code)
+
+	s.token = "secret"
+	conn := s.dial(t, "/api/containers/om/exec?cmd=rostopic&cmd=echo&rows=24&cols=80")
+	assert.Equal(t, adminTokenProtocol, conn.Subprotocol())
+	require.NoError(t, conn.WriteJSON(execMessage{Type: "resize", Rows: 50, Cols: 120}))
+	require.NoError(t, conn.WriteJSON(execMessage{Type: "stdin", Data: base64.StdEncoding.EncodeToString([]byte("hello\n"))}))
+	assert.Equal(t, "hello\r\n", string(readBase64Message(t, conn)))
+	require.NoError(t, conn.WriteJSON(execMessage{Type: "stdin", Data: base64.StdEncoding.EncodeToString([]byte("exit\n"))}))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, "exit 3", closeErr.Text)
+	assert.Equal(t, []string{"exec om rostopic echo"}, s.docker.History())
+	assert.Equal(t, []types.TerminalSize{{Rows: 24, Cols: 80}, {Rows: 50, Cols: 120}}, s.docker.Resizes)
+}
+
+func TestOpenMowerServiceRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	calls := map[string]string{
+		"high_level_control": "/mower_service/high_level_control",
+		"emergency":          "/mower_service/emergency",
+		"mower_logic":        "/mower_logic/set_parameters",
+		"mow_enabled":        "/mower_service/mow_enabled",
+		"start_in_area":      "/mower_service/start_in_area",
+	}
+	for command, service := range calls {
+		code, _ := s.do(t, "POST", "/api/openmower/call/"+command, map[string]any{})
+		assert.Equal(t, 200, code, command)
+		history := s.ros.Calls()
+		assert.Equal(t, service, history[len(history)-1].Service)
+	}
+	code, body := s.do(t, "POST", "/api/openmower/call/high_level_control", map[string]any{"Command": 2})
+	assert.Equal(t, 200, code)
+	history := s.ros.Calls()
+	assert.Equal(t, mower_msgs.HighLevelControlSrvReq{Command: 2}, history[len(history)-1].Req)
+
+	code, body = s.do(t, "POST", "/api/openmower/call/unknown", map[string]any{})
+	assert.Equal(t, 500, code)
+	assert.JSONEq(t, `{"error":"unknown command"}`, string(body))
+
+	s.ros.ServiceErrors["/mower_service/emergency"] = errors.New("service unavailable")
+	code, body = s.do(t, "POST", "/api/openmower/call/emergency", map[string]any{"Emergency": 1})
+	assert.Equal(t, 500, code)
+	assert.JSONEq(t, `{"error":"service unavailable"}`, string(body))
+}
+
+func TestOpenMowerMapRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	area := map[string]any{
+		"area":             map[string]any{"name": "front", "area": map[string]any{"points": []map[string]any{{"x": 1, "y": 2}}}},
+		"isNavigationArea": false,
+	}
+
+	code, _ := s.do(t, "POST", "/api/openmower/map/area/add", area)
+	assert.Equal(t, 200, code)
+	history := s.ros.Calls()
+	require.Len(t, history, 1)
+	added := history[0].Req.(mower_map.AddMowingAreaSrvReq)
+	assert.Equal(t, "front", added.Area.Name)
+	assert.Equal(t, float32(2), added.Area.Area.Points[0].Y)
+
+	code, _ = s.do(t, "POST", "/api/openmower/map/docking", map[string]any{"dockingPose": map[string]any{"position": map[string]any{"x": 3}}})
+	assert.Equal(t, 200, code)
+	history = s.ros.Calls()
+	assert.Equal(t, 3.0, history[1].Req.(mower_map.SetDockingPointSrvReq).DockingPose.Position.X)
+
+	code, _ = s.do(t, "DELETE", "/api/openmower/map", nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "/mower_map_service/clear_map", s.ros.Calls()[2].Service)
+
+	code, _ = s.do(t, "PUT", "/api/openmower/map", map[string]any{"areas": []any{area, area}})
+	assert.Equal(t, 200, code)
+	var services []string
+	for _, call := range s.ros.Calls()[3:] {
+		services = append(services, call.Service)
+	}
+	assert.Equal(t, []string{
+		"/mower_map_service/clear_map",
+		"/mower_map_service/add_mowing_area",
+		"/mower_map_service/add_mowing_area",
+	}, services)
+}
+
+func TestOpenMowerSubscribeRoute(t *testing.T) {
+	s := newTestServer(t, nil)
+	// the teleop, alert, notification, metrics and health providers are always subscribed to the high level status
+	conn := s.dial(t, "/api/openmower/subscribe/highLevelStatus")
+	require.Eventually(t, func() bool {
+		return s.ros.Subscribers("/mower_logic/current_state") == 6
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, s.ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "MOWING"}))
+	var status mower_msgs.HighLevelStatus
+	require.NoError(t, json.Unmarshal(readBase64Message(t, conn), &status))
+	assert.Equal(t, "MOWING", status.StateName)
+
+	require.NoError(t, conn.Close())
+	assert.Eventually(t, func() bool {
+		return s.ros.Subscribers("/mower_logic/current_state") == 5
+	}, 5*time.Second, 10*time.Millisecond)
+
+	conn = s.dial(t, "/api/openmower/subscribe/unknown")
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, _, err := conn.ReadMessage()
+	assert.Error(t, err)
+}
+
+func TestOpenMowerPublishRoute(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	_, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/api/openmower/publish/joy", nil)
+	assert.Error(t, err)
+
+	require.NoError(t, s.ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "IDLE"}))
+	conn := s.dial(t, "/api/openmower/publish/joy?driver=first")
+	_, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/api/openmower/publish/joy?driver=second", nil)
+	require.Error(t, err)
+	assert.Equal(t, 409, res.StatusCode)
+
+	code, body := s.do(t, "GET", "/api/openmower/teleop", nil)
+	assert.Equal(t, 200, code)
+	var status types.TeleopStatus
+	require.NoError(t, json.Unmarshal(body, &status))
+	assert.True(t, status.Active)
+	assert.Equal(t, "first", status.Driver)
+	assert.True(t, status.Allowed)
+
+	require.NoError(t, conn.WriteJSON(geometry_msgs.Twist{Linear: geometry_msgs.Vector3{X: 2}, Angular: geometry_msgs.Vector3{Z: -0.5}}))
+	require.Eventually(t, func() bool {
+		return len(s.ros.Published("/joy_vel")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	twist := s.ros.Published("/joy_vel")[0].(*geometry_msgs.Twist)
+	assert.Equal(t, 0.5, twist.Linear.X)
+	assert.Equal(t, -0.5, twist.Angular.Z)
+
+	// the watchdog stops the mower when the driver stops sending velocities
+	require.Eventually(t, func() bool {
+		return len(s.ros.Published("/joy_vel")) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, &geometry_msgs.Twist{}, s.ros.Published("/joy_vel")[1])
+
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool {
+		_, body := s.do(t, "GET", "/api/openmower/teleop", nil)
+		return strings.Contains(string(body), `"active":false`)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, s.ros.Published("/joy_vel"), 3)
+}
+
+func TestOpenMowerPublishRouteLostDriver(t *testing.T) {
+	s := newTestServer(t, map[string]string{"system.teleop.timeout": "100"})
+	require.NoError(t, s.ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "IDLE"}))
+
+	// the client does not read so it does not answer the pings, like a connection lost without being closed
+	conn := s.dial(t, "/api/openmower/publish/joy?driver=first")
+	defer conn.Close()
+	require.Eventually(t, func() bool {
+		_, body := s.do(t, "GET", "/api/openmower/teleop", nil)
+		return strings.Contains(string(body), `"active":false`)
+	}, 5*time.Second, 10*time.Millisecond)
+	conn = s.dial(t, "/api/openmower/publish/joy?driver=second")
+	defer conn.Close()
+}
+
+func TestRosPublishRoutes(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"system.ros.publish.topics":      "/cmd_vel=geometry_msgs/Twist",
+		"system.ros.publish.defaultRate": "0",
+	})
+
+	code, body := s.do(t, "GET", "/api/ros/publishers", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `[{"topic":"/cmd_vel","type":"geometry_msgs/Twist","rate":0}]`, string(body))
+
+	code, _ = s.do(t, "POST", "/api/ros/publish/cmd_vel", map[string]any{"linear": map[string]any{"x": 0.2}})
+	assert.Equal(t, 200, code)
+	code, _ = s.do(t, "POST", "/api/ros/publish/joy_vel", map[string]any{})
+	assert.Equal(t, 403, code)
+
+	conn := s.dial(t, "/api/ros/publish/cmd_vel")
+	require.NoError(t, conn.WriteJSON(map[string]any{"angular": map[string]any{"z": 0.3}}))
+	require.Eventually(t, func() bool {
+		return len(s.ros.Published("/cmd_vel")) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0.2, s.ros.Published("/cmd_vel")[0].(*geometry_msgs.Twist).Linear.X)
+	assert.Equal(t, 0.3, s.ros.Published("/cmd_vel")[1].(*geometry_msgs.Twist).Angular.Z)
+
+	_, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/api/openmower/publish/cmd_vel", nil)
+	require.Error(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+}
+
+func TestRosGraphRoutes(t *testing.T) {
+	s := newTestServer(t, map[string]string{"system.ros.expectedNodes": "/mower_logic,/xbot_positioning"})
+	s.ros.SetGraph(
+		[]types.RosNode{{Name: "/mower_logic"}},
+		[]types.RosTopic{{Name: "/mower/status", Type: "mower_msgs/Status"}},
+		[]types.RosService{{Name: "/mower_service/emergency"}},
+	)
+
+	code, body := s.do(t, "GET", "/api/ros/graph", nil)
+	assert.Equal(t, 200, code)
+	var graph RosGraphResponse
+	require.NoError(t, json.Unmarshal(body, &graph))
+	assert.Equal(t, []string{"/xbot_positioning"}, graph.MissingNodes)
+	require.Len(t, graph.Nodes, 2)
+	assert.True(t, graph.Nodes[0].Alive)
+	assert.True(t, graph.Nodes[1].Missing)
+
+	code, body = s.do(t, "GET", "/api/ros/graph/nodes", nil)
+	assert.Equal(t, 200, code)
+	var nodes []RosNodeStatus
+	require.NoError(t, json.Unmarshal(body, &nodes))
+	assert.Len(t, nodes, 2)
+
+	code, body = s.do(t, "GET", "/api/ros/graph/topics", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), "mower_msgs/Status")
+
+	code, body = s.do(t, "GET", "/api/ros/graph/services", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), "/mower_service/emergency")
+}
+
+func TestNodeStatuses(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	var nodes []types.RosNode
+	for i := 0; i < 3*nodePingWorkers; i++ {
+		nodes = append(nodes, types.RosNode{Name: fmt.Sprintf("/node%d", i)})
+	}
+	ros.SetGraph(nodes, nil, nil)
+	db := fakes.NewDBProvider(nil)
+
+	statuses, _, err := nodeStatuses(context.Background(), ros, db)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(nodes))
+	for i, status := range statuses {
+		assert.Equal(t, nodes[i].Name, status.Name)
+		assert.True(t, status.Alive, status.Name)
+	}
+
+	// the nodes are not pinged once the request is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	statuses, _, err = nodeStatuses(ctx, ros, db)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(nodes))
+	for _, status := range statuses {
+		assert.False(t, status.Alive, status.Name)
+	}
+}
+
+func TestRosCallRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.ros.ServiceResponses["/mower_map_service/get_docking_point"] = mower_map.GetDockingPointSrvRes{
+		DockingPose: geometry_msgs.Pose{Position: geometry_msgs.Point{X: 1, Y: 2}},
+	}
+
+	code, body := s.do(t, "GET", "/api/ros/call", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), "/mower_map_service/get_docking_point")
+
+	code, body = s.do(t, "POST", "/api/ros/call/mower_map_service/get_docking_point", nil)
+	assert.Equal(t, 200, code)
+	var res mower_map.GetDockingPointSrvRes
+	require.NoError(t, json.Unmarshal(body, &res))
+	assert.Equal(t, 2.0, res.DockingPose.Position.Y)
+
+	code, _ = s.do(t, "POST", "/api/ros/call/mower_map_service/delete_mowing_area", map[string]any{"Index": 3})
+	assert.Equal(t, 200, code)
+	calls := s.ros.Calls()
+	assert.Equal(t, mower_map.DeleteMowingAreaSrvReq{Index: 3}, calls[len(calls)-1].Req)
+
+	code, _ = s.do(t, "POST", "/api/ros/call/unknown", nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestMonitoringRoutes(t *testing.T) {
+	s := newTestServer(t, map[string]string{"system.monitoring.registerActions": "true"})
+
+	code, body := s.do(t, "GET", "/api/monitoring/sensors", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `[]`, string(body))
+
+	_, err := s.ros.CallAdvertised("/xbot/register_actions", &xbot_msgs.RegisterActionsSrvReq{
+		NodePrefix: "mower_logic:idle",
+		Actions: []xbot_msgs.ActionInfo{
+			{ActionId: "start_mowing", ActionName: "Start mowing", Enabled: true},
+			{ActionId: "start_area_recording", ActionName: "Start area recording", Enabled: false},
+		},
+	})
+	require.NoError(t, err)
+	code, body = s.do(t, "GET", "/api/monitoring/actions", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `[
+		{"id":"mower_logic:idle/start_area_recording","node":"mower_logic:idle","name":"Start area recording","enabled":false},
+		{"id":"mower_logic:idle/start_mowing","node":"mower_logic:idle","name":"Start mowing","enabled":true}
+	]`, string(body))
+
+	code, _ = s.do(t, "POST", "/api/monitoring/actions/mower_logic:idle/start_mowing", nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []any{&std_msgs.String{Data: "mower_logic:idle/start_mowing"}}, s.ros.Published("/xbot/action"))
+	code, _ = s.do(t, "POST", "/api/monitoring/actions/mower_logic:idle/start_area_recording", nil)
+	assert.Equal(t, 409, code)
+	code, _ = s.do(t, "POST", "/api/monitoring/actions/mower_logic:idle/unknown", nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestAlertsRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	code, body := s.do(t, "PUT", "/api/alerts/rules", []types.AlertRule{{ID: "rain", Severity: "info", Source: "rain"}})
+	assert.Equal(t, 400, code)
+	var validation ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(body, &validation))
+	assert.Contains(t, validation.Fields, "rules[0].source")
+
+	rule := types.AlertRule{ID: "rain", Name: "Rain", Severity: "info", Source: "status", Field: "RainDetected", Operator: "==", Value: true}
+	code, _ = s.do(t, "PUT", "/api/alerts/rules", []types.AlertRule{rule})
+	assert.Equal(t, 200, code)
+	code, body = s.do(t, "GET", "/api/alerts/rules", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `[{"id":"rain","name":"Rain","severity":"info","source":"status","field":"RainDetected","operator":"==","value":true,"for":0,"hysteresis":0}]`, string(body))
+
+	conn := s.dial(t, "/api/alerts/stream")
+	require.NoError(t, s.ros.Publish("/mower/status", &mower_msgs.Status{RainDetected: true}))
+	var event types.AlertEvent
+	require.NoError(t, json.Unmarshal(readBase64Message(t, conn), &event))
+	assert.Equal(t, types.AlertRaised, event.Type)
+	assert.Equal(t, "rain", event.Alert.ID)
+
+	code, body = s.do(t, "GET", "/api/alerts", nil)
+	assert.Equal(t, 200, code)
+	var alerts []types.Alert
+	require.NoError(t, json.Unmarshal(body, &alerts))
+	require.Len(t, alerts, 1)
+	assert.False(t, alerts[0].Acknowledged)
+	code, _ = s.do(t, "POST", "/api/alerts/rain/ack", nil)
+	assert.Equal(t, 200, code)
+	code, _ = s.do(t, "POST", "/api/alerts/unknown/ack", nil)
+	assert.Equal(t, 404, code)
+}
+
+func TestNotificationsRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	code, _ := s.do(t, "PUT", "/api/notifications/channels", []types.NotificationChannel{})
+	assert.Equal(t, 403, code)
+	code, _ = s.do(t, "POST", "/api/notifications/channels/hook/test", nil)
+	assert.Equal(t, 403, code)
+
+	s = newTestServer(t, map[string]string{"system.api.adminToken": "secret"})
+	var received []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	defer hook.Close()
+
+	code, body := s.do(t, "PUT", "/api/notifications/channels", []types.NotificationChannel{{ID: "hook", Type: "webhook"}})
+	assert.Equal(t, 400, code)
+	var validation ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(body, &validation))
+	assert.Equal(t, map[string]string{"channels[0].url": "must be an http or https URL"}, validation.Fields)
+
+	channel := types.NotificationChannel{ID: "hook", Type: "webhook", URL: hook.URL, Body: `{{.Title}}`, Token: "hook-token"}
+	code, _ = s.do(t, "PUT", "/api/notifications/channels", []types.NotificationChannel{channel})
+	assert.Equal(t, 200, code)
+	code, body = s.do(t, "GET", "/api/notifications/channels", nil)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `[{"id":"hook","name":"","type":"webhook","enabled":false,"url":"`+hook.URL+`","body":"{{.Title}}","token":"********"}]`, string(body))
+
+	// the redacted token sent back keeps the saved one
+	var channels []types.NotificationChannel
+	require.NoError(t, json.Unmarshal(body, &channels))
+	channels[0].Name = "Hook"
+	code, _ = s.do(t, "PUT", "/api/notifications/channels", channels)
+	assert.Equal(t, 200, code)
+	value, err := s.db.Get("system.notifications.channels")
+	require.NoError(t, err)
+	var saved []types.NotificationChannel
+	require.NoError(t, json.Unmarshal(value, &saved))
+	assert.Equal(t, "Hook", saved[0].Name)
+	assert.Equal(t, "hook-token", saved[0].Token)
+
+	code, body = s.do(t, "POST", "/api/notifications/channels/hook/test", nil)
+	assert.Equal(t, 200, code)
+	var delivery types.NotificationDelivery
+	require.NoError(t, json.Unmarshal(body, &delivery))
+	assert.True(t, delivery.Success)
+	assert.Equal(t, []string{"OpenMower test notification"}, received)
+	code, _ = s.do(t, "POST", "/api/notifications/channels/unknown/test", nil)
+	assert.Equal(t, 404, code)
+
+	code, body = s.do(t, "GET", "/api/notifications/deliveries", nil)
+	assert.Equal(t, 200, code)
+	var deliveries []types.NotificationDelivery
+	require.NoError(t, json.Unmarshal(body, &deliveries))
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "test", deliveries[0].Event)
+}
+
+func TestMetricsRoute(t *testing.T) {
+	s := newTestServer(t, nil)
+	conn := s.dial(t, "/api/openmower/subscribe/status")
+	require.NoError(t, s.ros.Publish("/mower/status", &mower_msgs.Status{VBattery: 27.5, MowEscStatus: mower_msgs.ESCStatus{Rpm: 3000}}))
+	readBase64Message(t, conn)
+	require.NoError(t, s.ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "MOWING", GpsQualityPercent: 0.9}))
+
+	res, err := http.Get(s.URL + "/metrics")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+	metrics := string(body)
+	assert.Contains(t, metrics, "# TYPE openmower_battery_voltage_volts gauge\nopenmower_battery_voltage_volts 27.5\n")
+	assert.Contains(t, metrics, `openmower_esc_rpm{esc="mow"} 3000`)
+	assert.Contains(t, metrics, `openmower_state{state="MOWING"} 1`)
+	assert.Contains(t, metrics, "openmower_gps_quality_ratio 0.9")
+	assert.Contains(t, metrics, `openmower_gui_websocket_clients{topic="/mower/status"}`)
+	assert.Contains(t, metrics, "# TYPE go_goroutines gauge")
+}
+
+func TestHealthRoutes(t *testing.T) {
+	s := newTestServer(t, map[string]string{"system.health.topics": "/mower/status"})
+	s.ros.SetGraph([]types.RosNode{{Name: "/mower_logic"}}, nil, nil)
+
+	code, body := s.do(t, "GET", "/healthz", nil)
+	assert.Equal(t, 200, code)
+	var report types.HealthReport
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.Equal(t, types.HealthOK, report.Status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "db", report.Checks[0].Name)
+
+	code, body = s.do(t, "GET", "/readyz", nil)
+	assert.Equal(t, 200, code)
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.Equal(t, types.HealthDegraded, report.Status)
+	statuses := map[string]string{}
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	assert.Equal(t, map[string]string{
+		"db": "ok", "docker": "ok", "rosMaster": "ok", "topic:/mower/status": "degraded", "mqtt": "disabled", "homekit": "disabled",
+	}, statuses)
+
+	require.NoError(t, s.ros.Publish("/mower/status", &mower_msgs.Status{}))
+	code, body = s.do(t, "GET", "/readyz", nil)
+	assert.Equal(t, 200, code)
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.Equal(t, types.HealthOK, report.Status)
+
+	s.docker.ListError = errors.New("cannot connect to the Docker daemon")
+	code, body = s.do(t, "GET", "/readyz", nil)
+	assert.Equal(t, 503, code)
+	require.NoError(t, json.Unmarshal(body, &report))
+	assert.Equal(t, types.HealthFailed, report.Status)
+	assert.Equal(t, "cannot connect to the Docker daemon", report.Checks[1].Message)
+}
+
+func TestBackupRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	code, _ := s.do(t, "POST", "/api/system/backup", nil)
+	assert.Equal(t, 403, code)
+
+	s = newTestServer(t, map[string]string{"system.api.adminToken": "secret"})
+	require.NoError(t, os.WriteFile(s.configFile, []byte("export OM_TOOL_WIDTH=0.13\n"), 0644))
+	require.NoError(t, s.ros.Publish("/xbot_monitoring/map", xbot_msgs.Map{DockX: 1}))
+	s.token = "wrong"
+	code, _ = s.do(t, "POST", "/api/system/backup", nil)
+	assert.Equal(t, 401, code)
+	s.token = "secret"
+	code, archive := s.do(t, "POST", "/api/system/backup", BackupRequest{Passphrase: "pass"})
+	assert.Equal(t, 200, code)
+
+	restore := func(archive []byte, passphrase string) (int, []byte) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("archive", "backup.tar.gz.enc")
+		require.NoError(t, err)
+		_, err = part.Write(archive)
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteField("passphrase", passphrase))
+		require.NoError(t, writer.Close())
+		req, err := http.NewRequest("POST", s.URL+"/api/system/restore", &body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer secret")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		content, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, content
+	}
+	code, _ = restore(archive, "wrong")
+	assert.Equal(t, 400, code)
+
+	require.NoError(t, os.WriteFile(s.configFile, []byte("export OM_TOOL_WIDTH=0.2\n"), 0644))
+	code, body := restore(archive, "pass")
+	assert.Equal(t, 200, code)
+	var manifest types.BackupManifest
+	require.NoError(t, json.Unmarshal(body, &manifest))
+	assert.True(t, manifest.Encrypted)
+	assert.Equal(t, []string{types.BackupDB, types.BackupMowerConfig, types.BackupMap}, manifest.Contents)
+	content, err := os.ReadFile(s.configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "export OM_TOOL_WIDTH=0.13\n", string(content))
+}
+
+func TestLogsRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	code, _ := s.do(t, "GET", "/api/system/logs", nil)
+	assert.Equal(t, 403, code)
+
+	s = newTestServer(t, map[string]string{"system.api.adminToken": "secret"})
+	s.token = ""
+	code, _ = s.do(t, "GET", "/api/system/logs?subsystem=api", nil)
+	assert.Equal(t, 401, code)
+	s.token = "secret"
+
+	code, body := s.do(t, "PUT", "/api/system/logs/levels", map[string]string{"ros": "loud", "unknown": "debug"})
+	assert.Equal(t, 400, code)
+	var validation ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(body, &validation))
+	assert.Equal(t, map[string]string{"levels.ros": `not a valid logrus Level: "loud"`, "levels.unknown": "unknown subsystem"}, validation.Fields)
+
+	code, _ = s.do(t, "PUT", "/api/system/logs/levels", map[string]string{"api": "debug"})
+	assert.Equal(t, 200, code)
+	code, body = s.do(t, "GET", "/api/system/logs/levels", nil)
+	assert.Equal(t, 200, code)
+	var levels map[string]string
+	require.NoError(t, json.Unmarshal(body, &levels))
+	assert.Equal(t, "debug", levels["api"])
+	assert.Equal(t, "info", levels["ros"])
+
+	apiLog.WithField("route", "logs").Debug("logs route test")
+	code, body = s.do(t, "GET", "/api/system/logs?subsystem=api&level=debug&limit=1", nil)
+	assert.Equal(t, 200, code)
+	var entries []types.LogEntry
+	require.NoError(t, json.Unmarshal(body, &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "debug", entries[0].Level)
+	assert.Equal(t, "logs route test", entries[0].Message)
+	assert.Equal(t, map[string]any{"route": "logs"}, entries[0].Fields)
+	code, _ = s.do(t, "GET", "/api/system/logs?level=loud", nil)
+	assert.Equal(t, 400, code)
+
+	conn := s.dial(t, "/api/system/logs/stream?subsystem=api&level=debug&limit=1")
+	var entry types.LogEntry
+	require.NoError(t, json.Unmarshal(readBase64Message(t, conn), &entry))
+	assert.Equal(t, "logs route test", entry.Message)
+	apiLog.Warn("logs stream test")
+	require.NoError(t, json.Unmarshal(readBase64Message(t, conn), &entry))
+	assert.Equal(t, "warning", entry.Level)
+	assert.Equal(t, "logs stream test", entry.Message)
+}
+
+func TestReconfigureRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	code, body := s.do(t, "GET", "/api/ros/parameters/mower_logic", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), "automatic_mode")
+
+	code, body = s.do(t, "POST", "/api/ros/parameters/mower_logic", map[string]any{"automatic_mode": 2})
+	assert.Equal(t, 200, code)
+	var parameters []types.ReconfigureParameter
+	require.NoError(t, json.Unmarshal(body, &parameters))
+	assert.Equal(t, 2.0, parameters[0].Value)
+
+	code, body = s.do(t, "POST", "/api/ros/parameters/mower_logic", map[string]any{"unknown": 1})
+	assert.Equal(t, 400, code)
+	var validation ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(body, &validation))
+	assert.Equal(t, map[string]string{"unknown": "unknown parameter"}, validation.Fields)
+
+	code, _ = s.do(t, "GET", "/api/ros/parameters/unknown", nil)
+	assert.Equal(t, 500, code)
+}
+
+func TestRecordingAndReplayRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+	require.NoError(t, s.ros.RegisterTopic("/mower_logic/current_state", &mower_msgs.HighLevelStatus{}))
+
+	code, body := s.do(t, "POST", "/api/recordings/start", StartRecordingRequest{Name: "test", Topics: []string{"/mower_logic/current_state"}})
+	require.Equal(t, 200, code, string(body))
+	for _, state := range []string{"IDLE", "UNDOCKING", "MOWING"} {
+		require.NoError(t, s.ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: state}))
+	}
+	code, body = s.do(t, "POST", "/api/recordings/stop", nil)
+	require.Equal(t, 200, code, string(body))
+	var recording types.Recording
+	require.NoError(t, json.Unmarshal(body, &recording))
+	assert.Equal(t, "test.bag", recording.Name)
+	assert.False(t, recording.Active)
+
+	code, body = s.do(t, "GET", "/api/recordings", nil)
+	assert.Equal(t, 200, code)
+	var recordings []types.Recording
+	require.NoError(t, json.Unmarshal(body, &recordings))
+	require.Len(t, recordings, 1)
+	assert.Equal(t, "manual", recordings[0].Trigger)
+
+	code, body = s.do(t, "GET", "/api/recordings/test.bag", nil)
+	assert.Equal(t, 200, code)
+	assert.True(t, bytes.HasPrefix(body, []byte("#ROSBAG V2.0\n")))
+	code, _ = s.do(t, "GET", "/api/recordings/missing.bag", nil)
+	assert.Equal(t, 404, code)
+
+	code, body = s.do(t, "GET", "/api/replay", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), `"active":false`)
+
+	conn := s.dial(t, "/api/openmower/subscribe/highLevelStatus")
+	require.Eventually(t, func() bool {
+		return s.ros.Subscribers("/mower_logic/current_state") == 6
+	}, 5*time.Second, 10*time.Millisecond)
+	var highLevelStatus mower_msgs.HighLevelStatus
+	require.NoError(t, json.Unmarshal(readBase64Message(t, conn), &highLevelStatus))
+	assert.Equal(t, "MOWING", highLevelStatus.StateName)
+	code, body = s.do(t, "POST", "/api/replay/load", ReplayCommandRequest{Name: "test.bag"})
+	require.Equal(t, 200, code, string(body))
+	var status types.ReplayStatus
+	require.NoError(t, json.Unmarshal(body, &status))
+	assert.True(t, status.Active)
+	assert.Equal(t, []string{"/mower_logic/current_state"}, status.Topics)
+	require.NoError(t, json.Unmarshal(readBase64Message(t, conn), &highLevelStatus))
+	assert.Equal(t, "IDLE", highLevelStatus.StateName)
+
+	code, _ = s.do(t, "POST", "/api/replay/speed", ReplayCommandRequest{Speed: 2})
+	assert.Equal(t, 200, code)
+	code, _ = s.do(t, "POST", "/api/replay/speed", ReplayCommandRequest{Speed: 50})
+	assert.Equal(t, 500, code)
+	code, _ = s.do(t, "POST", "/api/replay/play", nil)
+	assert.Equal(t, 200, code)
+	code, _ = s.do(t, "POST", "/api/replay/pause", nil)
+	assert.Equal(t, 200, code)
+	code, body = s.do(t, "POST", "/api/replay/seek", ReplayCommandRequest{Position: 10})
+	assert.Equal(t, 200, code)
+	require.NoError(t, json.Unmarshal(body, &status))
+	assert.Equal(t, status.Duration, status.Position)
+	code, _ = s.do(t, "POST", "/api/replay/rewind", nil)
+	assert.Equal(t, 500, code)
+	code, body = s.do(t, "POST", "/api/replay/stop", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), `"active":false`)
+
+	code, _ = s.do(t, "DELETE", "/api/recordings/test.bag", nil)
+	assert.Equal(t, 200, code)
+	code, _ = s.do(t, "DELETE", "/api/recordings/test.bag", nil)
+	assert.Equal(t, 500, code)
+}
+
+func TestSetupRoutes(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	code, body := s.do(t, "POST", "/api/setup/flashBoard", types.FirmwareConfig{BoardType: "BOARD_YARDFORCE500"})
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "event:message\ndata:building\n\nevent:message\ndata:flashing\n\nevent:end\ndata:end\n\n", string(body))
+	assert.Equal(t, "BOARD_YARDFORCE500", s.firmware.Flashed[0].BoardType)
+
+	s.gps.Err = errors.New("no gps")
+	code, body = s.do(t, "POST", "/api/setup/flashGPS", nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "event:message\ndata:configuring\n\nevent:error\ndata:no gps\n\n", string(body))
+}
+
+func TestTilesAndSwaggerRoutes(t *testing.T) {
+	tiles := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tile " + r.URL.Path))
+	}))
+	defer tiles.Close()
+	s := newTestServer(t, map[string]string{"system.map.enabled": "true", "system.map.tileServer": tiles.URL})
+
+	code, body := s.do(t, "GET", "/tiles/vt/1/2/3", nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "tile /vt/1/2/3", string(body))
+
+	code, body = s.do(t, "GET", "/swagger/doc.json", nil)
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), "/openmower/call/{command}")
+}
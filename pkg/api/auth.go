@@ -0,0 +1,60 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// adminTokenProtocol is the WebSocket subprotocol carrying the admin token. Browsers cannot set the headers of the
+// WebSockets, they offer the subprotocols "bearer" and the token, and the server answers with "bearer". The token is
+// never read from the query string since the access log records it.
+const adminTokenProtocol = "bearer"
+
+// adminUpgrader upgrades the connections of the admin WebSockets, selecting the adminTokenProtocol subprotocol
+var adminUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+	Subprotocols:    []string{adminTokenProtocol},
+}
+
+// AdminRequired only lets through the requests carrying the admin token of system.api.adminToken, as a bearer token or
+// in the subprotocols of the WebSockets. The routes are disabled when no admin token is set.
+func AdminRequired(dbProvider types.IDBProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authorizeAdmin(c, dbProvider) {
+			c.Next()
+		}
+	}
+}
+
+// authorizeAdmin tells if the request carries the admin token, otherwise it aborts the request with 403 when no admin
+// token is set or 401 when the token is invalid
+func authorizeAdmin(c *gin.Context, dbProvider types.IDBProvider) bool {
+	adminToken, err := dbProvider.Get("system.api.adminToken")
+	if err != nil || len(adminToken) == 0 {
+		c.AbortWithStatusJSON(403, ErrorResponse{Error: "admin routes are disabled, set system.api.adminToken to enable them"})
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(requestToken(c)), adminToken) != 1 {
+		c.AbortWithStatusJSON(401, ErrorResponse{Error: "invalid admin token"})
+		return false
+	}
+	return true
+}
+
+func requestToken(c *gin.Context) string {
+	if authorization := c.GetHeader("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimPrefix(authorization, "Bearer ")
+	}
+	protocols := websocket.Subprotocols(c.Request)
+	if len(protocols) == 2 && protocols[0] == adminTokenProtocol {
+		return protocols[1]
+	}
+	return ""
+}
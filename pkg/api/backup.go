@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBackupSize bounds the archives uploaded to the restore route
+const maxBackupSize = 256 << 20
+
+func BackupRoutes(r *gin.RouterGroup, provider types.IBackupProvider, dbProvider types.IDBProvider) {
+	group := r.Group("/system", AdminRequired(dbProvider))
+	BackupRoute(group, provider)
+	RestoreBackupRoute(group, provider)
+}
+
+// BackupRoute download a backup of the GUI
+//
+// @Summary download a backup of the GUI
+// @Description download a tar.gz archive of the GUI DB keys, including the firmware config and the HomeKit identity, the mower_config.sh file and the map. The archive is encrypted when a passphrase is given. Requires the admin token.
+// @Tags system
+// @Accept  json
+// @Produce  application/octet-stream
+// @Param request body BackupRequest false "passphrase encrypting the archive"
+// @Success 200 {file} file
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /system/backup [post]
+func BackupRoute(group *gin.RouterGroup, provider types.IBackupProvider) {
+	group.POST("/backup", func(c *gin.Context) {
+		var request BackupRequest
+		if c.Request.ContentLength != 0 {
+			err := c.BindJSON(&request)
+			if err != nil {
+				c.JSON(500, ErrorResponse{Error: err.Error()})
+				return
+			}
+		}
+		archive, manifest, err := provider.Backup(c.Request.Context(), request.Passphrase)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		name := "openmower-gui-backup-" + manifest.CreatedAt.Format("20060102-150405") + ".tar.gz"
+		if manifest.Encrypted {
+			name += ".enc"
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+		c.Data(200, "application/octet-stream", archive)
+	})
+}
+
+// RestoreBackupRoute restore a backup of the GUI
+//
+// @Summary restore a backup of the GUI
+// @Description validates then applies an archive of the backup route: the DB keys of the archive are set, the mower_config.sh file is replaced, its previous content is kept in the settings history, and the map is replaced. If a part fails, the parts already restored are rolled back and the error tells which ones. The GUI must be restarted to use the restored HomeKit identity. Requires the admin token.
+// @Tags system
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param archive formData file true "backup archive"
+// @Param passphrase formData string false "passphrase of an encrypted archive"
+// @Success 200 {object} types.BackupManifest
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /system/restore [post]
+func RestoreBackupRoute(group *gin.RouterGroup, provider types.IBackupProvider) {
+	group.POST("/restore", func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupSize)
+		header, err := c.FormFile("archive")
+		if err != nil {
+			c.JSON(400, ErrorResponse{Error: err.Error()})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		defer file.Close()
+		archive, err := io.ReadAll(file)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		manifest, err := provider.Restore(c.Request.Context(), archive, c.PostForm("passphrase"))
+		switch {
+		case errors.Is(err, types.ErrInvalidBackup):
+			c.JSON(400, ErrorResponse{Error: err.Error()})
+		case err != nil:
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(200, manifest)
+		}
+	})
+}
@@ -1,10 +1,29 @@
 package api
 
 import (
+	"strings"
+
 	"github.com/cedbossneo/openmower-gui/pkg/types"
 	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
 )
 
+// protectedConfigKeys cannot be read nor written through the config keys routes
+var protectedConfigKeys = []string{"system.api.adminToken"}
+
+// settingsConfigKeys are the system keys of the settings page, they can be read and written without the admin token
+var settingsConfigKeys = []string{
+	"system.api.addr", "system.api.webDirectory", "system.map.enabled", "system.map.tileServer", "system.map.tileUri",
+	"system.mower.configFile", "system.mqtt.enabled", "system.mqtt.host", "system.mqtt.prefix",
+	"system.homekit.enabled", "system.homekit.pincode", "system.ros.nodeName", "system.ros.masterUri",
+	"system.ros.nodeHost",
+}
+
+// adminConfigKey tells if key can only be read or written with the admin token
+func adminConfigKey(key string) bool {
+	return strings.HasPrefix(key, "system.") && !lo.Contains(settingsConfigKeys, key)
+}
+
 func ConfigRoute(r *gin.RouterGroup, db types.IDBProvider) {
 	ConfigEnvRoute(r, db)
 	ConfigGetKeysRoute(r, db)
@@ -14,11 +33,13 @@ func ConfigRoute(r *gin.RouterGroup, db types.IDBProvider) {
 // ConfigGetKeysRoute get config from backend
 //
 // @Summary get config from backend
-// @Description get config from backend
+// @Description get config from backend, the system.* keys that are not on the settings page require the admin token
 // @Tags config
 // @Produce  json
 // @Param settings body map[string]string true "settings"
 // @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /config/keys/get [post]
 func ConfigGetKeysRoute(r *gin.RouterGroup, db types.IDBProvider) gin.IRoutes {
@@ -31,7 +52,14 @@ func ConfigGetKeysRoute(r *gin.RouterGroup, db types.IDBProvider) gin.IRoutes {
 			})
 			return
 		}
+		if lo.SomeBy(lo.Keys(body), adminConfigKey) && !authorizeAdmin(context, db) {
+			return
+		}
 		for key, _ := range body {
+			if lo.Contains(protectedConfigKeys, key) {
+				delete(body, key)
+				continue
+			}
 			get, err := db.Get(key)
 			if err != nil {
 				continue
@@ -46,11 +74,13 @@ func ConfigGetKeysRoute(r *gin.RouterGroup, db types.IDBProvider) gin.IRoutes {
 // ConfigSetKeysRoute set config to backend
 //
 // @Summary set config to backend
-// @Description set config to backend
+// @Description set config to backend, the system.* keys that are not on the settings page require the admin token
 // @Tags config
 // @Produce  json
 // @Param settings body map[string]string true "settings"
 // @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /config/keys/set [post]
 func ConfigSetKeysRoute(r *gin.RouterGroup, db types.IDBProvider) gin.IRoutes {
@@ -63,7 +93,14 @@ func ConfigSetKeysRoute(r *gin.RouterGroup, db types.IDBProvider) gin.IRoutes {
 			})
 			return
 		}
+		if lo.SomeBy(lo.Keys(body), adminConfigKey) && !authorizeAdmin(context, db) {
+			return
+		}
 		for key, value := range body {
+			if lo.Contains(protectedConfigKeys, key) {
+				delete(body, key)
+				continue
+			}
 			err := db.Set(key, []byte(value.(string)))
 			if err != nil {
 				continue
@@ -1,24 +1,38 @@
 package api
 
 import (
-	"bufio"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
-	"fmt"
+	"encoding/json"
+	"errors"
 	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
 	"github.com/docker/docker/api/types"
+	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
 	"io"
-	"log"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-func ContainersRoutes(r *gin.RouterGroup, provider types2.IDockerProvider) {
+func ContainersRoutes(r *gin.RouterGroup, provider types2.IDockerProvider, dbProvider types2.IDBProvider) {
 	group := r.Group("/containers")
 	ContainerListRoutes(group, provider)
 	ContainerLogsRoutes(group, provider)
+	ContainerLogsDownloadRoutes(group, provider)
+	ContainerStatsRoutes(group, provider)
+	ContainerStatsStreamRoutes(group, provider)
+	ContainerUpdateCheckRoutes(group, provider)
+	ContainerUpdateRoutes(group, provider)
+	ContainerExecRoutes(group, provider, dbProvider)
 	ContainerCommandRoutes(group, provider)
 }
 
@@ -43,13 +57,9 @@ func ContainerListRoutes(group *gin.RouterGroup, provider types2.IDockerProvider
 			if container.Labels == nil {
 				container.Labels = map[string]string{}
 			}
-			if lo.Contains(container.Names, "/openmower") {
+			if app := containerApp(container); app != "" {
 				container.Labels["project"] = "openmower"
-				container.Labels["app"] = "openmower"
-			}
-			if lo.Contains(container.Names, "/openmower-gui") {
-				container.Labels["project"] = "openmower"
-				container.Labels["app"] = "gui"
+				container.Labels["app"] = app
 			}
 			return Container{
 				ID:     container.ID,
@@ -61,6 +71,202 @@ func ContainerListRoutes(group *gin.RouterGroup, provider types2.IDockerProvider
 	})
 }
 
+// containerApp returns the OpenMower application run by the container, openmower or gui, or an empty string
+func containerApp(container types.Container) string {
+	switch {
+	case lo.Contains(container.Names, "/openmower"):
+		return "openmower"
+	case lo.Contains(container.Names, "/openmower-gui"):
+		return "gui"
+	}
+	return ""
+}
+
+// appContainers returns the containers of the openmower and gui applications
+func appContainers(ctx context.Context, provider types2.IDockerProvider) ([]types.Container, error) {
+	containers, err := provider.ContainerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(containers, func(container types.Container, idx int) bool {
+		return containerApp(container) != ""
+	}), nil
+}
+
+// ContainerStatsRoutes get the resource usage of the OpenMower containers
+//
+// @Summary get the resource usage of the OpenMower containers
+// @Description get the CPU, memory, network and block I/O usage, the health and the uptime of the openmower and gui containers
+// @Tags containers
+// @Produce  json
+// @Success 200 {object} ContainerStatsResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /containers/stats [get]
+func ContainerStatsRoutes(group *gin.RouterGroup, provider types2.IDockerProvider) {
+	group.GET("/stats", func(c *gin.Context) {
+		containers, err := appContainers(c.Request.Context(), provider)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		// a snapshot takes two samples, the containers are sampled concurrently
+		response := ContainerStatsResponse{Stats: make([]ContainerStats, len(containers))}
+		errs := make([]error, len(containers))
+		var wg sync.WaitGroup
+		for i, container := range containers {
+			wg.Add(1)
+			go func(i int, container types.Container) {
+				defer wg.Done()
+				errs[i] = provider.ContainerStats(c.Request.Context(), container.ID, false, func(stats types2.ContainerStats) error {
+					response.Stats[i] = ContainerStats{ContainerStats: stats, App: containerApp(container)}
+					return nil
+				})
+			}(i, container)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				c.JSON(500, ErrorResponse{Error: err.Error()})
+				return
+			}
+		}
+		c.JSON(200, response)
+	})
+}
+
+// ContainerStatsStreamRoutes stream the resource usage of the OpenMower containers
+//
+// @Summary stream the resource usage of the OpenMower containers
+// @Description websocket sending the usage of the openmower and gui containers every second, messages are base64 encoded JSON ContainerStats
+// @Tags containers
+// @Router /containers/stats/stream [get]
+func ContainerStatsStreamRoutes(group *gin.RouterGroup, provider types2.IDockerProvider) {
+	group.GET("/stats/stream", func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		containers, err := appContainers(ctx, provider)
+		if err != nil {
+			apiLog.Error(xerrors.Errorf("error listing the containers: %w", err))
+			return
+		}
+		var mtx sync.Mutex
+		for _, container := range containers {
+			go func(container types.Container) {
+				err := provider.ContainerStats(ctx, container.ID, true, func(stats types2.ContainerStats) error {
+					data, err := json.Marshal(ContainerStats{ContainerStats: stats, App: containerApp(container)})
+					if err != nil {
+						return err
+					}
+					mtx.Lock()
+					defer mtx.Unlock()
+					return conn.WriteMessage(websocket.TextMessage, []byte(base64.StdEncoding.EncodeToString(data)))
+				})
+				if err != nil && ctx.Err() == nil {
+					apiLog.Error(xerrors.Errorf("error reading the stats of %s: %w", container.ID, err))
+				}
+			}(container)
+		}
+		// the stats are streamed until the client closes the connection
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// ContainerUpdateCheckRoutes check if the image of a container has an update
+//
+// @Summary check if the image of a container has an update
+// @Description compare the digest of the image tag of the container in its registry with the digest of the local image
+// @Tags containers
+// @Produce  json
+// @Param containerId path string true "container id"
+// @Success 200 {object} types.ImageUpdateStatus
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /containers/{containerId}/update [get]
+func ContainerUpdateCheckRoutes(group *gin.RouterGroup, provider types2.IDockerProvider) {
+	group.GET("/:containerId/update", func(c *gin.Context) {
+		status, err := provider.CheckImageUpdate(c.Request.Context(), c.Param("containerId"))
+		switch {
+		case errors.Is(err, types2.ErrUnknownContainer):
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+		case err != nil:
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(200, status)
+		}
+	})
+}
+
+// ContainerUpdateRoutes update the image of a container
+//
+// @Summary update the image of a container
+// @Description pull the image tag of the container and recreate it, the previous container is restored if the new one does not become healthy. The progress events are JSON types.ImageUpdateEvent, the end event is a JSON types.ImageUpdateResult. The GUI container cannot be updated this way.
+// @Tags containers
+// @Produce  text/event-stream
+// @Param containerId path string true "container id"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /containers/{containerId}/update [post]
+func ContainerUpdateRoutes(group *gin.RouterGroup, provider types2.IDockerProvider) {
+	group.POST("/:containerId/update", func(c *gin.Context) {
+		containerID := c.Param("containerId")
+		containers, err := provider.ContainerList(c.Request.Context())
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		container, ok := lo.Find(containers, func(container types.Container) bool {
+			return container.ID == containerID
+		})
+		if ok && containerApp(container) == "gui" {
+			c.JSON(400, ErrorResponse{Error: "the GUI cannot recreate its own container"})
+			return
+		}
+		// the update goes on if the client disconnects, a container must not be left stopped
+		type updateEvent struct {
+			name string
+			data any
+		}
+		events := make(chan updateEvent)
+		done := make(chan struct{})
+		defer close(done)
+		send := func(event updateEvent) {
+			select {
+			case events <- event:
+			case <-done:
+			}
+		}
+		go func() {
+			defer close(events)
+			result, err := provider.UpdateContainer(context.Background(), containerID, func(event types2.ImageUpdateEvent) {
+				send(updateEvent{name: "progress", data: event})
+			})
+			if err != nil {
+				apiLog.Error(xerrors.Errorf("failed to update %s: %w", containerID, err))
+				send(updateEvent{name: "error", data: err.Error()})
+				return
+			}
+			send(updateEvent{name: "end", data: result})
+		}()
+		c.Stream(func(w io.Writer) bool {
+			event, ok := <-events
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.name, event.data)
+			return true
+		})
+	})
+}
+
 // ContainerCommandRoutes execute a command on a container
 //
 // @Summary execute a command on a container
@@ -70,6 +276,8 @@ func ContainerListRoutes(group *gin.RouterGroup, provider types2.IDockerProvider
 // @Param containerId path string true "container id"
 // @Param command path string true "command to execute (start/stop/restart)"
 // @Success 200 {object} OkResponse
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /containers/{containerId}/{command} [post]
 func ContainerCommandRoutes(group *gin.RouterGroup, provider types2.IDockerProvider) {
@@ -85,22 +293,124 @@ func ContainerCommandRoutes(group *gin.RouterGroup, provider types2.IDockerProvi
 			err = provider.ContainerStop(c.Request.Context(), containerID)
 		case "start":
 			err = provider.ContainerStart(c.Request.Context(), containerID)
+		default:
+			err = &types2.ValidationError{Fields: map[string]string{"command": "must be start, stop or restart"}}
 		}
-		if err != nil {
+		var validationErr *types2.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+		case errors.Is(err, types2.ErrUnknownContainer):
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+		case err != nil:
 			c.JSON(500, ErrorResponse{Error: err.Error()})
-			return
+		default:
+			c.JSON(200, OkResponse{})
 		}
-		c.JSON(200, OkResponse{})
 	})
 }
 
+// containerLogsQuery are the options of the container logs routes, Filter is a regular expression the messages must
+// match, Level the minimum level of the messages and Format the format of the downloaded file (text or gzip)
+type containerLogsQuery struct {
+	types2.ContainerLogsQuery
+	Timestamps bool   `form:"timestamps"`
+	Filter     string `form:"filter"`
+	Level      string `form:"level"`
+	Format     string `form:"format"`
+}
+
+// containerLogLevel finds the level of a log line, e.g. "[ WARN] [1690000000.0]: ..." for ROS or level=warning for
+// logrus
+var containerLogLevel = regexp.MustCompile(`(?i)\b(trace|debug|info|warn|warning|error|fatal|panic)\b`)
+
+// matcher validates the query and returns the function selecting the lines. A line without a level, such as a
+// stack trace, has the level of the previous line of its stream.
+func (q containerLogsQuery) matcher() (func(line types2.ContainerLogLine) bool, error) {
+	fields := map[string]string{}
+	now := time.Now()
+	for field, value := range map[string]string{"since": q.Since, "until": q.Until} {
+		if _, err := timetypes.GetTimestamp(value, now); value != "" && err != nil {
+			fields[field] = err.Error()
+		}
+	}
+	if q.Tail != "" && q.Tail != "all" {
+		if _, err := strconv.Atoi(q.Tail); err != nil {
+			fields["tail"] = "must be a number of lines or all"
+		}
+	}
+	if !lo.Contains([]string{"", "stdout", "stderr"}, q.Stream) {
+		fields["stream"] = "must be stdout or stderr"
+	}
+	filter, err := regexp.Compile(q.Filter)
+	if err != nil {
+		fields["filter"] = err.Error()
+	}
+	minLevel := logrus.TraceLevel
+	if q.Level != "" {
+		minLevel, err = logrus.ParseLevel(q.Level)
+		if err != nil {
+			fields["level"] = err.Error()
+		}
+	}
+	if !lo.Contains([]string{"", "text", "gzip"}, q.Format) {
+		fields["format"] = "must be text or gzip"
+	}
+	if len(fields) > 0 {
+		return nil, &types2.ValidationError{Fields: fields}
+	}
+	levels := map[string]logrus.Level{}
+	return func(line types2.ContainerLogLine) bool {
+		if match := containerLogLevel.FindString(line.Message); match != "" {
+			levels[line.Stream], _ = logrus.ParseLevel(strings.ToLower(match))
+		}
+		level, ok := levels[line.Stream]
+		if q.Level != "" && (!ok || level > minLevel) {
+			return false
+		}
+		return filter.MatchString(line.Message)
+	}, nil
+}
+
+func (q containerLogsQuery) format(line types2.ContainerLogLine) string {
+	if q.Timestamps && !line.Timestamp.IsZero() {
+		return line.Timestamp.Format(time.RFC3339Nano) + " " + line.Message
+	}
+	return line.Message
+}
+
+// bindContainerLogsQuery reads the query, it answers with an error and returns false if it is invalid
+func bindContainerLogsQuery(c *gin.Context) (containerLogsQuery, func(line types2.ContainerLogLine) bool, bool) {
+	var query containerLogsQuery
+	err := c.BindQuery(&query)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Error: err.Error()})
+		return query, nil, false
+	}
+	match, err := query.matcher()
+	var validationErr *types2.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+		return query, nil, false
+	}
+	return query, match, true
+}
+
 // ContainerLogsRoutes stream container logs
 //
 // @Summary get container logs
-// @Description get container logs
+// @Description websocket sending the last lines of the container logs then the new ones, messages are base64 encoded lines
 // @Tags containers
 // @Produce text/event-stream
 // @Param containerId path string true "container id"
+// @Param since query string false "only the lines since this RFC 3339 date, unix timestamp or duration, e.g. 10m"
+// @Param until query string false "only the lines until this RFC 3339 date, unix timestamp or duration"
+// @Param tail query string false "number of lines sent first or all, 100 by default"
+// @Param stream query string false "stdout or stderr, both by default"
+// @Param timestamps query bool false "prefix the lines with their timestamp"
+// @Param filter query string false "regular expression the lines must match"
+// @Param level query string false "minimum level of the lines, e.g. warning"
+// @Failure 400 {object} ValidationErrorResponse
 // @Router /containers/{containerId}/logs [get]
 func ContainerLogsRoutes(group *gin.RouterGroup, provider types2.IDockerProvider) {
 	var upgrader = websocket.Upgrader{
@@ -111,6 +421,14 @@ func ContainerLogsRoutes(group *gin.RouterGroup, provider types2.IDockerProvider
 
 	group.GET("/:containerId/logs", func(c *gin.Context) {
 		containerID := c.Param("containerId")
+		query, match, ok := bindContainerLogsQuery(c)
+		if !ok {
+			return
+		}
+		if query.Tail == "" {
+			query.Tail = "100"
+		}
+		query.Follow = true
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
 			return
@@ -118,42 +436,220 @@ func ContainerLogsRoutes(group *gin.RouterGroup, provider types2.IDockerProvider
 		defer func(conn *websocket.Conn) {
 			err := conn.Close()
 			if err != nil {
-				fmt.Println("error closing websocket connection: ", err.Error())
+				apiLog.Error(xerrors.Errorf("error closing websocket connection: %w", err))
 			}
 		}(conn)
 
-		/*
-		   read the logs from docker using docker SDK. be noticed that the Follow value must set to true.
-		*/
-		reader, err := provider.ContainerLogs(context.Background(), containerID)
+		// the logs are followed until the client closes the connection
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+		err = provider.ContainerLogs(ctx, containerID, query.ContainerLogsQuery, func(line types2.ContainerLogLine) error {
+			if !match(line) {
+				return nil
+			}
+			return conn.WriteMessage(websocket.TextMessage, []byte(base64.StdEncoding.EncodeToString([]byte(query.format(line)))))
+		})
+		if err != nil && ctx.Err() == nil {
+			apiLog.Error(xerrors.Errorf("error reading the logs of %s: %w", containerID, err))
+		}
+	})
+}
+
+// containerExecQuery is the command run by the exec route and the initial size of its TTY
+type containerExecQuery struct {
+	Cmd  []string `form:"cmd"`
+	Rows uint     `form:"rows"`
+	Cols uint     `form:"cols"`
+}
+
+// execMessage is a message sent by the client of the exec route, Data is base64 encoded stdin for the stdin type and
+// Rows and Cols the TTY size for the resize type
+type execMessage struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+	Rows uint   `json:"rows"`
+	Cols uint   `json:"cols"`
+}
+
+// execOutput sends the output of an exec session as base64 encoded messages
+type execOutput struct {
+	conn *websocket.Conn
+}
+
+func (o execOutput) Write(p []byte) (int, error) {
+	err := o.conn.WriteMessage(websocket.TextMessage, []byte(base64.StdEncoding.EncodeToString(p)))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ContainerExecRoutes open a shell in a container
+//
+// @Summary open a shell in a container
+// @Description websocket running a command with a TTY in the container, /bin/bash by default. The messages sent are the base64 encoded output, the messages received are JSON objects: {"type":"stdin","data":"<base64>"} or {"type":"resize","rows":24,"cols":80}. The connection is closed with the reason "exit <code>" when the command exits. Restricted to the admin token, sent as a bearer token or, from a browser, in the subprotocols "bearer" and the token.
+// @Tags containers
+// @Param containerId path string true "container id"
+// @Param cmd query []string false "command and arguments" collectionFormat(multi)
+// @Param rows query int false "initial rows of the TTY"
+// @Param cols query int false "initial columns of the TTY"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /containers/{containerId}/exec [get]
+func ContainerExecRoutes(group *gin.RouterGroup, provider types2.IDockerProvider, dbProvider types2.IDBProvider) {
+	group.GET("/:containerId/exec", AdminRequired(dbProvider), func(c *gin.Context) {
+		containerID := c.Param("containerId")
+		var query containerExecQuery
+		err := c.BindQuery(&query)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if len(query.Cmd) == 0 {
+			query.Cmd = []string{"/bin/bash"}
+		}
+		conn, err := adminUpgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
-			fmt.Println("error reader: ", err.Error())
 			return
 		}
-		defer func(reader io.ReadCloser) {
-			err := reader.Close()
+		defer func(conn *websocket.Conn) {
+			err := conn.Close()
 			if err != nil {
-				fmt.Println("error closing reader: ", err.Error())
+				apiLog.Error(xerrors.Errorf("error closing websocket connection: %w", err))
 			}
-		}(reader)
-		/*
-		   send log lines to channel
-		*/
-		rd := bufio.NewReader(reader)
-		for {
-			// read lines from the reader
-			str, _, err := rd.ReadLine()
-			if err != nil {
-				log.Println("Read Error:", err.Error())
-				return
+		}(conn)
+		apiLog.Infof("exec %s in %s from %s", strings.Join(query.Cmd, " "), containerID, c.ClientIP())
+
+		// the session ends when the command exits or when the client closes the connection
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stdin, stdinWriter := io.Pipe()
+		defer stdin.Close()
+		resize := make(chan types2.TerminalSize)
+		go func() {
+			defer cancel()
+			defer stdinWriter.Close()
+			for {
+				var message execMessage
+				if err := conn.ReadJSON(&message); err != nil {
+					return
+				}
+				switch message.Type {
+				case "stdin":
+					data, err := base64.StdEncoding.DecodeString(message.Data)
+					if err != nil {
+						apiLog.Error(xerrors.Errorf("invalid exec stdin: %w", err))
+						continue
+					}
+					if _, err := stdinWriter.Write(data); err != nil {
+						return
+					}
+				case "resize":
+					select {
+					case resize <- types2.TerminalSize{Rows: message.Rows, Cols: message.Cols}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+		exitCode, err := provider.ContainerExec(ctx, containerID, types2.ExecOptions{
+			Cmd:  query.Cmd,
+			Size: types2.TerminalSize{Rows: query.Rows, Cols: query.Cols},
+		}, stdin, execOutput{conn: conn}, resize)
+		if ctx.Err() != nil {
+			return
+		}
+		reason := "exit " + strconv.Itoa(exitCode)
+		if err != nil {
+			apiLog.Error(xerrors.Errorf("error executing %s in %s: %w", strings.Join(query.Cmd, " "), containerID, err))
+			// the reason of a close message is limited to 123 bytes
+			reason = lo.Substring(err.Error(), 0, 123)
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason), time.Now().Add(time.Second))
+	})
+}
+
+// ContainerLogsDownloadRoutes download container logs
+//
+// @Summary download container logs
+// @Description download the container logs matching the query as a text or gzip file
+// @Tags containers
+// @Produce plain
+// @Produce application/gzip
+// @Param containerId path string true "container id"
+// @Param since query string false "only the lines since this RFC 3339 date, unix timestamp or duration, e.g. 10m"
+// @Param until query string false "only the lines until this RFC 3339 date, unix timestamp or duration"
+// @Param tail query string false "number of last lines or all, all by default"
+// @Param stream query string false "stdout or stderr, both by default"
+// @Param timestamps query bool false "prefix the lines with their timestamp"
+// @Param filter query string false "regular expression the lines must match"
+// @Param level query string false "minimum level of the lines, e.g. warning"
+// @Param format query string false "text or gzip, text by default"
+// @Success 200 {file} file
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /containers/{containerId}/logs/download [get]
+func ContainerLogsDownloadRoutes(group *gin.RouterGroup, provider types2.IDockerProvider) {
+	group.GET("/:containerId/logs/download", func(c *gin.Context) {
+		containerID := c.Param("containerId")
+		query, match, ok := bindContainerLogsQuery(c)
+		if !ok {
+			return
+		}
+		if query.Tail == "" {
+			query.Tail = "all"
+		}
+		// the response is started with the first line so that an error reading the logs can still be answered
+		var writer io.Writer
+		var gzipWriter *gzip.Writer
+		start := func() {
+			filename := containerID + "-logs.txt"
+			c.Header("Content-Type", "text/plain; charset=utf-8")
+			writer = c.Writer
+			if query.Format == "gzip" {
+				filename += ".gz"
+				c.Header("Content-Type", "application/gzip")
+				gzipWriter = gzip.NewWriter(c.Writer)
+				writer = gzipWriter
 			}
-			// send events to client
-			err = conn.WriteMessage(websocket.TextMessage, []byte(base64.StdEncoding.EncodeToString(str)))
+			c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+			c.Status(200)
+		}
+		err := provider.ContainerLogs(c.Request.Context(), containerID, query.ContainerLogsQuery, func(line types2.ContainerLogLine) error {
+			if !match(line) {
+				return nil
+			}
+			if writer == nil {
+				start()
+			}
+			_, err := io.WriteString(writer, query.format(line)+"\n")
+			return err
+		})
+		if err != nil && writer == nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			apiLog.Error(xerrors.Errorf("error downloading the logs of %s: %w", containerID, err))
+		}
+		if writer == nil {
+			start()
+		}
+		if gzipWriter != nil {
+			err = gzipWriter.Close()
 			if err != nil {
-				log.Println("Write Error:", err.Error())
-				return
+				apiLog.Error(xerrors.Errorf("error closing the logs of %s: %w", containerID, err))
 			}
-			// send the lines to channel
 		}
 	})
 }
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthRoutes serves /healthz and /readyz outside of the API base path, they answer 503 when a critical check
+// failed so that container runtimes and supervisors can act on them
+func HealthRoutes(r *gin.Engine, provider types.IHealthProvider) {
+	r.GET("/healthz", func(c *gin.Context) {
+		writeHealthReport(c, provider.Live(c.Request.Context()))
+	})
+	r.GET("/readyz", func(c *gin.Context) {
+		writeHealthReport(c, provider.Ready(c.Request.Context()))
+	})
+}
+
+func writeHealthReport(c *gin.Context, report types.HealthReport) {
+	if report.Status == types.HealthFailed {
+		c.JSON(503, report)
+		return
+	}
+	c.JSON(200, report)
+}
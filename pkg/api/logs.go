@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/distribution/uuid"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// defaultLogsTail is the number of recent entries sent when a logs stream is opened without a limit
+	defaultLogsTail = 100
+	// logsStreamQueueSize is the number of new entries waiting to be sent to a stream before they are dropped
+	logsStreamQueueSize = 256
+	// logsStreamWriteTimeout closes a stream whose client doesn't read the entries
+	logsStreamWriteTimeout = 10 * time.Second
+)
+
+func LogsRoutes(r *gin.RouterGroup, provider types.ILogProvider, dbProvider types.IDBProvider) {
+	// the logs may hold secrets, they are restricted to the admin token
+	group := r.Group("/system/logs", AdminRequired(dbProvider))
+	LogsRoute(group, provider)
+	LogsStreamRoute(group, provider)
+	LogLevelsRoute(group, provider)
+	SetLogLevelsRoute(group, provider)
+}
+
+// LogsRoute list the recent log entries of the GUI
+//
+// @Summary list the recent log entries of the GUI
+// @Description list the entries kept in the log buffer, from the oldest to the most recent
+// @Tags system
+// @Produce  json
+// @Param subsystem query string false "only the entries of this subsystem"
+// @Param level query string false "minimum level, e.g. warning"
+// @Param limit query int false "maximum number of most recent entries"
+// @Success 200 {array} types.LogEntry
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /system/logs [get]
+func LogsRoute(group *gin.RouterGroup, provider types.ILogProvider) {
+	group.GET("", func(c *gin.Context) {
+		var query types.LogQuery
+		err := c.BindQuery(&query)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		entries, err := provider.Entries(query)
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, entries)
+	})
+}
+
+// LogsStreamRoute stream the log entries of the GUI
+//
+// @Summary stream the log entries of the GUI
+// @Description websocket sending the most recent entries (100 unless limit is set) then each new entry, the new entries are dropped when the client doesn't keep up. Messages are base64 encoded JSON types.LogEntry. Restricted to the admin token, sent as a bearer token or, from a browser, in the subprotocols "bearer" and the token.
+// @Tags system
+// @Param subsystem query string false "only the entries of this subsystem"
+// @Param level query string false "minimum level, e.g. warning"
+// @Param limit query int false "number of recent entries sent first"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /system/logs/stream [get]
+func LogsStreamRoute(group *gin.RouterGroup, provider types.ILogProvider) {
+	group.GET("/stream", func(c *gin.Context) {
+		var query types.LogQuery
+		err := c.BindQuery(&query)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if query.Limit == 0 {
+			query.Limit = defaultLogsTail
+		}
+		conn, err := adminUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// the entries are logged synchronously, they are queued so that a slow client doesn't block the loggers
+		queue := make(chan types.LogEntry, logsStreamQueueSize)
+		id := uuid.Generate().String()
+		entries, err := provider.SubscribeLogs(id, query, func(entry types.LogEntry) {
+			select {
+			case queue <- entry:
+			default:
+				// the client doesn't keep up, the entry is dropped
+			}
+		})
+		if err != nil {
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseUnsupportedData, err.Error()))
+			return
+		}
+		defer provider.UnSubscribeLogs(id)
+		done := make(chan struct{})
+		defer close(done)
+		write := func(entry types.LogEntry) error {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			err = conn.SetWriteDeadline(time.Now().Add(logsStreamWriteTimeout))
+			if err != nil {
+				return err
+			}
+			return conn.WriteMessage(websocket.TextMessage, []byte(base64.StdEncoding.EncodeToString(data)))
+		}
+		go func() {
+			// the recent entries are written before the new ones
+			for _, entry := range entries {
+				if err := write(entry); err != nil {
+					_ = conn.Close()
+					return
+				}
+			}
+			for {
+				select {
+				case <-done:
+					return
+				case entry := <-queue:
+					if err := write(entry); err != nil {
+						_ = conn.Close()
+						return
+					}
+				}
+			}
+		}()
+		_, _, err = conn.ReadMessage()
+		if err != nil {
+			c.Error(err)
+		}
+	})
+}
+
+// LogLevelsRoute list the log level of each subsystem
+//
+// @Summary list the log level of each subsystem
+// @Description list the log level of each subsystem of the GUI
+// @Tags system
+// @Produce  json
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /system/logs/levels [get]
+func LogLevelsRoute(group *gin.RouterGroup, provider types.ILogProvider) {
+	group.GET("/levels", func(c *gin.Context) {
+		c.JSON(200, provider.Levels())
+	})
+}
+
+// SetLogLevelsRoute override the log level of subsystems
+//
+// @Summary override the log level of subsystems
+// @Description validate, save and apply the log level overrides, the subsystems without an override use the system.logs.level setting
+// @Tags system
+// @Accept  json
+// @Produce  json
+// @Param levels body map[string]string true "level of each overridden subsystem"
+// @Success 200 {object} OkResponse
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /system/logs/levels [put]
+func SetLogLevelsRoute(group *gin.RouterGroup, provider types.ILogProvider) {
+	group.PUT("/levels", func(c *gin.Context) {
+		var levels map[string]string
+		err := c.BindJSON(&levels)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		err = provider.SetLevels(levels)
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, OkResponse{})
+	})
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsRoute serves the mower status and the GUI process metrics in the Prometheus text format on /metrics, outside
+// of the API base path where scrapers expect it
+func MetricsRoute(r *gin.Engine, provider types.IMetricsProvider) {
+	r.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(200)
+		err := provider.WriteMetrics(c.Writer)
+		if err != nil {
+			c.Error(err)
+		}
+	})
+}
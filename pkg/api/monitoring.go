@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/distribution/uuid"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func MonitoringRoutes(r *gin.RouterGroup, provider types.IMonitoringProvider) {
+	group := r.Group("/monitoring")
+	SensorsRoute(group, provider)
+	SensorsStreamRoute(group, provider)
+	ActionsRoute(group, provider)
+	TriggerActionRoute(group, provider)
+}
+
+// SensorsRoute list the sensors
+//
+// @Summary list the sensors
+// @Description list the sensors published by xbot_monitoring with their unit, critical thresholds and last value
+// @Tags monitoring
+// @Produce  json
+// @Success 200 {array} types.Sensor
+// @Router /monitoring/sensors [get]
+func SensorsRoute(group *gin.RouterGroup, provider types.IMonitoringProvider) {
+	group.GET("/sensors", func(c *gin.Context) {
+		sensors := provider.Sensors()
+		if sensors == nil {
+			sensors = []types.Sensor{}
+		}
+		c.JSON(200, sensors)
+	})
+}
+
+// SensorsStreamRoute stream the sensor values
+//
+// @Summary stream the sensor values
+// @Description websocket sending the current sensors then each sensor update, messages are base64 encoded JSON types.Sensor
+// @Tags monitoring
+// @Router /monitoring/sensors/stream [get]
+func SensorsStreamRoute(group *gin.RouterGroup, provider types.IMonitoringProvider) {
+	group.GET("/sensors/stream", func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// sensors are updated from one goroutine per topic, writes must be serialized
+		var mtx sync.Mutex
+		send := func(sensor types.Sensor) {
+			data, err := json.Marshal(sensor)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			mtx.Lock()
+			defer mtx.Unlock()
+			err = conn.WriteMessage(websocket.TextMessage, []byte(base64.StdEncoding.EncodeToString(data)))
+			if err != nil {
+				c.Error(err)
+			}
+		}
+		id := uuid.Generate().String()
+		provider.SubscribeSensors(id, send)
+		defer provider.UnSubscribeSensors(id)
+		for _, sensor := range provider.Sensors() {
+			send(sensor)
+		}
+		_, _, err = conn.ReadMessage()
+		if err != nil {
+			c.Error(err)
+		}
+	})
+}
+
+// ActionsRoute list the actions
+//
+// @Summary list the actions
+// @Description list the actions registered by the nodes, only enabled actions can be triggered. The list is empty unless
+// @Description system.monitoring.registerActions is enabled, the GUI then provides /xbot/register_actions in place of
+// @Description xbot_monitoring and only knows the actions registered after it started
+// @Tags monitoring
+// @Produce  json
+// @Success 200 {array} types.Action
+// @Router /monitoring/actions [get]
+func ActionsRoute(group *gin.RouterGroup, provider types.IMonitoringProvider) {
+	group.GET("/actions", func(c *gin.Context) {
+		actions := provider.Actions()
+		if actions == nil {
+			actions = []types.Action{}
+		}
+		c.JSON(200, actions)
+	})
+}
+
+// TriggerActionRoute trigger an action
+//
+// @Summary trigger an action
+// @Description trigger an enabled action by its id, e.g. mower_logic:idle/start_mowing
+// @Tags monitoring
+// @Produce  json
+// @Param id path string true "action id"
+// @Success 200 {object} OkResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /monitoring/actions/{id} [post]
+func TriggerActionRoute(group *gin.RouterGroup, provider types.IMonitoringProvider) {
+	group.POST("/actions/*id", func(c *gin.Context) {
+		err := provider.TriggerAction(strings.TrimPrefix(c.Param("id"), "/"))
+		switch {
+		case errors.Is(err, types.ErrUnknownAction):
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, types.ErrActionDisabled):
+			c.JSON(409, ErrorResponse{Error: err.Error()})
+		case err != nil:
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(200, OkResponse{})
+		}
+	})
+}
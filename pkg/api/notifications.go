@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+)
+
+// redactedSecret replaces the passwords and tokens of the channels, it keeps the stored value when it is sent back
+const redactedSecret = "********"
+
+func NotificationsRoutes(r *gin.RouterGroup, provider types.INotificationProvider, dbProvider types.IDBProvider) {
+	group := r.Group("/notifications")
+	NotificationChannelsRoute(group, provider)
+	// the channels hold the secrets of the services and can send messages, they are changed with the admin token
+	SetNotificationChannelsRoute(group.Group("", AdminRequired(dbProvider)), provider)
+	TestNotificationRoute(group.Group("", AdminRequired(dbProvider)), provider)
+	NotificationDeliveriesRoute(group, provider)
+}
+
+// NotificationChannelsRoute list the notification channels
+//
+// @Summary list the notification channels
+// @Description list the webhook, smtp, ntfy and gotify channels with the events routed to them, the passwords and tokens are replaced with ********
+// @Tags notifications
+// @Produce  json
+// @Success 200 {array} types.NotificationChannel
+// @Failure 500 {object} ErrorResponse
+// @Router /notifications/channels [get]
+func NotificationChannelsRoute(group *gin.RouterGroup, provider types.INotificationProvider) {
+	group.GET("/channels", func(c *gin.Context) {
+		channels, err := provider.Channels()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		for i := range channels {
+			channels[i].Password = redact(channels[i].Password)
+			channels[i].Token = redact(channels[i].Token)
+		}
+		c.JSON(200, channels)
+	})
+}
+
+// SetNotificationChannelsRoute replace the notification channels
+//
+// @Summary replace the notification channels
+// @Description validate and save the notification channels. Events routed to a channel are alert.raised, alert.cleared, state.changed, mowing.started, mowing.finished and test, all events are routed when none is set. A password or token set to ******** keeps the saved one. Restricted to the admin token.
+// @Tags notifications
+// @Accept  json
+// @Produce  json
+// @Param channels body []types.NotificationChannel true "notification channels"
+// @Success 200 {object} OkResponse
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /notifications/channels [put]
+func SetNotificationChannelsRoute(group *gin.RouterGroup, provider types.INotificationProvider) {
+	group.PUT("/channels", func(c *gin.Context) {
+		var channels []types.NotificationChannel
+		err := c.BindJSON(&channels)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		saved, err := provider.Channels()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		for i, channel := range channels {
+			previous, _ := lo.Find(saved, func(s types.NotificationChannel) bool {
+				return s.ID == channel.ID
+			})
+			if channel.Password == redactedSecret {
+				channels[i].Password = previous.Password
+			}
+			if channel.Token == redactedSecret {
+				channels[i].Token = previous.Token
+			}
+		}
+		err = provider.SetChannels(channels)
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, OkResponse{})
+	})
+}
+
+// TestNotificationRoute send a test notification
+//
+// @Summary send a test notification
+// @Description send a test event to the channel, even if it is disabled, and return the delivery. Restricted to the admin token.
+// @Tags notifications
+// @Produce  json
+// @Param id path string true "channel id"
+// @Success 200 {object} types.NotificationDelivery
+// @Failure 404 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /notifications/channels/{id}/test [post]
+func TestNotificationRoute(group *gin.RouterGroup, provider types.INotificationProvider) {
+	group.POST("/channels/:id/test", func(c *gin.Context) {
+		delivery, err := provider.Test(c.Param("id"))
+		if errors.Is(err, types.ErrUnknownChannel) {
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, delivery)
+	})
+}
+
+// NotificationDeliveriesRoute list the last deliveries
+//
+// @Summary list the last deliveries
+// @Description list the last notification deliveries, newest first
+// @Tags notifications
+// @Produce  json
+// @Success 200 {array} types.NotificationDelivery
+// @Router /notifications/deliveries [get]
+func NotificationDeliveriesRoute(group *gin.RouterGroup, provider types.INotificationProvider) {
+	group.GET("/deliveries", func(c *gin.Context) {
+		c.JSON(200, provider.Deliveries())
+	})
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedSecret
+}
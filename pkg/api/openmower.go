@@ -4,27 +4,34 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
-	"github.com/cedbossneo/openmower-gui/pkg/msgs/dynamic_reconfigure"
 	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
-	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/providers"
 	"github.com/cedbossneo/openmower-gui/pkg/types"
 	"github.com/docker/distribution/uuid"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
+// serviceCommands maps the commands of ServiceRoute to the service they call
+var serviceCommands = map[string]string{
+	"high_level_control": "/mower_service/high_level_control",
+	"emergency":          "/mower_service/emergency",
+	"mower_logic":        "/mower_logic/set_parameters",
+	"mow_enabled":        "/mower_service/mow_enabled",
+	"start_in_area":      "/mower_service/start_in_area",
+}
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 	CheckOrigin:     func(r *http.Request) bool { return true },
 }
 
-func OpenMowerRoutes(r *gin.RouterGroup, provider types.IRosProvider) {
+func OpenMowerRoutes(r *gin.RouterGroup, provider types.IRosProvider, teleopProvider types.ITeleopProvider) {
 	group := r.Group("/openmower")
 	ServiceRoute(group, provider)
 	AddMapAreaRoute(group, provider)
@@ -32,7 +39,8 @@ func OpenMowerRoutes(r *gin.RouterGroup, provider types.IRosProvider) {
 	ClearMapRoute(group, provider)
 	ReplaceMapRoute(group, provider)
 	SubscriberRoute(group, provider)
-	PublisherRoute(group, provider)
+	TeleopStatusRoute(group, teleopProvider)
+	PublisherRoute(group, teleopProvider)
 }
 
 // AddMapAreaRoute add a map area
@@ -151,7 +159,7 @@ func SetDockingPointRoute(group *gin.RouterGroup, provider types.IRosProvider) {
 // @Summary subscribe to a topic
 // @Description subscribe to a topic
 // @Tags openmower
-// @Param topic path string true "topic to subscribe to, could be: diagnostics, status, gps, imu, ticks, highLevelStatus"
+// @Param topic path string true "topic to subscribe to, could be: diagnostics, status, gps, imu, ticks, highLevelStatus, robotState"
 // @Router /openmower/subscribe/{topic} [get]
 func SubscriberRoute(group *gin.RouterGroup, provider types.IRosProvider) {
 	group.GET("/subscribe/:topic", func(c *gin.Context) {
@@ -185,15 +193,19 @@ func SubscriberRoute(group *gin.RouterGroup, provider types.IRosProvider) {
 			def, err = subscribe(provider, c, conn, "/mower/wheel_ticks", 100)
 		case "map":
 			def, err = subscribe(provider, c, conn, "/xbot_monitoring/map", -1)
+		case "robotState":
+			def, err = subscribe(provider, c, conn, "/xbot_monitoring/robot_state", 100)
 		case "path":
 			def, err = subscribe(provider, c, conn, "/slic3r_coverage_planner/path_marker_array", -1)
 		case "plan":
 			def, err = subscribe(provider, c, conn, "/move_base_flex/FTCPlanner/global_plan", -1)
 		case "mowingPath":
 			def, err = subscribe(provider, c, conn, "/mowing_path", -1)
+		default:
+			err = errors.New("unknown topic " + topic)
 		}
 		if err != nil {
-			log.Println(err.Error())
+			apiLog.Error(err)
 			return
 		}
 		defer def()
@@ -208,29 +220,75 @@ func SubscriberRoute(group *gin.RouterGroup, provider types.IRosProvider) {
 	})
 }
 
+// TeleopStatusRoute get the teleop status
+//
+// @Summary get the teleop status
+// @Description get the active driver, the mower state and the configured speed limits and watchdog timeout
+// @Tags openmower
+// @Produce  json
+// @Success 200 {object} types.TeleopStatus
+// @Router /openmower/teleop [get]
+func TeleopStatusRoute(group *gin.RouterGroup, provider types.ITeleopProvider) {
+	group.GET("/teleop", func(c *gin.Context) {
+		c.JSON(200, provider.Status())
+	})
+}
+
 // PublisherRoute publish to a topic
 //
 // @Summary publish to a topic
-// @Description publish to a topic
+// @Description publish joystick velocities to the mower. Only one driver is allowed at a time and only in the configured states, velocities are clamped to the configured limits and the mower is stopped if no message is received before the watchdog timeout. The driver is pinged every watchdog timeout and disconnected when it answers neither with a message nor with a pong for two timeouts.
 // @Tags openmower
 // @Param topic path string true "topic to publish to, could be: joy"
+// @Param driver query string false "name of the driver, defaults to the client IP"
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /openmower/publish/{topic} [get]
-func PublisherRoute(group *gin.RouterGroup, provider types.IRosProvider) {
+func PublisherRoute(group *gin.RouterGroup, provider types.ITeleopProvider) {
 	group.GET("/publish/:topic", func(c *gin.Context) {
-		// create a node and connect to the master
-		var err error
-		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if c.Param("topic") != "joy" {
+			c.JSON(404, ErrorResponse{Error: "unknown topic " + c.Param("topic") + ", use /ros/publish to publish to other topics"})
+			return
+		}
+		driver := c.DefaultQuery("driver", c.ClientIP())
+		session, err := provider.Acquire(driver)
 		if err != nil {
+			c.JSON(409, ErrorResponse{Error: err.Error()})
 			return
 		}
-		defer conn.Close()
-		// Read messages from the websocket connection and publish them to ROS
-		publisher, err := provider.Publisher("/joy_vel", &geometry_msgs.Twist{})
+		defer session.Release()
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
-			c.Error(err)
 			return
 		}
-		defer publisher.Close()
+		defer conn.Close()
+		// the driver is pinged every watchdog timeout and the session is released when no message or pong is received
+		// for two timeouts, so that a connection that is lost without being closed does not keep the session
+		timeout := time.Duration(provider.Status().Timeout) * time.Millisecond
+		if timeout <= 0 {
+			timeout = time.Second
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * timeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * timeout))
+		})
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(timeout)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout)); err != nil {
+						return
+					}
+				}
+			}
+		}()
+		// Read messages from the websocket connection and publish them to ROS
 		for {
 			_, msg, err := conn.ReadMessage()
 			if err != nil {
@@ -243,11 +301,22 @@ func PublisherRoute(group *gin.RouterGroup, provider types.IRosProvider) {
 				c.Error(err)
 				break
 			}
-			publisher.Write(&msgObj)
+			_ = conn.SetReadDeadline(time.Now().Add(2 * timeout))
+			err = session.Drive(msgObj.Linear.X, msgObj.Angular.Z)
+			if err != nil {
+				c.Error(err)
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()))
+				break
+			}
 		}
 	})
 }
 
+const (
+	websocketClientsMetric = "openmower_gui_websocket_clients"
+	websocketClientsHelp   = "Number of websocket clients subscribed to a topic."
+)
+
 func subscribe(provider types.IRosProvider, c *gin.Context, conn *websocket.Conn, topic string, interval int) (func(), error) {
 	id := uuid.Generate()
 	uidString := id.String()
@@ -275,8 +344,10 @@ func subscribe(provider types.IRosProvider, c *gin.Context, conn *websocket.Conn
 	if err != nil {
 		return nil, err
 	}
+	providers.Metrics.AddGauge(websocketClientsMetric, websocketClientsHelp, 1, "topic", topic)
 	return func() {
 		provider.UnSubscribe(topic, uidString)
+		providers.Metrics.AddGauge(websocketClientsMetric, websocketClientsHelp, -1, "topic", topic)
 	}, nil
 }
 
@@ -293,50 +364,13 @@ func subscribe(provider types.IRosProvider, c *gin.Context, conn *websocket.Conn
 // @Failure 500 {object} ErrorResponse
 // @Router /openmower/call/{command} [post]
 func ServiceRoute(group *gin.RouterGroup, provider types.IRosProvider) {
-	// create a node and connect to the master
 	group.POST("/call/:command", func(c *gin.Context) {
-		// create a node and connect to the master
-		command := c.Param("command")
-		var err error
-		switch command {
-		case "high_level_control":
-			var CallReq mower_msgs.HighLevelControlSrvReq
-			err = c.BindJSON(&CallReq)
-			if err != nil {
-				return
-			}
-			err = provider.CallService(c.Request.Context(), "/mower_service/high_level_control", &mower_msgs.HighLevelControlSrv{}, &CallReq, &mower_msgs.HighLevelControlSrvRes{})
-		case "emergency":
-			var CallReq mower_msgs.EmergencyStopSrvReq
-			err = c.BindJSON(&CallReq)
-			if err != nil {
-				return
-			}
-			err = provider.CallService(c.Request.Context(), "/mower_service/emergency", &mower_msgs.EmergencyStopSrv{}, &CallReq, &mower_msgs.EmergencyStopSrvRes{})
-		case "mower_logic":
-			var CallReq dynamic_reconfigure.ReconfigureReq
-			err = c.BindJSON(&CallReq)
-			if err != nil {
-				return
-			}
-			err = provider.CallService(c.Request.Context(), "/mower_logic/set_parameters", &dynamic_reconfigure.Reconfigure{}, &CallReq, &dynamic_reconfigure.ReconfigureRes{})
-		case "mow_enabled":
-			var CallReq mower_msgs.MowerControlSrvReq
-			err = c.BindJSON(&CallReq)
-			if err != nil {
-				return
-			}
-			err = provider.CallService(c.Request.Context(), "/mower_service/mow_enabled", &mower_msgs.MowerControlSrv{}, &CallReq, &mower_msgs.MowerControlSrvRes{})
-		case "start_in_area":
-			var CallReq mower_msgs.StartInAreaSrvReq
-			err = c.BindJSON(&CallReq)
-			if err != nil {
-				return
-			}
-			err = provider.CallService(c.Request.Context(), "/mower_service/start_in_area", &mower_msgs.StartInAreaSrv{}, &CallReq, &mower_msgs.StartInAreaSrvRes{})
-		default:
-			err = errors.New("unknown command")
+		service, ok := serviceCommands[c.Param("command")]
+		if !ok {
+			c.JSON(500, ErrorResponse{Error: "unknown command"})
+			return
 		}
+		_, err := providers.CallRegisteredService(c.Request.Context(), provider, service, decodeServiceRequest(c))
 		if err != nil {
 			c.JSON(500, ErrorResponse{Error: err.Error()})
 		} else {
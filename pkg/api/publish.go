@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+func PublishRoutes(r *gin.RouterGroup, provider types.IPublishProvider) {
+	group := r.Group("/ros")
+	PublishTopicsRoute(group, provider)
+	PublishMessageRoute(group, provider)
+	PublishStreamRoute(group, provider)
+}
+
+// PublishTopicsRoute list the topics that can be published to
+//
+// @Summary list the topics that can be published to
+// @Description list the whitelisted topics with their message type and maximum publish rate in messages per second (0 if unlimited)
+// @Tags ros
+// @Produce  json
+// @Success 200 {array} types.PublishTopic
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/publishers [get]
+func PublishTopicsRoute(group *gin.RouterGroup, provider types.IPublishProvider) {
+	group.GET("/publishers", func(c *gin.Context) {
+		topics, err := provider.Topics()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, topics)
+	})
+}
+
+// PublishMessageRoute publish a message to a topic
+//
+// @Summary publish a message to a topic
+// @Description decode the JSON message into the message type of the topic and publish it, the topic must be whitelisted
+// @Tags ros
+// @Accept  json
+// @Produce  json
+// @Param topic path string true "topic, e.g. /mower/status"
+// @Param message body map[string]any true "message"
+// @Success 200 {object} OkResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/publish/{topic} [post]
+func PublishMessageRoute(group *gin.RouterGroup, provider types.IPublishProvider) {
+	group.POST("/publish/*topic", func(c *gin.Context) {
+		topic := c.Param("topic")
+		msg, err := provider.Message(topic)
+		if err == nil {
+			err = unmarshalROSMessage(c.Request.Body, msg)
+		}
+		if err == nil {
+			err = provider.Publish(topic, msg)
+		}
+		switch {
+		case errors.Is(err, types.ErrTopicNotAllowed):
+			c.JSON(403, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, types.ErrRateLimited):
+			c.JSON(429, ErrorResponse{Error: err.Error()})
+		case err != nil:
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(200, OkResponse{})
+		}
+	})
+}
+
+// PublishStreamRoute publish a stream of messages to a topic
+//
+// @Summary publish a stream of messages to a topic
+// @Description websocket receiving JSON messages, each message is decoded into the message type of the topic and published. Messages exceeding the rate limit of the topic are dropped.
+// @Tags ros
+// @Param topic path string true "topic, e.g. /mower/status"
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/publish/{topic} [get]
+func PublishStreamRoute(group *gin.RouterGroup, provider types.IPublishProvider) {
+	group.GET("/publish/*topic", func(c *gin.Context) {
+		topic := c.Param("topic")
+		_, err := provider.Message(topic)
+		if errors.Is(err, types.ErrTopicNotAllowed) {
+			c.JSON(403, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			msg, err := provider.Message(topic)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			err = unmarshalROSMessage(io.NopCloser(bytes.NewReader(data)), msg)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			err = provider.Publish(topic, msg)
+			if errors.Is(err, types.ErrRateLimited) {
+				continue
+			}
+			if err != nil {
+				c.Error(err)
+				return
+			}
+		}
+	})
+}
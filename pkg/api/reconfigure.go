@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+func ReconfigureRoutes(r *gin.RouterGroup, provider types.IReconfigureProvider) {
+	group := r.Group("/ros")
+	GetParametersRoute(group, provider)
+	SetParametersRoute(group, provider)
+}
+
+// GetParametersRoute get the dynamic_reconfigure parameters of a node
+//
+// @Summary get the dynamic_reconfigure parameters of a node
+// @Description get the schema (type, min, max, default, enum values) and current value of each parameter of a node
+// @Tags ros
+// @Produce  json
+// @Param node path string true "node name, e.g. mower_logic"
+// @Success 200 {array} types.ReconfigureParameter
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/parameters/{node} [get]
+func GetParametersRoute(group *gin.RouterGroup, provider types.IReconfigureProvider) {
+	group.GET("/parameters/*node", func(c *gin.Context) {
+		parameters, err := provider.Parameters(c.Request.Context(), c.Param("node"))
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, parameters)
+	})
+}
+
+// SetParametersRoute set dynamic_reconfigure parameters of a node
+//
+// @Summary set dynamic_reconfigure parameters of a node
+// @Description validate the values against the parameters schema and set them
+// @Tags ros
+// @Accept  json
+// @Produce  json
+// @Param node path string true "node name, e.g. mower_logic"
+// @Param values body map[string]any true "parameter values"
+// @Success 200 {array} types.ReconfigureParameter
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/parameters/{node} [post]
+func SetParametersRoute(group *gin.RouterGroup, provider types.IReconfigureProvider) {
+	group.POST("/parameters/*node", func(c *gin.Context) {
+		var values map[string]any
+		err := c.BindJSON(&values)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		parameters, err := provider.SetParameters(c.Request.Context(), c.Param("node"), values)
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, parameters)
+	})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+func RecorderRoutes(r *gin.RouterGroup, provider types.IRecorderProvider) {
+	group := r.Group("/recordings")
+	ListRecordingsRoute(group, provider)
+	StartRecordingRoute(group, provider)
+	StopRecordingRoute(group, provider)
+	DownloadRecordingRoute(group, provider)
+	DeleteRecordingRoute(group, provider)
+}
+
+// ListRecordingsRoute list the recordings
+//
+// @Summary list the recordings
+// @Description list the recorded bag files, including the running one
+// @Tags recordings
+// @Produce  json
+// @Success 200 {array} types.Recording
+// @Failure 500 {object} ErrorResponse
+// @Router /recordings [get]
+func ListRecordingsRoute(group *gin.RouterGroup, provider types.IRecorderProvider) {
+	group.GET("", func(c *gin.Context) {
+		recordings, err := provider.List()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, recordings)
+	})
+}
+
+// StartRecordingRoute start a recording
+//
+// @Summary start a recording
+// @Description record the given topics (or the configured ones if empty) into a bag file
+// @Tags recordings
+// @Accept  json
+// @Produce  json
+// @Param request body StartRecordingRequest true "recording name and topics"
+// @Success 200 {object} types.Recording
+// @Failure 500 {object} ErrorResponse
+// @Router /recordings/start [post]
+func StartRecordingRoute(group *gin.RouterGroup, provider types.IRecorderProvider) {
+	group.POST("/start", func(c *gin.Context) {
+		var request StartRecordingRequest
+		err := c.BindJSON(&request)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		recording, err := provider.Start(request.Name, request.Topics)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, recording)
+	})
+}
+
+// StopRecordingRoute stop the running recording
+//
+// @Summary stop the running recording
+// @Description stop the running recording
+// @Tags recordings
+// @Produce  json
+// @Success 200 {object} types.Recording
+// @Failure 500 {object} ErrorResponse
+// @Router /recordings/stop [post]
+func StopRecordingRoute(group *gin.RouterGroup, provider types.IRecorderProvider) {
+	group.POST("/stop", func(c *gin.Context) {
+		recording, err := provider.Stop()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, recording)
+	})
+}
+
+// DownloadRecordingRoute download a recording
+//
+// @Summary download a recording
+// @Description download a recording as a bag file
+// @Tags recordings
+// @Produce  application/octet-stream
+// @Param name path string true "recording name"
+// @Failure 404 {object} ErrorResponse
+// @Router /recordings/{name} [get]
+func DownloadRecordingRoute(group *gin.RouterGroup, provider types.IRecorderProvider) {
+	group.GET("/:name", func(c *gin.Context) {
+		name := c.Param("name")
+		path, err := provider.Path(name)
+		if err != nil {
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.FileAttachment(path, name)
+	})
+}
+
+// DeleteRecordingRoute delete a recording
+//
+// @Summary delete a recording
+// @Description delete a recording
+// @Tags recordings
+// @Produce  json
+// @Param name path string true "recording name"
+// @Success 200 {object} OkResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /recordings/{name} [delete]
+func DeleteRecordingRoute(group *gin.RouterGroup, provider types.IRecorderProvider) {
+	group.DELETE("/:name", func(c *gin.Context) {
+		err := provider.Delete(c.Param("name"))
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, OkResponse{})
+	})
+}
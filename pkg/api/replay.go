@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+func ReplayRoutes(r *gin.RouterGroup, provider types.IReplayProvider) {
+	group := r.Group("/replay")
+	ReplayStatusRoute(group, provider)
+	ReplayCommandRoute(group, provider)
+}
+
+// ReplayStatusRoute get the replay status
+//
+// @Summary get the replay status
+// @Description get the loaded recording, playback position and speed
+// @Tags replay
+// @Produce  json
+// @Success 200 {object} types.ReplayStatus
+// @Router /replay [get]
+func ReplayStatusRoute(group *gin.RouterGroup, provider types.IReplayProvider) {
+	group.GET("", func(c *gin.Context) {
+		c.JSON(200, provider.Status())
+	})
+}
+
+// ReplayCommandRoute control the replay
+//
+// @Summary control the replay
+// @Description load a recording and replay it through the subscribe endpoints instead of live data. load needs a name, seek a position in seconds and speed a speed factor. stop switches back to live data.
+// @Tags replay
+// @Accept  json
+// @Produce  json
+// @Param command path string true "command to execute (load/play/pause/seek/speed/stop)"
+// @Param request body ReplayCommandRequest false "command parameters"
+// @Success 200 {object} types.ReplayStatus
+// @Failure 500 {object} ErrorResponse
+// @Router /replay/{command} [post]
+func ReplayCommandRoute(group *gin.RouterGroup, provider types.IReplayProvider) {
+	group.POST("/:command", func(c *gin.Context) {
+		command := c.Param("command")
+		var request ReplayCommandRequest
+		if command == "load" || command == "seek" || command == "speed" {
+			err := c.BindJSON(&request)
+			if err != nil {
+				c.JSON(500, ErrorResponse{Error: err.Error()})
+				return
+			}
+		}
+		var status types.ReplayStatus
+		var err error
+		switch command {
+		case "load":
+			status, err = provider.Load(request.Name)
+		case "play":
+			status, err = provider.Play()
+		case "pause":
+			status, err = provider.Pause()
+		case "seek":
+			status, err = provider.Seek(request.Position)
+		case "speed":
+			status, err = provider.Speed(request.Speed)
+		case "stop":
+			status, err = provider.Stop()
+		default:
+			err = errors.New("unknown command")
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, status)
+	})
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/providers"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+)
+
+func RosRoutes(r *gin.RouterGroup, provider types.IRosProvider, dbProvider types.IDBProvider) {
+	group := r.Group("/ros")
+	GraphRoute(group, provider, dbProvider)
+	GraphNodesRoute(group, provider, dbProvider)
+	GraphTopicsRoute(group, provider)
+	GraphServicesRoute(group, provider)
+	CallableServicesRoute(group)
+	CallServiceRoute(group, provider)
+}
+
+// GraphRoute get the whole ROS graph
+//
+// @Summary get the ROS graph
+// @Description get nodes (with ping latency), topics and services known by the ROS master, and the expected nodes that are missing
+// @Tags ros
+// @Produce  json
+// @Success 200 {object} RosGraphResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/graph [get]
+func GraphRoute(group *gin.RouterGroup, provider types.IRosProvider, dbProvider types.IDBProvider) {
+	group.GET("/graph", func(c *gin.Context) {
+		nodes, missing, err := nodeStatuses(c.Request.Context(), provider, dbProvider)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		topics, err := provider.Topics()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		services, err := provider.Services(c.Request.Context())
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, RosGraphResponse{
+			Nodes:        nodes,
+			Topics:       topics,
+			Services:     services,
+			MissingNodes: missing,
+		})
+	})
+}
+
+// GraphNodesRoute list ROS nodes
+//
+// @Summary list ROS nodes
+// @Description list ROS nodes with their ping latency, expected nodes that are not registered are returned with missing=true
+// @Tags ros
+// @Produce  json
+// @Success 200 {array} RosNodeStatus
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/graph/nodes [get]
+func GraphNodesRoute(group *gin.RouterGroup, provider types.IRosProvider, dbProvider types.IDBProvider) {
+	group.GET("/graph/nodes", func(c *gin.Context) {
+		nodes, _, err := nodeStatuses(c.Request.Context(), provider, dbProvider)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, nodes)
+	})
+}
+
+// GraphTopicsRoute list ROS topics
+//
+// @Summary list ROS topics
+// @Description list ROS topics with their type, publishers and subscribers
+// @Tags ros
+// @Produce  json
+// @Success 200 {array} types.RosTopic
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/graph/topics [get]
+func GraphTopicsRoute(group *gin.RouterGroup, provider types.IRosProvider) {
+	group.GET("/graph/topics", func(c *gin.Context) {
+		topics, err := provider.Topics()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, topics)
+	})
+}
+
+// GraphServicesRoute list ROS services
+//
+// @Summary list ROS services
+// @Description list ROS services with their type and providers
+// @Tags ros
+// @Produce  json
+// @Success 200 {array} types.RosService
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/graph/services [get]
+func GraphServicesRoute(group *gin.RouterGroup, provider types.IRosProvider) {
+	group.GET("/graph/services", func(c *gin.Context) {
+		services, err := provider.Services(c.Request.Context())
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, services)
+	})
+}
+
+// CallableServicesRoute list the services that can be called
+//
+// @Summary list the services that can be called
+// @Description list the services of the service registry, they can be called with /ros/call/{service}
+// @Tags ros
+// @Produce  json
+// @Success 200 {array} string
+// @Router /ros/call [get]
+func CallableServicesRoute(group *gin.RouterGroup) {
+	group.GET("/call", func(c *gin.Context) {
+		c.JSON(200, providers.ServiceNames())
+	})
+}
+
+// CallServiceRoute call a ROS service
+//
+// @Summary call a ROS service
+// @Description decode the JSON body into the request type of the service, call it and return the response
+// @Tags ros
+// @Accept  json
+// @Produce  json
+// @Param service path string true "service name, e.g. /mower_map_service/get_docking_point"
+// @Param request body map[string]any false "service request"
+// @Success 200 {object} map[string]any
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /ros/call/{service} [post]
+func CallServiceRoute(group *gin.RouterGroup, provider types.IRosProvider) {
+	group.POST("/call/*service", func(c *gin.Context) {
+		res, err := providers.CallRegisteredService(c.Request.Context(), provider, c.Param("service"), decodeServiceRequest(c))
+		if errors.Is(err, types.ErrUnknownService) {
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, res)
+	})
+}
+
+// decodeServiceRequest decodes the JSON body of the request into a service request, an empty body leaves the service
+// request empty
+func decodeServiceRequest(c *gin.Context) func(req any) error {
+	return func(req any) error {
+		err := json.NewDecoder(c.Request.Body).Decode(req)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+}
+
+// nodePingWorkers is the number of nodes pinged at the same time
+const nodePingWorkers = 8
+
+// nodePingTimeout bounds the pings of all the nodes
+var nodePingTimeout = 5 * time.Second
+
+// nodeStatuses pings every registered node with nodePingWorkers pings at the same time, until ctx is done or for
+// nodePingTimeout, and appends the expected nodes that are not registered
+func nodeStatuses(ctx context.Context, provider types.IRosProvider, dbProvider types.IDBProvider) ([]RosNodeStatus, []string, error) {
+	nodes, err := provider.Nodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, nodePingTimeout)
+	defer cancel()
+	result := make([]RosNodeStatus, len(nodes))
+	// each worker pings the next node until there is none left, the nodes left once ctx is done are not alive
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < lo.Min([]int{nodePingWorkers, len(nodes)}); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result[i] = RosNodeStatus{RosNode: nodes[i]}
+				if ctx.Err() != nil {
+					continue
+				}
+				latency, err := provider.NodePing(nodes[i].Name)
+				if err == nil {
+					result[i].Alive = true
+					result[i].PingMs = float64(latency.Microseconds()) / 1000
+				}
+			}
+		}()
+	}
+	for i := range nodes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	var missing []string
+	expectedNodes, err := providers.GetList(dbProvider, "system.ros.expectedNodes")
+	if err != nil {
+		return result, missing, nil
+	}
+	for _, expected := range expectedNodes {
+		_, found := lo.Find(nodes, func(node types.RosNode) bool {
+			return node.Name == expected
+		})
+		if !found {
+			missing = append(missing, expected)
+			result = append(result, RosNodeStatus{
+				RosNode: types.RosNode{Name: expected},
+				Missing: true,
+			})
+		}
+	}
+	return result, missing, nil
+}
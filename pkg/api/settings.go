@@ -1,31 +1,54 @@
 package api
 
 import (
-	"fmt"
+	"errors"
+	"github.com/cedbossneo/openmower-gui/pkg/providers"
 	"github.com/cedbossneo/openmower-gui/pkg/types"
+	dockertypes "github.com/docker/docker/api/types"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/samber/lo"
 	"os"
-	"strings"
+	"sort"
+	"strconv"
 )
 
-func SettingsRoutes(r *gin.RouterGroup, dbProvider types.IDBProvider) {
+func SettingsRoutes(r *gin.RouterGroup, dbProvider types.IDBProvider, history *providers.SettingsHistory, dockerProvider types.IDockerProvider) {
 	GetSettings(r, dbProvider)
-	PostSettings(r, dbProvider)
+	GetSettingsSchema(r)
+	PostSettings(r, history)
+	SettingsRevisionsRoutes(r.Group("/settings/revisions"), history, dockerProvider)
+}
+
+// GetSettingsSchema returns the schema of the known settings
+//
+// @Summary returns the schema of the known settings
+// @Description returns the type, range, options and dependencies of the known settings of the mower_config.sh file, the settings are checked against it when they are saved
+// @Tags settings
+// @Produce  json
+// @Success 200 {object} SettingsSchemaResponse
+// @Router /settings/schema [get]
+func GetSettingsSchema(r *gin.RouterGroup) gin.IRoutes {
+	return r.GET("/settings/schema", func(c *gin.Context) {
+		c.JSON(200, SettingsSchemaResponse{
+			Settings: providers.MowerSettingsSchema,
+		})
+	})
 }
 
 // PostSettings saves the settings to the mower_config.sh file
 //
 // @Summary saves the settings to the mower_config.sh file
-// @Description saves the settings to the mower_config.sh file
+// @Description saves the settings to the mower_config.sh file, the changed known settings and the settings depending on them are checked against the settings schema and only the changed keys are rewritten and the previous content is saved in the settings history and the comments, order and quoting of the file are kept. Booleans are written True or False and a null value removes the key.
 // @Tags settings
 // @Accept  json
 // @Produce  json
 // @Param settings body map[string]any true "settings"
+// @Param author query string false "author of the change saved in the settings history, defaults to the client IP"
 // @Success 200 {object} OkResponse
+// @Failure 400 {object} ValidationErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /settings [post]
-func PostSettings(r *gin.RouterGroup, dbProvider types.IDBProvider) gin.IRoutes {
+func PostSettings(r *gin.RouterGroup, history *providers.SettingsHistory) gin.IRoutes {
 	return r.POST("/settings", func(c *gin.Context) {
 		var settingsPayload map[string]any
 		err := c.BindJSON(&settingsPayload)
@@ -35,38 +58,42 @@ func PostSettings(r *gin.RouterGroup, dbProvider types.IDBProvider) gin.IRoutes
 			})
 			return
 		}
-		mowerConfigFile, err := dbProvider.Get("system.mower.configFile")
-		if err != nil {
-			c.JSON(500, ErrorResponse{
-				Error: err.Error(),
-			})
-			return
-		}
-		var settings = map[string]any{}
-		configFileContent, err := os.ReadFile(string(mowerConfigFile))
-		if err == nil {
-			parse, err := godotenv.Parse(strings.NewReader(string(configFileContent)))
-			if err == nil {
-				for s, s2 := range parse {
-					settings[s] = s2
+		err = history.Update(func(config *providers.MowerConfig) error {
+			// the new keys are appended in a stable order
+			keys := lo.Keys(settingsPayload)
+			sort.Strings(keys)
+			fields := map[string]string{}
+			for _, key := range keys {
+				var value string
+				switch v := settingsPayload[key].(type) {
+				case nil:
+					config.Delete(key)
+					continue
+				case bool:
+					value = lo.Ternary(v, "True", "False")
+				case float64:
+					value = strconv.FormatFloat(v, 'f', -1, 64)
+				case string:
+					value = v
+				default:
+					fields[key] = "must be a string, a number, a boolean or null"
+					continue
+				}
+				err := config.Set(key, value)
+				if err != nil {
+					fields[key] = err.Error()
 				}
 			}
-		}
-		for key, value := range settingsPayload {
-			settings[key] = value
-		}
-		// Write settings to file mower_config.sh
-		var fileContent string
-		for key, value := range settings {
-			if value == true {
-				value = "True"
-			}
-			if value == false {
-				value = "False"
+			if len(fields) > 0 {
+				return &types.ValidationError{Fields: fields}
 			}
-			fileContent += "export " + key + "=" + fmt.Sprintf("%#v", value) + "\n"
+			return providers.ValidateMowerSettings(config.Values(), keys)
+		}, c.DefaultQuery("author", c.ClientIP()))
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, ValidationErrorResponse{Error: err.Error(), Fields: validationErr.Fields})
+			return
 		}
-		err = os.WriteFile(string(mowerConfigFile), []byte(fileContent), 0644)
 		if err != nil {
 			c.JSON(500, ErrorResponse{
 				Error: err.Error(),
@@ -102,7 +129,7 @@ func GetSettings(r *gin.RouterGroup, dbProvider types.IDBProvider) gin.IRoutes {
 			})
 			return
 		}
-		settings, err := godotenv.Parse(strings.NewReader(string(file)))
+		config, err := providers.ParseMowerConfig(file)
 		if err != nil {
 			c.JSON(500, ErrorResponse{
 				Error: err.Error(),
@@ -110,7 +137,114 @@ func GetSettings(r *gin.RouterGroup, dbProvider types.IDBProvider) gin.IRoutes {
 			return
 		}
 		c.JSON(200, GetSettingsResponse{
-			Settings: settings,
+			Settings: config.Values(),
 		})
 	})
 }
+
+func SettingsRevisionsRoutes(group *gin.RouterGroup, history *providers.SettingsHistory, dockerProvider types.IDockerProvider) {
+	SettingsRevisionsRoute(group, history)
+	SettingsDiffRoute(group, history)
+	SettingsRestoreRoute(group, history, dockerProvider)
+}
+
+// SettingsRevisionsRoute list the previous revisions of the settings
+//
+// @Summary list the previous revisions of the settings
+// @Description list the previous contents of the mower_config.sh file saved by each change, newest first, with the author, the time and the keys of the change
+// @Tags settings
+// @Produce  json
+// @Success 200 {object} SettingsRevisionsResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /settings/revisions [get]
+func SettingsRevisionsRoute(group *gin.RouterGroup, history *providers.SettingsHistory) {
+	group.GET("", func(c *gin.Context) {
+		revisions, err := history.Revisions()
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(200, SettingsRevisionsResponse{Revisions: revisions})
+	})
+}
+
+// SettingsDiffRoute diff two revisions of the settings
+//
+// @Summary diff two revisions of the settings
+// @Description returns the settings added, removed or changed from a revision to another
+// @Tags settings
+// @Produce  json
+// @Param from query string true "revision id or current"
+// @Param to query string false "revision id or current, defaults to current"
+// @Success 200 {object} SettingsDiffResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /settings/revisions/diff [get]
+func SettingsDiffRoute(group *gin.RouterGroup, history *providers.SettingsHistory) {
+	group.GET("/diff", func(c *gin.Context) {
+		from := c.Query("from")
+		if from == "" {
+			c.JSON(400, ErrorResponse{Error: "from is required"})
+			return
+		}
+		changes, err := history.Diff(from, c.DefaultQuery("to", providers.CurrentRevision))
+		switch {
+		case errors.Is(err, types.ErrUnknownRevision):
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+		case err != nil:
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+		default:
+			c.JSON(200, SettingsDiffResponse{Changes: changes})
+		}
+	})
+}
+
+// SettingsRestoreRoute restore a revision of the settings
+//
+// @Summary restore a revision of the settings
+// @Description writes a previous content of the mower_config.sh file, the replaced content is saved as a new revision. The openmower container is restarted when restart is true.
+// @Tags settings
+// @Produce  json
+// @Param id path string true "revision id"
+// @Param restart query bool false "restart the openmower container"
+// @Param author query string false "author of the change saved in the settings history, defaults to the client IP"
+// @Success 200 {object} OkResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /settings/revisions/{id}/restore [post]
+func SettingsRestoreRoute(group *gin.RouterGroup, history *providers.SettingsHistory, dockerProvider types.IDockerProvider) {
+	group.POST("/:id/restore", func(c *gin.Context) {
+		err := history.Restore(c.Param("id"), c.DefaultQuery("author", c.ClientIP()))
+		switch {
+		case errors.Is(err, types.ErrUnknownRevision):
+			c.JSON(404, ErrorResponse{Error: err.Error()})
+			return
+		case err != nil:
+			c.JSON(500, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if c.Query("restart") != "true" {
+			c.JSON(200, OkResponse{})
+			return
+		}
+		containers, err := appContainers(c.Request.Context(), dockerProvider)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: "settings restored but failed to restart openmower: " + err.Error()})
+			return
+		}
+		container, ok := lo.Find(containers, func(container dockertypes.Container) bool {
+			return containerApp(container) == "openmower"
+		})
+		if !ok {
+			c.JSON(500, ErrorResponse{Error: "settings restored but the openmower container was not found"})
+			return
+		}
+		err = dockerProvider.ContainerRestart(c.Request.Context(), container.ID)
+		if err != nil {
+			c.JSON(500, ErrorResponse{Error: "settings restored but failed to restart openmower: " + err.Error()})
+			return
+		}
+		c.JSON(200, OkResponse{})
+	})
+}
@@ -1,5 +1,7 @@
 package api
 
+import "github.com/cedbossneo/openmower-gui/pkg/types"
+
 type OkResponse struct {
 	Ok string `json:"ok,omitempty"`
 }
@@ -11,6 +13,22 @@ type GetSettingsResponse struct {
 	Settings map[string]string `json:"settings,omitempty"`
 }
 
+type SettingsSchemaResponse struct {
+	Settings []types.SettingSchema `json:"settings"`
+}
+
+type BackupRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type SettingsRevisionsResponse struct {
+	Revisions []types.SettingsRevision `json:"revisions"`
+}
+
+type SettingsDiffResponse struct {
+	Changes []types.SettingChange `json:"changes"`
+}
+
 type GetConfigResponse struct {
 	TileUri string `json:"tileUri"`
 }
@@ -25,3 +43,43 @@ type Container struct {
 type ContainerListResponse struct {
 	Containers []Container `json:"containers"`
 }
+
+// ContainerStats is the resource usage of the container of App, openmower or gui
+type ContainerStats struct {
+	types.ContainerStats
+	App string `json:"app"`
+}
+
+type ContainerStatsResponse struct {
+	Stats []ContainerStats `json:"stats"`
+}
+
+type RosNodeStatus struct {
+	types.RosNode
+	Alive   bool    `json:"alive"`
+	PingMs  float64 `json:"pingMs"`
+	Missing bool    `json:"missing"`
+}
+
+type RosGraphResponse struct {
+	Nodes        []RosNodeStatus    `json:"nodes"`
+	Topics       []types.RosTopic   `json:"topics"`
+	Services     []types.RosService `json:"services"`
+	MissingNodes []string           `json:"missingNodes"`
+}
+
+type ValidationErrorResponse struct {
+	Error  string            `json:"error,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type StartRecordingRequest struct {
+	Name   string   `json:"name"`
+	Topics []string `json:"topics"`
+}
+
+type ReplayCommandRequest struct {
+	Name     string  `json:"name"`
+	Position float64 `json:"position"`
+	Speed    float64 `json:"speed"`
+}
@@ -0,0 +1,62 @@
+package fakes
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// DBProvider is an in-memory types.IDBProvider
+type DBProvider struct {
+	mtx    sync.Mutex
+	values map[string][]byte
+}
+
+// NewDBProvider returns a DBProvider containing the given values
+func NewDBProvider(values map[string]string) *DBProvider {
+	d := &DBProvider{values: make(map[string][]byte)}
+	for key, value := range values {
+		d.values[key] = []byte(value)
+	}
+	return d
+}
+
+func (d *DBProvider) Set(key string, value []byte) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.values[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (d *DBProvider) Get(key string) ([]byte, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	value, ok := d.values[key]
+	if !ok || len(value) == 0 {
+		return nil, xerrors.Errorf("config key %s not found", key)
+	}
+	return append([]byte{}, value...), nil
+}
+
+func (d *DBProvider) Delete(key string) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	delete(d.values, key)
+	return nil
+}
+
+// KeysWithSuffix behaves like bitcask Scan, it returns the keys starting with suffix
+func (d *DBProvider) KeysWithSuffix(suffix string) ([]string, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	var keys []string
+	for key := range d.values {
+		if strings.HasPrefix(key, suffix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
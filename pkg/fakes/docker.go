@@ -0,0 +1,216 @@
+package fakes
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/docker/api/types"
+	"golang.org/x/xerrors"
+)
+
+// DockerProvider is an in-memory types.IDockerProvider, it records the commands sent to the containers
+type DockerProvider struct {
+	mtx        sync.Mutex
+	Containers []types.Container
+	// Logs are the log lines returned by ContainerLogs for each container ID
+	Logs map[string][]types2.ContainerLogLine
+	// Stats are the samples returned by ContainerStats for each container ID
+	Stats map[string][]types2.ContainerStats
+	// Updates are the statuses returned by CheckImageUpdate for each container ID
+	Updates map[string]types2.ImageUpdateStatus
+	// UpdateEvents are the progress events sent by UpdateContainer before it returns UpdateResult and UpdateError
+	UpdateEvents []types2.ImageUpdateEvent
+	UpdateResult types2.ImageUpdateResult
+	UpdateError  error
+	// LogsQueries are the queries received by ContainerLogs
+	LogsQueries []types2.ContainerLogsQuery
+	// Commands are the commands executed, formatted as "<command> <containerID>"
+	Commands []string
+	// ListError makes ContainerList fail, e.g. when the Docker socket is not reachable
+	ListError error
+	// Resizes are the TTY sizes received by ContainerExec, starting with the initial size
+	Resizes []types2.TerminalSize
+	// ExecExitCode is the exit code returned by ContainerExec
+	ExecExitCode int
+}
+
+func NewDockerProvider(containers ...types.Container) *DockerProvider {
+	return &DockerProvider{
+		Containers: containers,
+		Logs:       make(map[string][]types2.ContainerLogLine),
+		Stats:      make(map[string][]types2.ContainerStats),
+		Updates:    make(map[string]types2.ImageUpdateStatus),
+	}
+}
+
+func (d *DockerProvider) ContainerList(ctx context.Context) ([]types.Container, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.ListError != nil {
+		return nil, d.ListError
+	}
+	return append([]types.Container{}, d.Containers...), nil
+}
+
+// ContainerLogs returns the Logs of the container on the query stream, the Since, Until and Follow options are ignored
+func (d *DockerProvider) ContainerLogs(ctx context.Context, containerID string, query types2.ContainerLogsQuery, cb func(line types2.ContainerLogLine) error) error {
+	d.mtx.Lock()
+	if err := d.find(containerID); err != nil {
+		d.mtx.Unlock()
+		return err
+	}
+	d.LogsQueries = append(d.LogsQueries, query)
+	var lines []types2.ContainerLogLine
+	for _, line := range d.Logs[containerID] {
+		if query.Stream == "" || query.Stream == line.Stream {
+			lines = append(lines, line)
+		}
+	}
+	d.mtx.Unlock()
+	if tail, err := strconv.Atoi(query.Tail); err == nil && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+	for _, line := range lines {
+		if err := cb(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContainerStats returns the first Stats sample of the container, or all the samples when stream is true
+func (d *DockerProvider) ContainerStats(ctx context.Context, containerID string, stream bool, cb func(stats types2.ContainerStats) error) error {
+	d.mtx.Lock()
+	if err := d.find(containerID); err != nil {
+		d.mtx.Unlock()
+		return err
+	}
+	samples := append([]types2.ContainerStats{}, d.Stats[containerID]...)
+	d.mtx.Unlock()
+	if !stream && len(samples) > 1 {
+		samples = samples[:1]
+	}
+	for _, sample := range samples {
+		if err := cb(sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DockerProvider) CheckImageUpdate(ctx context.Context, containerID string) (types2.ImageUpdateStatus, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if err := d.find(containerID); err != nil {
+		return types2.ImageUpdateStatus{}, err
+	}
+	return d.Updates[containerID], nil
+}
+
+func (d *DockerProvider) UpdateContainer(ctx context.Context, containerID string, progress func(event types2.ImageUpdateEvent)) (types2.ImageUpdateResult, error) {
+	d.mtx.Lock()
+	if err := d.find(containerID); err != nil {
+		d.mtx.Unlock()
+		return types2.ImageUpdateResult{}, err
+	}
+	d.Commands = append(d.Commands, "update "+containerID)
+	events := append([]types2.ImageUpdateEvent{}, d.UpdateEvents...)
+	result, err := d.UpdateResult, d.UpdateError
+	d.mtx.Unlock()
+	for _, event := range events {
+		progress(event)
+	}
+	return result, err
+}
+
+// ContainerExec records "exec <containerID> <cmd>" and echoes the lines of stdin until it reads exit
+func (d *DockerProvider) ContainerExec(ctx context.Context, containerID string, options types2.ExecOptions, stdin io.Reader, stdout io.Writer, resize <-chan types2.TerminalSize) (int, error) {
+	d.mtx.Lock()
+	if err := d.find(containerID); err != nil {
+		d.mtx.Unlock()
+		return -1, err
+	}
+	d.Commands = append(d.Commands, "exec "+containerID+" "+strings.Join(options.Cmd, " "))
+	d.Resizes = append(d.Resizes, options.Size)
+	exitCode := d.ExecExitCode
+	d.mtx.Unlock()
+	ended := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if scanner.Text() == "exit" {
+				ended <- nil
+				return
+			}
+			if _, err := io.WriteString(stdout, scanner.Text()+"\r\n"); err != nil {
+				ended <- err
+				return
+			}
+		}
+		ended <- scanner.Err()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case err := <-ended:
+			return exitCode, err
+		case size, ok := <-resize:
+			if !ok {
+				resize = nil
+				continue
+			}
+			d.mtx.Lock()
+			d.Resizes = append(d.Resizes, size)
+			d.mtx.Unlock()
+		}
+	}
+}
+
+func (d *DockerProvider) ContainerStart(ctx context.Context, containerID string) error {
+	return d.command("start", containerID, "running")
+}
+
+func (d *DockerProvider) ContainerStop(ctx context.Context, containerID string) error {
+	return d.command("stop", containerID, "exited")
+}
+
+func (d *DockerProvider) ContainerRestart(ctx context.Context, containerID string) error {
+	return d.command("restart", containerID, "running")
+}
+
+// History returns a copy of the executed commands
+func (d *DockerProvider) History() []string {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return append([]string{}, d.Commands...)
+}
+
+func (d *DockerProvider) command(command string, containerID string, state string) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if err := d.find(containerID); err != nil {
+		return err
+	}
+	d.Commands = append(d.Commands, command+" "+containerID)
+	for i := range d.Containers {
+		if d.Containers[i].ID == containerID {
+			d.Containers[i].State = state
+		}
+	}
+	return nil
+}
+
+func (d *DockerProvider) find(containerID string) error {
+	for _, container := range d.Containers {
+		if container.ID == containerID {
+			return nil
+		}
+	}
+	return xerrors.Errorf("%s: %w", containerID, types2.ErrUnknownContainer)
+}
@@ -0,0 +1,43 @@
+package fakes
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+)
+
+// FirmwareProvider is a types.IFirmwareProvider writing Output instead of flashing the board
+type FirmwareProvider struct {
+	mtx     sync.Mutex
+	Output  []string
+	Err     error
+	Flashed []types.FirmwareConfig
+}
+
+func (f *FirmwareProvider) FlashFirmware(writer io.Writer, config types.FirmwareConfig) error {
+	f.mtx.Lock()
+	f.Flashed = append(f.Flashed, config)
+	f.mtx.Unlock()
+	return writeLines(writer, f.Output, f.Err)
+}
+
+// GpsProvider is a types.IGpsProvider writing Output instead of configuring the GPS
+type GpsProvider struct {
+	Output []string
+	Err    error
+}
+
+func (g *GpsProvider) FlashGPS(writer io.Writer) error {
+	return writeLines(writer, g.Output, g.Err)
+}
+
+func writeLines(writer io.Writer, lines []string, err error) error {
+	for _, line := range lines {
+		_, werr := writer.Write([]byte(line + "\n"))
+		if werr != nil {
+			return werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+// ReconfigureProvider is an in-memory types.IReconfigureProvider, parameters are indexed by node name
+type ReconfigureProvider struct {
+	mtx   sync.Mutex
+	Nodes map[string][]types.ReconfigureParameter
+}
+
+func (r *ReconfigureProvider) Parameters(ctx context.Context, node string) ([]types.ReconfigureParameter, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	parameters, ok := r.Nodes[node]
+	if !ok {
+		return nil, xerrors.Errorf("node %s has no parameters", node)
+	}
+	return append([]types.ReconfigureParameter{}, parameters...), nil
+}
+
+func (r *ReconfigureProvider) SetParameters(ctx context.Context, node string, values map[string]any) ([]types.ReconfigureParameter, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	parameters, ok := r.Nodes[node]
+	if !ok {
+		return nil, xerrors.Errorf("node %s has no parameters", node)
+	}
+	fields := make(map[string]string)
+	for name := range values {
+		fields[name] = "unknown parameter"
+		for _, parameter := range parameters {
+			if parameter.Name == name {
+				delete(fields, name)
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &types.ValidationError{Fields: fields}
+	}
+	for i := range parameters {
+		if value, ok := values[parameters[i].Name]; ok {
+			parameters[i].Value = value
+		}
+	}
+	return append([]types.ReconfigureParameter{}, parameters...), nil
+}
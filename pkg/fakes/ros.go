@@ -0,0 +1,316 @@
+package fakes
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+// ServiceCall is a service call received by RosProvider
+type ServiceCall struct {
+	Service string
+	Req     any
+}
+
+// RosProvider is an in-memory types.IRosProvider. Messages sent with Publish are delivered to each subscriber on its
+// own goroutine, like RosSubscriber, and Publish returns once they are delivered. Service calls and messages written by
+// the publishers are recorded.
+type RosProvider struct {
+	mtx         sync.Mutex
+	subscribers map[string]map[string]*rosSubscriber
+	lastMessage map[string][]byte
+	topicTypes  map[string]reflect.Type
+	replaying   bool
+	calls       []ServiceCall
+	published   map[string][]any
+	advertised  map[string]func(req any) (any, error)
+
+	// ServiceErrors makes CallService fail for the given services
+	ServiceErrors map[string]error
+	// ServiceResponses are copied into the response of the given services
+	ServiceResponses map[string]any
+	graphNodes       []types.RosNode
+	graphTopics      []types.RosTopic
+	graphServices    []types.RosService
+}
+
+func NewRosProvider() *RosProvider {
+	return &RosProvider{
+		subscribers:      make(map[string]map[string]*rosSubscriber),
+		lastMessage:      make(map[string][]byte),
+		topicTypes:       make(map[string]reflect.Type),
+		published:        make(map[string][]any),
+		advertised:       make(map[string]func(req any) (any, error)),
+		ServiceErrors:    make(map[string]error),
+		ServiceResponses: make(map[string]any),
+	}
+}
+
+func (r *RosProvider) CallService(ctx context.Context, srvName string, srv any, req any, res any) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.calls = append(r.calls, ServiceCall{Service: srvName, Req: reflect.ValueOf(req).Elem().Interface()})
+	if err, ok := r.ServiceErrors[srvName]; ok {
+		return err
+	}
+	if response, ok := r.ServiceResponses[srvName]; ok {
+		value := reflect.ValueOf(response)
+		target := reflect.ValueOf(res).Elem()
+		if !value.Type().AssignableTo(target.Type()) {
+			return xerrors.Errorf("invalid response type %T for service %s", response, srvName)
+		}
+		target.Set(value)
+	}
+	return nil
+}
+
+func (r *RosProvider) Subscribe(topic string, id string, cb func(msg []byte)) error {
+	r.mtx.Lock()
+	if _, ok := r.subscribers[topic]; !ok {
+		r.subscribers[topic] = make(map[string]*rosSubscriber)
+	}
+	subscriber, ok := r.subscribers[topic][id]
+	if !ok {
+		subscriber = newRosSubscriber(cb)
+		r.subscribers[topic][id] = subscriber
+	}
+	lastMessage, hasLastMessage := r.lastMessage[topic]
+	r.mtx.Unlock()
+	if hasLastMessage {
+		subscriber.deliver(lastMessage)
+	}
+	return nil
+}
+
+// SubscribeQueued is Subscribe, the subscribers of the fake provider already receive every message
+func (r *RosProvider) SubscribeQueued(topic string, id string, cb func(msg []byte)) error {
+	return r.Subscribe(topic, id, cb)
+}
+
+func (r *RosProvider) UnSubscribe(topic string, id string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if subscriber, ok := r.subscribers[topic][id]; ok {
+		subscriber.close()
+		delete(r.subscribers[topic], id)
+	}
+}
+
+// Publish sends msg to the subscribers of topic as if it was received from ROS
+func (r *RosProvider) Publish(topic string, msg any) error {
+	msgJson, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	replaying := r.replaying
+	r.mtx.Unlock()
+	if !replaying {
+		r.dispatch(topic, msgJson)
+	}
+	return nil
+}
+
+// Subscribers returns the number of subscribers of topic
+func (r *RosProvider) Subscribers(topic string) int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return len(r.subscribers[topic])
+}
+
+// Calls returns the service calls received so far
+func (r *RosProvider) Calls() []ServiceCall {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]ServiceCall{}, r.calls...)
+}
+
+// Published returns the messages written by the publishers of topic
+func (r *RosProvider) Published(topic string) []any {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]any{}, r.published[topic]...)
+}
+
+func (r *RosProvider) Publisher(topic string, obj interface{}) (types.IRosPublisher, error) {
+	return &rosPublisher{provider: r, topic: topic}, nil
+}
+
+func (r *RosProvider) AdvertiseService(name string, srv any, cb func(req any) (any, error)) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.advertised[name] = cb
+	return nil
+}
+
+// CallAdvertised calls the handler of an advertised service as if it was called from ROS
+func (r *RosProvider) CallAdvertised(name string, req any) (any, error) {
+	r.mtx.Lock()
+	cb, ok := r.advertised[name]
+	r.mtx.Unlock()
+	if !ok {
+		return nil, xerrors.Errorf("%s: %w", name, types.ErrUnknownService)
+	}
+	return cb(req)
+}
+
+func (r *RosProvider) RegisterTopic(topic string, msg any) error {
+	msgType := reflect.TypeOf(msg)
+	if msgType == nil || msgType.Kind() != reflect.Ptr {
+		return xerrors.Errorf("message type of %s must be a pointer", topic)
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.topicTypes[topic] = msgType
+	return nil
+}
+
+func (r *RosProvider) MessageType(topic string) (any, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	msgType, ok := r.topicTypes[topic]
+	if !ok {
+		return nil, xerrors.Errorf("unknown message type for topic %s", topic)
+	}
+	return reflect.New(msgType.Elem()).Interface(), nil
+}
+
+func (r *RosProvider) Replay(enabled bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.replaying = enabled
+	r.lastMessage = make(map[string][]byte)
+}
+
+func (r *RosProvider) Replaying() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.replaying
+}
+
+func (r *RosProvider) Inject(topic string, msg []byte) {
+	r.dispatch(topic, msg)
+}
+
+// SetGraph sets the nodes, topics and services returned by Nodes, Topics and Services
+func (r *RosProvider) SetGraph(nodes []types.RosNode, topics []types.RosTopic, services []types.RosService) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.graphNodes = nodes
+	r.graphTopics = topics
+	r.graphServices = services
+}
+
+func (r *RosProvider) Nodes() ([]types.RosNode, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.graphNodes, nil
+}
+
+func (r *RosProvider) Topics() ([]types.RosTopic, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.graphTopics, nil
+}
+
+func (r *RosProvider) Services(ctx context.Context) ([]types.RosService, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.graphServices, nil
+}
+
+func (r *RosProvider) NodePing(node string) (time.Duration, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, graphNode := range r.graphNodes {
+		if graphNode.Name == node {
+			return time.Millisecond, nil
+		}
+	}
+	return 0, xerrors.Errorf("node %s not found", node)
+}
+
+// dispatch stores the message and delivers it to the subscribers. The messages are delivered without holding r.mtx
+// as the callbacks may call the provider back.
+func (r *RosProvider) dispatch(topic string, msg []byte) {
+	r.mtx.Lock()
+	r.lastMessage[topic] = msg
+	var subscribers []*rosSubscriber
+	for _, subscriber := range r.subscribers[topic] {
+		subscribers = append(subscribers, subscriber)
+	}
+	r.mtx.Unlock()
+	for _, subscriber := range subscribers {
+		subscriber.deliver(msg)
+	}
+}
+
+// rosSubscriber calls the callback of a subscription on its own goroutine so that it is never called concurrently
+type rosSubscriber struct {
+	cb       func(msg []byte)
+	messages chan rosMessage
+	closed   chan struct{}
+	once     sync.Once
+}
+
+type rosMessage struct {
+	msg       []byte
+	delivered chan struct{}
+}
+
+func newRosSubscriber(cb func(msg []byte)) *rosSubscriber {
+	s := &rosSubscriber{cb: cb, messages: make(chan rosMessage), closed: make(chan struct{})}
+	go s.run()
+	return s
+}
+
+func (s *rosSubscriber) run() {
+	for {
+		select {
+		case message := <-s.messages:
+			s.cb(message.msg)
+			close(message.delivered)
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// deliver waits until the callback returned, the message is dropped if the subscription is closed
+func (s *rosSubscriber) deliver(msg []byte) {
+	message := rosMessage{msg: msg, delivered: make(chan struct{})}
+	select {
+	case s.messages <- message:
+	case <-s.closed:
+		return
+	}
+	select {
+	case <-message.delivered:
+	case <-s.closed:
+	}
+}
+
+func (s *rosSubscriber) close() {
+	s.once.Do(func() {
+		close(s.closed)
+	})
+}
+
+type rosPublisher struct {
+	provider *RosProvider
+	topic    string
+}
+
+func (p *rosPublisher) Write(msg interface{}) {
+	p.provider.mtx.Lock()
+	defer p.provider.mtx.Unlock()
+	p.provider.published[p.topic] = append(p.provider.published[p.topic], msg)
+}
+
+func (p *rosPublisher) Close() {
+}
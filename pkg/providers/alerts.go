@@ -0,0 +1,384 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+var alertsLog = Logs.Logger("alerts")
+
+// alertSources are the messages rules can be evaluated on
+var alertSources = map[string]string{
+	"status":          "/mower/status",
+	"highLevelStatus": "/mower_logic/current_state",
+	"pose":            "/xbot_positioning/xb_pose",
+}
+
+var alertOperators = []string{">", ">=", "<", "<=", "==", "!="}
+
+var alertSeverities = []string{"info", "warning", "critical"}
+
+// DefaultAlertRules are used until rules are saved in system.alerts.rules
+var DefaultAlertRules = []types.AlertRule{
+	{
+		ID: "mow_motor_temperature", Name: "Mow motor temperature is too high", Severity: "critical",
+		Source: "status", Field: "MowEscStatus.TemperatureMotor", Operator: ">", Value: 80.0, For: 30, Hysteresis: 5,
+	},
+	{
+		ID: "battery_empty", Name: "Battery is empty", Severity: "critical",
+		Source: "status", Field: "VBattery", Operator: "<", Value: 21.7, For: 10, Hysteresis: 0.5,
+	},
+	{
+		ID: "emergency", Name: "Mower is in emergency", Severity: "warning",
+		Source: "highLevelStatus", Field: "Emergency", Operator: "==", Value: true,
+	},
+	{
+		ID: "emergency_stuck", Name: "Mower is in emergency for an hour", Severity: "critical",
+		Source: "highLevelStatus", Field: "Emergency", Operator: "==", Value: true, For: 3600,
+	},
+	{
+		ID: "gps_quality", Name: "GPS quality is low while mowing", Severity: "warning",
+		Source: "highLevelStatus", Field: "GpsQualityPercent", Operator: "<", Value: 0.5, For: 60, Hysteresis: 0.1,
+		States: []string{"MOWING"},
+	},
+}
+
+// AlertProvider evaluates the alert rules on each status, high level status and pose message. An alert is raised
+// once its rule held for the configured duration and stays active, without being raised again, until the rule no
+// longer holds. Rules are stored as JSON in system.alerts.rules. Rules are not evaluated while a recording is replayed.
+type AlertProvider struct {
+	rosProvider types.IRosProvider
+	dbProvider  types.IDBProvider
+	mtx         sync.Mutex
+	rules       []types.AlertRule
+	state       string
+	pending     map[string]time.Time
+	active      map[string]*types.Alert
+	subscribers map[string]func(event types.AlertEvent)
+}
+
+func NewAlertProvider(rosProvider types.IRosProvider, dbProvider types.IDBProvider) *AlertProvider {
+	a := &AlertProvider{
+		rosProvider: rosProvider,
+		dbProvider:  dbProvider,
+		pending:     make(map[string]time.Time),
+		active:      make(map[string]*types.Alert),
+		subscribers: make(map[string]func(event types.AlertEvent)),
+	}
+	a.rules = DefaultAlertRules
+	value, err := dbProvider.Get("system.alerts.rules")
+	if err == nil {
+		err = json.Unmarshal(value, &a.rules)
+		if err != nil {
+			alertsLog.Error(xerrors.Errorf("failed to read alert rules, using the default rules: %w", err))
+			a.rules = DefaultAlertRules
+		}
+	}
+	for source, topic := range alertSources {
+		source := source
+		err = rosProvider.Subscribe(topic, "alerts", func(msg []byte) {
+			a.onMessage(source, msg)
+		})
+		if err != nil {
+			alertsLog.Error(xerrors.Errorf("failed to subscribe to %s: %w", topic, err))
+		}
+	}
+	return a
+}
+
+func (a *AlertProvider) Rules() ([]types.AlertRule, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return append([]types.AlertRule{}, a.rules...), nil
+}
+
+// SetRules validates and saves the rules, the alerts of the removed rules are cleared
+func (a *AlertProvider) SetRules(rules []types.AlertRule) error {
+	err := validateAlertRules(rules)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	err = a.dbProvider.Set("system.alerts.rules", value)
+	if err != nil {
+		return err
+	}
+	a.mtx.Lock()
+	a.rules = rules
+	a.pending = make(map[string]time.Time)
+	var events []types.AlertEvent
+	for id := range a.active {
+		_, ok := lo.Find(rules, func(rule types.AlertRule) bool {
+			return rule.ID == id
+		})
+		if !ok {
+			events = append(events, a.clear(id, time.Now()))
+		}
+	}
+	a.mtx.Unlock()
+	a.notify(events)
+	return nil
+}
+
+func (a *AlertProvider) Active() []types.Alert {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	alerts := []types.Alert{}
+	for _, rule := range a.rules {
+		if alert, ok := a.active[rule.ID]; ok {
+			alerts = append(alerts, *alert)
+		}
+	}
+	return alerts
+}
+
+func (a *AlertProvider) Acknowledge(id string) error {
+	a.mtx.Lock()
+	alert, ok := a.active[id]
+	if !ok {
+		a.mtx.Unlock()
+		return xerrors.Errorf("%s: %w", id, types.ErrUnknownAlert)
+	}
+	if alert.Acknowledged {
+		a.mtx.Unlock()
+		return nil
+	}
+	now := time.Now()
+	alert.Acknowledged = true
+	alert.AcknowledgedAt = &now
+	event := types.AlertEvent{Type: types.AlertAcknowledged, Alert: *alert}
+	a.mtx.Unlock()
+	a.notify([]types.AlertEvent{event})
+	return nil
+}
+
+func (a *AlertProvider) SubscribeAlerts(id string, cb func(event types.AlertEvent)) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.subscribers[id] = cb
+}
+
+func (a *AlertProvider) UnSubscribeAlerts(id string) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	delete(a.subscribers, id)
+}
+
+// onMessage evaluates the rules of source, the replayed messages are ignored
+func (a *AlertProvider) onMessage(source string, msg []byte) {
+	if a.rosProvider.Replaying() {
+		return
+	}
+	var fields map[string]any
+	err := json.Unmarshal(msg, &fields)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	a.mtx.Lock()
+	if source == "highLevelStatus" {
+		a.state, _ = fields["StateName"].(string)
+	}
+	var events []types.AlertEvent
+	for _, rule := range a.rules {
+		if rule.Source != source {
+			continue
+		}
+		event, ok := a.evaluate(rule, fields, now)
+		if ok {
+			events = append(events, event)
+		}
+	}
+	a.mtx.Unlock()
+	a.notify(events)
+}
+
+// evaluate updates the alert of rule, an event is returned when the alert is raised or cleared. a.mtx must be held.
+func (a *AlertProvider) evaluate(rule types.AlertRule, fields map[string]any, now time.Time) (types.AlertEvent, bool) {
+	value, found := alertField(fields, rule.Field)
+	inState := len(rule.States) == 0 || lo.Contains(rule.States, a.state)
+	holds := found && inState && compareAlertValue(rule.Operator, value, rule.Value, 0)
+	alert, active := a.active[rule.ID]
+	if holds {
+		if active {
+			alert.Value = value
+			return types.AlertEvent{}, false
+		}
+		since, pending := a.pending[rule.ID]
+		if !pending {
+			since = now
+			a.pending[rule.ID] = now
+		}
+		if now.Sub(since) < time.Duration(rule.For*float64(time.Second)) {
+			return types.AlertEvent{}, false
+		}
+		delete(a.pending, rule.ID)
+		alert = &types.Alert{
+			ID:       rule.ID,
+			Name:     rule.Name,
+			Severity: rule.Severity,
+			Value:    value,
+			RaisedAt: now,
+		}
+		a.active[rule.ID] = alert
+		alertsLog.Warn("Alert raised: " + rule.Name)
+		return types.AlertEvent{Type: types.AlertRaised, Alert: *alert}, true
+	}
+	delete(a.pending, rule.ID)
+	if !active {
+		return types.AlertEvent{}, false
+	}
+	// the alert is kept while the value did not get back past the threshold by the hysteresis
+	if found && inState && compareAlertValue(rule.Operator, value, rule.Value, rule.Hysteresis) {
+		alert.Value = value
+		return types.AlertEvent{}, false
+	}
+	alert.Value = value
+	return a.clear(rule.ID, now), true
+}
+
+// clear removes the active alert id, a.mtx must be held
+func (a *AlertProvider) clear(id string, now time.Time) types.AlertEvent {
+	alert := a.active[id]
+	delete(a.active, id)
+	alert.ClearedAt = &now
+	alertsLog.Info("Alert cleared: " + alert.Name)
+	return types.AlertEvent{Type: types.AlertCleared, Alert: *alert}
+}
+
+func (a *AlertProvider) notify(events []types.AlertEvent) {
+	if len(events) == 0 {
+		return
+	}
+	a.mtx.Lock()
+	var callbacks []func(event types.AlertEvent)
+	for _, cb := range a.subscribers {
+		callbacks = append(callbacks, cb)
+	}
+	a.mtx.Unlock()
+	for _, event := range events {
+		for _, cb := range callbacks {
+			cb(event)
+		}
+	}
+}
+
+// alertField returns the value at the dotted path of a JSON message, e.g. MowEscStatus.TemperatureMotor or
+// UltrasonicRanges.0
+func alertField(fields map[string]any, path string) (any, bool) {
+	var value any = fields
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			var ok bool
+			value, ok = v[key]
+			if !ok {
+				return nil, false
+			}
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// compareAlertValue compares value to threshold with operator. For numbers, the threshold is moved by hysteresis
+// in the direction that makes the comparison hold longer.
+func compareAlertValue(operator string, value any, threshold any, hysteresis float64) bool {
+	number, isNumber := value.(float64)
+	limit, isNumberLimit := alertNumber(threshold)
+	if isNumber && isNumberLimit {
+		switch operator {
+		case ">":
+			return number > limit-hysteresis
+		case ">=":
+			return number >= limit-hysteresis
+		case "<":
+			return number < limit+hysteresis
+		case "<=":
+			return number <= limit+hysteresis
+		case "==":
+			return number == limit
+		case "!=":
+			return number != limit
+		}
+		return false
+	}
+	switch operator {
+	case "==":
+		return fmt.Sprint(value) == fmt.Sprint(threshold)
+	case "!=":
+		return fmt.Sprint(value) != fmt.Sprint(threshold)
+	}
+	return false
+}
+
+func alertNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func validateAlertRules(rules []types.AlertRule) error {
+	fields := map[string]string{}
+	ids := map[string]bool{}
+	for i, rule := range rules {
+		prefix := fmt.Sprintf("rules[%d].", i)
+		if rule.ID == "" {
+			fields[prefix+"id"] = "is required"
+		} else if ids[rule.ID] {
+			fields[prefix+"id"] = "is duplicated"
+		}
+		ids[rule.ID] = true
+		if _, ok := alertSources[rule.Source]; !ok {
+			fields[prefix+"source"] = "must be one of status, highLevelStatus, pose"
+		}
+		if rule.Field == "" {
+			fields[prefix+"field"] = "is required"
+		}
+		if !lo.Contains(alertOperators, rule.Operator) {
+			fields[prefix+"operator"] = "must be one of " + strings.Join(alertOperators, ", ")
+		} else if _, ok := alertNumber(rule.Value); !ok && rule.Operator != "==" && rule.Operator != "!=" {
+			fields[prefix+"value"] = "must be a number with operator " + rule.Operator
+		}
+		if rule.Value == nil {
+			fields[prefix+"value"] = "is required"
+		}
+		if !lo.Contains(alertSeverities, rule.Severity) {
+			fields[prefix+"severity"] = "must be one of " + strings.Join(alertSeverities, ", ")
+		}
+		if rule.For < 0 {
+			fields[prefix+"for"] = "must be positive"
+		}
+		if rule.Hysteresis < 0 {
+			fields[prefix+"hysteresis"] = "must be positive"
+		}
+	}
+	if len(fields) > 0 {
+		return &types.ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertHysteresisAndAcknowledge(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	alerts := NewAlertProvider(ros, fakes.NewDBProvider(map[string]string{}))
+	require.NoError(t, alerts.SetRules([]types.AlertRule{{
+		ID: "temperature", Name: "Temperature", Severity: "critical", Source: "status",
+		Field: "MowEscStatus.TemperatureMotor", Operator: ">", Value: 80.0, Hysteresis: 5,
+	}}))
+	var events []string
+	alerts.SubscribeAlerts("test", func(event types.AlertEvent) {
+		events = append(events, event.Type)
+	})
+	temperature := func(value float32) {
+		require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{MowEscStatus: mower_msgs.ESCStatus{TemperatureMotor: value}}))
+	}
+
+	temperature(70)
+	assert.Empty(t, alerts.Active())
+	temperature(85)
+	temperature(90)
+	require.Len(t, alerts.Active(), 1)
+	assert.Equal(t, 90.0, alerts.Active()[0].Value)
+	assert.True(t, errors.Is(alerts.Acknowledge("unknown"), types.ErrUnknownAlert))
+	require.NoError(t, alerts.Acknowledge("temperature"))
+	assert.True(t, alerts.Active()[0].Acknowledged)
+	// still above the threshold minus the hysteresis
+	temperature(77)
+	assert.Len(t, alerts.Active(), 1)
+	temperature(74)
+	assert.Empty(t, alerts.Active())
+	assert.Equal(t, []string{types.AlertRaised, types.AlertAcknowledged, types.AlertCleared}, events)
+}
+
+func TestAlertDurationAndStates(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	alerts := NewAlertProvider(ros, fakes.NewDBProvider(map[string]string{}))
+	require.NoError(t, alerts.SetRules([]types.AlertRule{
+		{
+			ID: "gps", Severity: "warning", Source: "highLevelStatus", Field: "GpsQualityPercent",
+			Operator: "<", Value: 0.5, States: []string{"MOWING"},
+		},
+		{
+			ID: "emergency", Severity: "warning", Source: "highLevelStatus", Field: "Emergency",
+			Operator: "==", Value: true, For: 0.05,
+		},
+	}))
+	publish := func(status mower_msgs.HighLevelStatus) {
+		require.NoError(t, ros.Publish("/mower_logic/current_state", &status))
+	}
+
+	publish(mower_msgs.HighLevelStatus{StateName: "IDLE", GpsQualityPercent: 0.2, Emergency: true})
+	assert.Empty(t, alerts.Active())
+	time.Sleep(60 * time.Millisecond)
+	publish(mower_msgs.HighLevelStatus{StateName: "MOWING", GpsQualityPercent: 0.2, Emergency: true})
+	active := alerts.Active()
+	require.Len(t, active, 2)
+	assert.Equal(t, "gps", active[0].ID)
+	assert.Equal(t, "emergency", active[1].ID)
+	publish(mower_msgs.HighLevelStatus{StateName: "IDLE", GpsQualityPercent: 0.2})
+	assert.Empty(t, alerts.Active())
+}
+
+func TestAlertRulesValidation(t *testing.T) {
+	db := fakes.NewDBProvider(map[string]string{})
+	alerts := NewAlertProvider(fakes.NewRosProvider(), db)
+	rules, err := alerts.Rules()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAlertRules, rules)
+
+	err = alerts.SetRules([]types.AlertRule{
+		{ID: "a", Severity: "critical", Source: "status", Field: "VBattery", Operator: "<", Value: 21.0},
+		{ID: "a", Severity: "fatal", Source: "odometry", Field: "VBattery", Operator: "~", Value: 1.0},
+		{ID: "b", Severity: "info", Source: "status", Field: "VBattery", Operator: "<", Value: "low"},
+	})
+	var validationErr *types.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, map[string]string{
+		"rules[1].id":       "is duplicated",
+		"rules[1].severity": "must be one of info, warning, critical",
+		"rules[1].source":   "must be one of status, highLevelStatus, pose",
+		"rules[1].operator": "must be one of >, >=, <, <=, ==, !=",
+		"rules[2].value":    "must be a number with operator <",
+	}, validationErr.Fields)
+
+	rules = []types.AlertRule{{ID: "a", Severity: "critical", Source: "status", Field: "VBattery", Operator: "<", Value: 21.0}}
+	require.NoError(t, alerts.SetRules(rules))
+	reloaded, err := NewAlertProvider(fakes.NewRosProvider(), db).Rules()
+	require.NoError(t, err)
+	assert.Equal(t, rules, reloaded)
+}
+
+func TestAlertReplay(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	alerts := NewAlertProvider(ros, fakes.NewDBProvider(map[string]string{}))
+	require.NoError(t, alerts.SetRules([]types.AlertRule{{
+		ID: "temperature", Severity: "critical", Source: "status",
+		Field: "MowEscStatus.TemperatureMotor", Operator: ">", Value: 80.0,
+	}}))
+
+	// the recorded messages don't raise alerts
+	ros.Replay(true)
+	ros.Inject("/mower/status", []byte(`{"MowEscStatus":{"TemperatureMotor":90}}`))
+	assert.Empty(t, alerts.Active())
+
+	ros.Replay(false)
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{MowEscStatus: mower_msgs.ESCStatus{TemperatureMotor: 90}}))
+	assert.Len(t, alerts.Active(), 1)
+}
@@ -0,0 +1,503 @@
+package providers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+)
+
+var backupLog = Logs.Logger("backup")
+
+const backupVersion = 1
+
+// backupMagic starts the encrypted archives, it is followed by the scrypt salt, the AES-GCM nonce and the sealed archive
+var backupMagic = []byte("OMGUIENC1")
+
+const backupSaltSize = 16
+
+// maxBackupFileSize bounds each file read from an archive
+const maxBackupFileSize = 64 << 20
+
+// backupMapTimeout is how long a backup waits for the map, the backup goes on without it when ROS does not send it.
+// A restore waits as long for the map it replaces.
+var backupMapTimeout = 2 * time.Second
+
+// backupRollbackTimeout bounds the rollback of a failed restore, which is done even if the request is canceled
+var backupRollbackTimeout = 30 * time.Second
+
+// homekitKeys are the DB keys of the HomeKit identity
+var homekitKeys = []string{"uuid", "keypair"}
+
+// BackupProvider archives the GUI DB keys, including the firmware config and the HomeKit identity, mower_config.sh
+// and the map in a tar.gz file, optionally encrypted with AES-GCM and a key derived from a passphrase with scrypt.
+type BackupProvider struct {
+	dbProvider  types.IDBProvider
+	rosProvider types.IRosProvider
+	history     *SettingsHistory
+}
+
+// NewBackupProvider returns a backup provider restoring mower_config.sh through history, which must be the history
+// used by the settings routes so that they do not write the file at the same time
+func NewBackupProvider(dbProvider types.IDBProvider, rosProvider types.IRosProvider, history *SettingsHistory) *BackupProvider {
+	return &BackupProvider{
+		dbProvider:  dbProvider,
+		rosProvider: rosProvider,
+		history:     history,
+	}
+}
+
+// backupContent is the content of an archive, a nil part is not in the archive
+type backupContent struct {
+	manifest    types.BackupManifest
+	db          map[string][]byte
+	mowerConfig []byte
+	mapJson     []byte
+}
+
+func (b *BackupProvider) Backup(ctx context.Context, passphrase string) ([]byte, types.BackupManifest, error) {
+	content := backupContent{
+		manifest: types.BackupManifest{Version: backupVersion, CreatedAt: time.Now(), Encrypted: passphrase != ""},
+		db:       map[string][]byte{},
+	}
+	keys, err := b.dbProvider.KeysWithSuffix("")
+	if err != nil {
+		return nil, types.BackupManifest{}, err
+	}
+	for _, key := range keys {
+		value, err := b.dbProvider.Get(key)
+		if err != nil {
+			return nil, types.BackupManifest{}, xerrors.Errorf("failed to read %s: %w", key, err)
+		}
+		content.db[key] = value
+	}
+	path, err := b.dbProvider.Get("system.mower.configFile")
+	if err != nil {
+		return nil, types.BackupManifest{}, err
+	}
+	content.mowerConfig, err = os.ReadFile(string(path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, types.BackupManifest{}, err
+	}
+	content.mapJson, err = b.currentMap(ctx)
+	if err != nil {
+		backupLog.Warn(xerrors.Errorf("the map is not in the backup: %w", err))
+	}
+	content.manifest.Contents = content.contents()
+	archive, err := writeBackup(content)
+	if err != nil {
+		return nil, types.BackupManifest{}, err
+	}
+	if passphrase != "" {
+		archive, err = encryptBackup(archive, passphrase)
+		if err != nil {
+			return nil, types.BackupManifest{}, err
+		}
+	}
+	return archive, content.manifest, nil
+}
+
+// currentMap returns the last map published by ROS
+func (b *BackupProvider) currentMap(ctx context.Context) ([]byte, error) {
+	maps := make(chan []byte, 1)
+	err := b.rosProvider.Subscribe("/xbot_monitoring/map", "backup", func(msg []byte) {
+		select {
+		case maps <- msg:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer b.rosProvider.UnSubscribe("/xbot_monitoring/map", "backup")
+	ctx, cancel := context.WithTimeout(ctx, backupMapTimeout)
+	defer cancel()
+	select {
+	case msg := <-maps:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, xerrors.Errorf("no map received: %w", ctx.Err())
+	}
+}
+
+func (b *BackupProvider) Restore(ctx context.Context, archive []byte, passphrase string) (types.BackupManifest, error) {
+	encrypted := bytes.HasPrefix(archive, backupMagic)
+	if encrypted {
+		var err error
+		archive, err = decryptBackup(archive, passphrase)
+		if err != nil {
+			return types.BackupManifest{}, err
+		}
+	}
+	content, err := readBackup(archive)
+	if err != nil {
+		return types.BackupManifest{}, err
+	}
+	var mapMsg xbot_msgs.Map
+	if content.mapJson != nil {
+		err = json.Unmarshal(content.mapJson, &mapMsg)
+		if err != nil {
+			return types.BackupManifest{}, xerrors.Errorf("%w: map.json: %s", types.ErrInvalidBackup, err)
+		}
+	}
+	if content.mowerConfig != nil {
+		if _, err = ParseMowerConfig(content.mowerConfig); err != nil {
+			return types.BackupManifest{}, xerrors.Errorf("%w: mower_config.sh: %s", types.ErrInvalidBackup, err)
+		}
+	}
+
+	// nothing is changed until the current state is saved, it is put back if a part of the backup fails
+	snapshot, err := b.snapshot(ctx, content)
+	if err != nil {
+		return types.BackupManifest{}, xerrors.Errorf("failed to save the current state: %w", err)
+	}
+	applied, err := b.apply(ctx, content, mapMsg)
+	if err != nil {
+		rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backupRollbackTimeout)
+		defer cancel()
+		rollbackErr := b.rollback(rollbackCtx, snapshot, applied)
+		if rollbackErr != nil {
+			return types.BackupManifest{}, fmt.Errorf("%w, %v were restored and could not be rolled back: %s", err, applied, rollbackErr)
+		}
+		return types.BackupManifest{}, fmt.Errorf("%w, %v were restored then rolled back", err, applied)
+	}
+	content.manifest.Encrypted = encrypted
+	backupLog.Infof("restored backup of %s with %v", content.manifest.CreatedAt.Format(time.RFC3339), content.manifest.Contents)
+	return content.manifest, nil
+}
+
+// backupSnapshot is the state replaced by a restore, db holds the previous values of the restored keys, nil for the
+// keys that did not exist, and mapMsg is nil when no map was received
+type backupSnapshot struct {
+	db          map[string][]byte
+	mowerConfig []byte
+	mapMsg      *xbot_msgs.Map
+}
+
+// snapshot saves the state that the restore of content replaces
+func (b *BackupProvider) snapshot(ctx context.Context, content backupContent) (backupSnapshot, error) {
+	snapshot := backupSnapshot{db: map[string][]byte{}}
+	keys, err := b.dbProvider.KeysWithSuffix("")
+	if err != nil {
+		return snapshot, err
+	}
+	for key := range content.db {
+		snapshot.db[key] = nil
+		if !lo.Contains(keys, key) {
+			continue
+		}
+		snapshot.db[key], err = b.dbProvider.Get(key)
+		if err != nil {
+			return snapshot, xerrors.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+	if content.mowerConfig != nil {
+		path, err := b.dbProvider.Get("system.mower.configFile")
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.mowerConfig, err = os.ReadFile(string(path))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return snapshot, err
+		}
+	}
+	if content.mapJson != nil {
+		current, err := b.currentMap(ctx)
+		if err == nil {
+			var mapMsg xbot_msgs.Map
+			err = json.Unmarshal(current, &mapMsg)
+			snapshot.mapMsg = &mapMsg
+		}
+		if err != nil {
+			snapshot.mapMsg = nil
+			backupLog.Warn(xerrors.Errorf("the map cannot be rolled back: %w", err))
+		}
+	}
+	return snapshot, nil
+}
+
+// apply restores the parts of content, it returns the parts that were changed, including the one that failed
+func (b *BackupProvider) apply(ctx context.Context, content backupContent, mapMsg xbot_msgs.Map) ([]string, error) {
+	applied := []string{types.BackupDB}
+	// the keys of the backup are set, the other keys are kept
+	for _, key := range lo.Keys(content.db) {
+		err := b.dbProvider.Set(key, content.db[key])
+		if err != nil {
+			return applied, xerrors.Errorf("failed to restore %s: %w", key, err)
+		}
+	}
+	if content.mowerConfig != nil {
+		applied = append(applied, types.BackupMowerConfig)
+		err := b.history.Write(content.mowerConfig, "backup restore")
+		if err != nil {
+			return applied, xerrors.Errorf("failed to restore the mower config: %w", err)
+		}
+	}
+	if content.mapJson != nil {
+		applied = append(applied, types.BackupMap)
+		err := b.restoreMap(ctx, mapMsg)
+		if err != nil {
+			return applied, xerrors.Errorf("failed to restore the map: %w", err)
+		}
+	}
+	return applied, nil
+}
+
+// rollback puts back the parts of the snapshot that were changed, in the reverse order
+func (b *BackupProvider) rollback(ctx context.Context, snapshot backupSnapshot, applied []string) error {
+	var errs []error
+	if lo.Contains(applied, types.BackupMap) {
+		if snapshot.mapMsg == nil {
+			errs = append(errs, xerrors.New("the previous map is unknown"))
+		} else if err := b.restoreMap(ctx, *snapshot.mapMsg); err != nil {
+			errs = append(errs, xerrors.Errorf("map: %w", err))
+		}
+	}
+	if lo.Contains(applied, types.BackupMowerConfig) {
+		var err error
+		if snapshot.mowerConfig == nil {
+			var path []byte
+			path, err = b.dbProvider.Get("system.mower.configFile")
+			if err == nil {
+				err = os.Remove(string(path))
+			}
+		} else {
+			err = b.history.Write(snapshot.mowerConfig, "backup rollback")
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, xerrors.Errorf("mower config: %w", err))
+		}
+	}
+	for key, value := range snapshot.db {
+		var err error
+		if value == nil {
+			err = b.dbProvider.Delete(key)
+		} else {
+			err = b.dbProvider.Set(key, value)
+		}
+		if err != nil {
+			errs = append(errs, xerrors.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restoreMap replaces the map areas and the docking point like the map editor does
+func (b *BackupProvider) restoreMap(ctx context.Context, mapMsg xbot_msgs.Map) error {
+	err := b.rosProvider.CallService(ctx, "/mower_map_service/clear_map", &mower_map.ClearMapSrv{}, &mower_map.ClearMapSrvReq{}, &mower_map.ClearMapSrvRes{})
+	if err != nil {
+		return err
+	}
+	for _, areas := range []struct {
+		areas      []xbot_msgs.MapArea
+		navigation bool
+	}{{mapMsg.WorkingArea, false}, {mapMsg.NavigationAreas, true}} {
+		for _, area := range areas.areas {
+			err = b.rosProvider.CallService(ctx, "/mower_map_service/add_mowing_area", &mower_map.AddMowingAreaSrv{}, &mower_map.AddMowingAreaSrvReq{
+				Area:             mower_map.MapArea{Name: area.Name, Area: area.Area, Obstacles: area.Obstacles},
+				IsNavigationArea: areas.navigation,
+			}, &mower_map.AddMowingAreaSrvRes{})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return b.rosProvider.CallService(ctx, "/mower_map_service/set_docking_point", &mower_map.SetDockingPointSrv{}, &mower_map.SetDockingPointSrvReq{
+		DockingPose: geometry_msgs.Pose{
+			Position:    geometry_msgs.Point{X: mapMsg.DockX, Y: mapMsg.DockY},
+			Orientation: geometry_msgs.Quaternion{Z: math.Sin(mapMsg.DockHeading / 2), W: math.Cos(mapMsg.DockHeading / 2)},
+		},
+	}, &mower_map.SetDockingPointSrvRes{})
+}
+
+// contents returns the parts of the content
+func (c backupContent) contents() []string {
+	contents := []string{types.BackupDB}
+	if _, ok := c.db["gui.firmware.config"]; ok {
+		contents = append(contents, types.BackupFirmwareConfig)
+	}
+	if lo.EveryBy(homekitKeys, func(key string) bool {
+		_, ok := c.db[key]
+		return ok
+	}) {
+		contents = append(contents, types.BackupHomeKit)
+	}
+	if c.mowerConfig != nil {
+		contents = append(contents, types.BackupMowerConfig)
+	}
+	if c.mapJson != nil {
+		contents = append(contents, types.BackupMap)
+	}
+	return contents
+}
+
+// writeBackup writes the content as a tar.gz archive of manifest.json, db.json, mower_config.sh and map.json
+func writeBackup(content backupContent) ([]byte, error) {
+	manifest, err := json.MarshalIndent(content.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	db, err := json.Marshal(content.db)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{"manifest.json", manifest},
+		{"db.json", db},
+		{"mower_config.sh", content.mowerConfig},
+		{"map.json", content.mapJson},
+	} {
+		if file.data == nil {
+			continue
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Name:    file.name,
+			Mode:    0600,
+			Size:    int64(len(file.data)),
+			ModTime: content.manifest.CreatedAt,
+		})
+		if err == nil {
+			_, err = tw.Write(file.data)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = gz.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// readBackup reads and validates an archive written by writeBackup
+func readBackup(archive []byte) (backupContent, error) {
+	var content backupContent
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return content, xerrors.Errorf("%w: %s", types.ErrInvalidBackup, err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return content, xerrors.Errorf("%w: %s", types.ErrInvalidBackup, err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxBackupFileSize {
+			return content, xerrors.Errorf("%w: unexpected file %s", types.ErrInvalidBackup, header.Name)
+		}
+		switch header.Name {
+		case "manifest.json", "db.json", "mower_config.sh", "map.json":
+		default:
+			return content, xerrors.Errorf("%w: unexpected file %s", types.ErrInvalidBackup, header.Name)
+		}
+		files[header.Name], err = io.ReadAll(tr)
+		if err != nil {
+			return content, xerrors.Errorf("%w: %s", types.ErrInvalidBackup, err)
+		}
+	}
+	manifest, ok := files["manifest.json"]
+	if !ok {
+		return content, xerrors.Errorf("%w: manifest.json is missing", types.ErrInvalidBackup)
+	}
+	if err = json.Unmarshal(manifest, &content.manifest); err != nil {
+		return content, xerrors.Errorf("%w: manifest.json: %s", types.ErrInvalidBackup, err)
+	}
+	if content.manifest.Version != backupVersion {
+		return content, xerrors.Errorf("%w: unsupported version %d", types.ErrInvalidBackup, content.manifest.Version)
+	}
+	db, ok := files["db.json"]
+	if !ok {
+		return content, xerrors.Errorf("%w: db.json is missing", types.ErrInvalidBackup)
+	}
+	if err = json.Unmarshal(db, &content.db); err != nil {
+		return content, xerrors.Errorf("%w: db.json: %s", types.ErrInvalidBackup, err)
+	}
+	content.mowerConfig = files["mower_config.sh"]
+	content.mapJson = files["map.json"]
+	content.manifest.Contents = content.contents()
+	return content, nil
+}
+
+func backupCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptBackup(archive []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, backupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := backupCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header := append(append(append([]byte{}, backupMagic...), salt...), nonce...)
+	// the header is authenticated with the archive
+	return aead.Seal(header, nonce, archive, header), nil
+}
+
+func decryptBackup(data []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, xerrors.Errorf("%w: the backup is encrypted, a passphrase is required", types.ErrInvalidBackup)
+	}
+	if len(data) < len(backupMagic)+backupSaltSize {
+		return nil, xerrors.Errorf("%w: truncated archive", types.ErrInvalidBackup)
+	}
+	salt := data[len(backupMagic) : len(backupMagic)+backupSaltSize]
+	aead, err := backupCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	headerSize := len(backupMagic) + backupSaltSize + aead.NonceSize()
+	if len(data) < headerSize {
+		return nil, xerrors.Errorf("%w: truncated archive", types.ErrInvalidBackup)
+	}
+	archive, err := aead.Open(nil, data[headerSize-aead.NonceSize():headerSize], data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, xerrors.Errorf("%w: wrong passphrase or corrupted archive", types.ErrInvalidBackup)
+	}
+	return archive, nil
+}
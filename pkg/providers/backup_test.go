@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupProvider(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mower_config.sh")
+	require.NoError(t, os.WriteFile(path, []byte("export OM_TOOL_WIDTH=0.13\n"), 0644))
+	db := fakes.NewDBProvider(map[string]string{
+		"system.mower.configFile": path,
+		"gui.firmware.config":     `{"boardType":"BOARD_VERMUT_YARDFORCE500"}`,
+		"uuid":                    "ab:cd",
+		"keypair":                 "{}",
+	})
+	ros := fakes.NewRosProvider()
+	require.NoError(t, ros.Publish("/xbot_monitoring/map", xbot_msgs.Map{
+		WorkingArea:     []xbot_msgs.MapArea{{Name: "garden", Area: geometry_msgs.Polygon{Points: []geometry_msgs.Point32{{X: 1}, {Y: 1}}}}},
+		NavigationAreas: []xbot_msgs.MapArea{{Name: "path"}},
+		DockX:           2, DockY: 3,
+	}))
+	archive, manifest, err := NewBackupProvider(db, ros, NewSettingsHistory(db)).Backup(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{types.BackupDB, types.BackupFirmwareConfig, types.BackupHomeKit, types.BackupMowerConfig, types.BackupMap}, manifest.Contents)
+	assert.Zero(t, ros.Subscribers("/xbot_monitoring/map"))
+
+	encrypted, _, err := NewBackupProvider(db, ros, NewSettingsHistory(db)).Backup(ctx, "secret")
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "BOARD_VERMUT")
+
+	// the config file is changed after the backup, the restored ROS has no map to roll back
+	backupMapTimeout = 10 * time.Millisecond
+	require.NoError(t, os.WriteFile(path, []byte("export OM_TOOL_WIDTH=0.2\n"), 0644))
+	restoredDB := fakes.NewDBProvider(map[string]string{"system.mower.configFile": path, "gui.local": "kept"})
+	restoredRos := fakes.NewRosProvider()
+	provider := NewBackupProvider(restoredDB, restoredRos, NewSettingsHistory(restoredDB))
+
+	_, err = provider.Restore(ctx, encrypted, "")
+	assert.ErrorIs(t, err, types.ErrInvalidBackup)
+	_, err = provider.Restore(ctx, encrypted, "wrong")
+	assert.ErrorIs(t, err, types.ErrInvalidBackup)
+	_, err = provider.Restore(ctx, []byte("not an archive"), "")
+	assert.ErrorIs(t, err, types.ErrInvalidBackup)
+	assert.Empty(t, restoredRos.Calls())
+
+	manifest, err = provider.Restore(ctx, archive, "")
+	require.NoError(t, err)
+	assert.False(t, manifest.Encrypted)
+	manifest, err = provider.Restore(ctx, encrypted, "secret")
+	require.NoError(t, err)
+	assert.True(t, manifest.Encrypted)
+	value, err := restoredDB.Get("gui.firmware.config")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"boardType":"BOARD_VERMUT_YARDFORCE500"}`, string(value))
+	value, err = restoredDB.Get("gui.local")
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(value))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export OM_TOOL_WIDTH=0.13\n", string(content))
+	revisions, err := NewSettingsHistory(restoredDB).Revisions()
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1)
+
+	calls := restoredRos.Calls()[4:]
+	require.Len(t, calls, 4)
+	assert.Equal(t, []string{
+		"/mower_map_service/clear_map", "/mower_map_service/add_mowing_area", "/mower_map_service/add_mowing_area", "/mower_map_service/set_docking_point",
+	}, lo.Map(calls, func(call fakes.ServiceCall, idx int) string {
+		return call.Service
+	}))
+	assert.Equal(t, "garden", calls[1].Req.(mower_map.AddMowingAreaSrvReq).Area.Name)
+	assert.True(t, calls[2].Req.(mower_map.AddMowingAreaSrvReq).IsNavigationArea)
+	dock := calls[3].Req.(mower_map.SetDockingPointSrvReq).DockingPose
+	assert.Equal(t, geometry_msgs.Point{X: 2, Y: 3}, dock.Position)
+	assert.Equal(t, 1.0, dock.Orientation.W)
+}
+
+func TestBackupWithoutMap(t *testing.T) {
+	backupMapTimeout = 10 * time.Millisecond
+	db := fakes.NewDBProvider(map[string]string{"system.mower.configFile": filepath.Join(t.TempDir(), "missing.sh")})
+	_, manifest, err := NewBackupProvider(db, fakes.NewRosProvider(), NewSettingsHistory(db)).Backup(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{types.BackupDB}, manifest.Contents)
+}
+
+func TestBackupRestoreRollback(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mower_config.sh")
+	require.NoError(t, os.WriteFile(path, []byte("export OM_TOOL_WIDTH=0.13\n"), 0644))
+	db := fakes.NewDBProvider(map[string]string{"system.mower.configFile": path, "gui.firmware.config": "backup"})
+	ros := fakes.NewRosProvider()
+	require.NoError(t, ros.Publish("/xbot_monitoring/map", xbot_msgs.Map{WorkingArea: []xbot_msgs.MapArea{{Name: "garden"}}}))
+	archive, _, err := NewBackupProvider(db, ros, NewSettingsHistory(db)).Backup(ctx, "")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("export OM_TOOL_WIDTH=0.2\n"), 0644))
+	restoredDB := fakes.NewDBProvider(map[string]string{"system.mower.configFile": path, "gui.firmware.config": "current"})
+	restoredRos := fakes.NewRosProvider()
+	require.NoError(t, restoredRos.Publish("/xbot_monitoring/map", xbot_msgs.Map{DockX: 5}))
+	restoredRos.ServiceErrors["/mower_map_service/add_mowing_area"] = errors.New("map service down")
+	_, err = NewBackupProvider(restoredDB, restoredRos, NewSettingsHistory(restoredDB)).Restore(ctx, archive, "")
+	assert.EqualError(t, err, "failed to restore the map: map service down, [db mowerConfig map] were restored then rolled back")
+
+	value, err := restoredDB.Get("gui.firmware.config")
+	require.NoError(t, err)
+	assert.Equal(t, "current", string(value))
+	keys, err := restoredDB.KeysWithSuffix("uuid")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export OM_TOOL_WIDTH=0.2\n", string(content))
+	calls := restoredRos.Calls()
+	require.Len(t, calls, 4)
+	assert.Equal(t, "/mower_map_service/set_docking_point", calls[3].Service)
+	assert.Equal(t, 5.0, calls[3].Req.(mower_map.SetDockingPointSrvReq).DockingPose.Position.X)
+
+	// the error tells what is left when the rollback fails too
+	restoredRos.ServiceErrors["/mower_map_service/clear_map"] = errors.New("map service down")
+	_, err = NewBackupProvider(restoredDB, restoredRos, NewSettingsHistory(restoredDB)).Restore(ctx, archive, "")
+	assert.EqualError(t, err, "failed to restore the map: map service down, [db mowerConfig map] were restored and could not be rolled back: map: map service down")
+}
@@ -0,0 +1,463 @@
+package providers
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgproc"
+	"github.com/bluenviron/goroslib/v2/pkg/protocommon"
+	"golang.org/x/xerrors"
+)
+
+// ROS1 bag format v2.0, see http://wiki.ros.org/Bags/Format/2.0
+const (
+	bagMagic          = "#ROSBAG V2.0\n"
+	bagHeaderLength   = 4096
+	bagChunkThreshold = 768 * 1024
+
+	bagOpMessageData = 0x02
+	bagOpBagHeader   = 0x03
+	bagOpIndexData   = 0x04
+	bagOpChunk       = 0x05
+	bagOpChunkInfo   = 0x06
+	bagOpConnection  = 0x07
+)
+
+type bagField struct {
+	name  string
+	value []byte
+}
+
+type bagConnection struct {
+	id         uint32
+	topic      string
+	msgType    string
+	md5sum     string
+	definition string
+}
+
+type bagIndexEntry struct {
+	time   time.Time
+	offset uint32
+}
+
+type bagChunkInfo struct {
+	pos    uint64
+	start  time.Time
+	end    time.Time
+	counts map[uint32]uint32
+}
+
+type bagWriter struct {
+	file        *os.File
+	pos         int64
+	connections map[string]*bagConnection
+	chunk       bytes.Buffer
+	chunkStart  time.Time
+	chunkEnd    time.Time
+	chunkIndex  map[uint32][]bagIndexEntry
+	chunkInfos  []bagChunkInfo
+}
+
+func newBagWriter(path string) (*bagWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &bagWriter{
+		file:        file,
+		connections: make(map[string]*bagConnection),
+		chunkIndex:  make(map[uint32][]bagIndexEntry),
+	}
+	_, err = file.WriteString(bagMagic)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	err = b.writeBagHeader(0, 0, 0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	b.pos = int64(len(bagMagic) + bagHeaderLength)
+	return b, nil
+}
+
+// Size returns the number of bytes written so far, including the pending chunk
+func (b *bagWriter) Size() int64 {
+	return b.pos + int64(b.chunk.Len())
+}
+
+// Write serializes msg and appends it to the current chunk, msg must be a pointer to a goroslib message
+func (b *bagWriter) Write(topic string, t time.Time, msg any) error {
+	conn, ok := b.connections[topic]
+	if !ok {
+		msgElem := reflect.ValueOf(msg).Elem().Interface()
+		msgType, err := msgproc.Type(msgElem)
+		if err != nil {
+			return err
+		}
+		md5sum, err := msgproc.MD5(msgElem)
+		if err != nil {
+			return err
+		}
+		definition, err := msgproc.Definition(msgElem)
+		if err != nil {
+			return err
+		}
+		conn = &bagConnection{
+			id:         uint32(len(b.connections)),
+			topic:      topic,
+			msgType:    msgType,
+			md5sum:     md5sum,
+			definition: definition,
+		}
+		b.connections[topic] = conn
+		writeBagConnection(&b.chunk, conn)
+	}
+	var data bytes.Buffer
+	err := protocommon.MessageEncode(&data, msg)
+	if err != nil {
+		return xerrors.Errorf("failed to encode message of %s: %w", topic, err)
+	}
+	if len(b.chunkIndex) == 0 {
+		b.chunkStart = t
+		b.chunkEnd = t
+	}
+	if t.Before(b.chunkStart) {
+		b.chunkStart = t
+	}
+	if t.After(b.chunkEnd) {
+		b.chunkEnd = t
+	}
+	b.chunkIndex[conn.id] = append(b.chunkIndex[conn.id], bagIndexEntry{time: t, offset: uint32(b.chunk.Len())})
+	writeBagRecord(&b.chunk, []bagField{
+		{"op", []byte{bagOpMessageData}},
+		{"conn", bagUint32(conn.id)},
+		{"time", bagTime(t)},
+	}, data.Bytes())
+	if b.chunk.Len() > bagChunkThreshold {
+		return b.flushChunk()
+	}
+	return nil
+}
+
+// Close flushes the pending chunk, writes the index section and updates the bag header
+func (b *bagWriter) Close() error {
+	defer b.file.Close()
+	err := b.flushChunk()
+	if err != nil {
+		return err
+	}
+	indexPos := b.pos
+	var index bytes.Buffer
+	connections := b.sortedConnections()
+	for _, conn := range connections {
+		writeBagConnection(&index, conn)
+	}
+	for _, info := range b.chunkInfos {
+		var data bytes.Buffer
+		ids := make([]uint32, 0, len(info.counts))
+		for id := range info.counts {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			data.Write(bagUint32(id))
+			data.Write(bagUint32(info.counts[id]))
+		}
+		writeBagRecord(&index, []bagField{
+			{"op", []byte{bagOpChunkInfo}},
+			{"ver", bagUint32(1)},
+			{"chunk_pos", bagUint64(info.pos)},
+			{"start_time", bagTime(info.start)},
+			{"end_time", bagTime(info.end)},
+			{"count", bagUint32(uint32(len(info.counts)))},
+		}, data.Bytes())
+	}
+	_, err = b.file.Write(index.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = b.file.Seek(int64(len(bagMagic)), 0)
+	if err != nil {
+		return err
+	}
+	err = b.writeBagHeader(uint64(indexPos), uint32(len(connections)), uint32(len(b.chunkInfos)))
+	if err != nil {
+		return err
+	}
+	return b.file.Sync()
+}
+
+func (b *bagWriter) flushChunk() error {
+	if b.chunk.Len() == 0 {
+		return nil
+	}
+	var record bytes.Buffer
+	writeBagRecord(&record, []bagField{
+		{"op", []byte{bagOpChunk}},
+		{"compression", []byte("none")},
+		{"size", bagUint32(uint32(b.chunk.Len()))},
+	}, b.chunk.Bytes())
+	info := bagChunkInfo{
+		pos:    uint64(b.pos),
+		start:  b.chunkStart,
+		end:    b.chunkEnd,
+		counts: make(map[uint32]uint32),
+	}
+	for _, conn := range b.sortedConnections() {
+		entries, ok := b.chunkIndex[conn.id]
+		if !ok {
+			continue
+		}
+		var data bytes.Buffer
+		for _, entry := range entries {
+			data.Write(bagTime(entry.time))
+			data.Write(bagUint32(entry.offset))
+		}
+		writeBagRecord(&record, []bagField{
+			{"op", []byte{bagOpIndexData}},
+			{"ver", bagUint32(1)},
+			{"conn", bagUint32(conn.id)},
+			{"count", bagUint32(uint32(len(entries)))},
+		}, data.Bytes())
+		info.counts[conn.id] = uint32(len(entries))
+	}
+	n, err := b.file.Write(record.Bytes())
+	b.pos += int64(n)
+	if err != nil {
+		return err
+	}
+	b.chunkInfos = append(b.chunkInfos, info)
+	b.chunk.Reset()
+	b.chunkIndex = make(map[uint32][]bagIndexEntry)
+	return nil
+}
+
+func (b *bagWriter) writeBagHeader(indexPos uint64, connCount uint32, chunkCount uint32) error {
+	header := encodeBagFields([]bagField{
+		{"op", []byte{bagOpBagHeader}},
+		{"index_pos", bagUint64(indexPos)},
+		{"conn_count", bagUint32(connCount)},
+		{"chunk_count", bagUint32(chunkCount)},
+	})
+	// the bag header record is padded with spaces to bagHeaderLength so it can be rewritten in place on close
+	padding := bytes.Repeat([]byte(" "), bagHeaderLength-8-len(header))
+	var record bytes.Buffer
+	record.Write(bagUint32(uint32(len(header))))
+	record.Write(header)
+	record.Write(bagUint32(uint32(len(padding))))
+	record.Write(padding)
+	_, err := b.file.Write(record.Bytes())
+	return err
+}
+
+func (b *bagWriter) sortedConnections() []*bagConnection {
+	connections := make([]*bagConnection, 0, len(b.connections))
+	for _, conn := range b.connections {
+		connections = append(connections, conn)
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].id < connections[j].id })
+	return connections
+}
+
+func writeBagConnection(buf *bytes.Buffer, conn *bagConnection) {
+	writeBagRecord(buf, []bagField{
+		{"op", []byte{bagOpConnection}},
+		{"conn", bagUint32(conn.id)},
+		{"topic", []byte(conn.topic)},
+	}, encodeBagFields([]bagField{
+		{"topic", []byte(conn.topic)},
+		{"type", []byte(conn.msgType)},
+		{"md5sum", []byte(conn.md5sum)},
+		{"message_definition", []byte(conn.definition)},
+	}))
+}
+
+func writeBagRecord(buf *bytes.Buffer, fields []bagField, data []byte) {
+	header := encodeBagFields(fields)
+	buf.Write(bagUint32(uint32(len(header))))
+	buf.Write(header)
+	buf.Write(bagUint32(uint32(len(data))))
+	buf.Write(data)
+}
+
+func encodeBagFields(fields []bagField) []byte {
+	var buf bytes.Buffer
+	for _, field := range fields {
+		buf.Write(bagUint32(uint32(len(field.name) + 1 + len(field.value))))
+		buf.WriteString(field.name)
+		buf.WriteByte('=')
+		buf.Write(field.value)
+	}
+	return buf.Bytes()
+}
+
+func bagUint32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+func bagUint64(v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, v)
+}
+
+func bagTime(t time.Time) []byte {
+	return binary.LittleEndian.AppendUint32(bagUint32(uint32(t.Unix())), uint32(t.Nanosecond()))
+}
+
+type bagMessage struct {
+	conn *bagConnection
+	time time.Time
+	data []byte
+}
+
+// readBag reads all the connections and messages of a bag file, messages are sorted by time
+func readBag(path string) ([]*bagConnection, []bagMessage, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.HasPrefix(content, []byte(bagMagic)) {
+		return nil, nil, xerrors.Errorf("%s is not a ROS bag v2.0 file", path)
+	}
+	connections := make(map[uint32]*bagConnection)
+	var messages []bagMessage
+	err = readBagRecords(content[len(bagMagic):], connections, &messages)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result []*bagConnection
+	for _, conn := range connections {
+		result = append(result, conn)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].time.Before(messages[j].time) })
+	return result, messages, nil
+}
+
+func readBagRecords(content []byte, connections map[uint32]*bagConnection, messages *[]bagMessage) error {
+	for len(content) > 0 {
+		fields, data, rest, err := readBagRecord(content)
+		if err != nil {
+			return err
+		}
+		content = rest
+		if len(fields["op"]) != 1 {
+			return xerrors.New("bag record without op")
+		}
+		switch fields["op"][0] {
+		case bagOpChunk:
+			switch string(fields["compression"]) {
+			case "none":
+			case "bz2":
+				data, err = io.ReadAll(bzip2.NewReader(bytes.NewReader(data)))
+				if err != nil {
+					return xerrors.Errorf("failed to decompress chunk: %w", err)
+				}
+			default:
+				return xerrors.Errorf("unsupported chunk compression %s", fields["compression"])
+			}
+			err = readBagRecords(data, connections, messages)
+			if err != nil {
+				return err
+			}
+		case bagOpConnection:
+			id, err := bagConnID(fields)
+			if err != nil {
+				return err
+			}
+			if _, ok := connections[id]; ok {
+				continue
+			}
+			connHeader, err := decodeBagFields(data)
+			if err != nil {
+				return err
+			}
+			connections[id] = &bagConnection{
+				id:         id,
+				topic:      string(fields["topic"]),
+				msgType:    string(connHeader["type"]),
+				md5sum:     string(connHeader["md5sum"]),
+				definition: string(connHeader["message_definition"]),
+			}
+		case bagOpMessageData:
+			id, err := bagConnID(fields)
+			if err != nil {
+				return err
+			}
+			conn, ok := connections[id]
+			if !ok {
+				return xerrors.New("bag message without connection")
+			}
+			*messages = append(*messages, bagMessage{
+				conn: conn,
+				time: parseBagTime(fields["time"]),
+				data: data,
+			})
+		}
+	}
+	return nil
+}
+
+func readBagRecord(content []byte) (map[string][]byte, []byte, []byte, error) {
+	if len(content) < 4 {
+		return nil, nil, nil, io.ErrUnexpectedEOF
+	}
+	headerLen := int(binary.LittleEndian.Uint32(content))
+	if len(content) < 8+headerLen {
+		return nil, nil, nil, io.ErrUnexpectedEOF
+	}
+	fields, err := decodeBagFields(content[4 : 4+headerLen])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dataLen := int(binary.LittleEndian.Uint32(content[4+headerLen:]))
+	start := 8 + headerLen
+	if len(content) < start+dataLen {
+		return nil, nil, nil, io.ErrUnexpectedEOF
+	}
+	return fields, content[start : start+dataLen], content[start+dataLen:], nil
+}
+
+func decodeBagFields(content []byte) (map[string][]byte, error) {
+	fields := make(map[string][]byte)
+	for len(content) > 0 {
+		if len(content) < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		fieldLen := int(binary.LittleEndian.Uint32(content))
+		if len(content) < 4+fieldLen {
+			return nil, io.ErrUnexpectedEOF
+		}
+		field := content[4 : 4+fieldLen]
+		separator := bytes.IndexByte(field, '=')
+		if separator < 0 {
+			return nil, xerrors.New("invalid bag header field")
+		}
+		fields[string(field[:separator])] = field[separator+1:]
+		content = content[4+fieldLen:]
+	}
+	return fields, nil
+}
+
+// bagConnID returns the connection id of a connection or message data record
+func bagConnID(fields map[string][]byte) (uint32, error) {
+	if len(fields["conn"]) != 4 {
+		return 0, xerrors.New("bag record with an invalid conn field")
+	}
+	return binary.LittleEndian.Uint32(fields["conn"]), nil
+}
+
+func parseBagTime(value []byte) time.Time {
+	if len(value) != 8 {
+		return time.Time{}
+	}
+	return time.Unix(int64(binary.LittleEndian.Uint32(value)), int64(binary.LittleEndian.Uint32(value[4:])))
+}
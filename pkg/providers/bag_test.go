@@ -0,0 +1,142 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/protocommon"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBagRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.bag")
+	writer, err := newBagWriter(path)
+	assert.NoError(t, err)
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 3; i++ {
+		err = writer.Write("/mower_logic/current_state", start.Add(time.Duration(i)*time.Second), &mower_msgs.HighLevelStatus{
+			StateName:      "MOWING",
+			BatteryPercent: float32(90 - i),
+		})
+		assert.NoError(t, err)
+	}
+	err = writer.Write("/mower/status", start.Add(500*time.Millisecond), &mower_msgs.Status{VBattery: 28.5})
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	connections, messages, err := readBag(path)
+	assert.NoError(t, err)
+	assert.Len(t, connections, 2)
+	assert.Equal(t, "mower_msgs/HighLevelStatus", connections[0].msgType)
+	assert.Equal(t, "/mower/status", connections[1].topic)
+	assert.Len(t, messages, 4)
+	assert.Equal(t, "/mower/status", messages[1].conn.topic)
+	assert.Equal(t, start.Add(2*time.Second), messages[3].time)
+
+	var status mower_msgs.HighLevelStatus
+	assert.NoError(t, protocommon.MessageDecode(bytes.NewReader(messages[3].data), &status))
+	assert.Equal(t, "MOWING", status.StateName)
+	assert.Equal(t, float32(88), status.BatteryPercent)
+}
+
+// TestBagIndex reads the bag through its index like rosbag does: the bag header gives the position of the connection
+// and chunk info records, the chunk infos give the chunks and the index data following each chunk gives the offsets of
+// the messages in it
+func TestBagIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.bag")
+	writer, err := newBagWriter(path)
+	require.NoError(t, err)
+	start := time.Unix(1700000000, 0)
+	// large messages so that the bag has several chunks
+	for i := 0; i < 10; i++ {
+		err = writer.Write("/mower_logic/current_state", start.Add(time.Duration(i)*time.Second), &mower_msgs.HighLevelStatus{
+			StateName: strings.Repeat("x", 200*1024),
+		})
+		require.NoError(t, err)
+		err = writer.Write("/mower/status", start.Add(time.Duration(i)*time.Second), &mower_msgs.Status{VBattery: float32(i)})
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(content, []byte(bagMagic)))
+	header, _, rest, err := readBagRecord(content[len(bagMagic):])
+	require.NoError(t, err)
+	assert.Equal(t, []byte{bagOpBagHeader}, header["op"])
+	assert.Equal(t, len(bagMagic)+bagHeaderLength, len(content)-len(rest))
+	indexPos := binary.LittleEndian.Uint64(header["index_pos"])
+	connCount := binary.LittleEndian.Uint32(header["conn_count"])
+	chunkCount := binary.LittleEndian.Uint32(header["chunk_count"])
+	assert.Equal(t, uint32(2), connCount)
+	assert.Greater(t, chunkCount, uint32(1))
+
+	index := content[indexPos:]
+	topics := make(map[uint32]string)
+	for i := uint32(0); i < connCount; i++ {
+		var fields map[string][]byte
+		fields, _, index, err = readBagRecord(index)
+		require.NoError(t, err)
+		require.Equal(t, []byte{bagOpConnection}, fields["op"])
+		topics[binary.LittleEndian.Uint32(fields["conn"])] = string(fields["topic"])
+	}
+	assert.Equal(t, map[uint32]string{0: "/mower_logic/current_state", 1: "/mower/status"}, topics)
+
+	counts := make(map[string]int)
+	for i := uint32(0); i < chunkCount; i++ {
+		var info map[string][]byte
+		info, _, index, err = readBagRecord(index)
+		require.NoError(t, err)
+		require.Equal(t, []byte{bagOpChunkInfo}, info["op"])
+		chunkStart := parseBagTime(info["start_time"])
+		chunkEnd := parseBagTime(info["end_time"])
+
+		chunk, data, records, err := readBagRecord(content[binary.LittleEndian.Uint64(info["chunk_pos"]):])
+		require.NoError(t, err)
+		require.Equal(t, []byte{bagOpChunk}, chunk["op"])
+		assert.Equal(t, "none", string(chunk["compression"]))
+		assert.Equal(t, uint32(len(data)), binary.LittleEndian.Uint32(chunk["size"]))
+		for j := uint32(0); j < binary.LittleEndian.Uint32(info["count"]); j++ {
+			var indexData map[string][]byte
+			var entries []byte
+			indexData, entries, records, err = readBagRecord(records)
+			require.NoError(t, err)
+			require.Equal(t, []byte{bagOpIndexData}, indexData["op"])
+			conn := binary.LittleEndian.Uint32(indexData["conn"])
+			require.Len(t, entries, 12*int(binary.LittleEndian.Uint32(indexData["count"])))
+			for ; len(entries) > 0; entries = entries[12:] {
+				entryTime := parseBagTime(entries[:8])
+				assert.False(t, entryTime.Before(chunkStart) || entryTime.After(chunkEnd))
+				message, _, _, err := readBagRecord(data[binary.LittleEndian.Uint32(entries[8:]):])
+				require.NoError(t, err)
+				assert.Equal(t, []byte{bagOpMessageData}, message["op"])
+				assert.Equal(t, conn, binary.LittleEndian.Uint32(message["conn"]))
+				assert.Equal(t, entryTime, parseBagTime(message["time"]))
+				counts[topics[conn]]++
+			}
+		}
+	}
+	assert.Empty(t, index)
+	assert.Equal(t, map[string]int{"/mower_logic/current_state": 10, "/mower/status": 10}, counts)
+}
+
+func TestBagInvalidConn(t *testing.T) {
+	for _, op := range []byte{bagOpConnection, bagOpMessageData} {
+		for _, conn := range [][]byte{nil, {1, 0}} {
+			fields := []bagField{{name: "op", value: []byte{op}}}
+			if conn != nil {
+				fields = append(fields, bagField{name: "conn", value: conn})
+			}
+			var buf bytes.Buffer
+			writeBagRecord(&buf, fields, nil)
+			assert.EqualError(t, readBagRecords(buf.Bytes(), map[uint32]*bagConnection{}, &[]bagMessage{}), "bag record with an invalid conn field")
+		}
+	}
+}
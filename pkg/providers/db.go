@@ -3,8 +3,11 @@ package providers
 import (
 	"errors"
 	"git.mills.io/prologic/bitcask"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
 	"golang.org/x/xerrors"
 	"os"
+	"strconv"
+	"strings"
 )
 
 type DBProvider struct {
@@ -26,6 +29,39 @@ var EnvFallbacks = map[string]string{
 	"system.ros.nodeName":     "ROS_NODE_NAME",
 	"system.ros.nodeHost":     "ROS_NODE_HOST",
 	"system.homekit.pincode":  "HOMEKIT_PINCODE",
+
+	"system.ros.expectedNodes":          "ROS_EXPECTED_NODES",
+	"system.ros.simulation":             "ROS_SIMULATION",
+	"system.recorder.directory":         "RECORDER_DIRECTORY",
+	"system.recorder.topics":            "RECORDER_TOPICS",
+	"system.recorder.maxSizeMB":         "RECORDER_MAX_SIZE_MB",
+	"system.recorder.maxDuration":       "RECORDER_MAX_DURATION",
+	"system.recorder.emergency.enabled": "RECORDER_EMERGENCY_ENABLED",
+	"system.recorder.emergency.before":  "RECORDER_EMERGENCY_BEFORE",
+	"system.recorder.emergency.after":   "RECORDER_EMERGENCY_AFTER",
+	"system.teleop.timeout":             "TELEOP_TIMEOUT",
+	"system.teleop.maxLinear":           "TELEOP_MAX_LINEAR",
+	"system.teleop.maxAngular":          "TELEOP_MAX_ANGULAR",
+	"system.teleop.allowedStates":       "TELEOP_ALLOWED_STATES",
+	"system.ros.publish.topics":         "ROS_PUBLISH_TOPICS",
+	"system.ros.publish.rates":          "ROS_PUBLISH_RATES",
+	"system.ros.publish.defaultRate":    "ROS_PUBLISH_DEFAULT_RATE",
+	"system.monitoring.registerActions": "MONITORING_REGISTER_ACTIONS",
+	"system.notifications.retries":      "NOTIFICATIONS_RETRIES",
+	"system.notifications.retryDelay":   "NOTIFICATIONS_RETRY_DELAY",
+	"system.health.topics":              "HEALTH_TOPICS",
+	"system.health.maxMessageAge":       "HEALTH_MAX_MESSAGE_AGE",
+	"system.logs.level":                 "LOG_LEVEL",
+	"system.logs.bufferSize":            "LOG_BUFFER_SIZE",
+	"system.updates.healthTimeout":      "UPDATES_HEALTH_TIMEOUT",
+	"system.updates.stablePeriod":       "UPDATES_STABLE_PERIOD",
+	"system.containers.backend":         "CONTAINERS_BACKEND",
+	"system.systemd.units":              "SYSTEMD_UNITS",
+	"system.systemd.busAddress":         "DBUS_SYSTEM_BUS_ADDRESS",
+	"system.api.adminToken":             "ADMIN_TOKEN",
+	"system.containers.labels":          "CONTAINERS_LABELS",
+	"system.containers.names":           "CONTAINERS_NAMES",
+	"system.settings.historySize":       "SETTINGS_HISTORY_SIZE",
 }
 var Defaults = map[string]string{
 	"system.api.addr":         ":4006",
@@ -42,6 +78,35 @@ var Defaults = map[string]string{
 	"system.ros.masterUri":    "http://localhost:11311",
 	"system.ros.nodeName":     "openmower-gui",
 	"system.ros.nodeHost":     "localhost",
+
+	"system.ros.simulation":             "false",
+	"system.ros.expectedNodes":          "/mower_logic,/mower_map_service,/xbot_positioning,/xbot_monitoring,/xbot_driver_gps",
+	"system.recorder.directory":         "/app/bags",
+	"system.recorder.topics":            "/mower/status,/mower_logic/current_state,/xbot_driver_gps/xb_pose,/xbot_positioning/xb_pose,/imu/data_raw,/mower/wheel_ticks",
+	"system.recorder.maxSizeMB":         "100",
+	"system.recorder.maxDuration":       "600",
+	"system.recorder.emergency.enabled": "false",
+	"system.recorder.emergency.before":  "30",
+	"system.recorder.emergency.after":   "30",
+	"system.teleop.timeout":             "500",
+	"system.teleop.maxLinear":           "0.5",
+	"system.teleop.maxAngular":          "1.5",
+	"system.teleop.allowedStates":       "IDLE,AREA_RECORDING",
+	"system.ros.publish.defaultRate":    "10",
+	"system.monitoring.registerActions": "false",
+	"system.notifications.retries":      "3",
+	"system.notifications.retryDelay":   "1000",
+	"system.health.topics":              "/mower/status,/mower_logic/current_state,/xbot_positioning/xb_pose",
+	"system.health.maxMessageAge":       "10",
+	"system.logs.level":                 "info",
+	"system.logs.bufferSize":            "1000",
+	"system.updates.healthTimeout":      "120",
+	"system.updates.stablePeriod":       "10",
+	"system.containers.backend":         "auto",
+	"system.systemd.units":              "openmower=openmower.service,openmower-gui=gui.service",
+	"system.systemd.busAddress":         "unix:path=/run/dbus/system_bus_socket",
+	"system.containers.names":           "openmower*",
+	"system.settings.historySize":       "50",
 }
 
 func (d *DBProvider) Set(key string, value []byte) error {
@@ -93,6 +158,49 @@ func (d *DBProvider) GetWithEnvFallback(key string, env string, def string) stri
 	return string(value)
 }
 
+// GetInt returns the value for the given key parsed as an integer
+func GetInt(db types.IDBProvider, key string) (int, error) {
+	value, err := db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+// GetFloat returns the value for the given key parsed as a float
+func GetFloat(db types.IDBProvider, key string) (float64, error) {
+	value, err := db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(value), 64)
+}
+
+// GetList returns the value for the given key split on commas, empty elements are dropped
+func GetList(db types.IDBProvider, key string) ([]string, error) {
+	value, err := db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, element := range strings.Split(string(value), ",") {
+		element = strings.TrimSpace(element)
+		if element != "" {
+			list = append(list, element)
+		}
+	}
+	return list, nil
+}
+
+// GetOptionalList returns the value for the given key split on commas, an empty list is returned if the key is not set
+func GetOptionalList(db types.IDBProvider, key string) []string {
+	list, err := GetList(db, key)
+	if err != nil {
+		return nil
+	}
+	return list
+}
+
 func NewDBProvider() *DBProvider {
 	var err error
 	d := &DBProvider{}
@@ -1,25 +1,66 @@
 package providers
 
 import (
+	"bufio"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	docker "github.com/docker/docker/client"
-	"github.com/sirupsen/logrus"
+	"github.com/docker/docker/pkg/stdcopy"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/xerrors"
 	"io"
+	"net/http"
+	"strings"
+	"time"
 )
 
+var dockerLog = Logs.Logger("docker")
+
+// dockerClient are the methods of the Docker client used by the DockerProvider
+type dockerClient interface {
+	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerLogs(ctx context.Context, containerID string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+	ContainerStats(ctx context.Context, containerID string, stream bool) (types.ContainerStats, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error)
+	ContainerRename(ctx context.Context, containerID string, newContainerName string) error
+	ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error
+	ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error
+	ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error
+	ContainerRestart(ctx context.Context, containerID string, timeout *time.Duration) error
+	NetworkConnect(ctx context.Context, networkID string, containerID string, config *network.EndpointSettings) error
+	ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error)
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+	ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error)
+	ContainerExecResize(ctx context.Context, execID string, options types.ResizeOptions) error
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+}
+
 type DockerProvider struct {
-	client *docker.Client
+	client         dockerClient
+	dbProvider     types2.IDBProvider
+	registryClient *http.Client
 }
 
-func NewDockerProvider() types2.IDockerProvider {
+func NewDockerProvider(dbProvider types2.IDBProvider) types2.IDockerProvider {
+	d := &DockerProvider{
+		dbProvider:     dbProvider,
+		registryClient: &http.Client{Timeout: 30 * time.Second},
+	}
 	client, err := docker.NewClientWithOpts(docker.FromEnv)
 	if err != nil {
-		logrus.Error(err)
+		dockerLog.Error(err)
+	} else {
+		d.client = client
 	}
-	return &DockerProvider{client: client}
+	return d
 }
 
 func (i *DockerProvider) ContainerList(ctx context.Context) ([]types.Container, error) {
@@ -31,11 +72,130 @@ func (i *DockerProvider) ContainerList(ctx context.Context) ([]types.Container,
 	})
 }
 
-func (i *DockerProvider) ContainerLogs(ctx context.Context, containerID string) (io.ReadCloser, error) {
+func (i *DockerProvider) ContainerLogs(ctx context.Context, containerID string, query types2.ContainerLogsQuery, cb func(line types2.ContainerLogLine) error) error {
 	if i.client == nil {
-		return nil, errors.New("docker client is not initialized")
+		return errors.New("docker client is not initialized")
+	}
+	container, err := i.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	reader, err := i.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: query.Stream != "stderr",
+		ShowStderr: query.Stream != "stdout",
+		Since:      query.Since,
+		Until:      query.Until,
+		Tail:       query.Tail,
+		Follow:     query.Follow,
+		Timestamps: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// the output of a container with a TTY is not multiplexed, everything is written on stdout
+	if container.Config != nil && container.Config.Tty {
+		return readContainerLogs(reader, cb)
+	}
+	return demuxContainerLogs(reader, cb)
+}
+
+func (i *DockerProvider) ContainerStats(ctx context.Context, containerID string, stream bool, cb func(stats types2.ContainerStats) error) error {
+	if i.client == nil {
+		return errors.New("docker client is not initialized")
+	}
+	response, err := i.client.ContainerStats(ctx, containerID, stream)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	decoder := json.NewDecoder(response.Body)
+	for {
+		var stats types.StatsJSON
+		err = decoder.Decode(&stats)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// the state, health and restart count are not part of the stats
+		container, err := i.client.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return err
+		}
+		err = cb(containerStats(container, stats, time.Now()))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (i *DockerProvider) ContainerExec(ctx context.Context, containerID string, options types2.ExecOptions, stdin io.Reader, stdout io.Writer, resize <-chan types2.TerminalSize) (int, error) {
+	if i.client == nil {
+		return -1, errors.New("docker client is not initialized")
+	}
+	exec, err := i.client.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          options.Cmd,
+	})
+	if err != nil {
+		return -1, err
+	}
+	attach, err := i.client.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{Tty: true})
+	if err != nil {
+		return -1, err
+	}
+	defer attach.Close()
+	done := make(chan struct{})
+	defer close(done)
+	resizeExec := func(size types2.TerminalSize) {
+		if size.Rows == 0 || size.Cols == 0 {
+			return
+		}
+		err := i.client.ContainerExecResize(ctx, exec.ID, types.ResizeOptions{Height: size.Rows, Width: size.Cols})
+		if err != nil {
+			dockerLog.Error(xerrors.Errorf("failed to resize the exec session of %s: %w", containerID, err))
+		}
+	}
+	resizeExec(options.Size)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				// closing the connection ends the session and the copy of the output
+				attach.Close()
+				return
+			case size, ok := <-resize:
+				if !ok {
+					resize = nil
+					continue
+				}
+				resizeExec(size)
+			}
+		}
+	}()
+	go func() {
+		_, _ = io.Copy(attach.Conn, stdin)
+		_ = attach.CloseWrite()
+	}()
+	_, err = io.Copy(stdout, attach.Reader)
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
 	}
-	return i.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: true, Tail: "100"})
+	if err != nil {
+		return -1, err
+	}
+	inspect, err := i.client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return -1, err
+	}
+	return inspect.ExitCode, nil
 }
 
 func (i *DockerProvider) ContainerStart(ctx context.Context, containerID string) error {
@@ -53,5 +213,147 @@ func (i *DockerProvider) ContainerStop(ctx context.Context, containerID string)
 }
 
 func (i *DockerProvider) ContainerRestart(ctx context.Context, containerID string) error {
+	if i.client == nil {
+		return errors.New("docker client is not initialized")
+	}
 	return i.client.ContainerRestart(ctx, containerID, nil)
 }
+
+func readContainerLogs(reader io.Reader, cb func(line types2.ContainerLogLine) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		err := cb(parseContainerLogLine("stdout", strings.TrimSuffix(scanner.Text(), "\r")))
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// demuxContainerLogs reads a multiplexed log stream, each frame starts with an 8 bytes header holding the stream in
+// its first byte and the size of the payload in its last 4 bytes. A line may be split across frames.
+func demuxContainerLogs(reader io.Reader, cb func(line types2.ContainerLogLine) error) error {
+	header := make([]byte, 8)
+	partial := map[string]string{}
+	for {
+		_, err := io.ReadFull(reader, header)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		_, err = io.ReadFull(reader, payload)
+		if err != nil {
+			return err
+		}
+		var stream string
+		switch stdcopy.StdType(header[0]) {
+		case stdcopy.Stdout:
+			stream = "stdout"
+		case stdcopy.Stderr:
+			stream = "stderr"
+		case stdcopy.Systemerr:
+			return xerrors.Errorf("docker error: %s", payload)
+		default:
+			return xerrors.Errorf("unknown log stream %d", header[0])
+		}
+		lines := strings.Split(partial[stream]+string(payload), "\n")
+		partial[stream] = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			err = cb(parseContainerLogLine(stream, line))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for stream, line := range partial {
+		if line == "" {
+			continue
+		}
+		err := cb(parseContainerLogLine(stream, line))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseContainerLogLine splits the timestamp added by Docker from the message
+func parseContainerLogLine(stream string, line string) types2.ContainerLogLine {
+	logLine := types2.ContainerLogLine{Stream: stream, Message: line}
+	timestamp, message, found := strings.Cut(line, " ")
+	if !found {
+		return logLine
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err == nil {
+		logLine.Timestamp = parsed
+		logLine.Message = message
+	}
+	return logLine
+}
+
+// containerStats computes the usage like the docker stats command: the CPU usage is the container share of the system
+// CPU time since the previous sample and the page cache is not counted in the memory usage
+func containerStats(container types.ContainerJSON, stats types.StatsJSON, now time.Time) types2.ContainerStats {
+	result := types2.ContainerStats{
+		ID:          stats.ID,
+		Name:        strings.TrimPrefix(stats.Name, "/"),
+		Time:        stats.Read,
+		Health:      "none",
+		MemoryLimit: stats.MemoryStats.Limit,
+	}
+	if container.ContainerJSONBase != nil {
+		result.RestartCount = container.RestartCount
+	}
+	if container.ContainerJSONBase != nil && container.State != nil {
+		result.State = container.State.Status
+		if container.State.Health != nil {
+			result.Health = strings.ToLower(container.State.Health.Status)
+		}
+		startedAt, err := time.Parse(time.RFC3339Nano, container.State.StartedAt)
+		if err == nil && container.State.Running {
+			result.StartedAt = startedAt
+			result.UptimeSeconds = now.Sub(startedAt).Seconds()
+		}
+	}
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		result.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+	// cgroup v1 reports the page cache as cache and cgroup v2 as inactive_file
+	cache, ok := stats.MemoryStats.Stats["total_inactive_file"]
+	if !ok {
+		cache, ok = stats.MemoryStats.Stats["inactive_file"]
+	}
+	if !ok {
+		cache = stats.MemoryStats.Stats["cache"]
+	}
+	if cache < stats.MemoryStats.Usage {
+		result.MemoryUsage = stats.MemoryStats.Usage - cache
+	}
+	if result.MemoryLimit > 0 {
+		result.MemoryPercent = float64(result.MemoryUsage) / float64(result.MemoryLimit) * 100
+	}
+	for _, network := range stats.Networks {
+		result.NetworkRx += network.RxBytes
+		result.NetworkTx += network.TxBytes
+	}
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			result.BlockRead += entry.Value
+		case "write":
+			result.BlockWrite += entry.Value
+		}
+	}
+	return result
+}
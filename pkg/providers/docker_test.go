@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logFrame(stream stdcopy.StdType, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = byte(stream)
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestDemuxContainerLogs(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(logFrame(stdcopy.Stdout, "2024-05-01T12:00:00.5Z [ INFO] started\n"))
+	stream.Write(logFrame(stdcopy.Stderr, "2024-05-01T12:00:01Z [ERROR] failed "))
+	stream.Write(logFrame(stdcopy.Stdout, "2024-05-01T12:00:02Z running\n"))
+	stream.Write(logFrame(stdcopy.Stderr, "to connect\n2024-05-01T12:00:03Z no newline"))
+
+	var lines []types.ContainerLogLine
+	err := demuxContainerLogs(&stream, func(line types.ContainerLogLine) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.ContainerLogLine{
+		{Stream: "stdout", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 500000000, time.UTC), Message: "[ INFO] started"},
+		{Stream: "stdout", Timestamp: time.Date(2024, 5, 1, 12, 0, 2, 0, time.UTC), Message: "running"},
+		{Stream: "stderr", Timestamp: time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC), Message: "[ERROR] failed to connect"},
+		{Stream: "stderr", Timestamp: time.Date(2024, 5, 1, 12, 0, 3, 0, time.UTC), Message: "no newline"},
+	}, lines)
+
+	err = demuxContainerLogs(bytes.NewReader(logFrame(stdcopy.Systemerr, "no such container")), func(line types.ContainerLogLine) error {
+		return nil
+	})
+	assert.EqualError(t, err, "docker error: no such container")
+}
+
+func TestReadContainerLogs(t *testing.T) {
+	var lines []types.ContainerLogLine
+	err := readContainerLogs(strings.NewReader("2024-05-01T12:00:00Z tty line\r\nplain line\r\n"), func(line types.ContainerLogLine) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.ContainerLogLine{
+		{Stream: "stdout", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Message: "tty line"},
+		{Stream: "stdout", Message: "plain line"},
+	}, lines)
+}
+
+func TestContainerStats(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	container := dockertypes.ContainerJSON{ContainerJSONBase: &dockertypes.ContainerJSONBase{
+		RestartCount: 2,
+		State: &dockertypes.ContainerState{
+			Status:    "running",
+			Running:   true,
+			StartedAt: "2024-05-01T11:00:00.000000000Z",
+			Health:    &dockertypes.Health{Status: "healthy"},
+		},
+	}}
+	var stats dockertypes.StatsJSON
+	stats.ID = "om"
+	stats.Name = "/openmower"
+	stats.Read = now
+	stats.CPUStats.CPUUsage.TotalUsage = 3_000_000
+	stats.CPUStats.SystemUsage = 10_000_000
+	stats.CPUStats.OnlineCPUs = 4
+	stats.PreCPUStats.CPUUsage.TotalUsage = 1_000_000
+	stats.PreCPUStats.SystemUsage = 6_000_000
+	stats.MemoryStats.Usage = 300
+	stats.MemoryStats.Limit = 1000
+	stats.MemoryStats.Stats = map[string]uint64{"inactive_file": 100}
+	stats.Networks = map[string]dockertypes.NetworkStats{"eth0": {RxBytes: 10, TxBytes: 20}, "eth1": {RxBytes: 1, TxBytes: 2}}
+	stats.BlkioStats.IoServiceBytesRecursive = []dockertypes.BlkioStatEntry{{Op: "Read", Value: 5}, {Op: "write", Value: 7}, {Op: "Total", Value: 12}}
+
+	assert.Equal(t, types.ContainerStats{
+		ID: "om", Name: "openmower", Time: now, State: "running", Health: "healthy", RestartCount: 2,
+		StartedAt: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), UptimeSeconds: 3600,
+		CPUPercent: 200, MemoryUsage: 200, MemoryLimit: 1000, MemoryPercent: 20,
+		NetworkRx: 11, NetworkTx: 22, BlockRead: 5, BlockWrite: 7,
+	}, containerStats(container, stats, now))
+}
@@ -0,0 +1,221 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"golang.org/x/xerrors"
+)
+
+// containerPollInterval is the interval between two checks of the state of a recreated container
+var containerPollInterval = time.Second
+
+// previousContainerSuffix is appended to the name of a container while it is replaced by a new one
+const previousContainerSuffix = "-previous"
+
+func (i *DockerProvider) CheckImageUpdate(ctx context.Context, containerID string) (types2.ImageUpdateStatus, error) {
+	if i.client == nil {
+		return types2.ImageUpdateStatus{}, errors.New("docker client is not initialized")
+	}
+	current, err := i.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return types2.ImageUpdateStatus{}, err
+	}
+	status := types2.ImageUpdateStatus{ContainerID: current.ID, Image: current.Config.Image}
+	image, _, err := i.client.ImageInspectWithRaw(ctx, current.Image)
+	if err != nil {
+		return status, err
+	}
+	status.LocalDigest = repoDigest(status.Image, image.RepoDigests)
+	status.RemoteDigest, err = registryDigest(ctx, i.registryClient, status.Image)
+	if err != nil {
+		return status, xerrors.Errorf("failed to get the digest of %s: %w", status.Image, err)
+	}
+	status.UpdateAvailable = status.RemoteDigest != status.LocalDigest
+	return status, nil
+}
+
+// UpdateContainer pulls the image, then the container is stopped and renamed so that a new container can be created
+// with its name and configuration. The previous container is removed once the new one is healthy, or is running for
+// system.updates.stablePeriod seconds when it has no health check. Otherwise the new container is removed and the
+// previous one is restored.
+func (i *DockerProvider) UpdateContainer(ctx context.Context, containerID string, progress func(event types2.ImageUpdateEvent)) (types2.ImageUpdateResult, error) {
+	if i.client == nil {
+		return types2.ImageUpdateResult{}, errors.New("docker client is not initialized")
+	}
+	previous, err := i.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return types2.ImageUpdateResult{}, err
+	}
+	result := types2.ImageUpdateResult{ContainerID: previous.ID, Image: previous.Config.Image, PreviousImageID: previous.Image}
+	progress(types2.ImageUpdateEvent{Step: "pull", Status: "Pulling " + result.Image})
+	err = i.pullImage(ctx, result.Image, progress)
+	if err != nil {
+		return result, xerrors.Errorf("failed to pull %s: %w", result.Image, err)
+	}
+	image, _, err := i.client.ImageInspectWithRaw(ctx, result.Image)
+	if err != nil {
+		return result, err
+	}
+	result.ImageID = image.ID
+	if image.ID == previous.Image {
+		progress(types2.ImageUpdateEvent{Step: "done", Status: "The container is up to date"})
+		return result, nil
+	}
+
+	name := strings.TrimPrefix(previous.Name, "/")
+	progress(types2.ImageUpdateEvent{Step: "recreate", Status: "Stopping " + name})
+	err = i.client.ContainerStop(ctx, previous.ID, nil)
+	if err != nil {
+		return result, err
+	}
+	err = i.client.ContainerRename(ctx, previous.ID, name+previousContainerSuffix)
+	if err != nil {
+		return result, i.restart(ctx, previous.ID, err)
+	}
+	progress(types2.ImageUpdateEvent{Step: "recreate", Status: "Creating " + name})
+	created, err := i.recreate(ctx, previous, name)
+	if err == nil {
+		result.ContainerID = created
+		progress(types2.ImageUpdateEvent{Step: "health", Status: "Waiting for " + name + " to become healthy"})
+		err = i.waitHealthy(ctx, created)
+	}
+	if err != nil {
+		progress(types2.ImageUpdateEvent{Step: "rollback", Status: "Restoring the previous container: " + err.Error()})
+		result.ContainerID = previous.ID
+		result.RolledBack = true
+		return result, i.rollback(ctx, previous.ID, created, name, err)
+	}
+	err = i.client.ContainerRemove(ctx, previous.ID, types.ContainerRemoveOptions{})
+	if err != nil {
+		dockerLog.Error(xerrors.Errorf("failed to remove the previous container of %s: %w", name, err))
+	}
+	result.Updated = true
+	progress(types2.ImageUpdateEvent{Step: "done", Status: name + " is updated"})
+	return result, nil
+}
+
+func (i *DockerProvider) pullImage(ctx context.Context, image string, progress func(event types2.ImageUpdateEvent)) error {
+	reader, err := i.client.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	decoder := json.NewDecoder(reader)
+	for {
+		var message jsonmessage.JSONMessage
+		err = decoder.Decode(&message)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if message.Error != nil {
+			return message.Error
+		}
+		event := types2.ImageUpdateEvent{Step: "pull", Status: message.Status, Layer: message.ID}
+		if message.Progress != nil {
+			event.Current = message.Progress.Current
+			event.Total = message.Progress.Total
+		}
+		progress(event)
+	}
+}
+
+// recreate creates and starts a container with the configuration of previous, it returns the ID of the new container
+// even if it could not be started
+func (i *DockerProvider) recreate(ctx context.Context, previous types.ContainerJSON, name string) (string, error) {
+	// only one network can be given on creation, the others are connected afterwards
+	var networking *network.NetworkingConfig
+	var others = map[string]*network.EndpointSettings{}
+	if !previous.HostConfig.NetworkMode.IsHost() && !previous.HostConfig.NetworkMode.IsNone() && previous.NetworkSettings != nil {
+		for networkName, endpoint := range previous.NetworkSettings.Networks {
+			settings := &network.EndpointSettings{IPAMConfig: endpoint.IPAMConfig, Links: endpoint.Links, Aliases: endpoint.Aliases}
+			if networking == nil && (networkName == string(previous.HostConfig.NetworkMode) || len(previous.NetworkSettings.Networks) == 1) {
+				networking = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{networkName: settings}}
+				continue
+			}
+			others[networkName] = settings
+		}
+	}
+	created, err := i.client.ContainerCreate(ctx, previous.Config, previous.HostConfig, networking, nil, name)
+	if err != nil {
+		return "", err
+	}
+	for networkName, settings := range others {
+		err = i.client.NetworkConnect(ctx, networkName, created.ID, settings)
+		if err != nil {
+			return created.ID, err
+		}
+	}
+	return created.ID, i.client.ContainerStart(ctx, created.ID, types.ContainerStartOptions{})
+}
+
+func (i *DockerProvider) waitHealthy(ctx context.Context, containerID string) error {
+	timeout, err := GetFloat(i.dbProvider, "system.updates.healthTimeout")
+	if err != nil {
+		return err
+	}
+	stablePeriod, err := GetFloat(i.dbProvider, "system.updates.stablePeriod")
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	for {
+		current, err := i.client.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return err
+		}
+		state := current.State
+		switch {
+		case !state.Running:
+			return xerrors.Errorf("the container exited with code %d", state.ExitCode)
+		case state.Health != nil && state.Health.Status == types.Healthy:
+			return nil
+		case state.Health != nil && state.Health.Status == types.Unhealthy:
+			return errors.New("the container is unhealthy")
+		case state.Health == nil && time.Since(start).Seconds() >= stablePeriod:
+			return nil
+		case time.Since(start).Seconds() >= timeout:
+			return errors.New("the container did not become healthy in time")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(containerPollInterval):
+		}
+	}
+}
+
+// rollback removes the new container and restores the previous one, cause is the reason of the rollback
+func (i *DockerProvider) rollback(ctx context.Context, previousID string, createdID string, name string, cause error) error {
+	if createdID != "" {
+		err := i.client.ContainerRemove(ctx, createdID, types.ContainerRemoveOptions{Force: true})
+		if err != nil {
+			return xerrors.Errorf("%s, failed to remove the new container: %w", cause, err)
+		}
+	}
+	err := i.client.ContainerRename(ctx, previousID, name)
+	if err != nil {
+		return xerrors.Errorf("%s, failed to rename the previous container: %w", cause, err)
+	}
+	return i.restart(ctx, previousID, xerrors.Errorf("rolled back: %w", cause))
+}
+
+// restart starts the previous container after a failed update, cause is the reason of the failure
+func (i *DockerProvider) restart(ctx context.Context, previousID string, cause error) error {
+	err := i.client.ContainerStart(ctx, previousID, types.ContainerStartOptions{})
+	if err != nil {
+		return xerrors.Errorf("%s, failed to restart the previous container: %w", cause, err)
+	}
+	return cause
+}
@@ -0,0 +1,234 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	previousDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	latestDigest   = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// newTestRegistry is a registry stand-in serving the manifest of openmower/ros:stable behind a bearer token
+func newTestRegistry(t *testing.T, digest string) string {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token" && r.URL.Query().Get("scope") == "repository:openmower/ros:pull":
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/openmower/ros/manifests/stable":
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:openmower/ros:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://") + "/openmower/ros:stable"
+}
+
+// fakeDockerClient keeps containers and images in memory, the containers created by an update get newHealth
+type fakeDockerClient struct {
+	dockerClient
+	mtx        sync.Mutex
+	containers map[string]*types.ContainerJSON
+	images     map[string]types.ImageInspect
+	pulled     types.ImageInspect
+	newHealth  string
+	calls      []string
+}
+
+func newFakeDockerClient(image string, newHealth string) *fakeDockerClient {
+	previousImage := types.ImageInspect{ID: "sha256:previous", RepoDigests: []string{strings.TrimSuffix(image, ":stable") + "@" + previousDigest}}
+	return &fakeDockerClient{
+		containers: map[string]*types.ContainerJSON{"om": {
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:         "om",
+				Name:       "/openmower",
+				Image:      previousImage.ID,
+				State:      &types.ContainerState{Status: "running", Running: true},
+				HostConfig: &container.HostConfig{NetworkMode: "host"},
+			},
+			Config: &container.Config{Image: image},
+		}},
+		images:    map[string]types.ImageInspect{previousImage.ID: previousImage, image: previousImage},
+		pulled:    types.ImageInspect{ID: "sha256:latest"},
+		newHealth: newHealth,
+	}
+}
+
+func (f *fakeDockerClient) record(call string) {
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeDockerClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	c, ok := f.containers[containerID]
+	if !ok {
+		return types.ContainerJSON{}, io.ErrUnexpectedEOF
+	}
+	base := *c.ContainerJSONBase
+	state := *base.State
+	base.State = &state
+	return types.ContainerJSON{ContainerJSONBase: &base, Config: c.Config}, nil
+}
+
+func (f *fakeDockerClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.images[imageID], nil, nil
+}
+
+func (f *fakeDockerClient) ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.record("pull " + ref)
+	f.images[ref] = f.pulled
+	return io.NopCloser(strings.NewReader(`{"status":"Pulling from openmower/ros","id":"stable"}
+{"status":"Downloading","id":"a1b2","progressDetail":{"current":512,"total":1024}}
+{"status":"Status: Downloaded newer image"}`)), nil
+}
+
+func (f *fakeDockerClient) ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.record("stop " + containerID)
+	f.containers[containerID].State.Running = false
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerRename(ctx context.Context, containerID string, newContainerName string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.record("rename " + containerID + " " + newContainerName)
+	f.containers[containerID].Name = "/" + newContainerName
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.record("create " + containerName + " " + config.Image)
+	f.containers["new"] = &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "new", Name: "/" + containerName, Image: f.images[config.Image].ID, State: &types.ContainerState{}, HostConfig: hostConfig},
+		Config:            config,
+	}
+	return container.ContainerCreateCreatedBody{ID: "new"}, nil
+}
+
+func (f *fakeDockerClient) ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.record("start " + containerID)
+	state := f.containers[containerID].State
+	state.Running = true
+	if containerID == "new" {
+		state.Health = &types.Health{Status: f.newHealth}
+	}
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.record("remove " + containerID)
+	delete(f.containers, containerID)
+	return nil
+}
+
+func newTestDockerProvider(client *fakeDockerClient) *DockerProvider {
+	containerPollInterval = time.Millisecond
+	return &DockerProvider{
+		client: client,
+		dbProvider: fakes.NewDBProvider(map[string]string{
+			"system.updates.healthTimeout": "1",
+			"system.updates.stablePeriod":  "0.01",
+		}),
+		registryClient: http.DefaultClient,
+	}
+}
+
+func TestCheckImageUpdate(t *testing.T) {
+	image := newTestRegistry(t, latestDigest)
+	provider := newTestDockerProvider(newFakeDockerClient(image, types.Healthy))
+
+	status, err := provider.CheckImageUpdate(context.Background(), "om")
+	require.NoError(t, err)
+	assert.Equal(t, types2.ImageUpdateStatus{
+		ContainerID: "om", Image: image, LocalDigest: previousDigest, RemoteDigest: latestDigest, UpdateAvailable: true,
+	}, status)
+
+	provider = newTestDockerProvider(newFakeDockerClient(newTestRegistry(t, previousDigest), types.Healthy))
+	status, err = provider.CheckImageUpdate(context.Background(), "om")
+	require.NoError(t, err)
+	assert.False(t, status.UpdateAvailable)
+}
+
+func TestUpdateContainer(t *testing.T) {
+	client := newFakeDockerClient("openmower/ros:stable", types.Healthy)
+	provider := newTestDockerProvider(client)
+
+	var events []types2.ImageUpdateEvent
+	result, err := provider.UpdateContainer(context.Background(), "om", func(event types2.ImageUpdateEvent) {
+		events = append(events, event)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types2.ImageUpdateResult{
+		ContainerID: "new", Image: "openmower/ros:stable", PreviousImageID: "sha256:previous", ImageID: "sha256:latest", Updated: true,
+	}, result)
+	assert.Equal(t, []string{
+		"pull openmower/ros:stable", "stop om", "rename om openmower-previous", "create openmower openmower/ros:stable", "start new", "remove om",
+	}, client.calls)
+	assert.Contains(t, events, types2.ImageUpdateEvent{Step: "pull", Status: "Downloading", Layer: "a1b2", Current: 512, Total: 1024})
+	assert.Equal(t, types2.ImageUpdateEvent{Step: "done", Status: "openmower is updated"}, events[len(events)-1])
+
+	client.calls = nil
+	result, err = provider.UpdateContainer(context.Background(), "new", func(event types2.ImageUpdateEvent) {})
+	require.NoError(t, err)
+	assert.False(t, result.Updated)
+	assert.Equal(t, []string{"pull openmower/ros:stable"}, client.calls)
+}
+
+func TestUpdateContainerRollback(t *testing.T) {
+	client := newFakeDockerClient("openmower/ros:stable", types.Unhealthy)
+	provider := newTestDockerProvider(client)
+
+	var steps []string
+	result, err := provider.UpdateContainer(context.Background(), "om", func(event types2.ImageUpdateEvent) {
+		steps = append(steps, event.Step)
+	})
+	assert.EqualError(t, err, "rolled back: the container is unhealthy")
+	assert.True(t, result.RolledBack)
+	assert.Equal(t, "om", result.ContainerID)
+	assert.Equal(t, []string{
+		"pull openmower/ros:stable", "stop om", "rename om openmower-previous", "create openmower openmower/ros:stable", "start new",
+		"remove new", "rename om openmower", "start om",
+	}, client.calls)
+	assert.Equal(t, "rollback", steps[len(steps)-1])
+	current, err := client.ContainerInspect(context.Background(), "om")
+	require.NoError(t, err)
+	assert.True(t, current.State.Running)
+	assert.Equal(t, "/openmower", current.Name)
+}
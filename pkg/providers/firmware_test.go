@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
 	"github.com/cedbossneo/openmower-gui/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -8,7 +9,7 @@ import (
 )
 
 func TestBuildBoard(t *testing.T) {
-	dbProvider := NewDBProvider()
+	dbProvider := fakes.NewDBProvider(Defaults)
 	firmwareProvider := NewFirmwareProvider(dbProvider)
 	config := types.FirmwareConfig{
 		BoardType:                      "BOARD_YARDFORCE500",
@@ -27,9 +28,12 @@ func TestBuildBoard(t *testing.T) {
 		ExternalImuAngular:             true,
 		MasterJ18:                      true,
 		MaxMps:                         0.6,
+		TickPerM:                       300,
+		WheelBase:                      0.325,
 	}
-	res, err := firmwareProvider.buildBoardHeader("./asserts/board.h.template", config)
+	res, err := firmwareProvider.buildBoardHeader("../../asserts/board.h.template", config)
+	assert.NoError(t, err)
+	file, err := os.ReadFile("../../asserts/board.h")
 	assert.NoError(t, err)
-	file, err := os.ReadFile("./asserts/board.h")
 	assert.Equal(t, string(file), string(res))
 }
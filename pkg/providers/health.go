@@ -0,0 +1,203 @@
+package providers
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+var healthLog = Logs.Logger("health")
+
+const healthCheckTimeout = 2 * time.Second
+
+// homekitAddr is the address of the HomeKit server
+const homekitAddr = ":8000"
+
+type healthCheckFunc func(ctx context.Context) (status string, message string, details map[string]any)
+
+type healthCheckDefinition struct {
+	name     string
+	critical bool
+	check    healthCheckFunc
+}
+
+// HealthProvider checks the DB, Docker, the ROS master, the age of the last message of the core topics
+// (system.health.topics, stale after system.health.maxMessageAge seconds) and the MQTT and HomeKit servers when they
+// are enabled. Only the DB, Docker and ROS master checks are critical. The topics are degraded while a recording is
+// replayed as the live messages are dropped.
+type HealthProvider struct {
+	rosProvider    types.IRosProvider
+	dbProvider     types.IDBProvider
+	dockerProvider types.IDockerProvider
+	mtx            sync.Mutex
+	lastMessage    map[string]time.Time
+	started        time.Time
+}
+
+func NewHealthProvider(rosProvider types.IRosProvider, dbProvider types.IDBProvider, dockerProvider types.IDockerProvider) *HealthProvider {
+	h := &HealthProvider{
+		rosProvider:    rosProvider,
+		dbProvider:     dbProvider,
+		dockerProvider: dockerProvider,
+		lastMessage:    make(map[string]time.Time),
+		started:        time.Now(),
+	}
+	for _, topic := range GetOptionalList(dbProvider, "system.health.topics") {
+		topic := topic
+		err := rosProvider.Subscribe(topic, "health", func(msg []byte) {
+			if h.rosProvider.Replaying() {
+				return
+			}
+			h.mtx.Lock()
+			defer h.mtx.Unlock()
+			h.lastMessage[topic] = time.Now()
+		})
+		if err != nil {
+			healthLog.Error(xerrors.Errorf("failed to subscribe to %s: %w", topic, err))
+		}
+	}
+	return h
+}
+
+func (h *HealthProvider) Live(ctx context.Context) types.HealthReport {
+	return h.run(ctx, []healthCheckDefinition{{name: "db", critical: true, check: h.checkDB}})
+}
+
+func (h *HealthProvider) Ready(ctx context.Context) types.HealthReport {
+	checks := []healthCheckDefinition{
+		{name: "db", critical: true, check: h.checkDB},
+		{name: "docker", critical: true, check: h.checkDocker},
+		{name: "rosMaster", critical: true, check: h.checkRosMaster},
+	}
+	for _, topic := range GetOptionalList(h.dbProvider, "system.health.topics") {
+		topic := topic
+		checks = append(checks, healthCheckDefinition{name: "topic:" + topic, check: func(ctx context.Context) (string, string, map[string]any) {
+			return h.checkTopic(topic)
+		}})
+	}
+	checks = append(checks,
+		healthCheckDefinition{name: "mqtt", check: h.checkServer("system.mqtt.enabled", "system.mqtt.host")},
+		healthCheckDefinition{name: "homekit", check: h.checkServer("system.homekit.enabled", "")},
+	)
+	return h.run(ctx, checks)
+}
+
+// run executes the checks concurrently, each check is given healthCheckTimeout to complete
+func (h *HealthProvider) run(ctx context.Context, definitions []healthCheckDefinition) types.HealthReport {
+	report := types.HealthReport{Status: types.HealthOK, Checks: make([]types.HealthCheck, len(definitions))}
+	var wg sync.WaitGroup
+	for i, definition := range definitions {
+		wg.Add(1)
+		go func(i int, definition healthCheckDefinition) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			result := make(chan types.HealthCheck, 1)
+			go func() {
+				status, message, details := definition.check(checkCtx)
+				result <- types.HealthCheck{Status: status, Message: message, Details: details}
+			}()
+			var check types.HealthCheck
+			select {
+			case check = <-result:
+			case <-checkCtx.Done():
+				check = types.HealthCheck{Status: types.HealthFailed, Message: "timed out after " + healthCheckTimeout.String()}
+			}
+			check.Name = definition.name
+			check.Critical = definition.critical
+			check.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+			report.Checks[i] = check
+		}(i, definition)
+	}
+	wg.Wait()
+	for _, check := range report.Checks {
+		switch {
+		case check.Status == types.HealthFailed && check.Critical:
+			report.Status = types.HealthFailed
+		case (check.Status == types.HealthFailed || check.Status == types.HealthDegraded) && report.Status == types.HealthOK:
+			report.Status = types.HealthDegraded
+		}
+	}
+	return report
+}
+
+func (h *HealthProvider) checkDB(ctx context.Context) (string, string, map[string]any) {
+	_, err := h.dbProvider.KeysWithSuffix("system.health")
+	if err != nil {
+		return types.HealthFailed, err.Error(), nil
+	}
+	return types.HealthOK, "", nil
+}
+
+func (h *HealthProvider) checkDocker(ctx context.Context) (string, string, map[string]any) {
+	containers, err := h.dockerProvider.ContainerList(ctx)
+	if err != nil {
+		return types.HealthFailed, err.Error(), nil
+	}
+	return types.HealthOK, "", map[string]any{"containers": len(containers)}
+}
+
+func (h *HealthProvider) checkRosMaster(ctx context.Context) (string, string, map[string]any) {
+	nodes, err := h.rosProvider.Nodes()
+	if err != nil {
+		return types.HealthFailed, err.Error(), nil
+	}
+	return types.HealthOK, "", map[string]any{"nodes": len(nodes)}
+}
+
+func (h *HealthProvider) checkTopic(topic string) (string, string, map[string]any) {
+	maxAge, err := GetFloat(h.dbProvider, "system.health.maxMessageAge")
+	if err != nil {
+		return types.HealthFailed, err.Error(), nil
+	}
+	if h.rosProvider.Replaying() {
+		return types.HealthDegraded, "a recording is replayed, the live messages are dropped", nil
+	}
+	h.mtx.Lock()
+	last, ok := h.lastMessage[topic]
+	h.mtx.Unlock()
+	if !ok {
+		return types.HealthDegraded, "no message received since " + h.started.Format(time.RFC3339), nil
+	}
+	age := time.Since(last).Seconds()
+	details := map[string]any{"ageSeconds": age, "maxAgeSeconds": maxAge}
+	if age > maxAge {
+		return types.HealthDegraded, "last message is too old", details
+	}
+	return types.HealthOK, "", details
+}
+
+// checkServer connects to a server of the GUI when it is enabled, the address is read from addrKey or is the HomeKit
+// address when addrKey is empty
+func (h *HealthProvider) checkServer(enabledKey string, addrKey string) healthCheckFunc {
+	return func(ctx context.Context) (string, string, map[string]any) {
+		enabled, err := h.dbProvider.Get(enabledKey)
+		if err != nil || string(enabled) != "true" {
+			return types.HealthDisabled, "", nil
+		}
+		addr := homekitAddr
+		if addrKey != "" {
+			value, err := h.dbProvider.Get(addrKey)
+			if err != nil {
+				return types.HealthFailed, err.Error(), nil
+			}
+			addr = string(value)
+		}
+		if strings.HasPrefix(addr, ":") {
+			addr = "localhost" + addr
+		}
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return types.HealthFailed, err.Error(), nil
+		}
+		_ = conn.Close()
+		return types.HealthOK, "", map[string]any{"addr": addr}
+	}
+}
@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthStaleTopicAndServers(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	ros := fakes.NewRosProvider()
+	health := NewHealthProvider(ros, fakes.NewDBProvider(map[string]string{
+		"system.health.topics":        "/mower/status",
+		"system.health.maxMessageAge": "0.05",
+		"system.mqtt.enabled":         "true",
+		"system.mqtt.host":            listener.Addr().String(),
+		"system.homekit.enabled":      "false",
+	}), fakes.NewDockerProvider())
+
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{}))
+	report := health.Ready(context.Background())
+	assert.Equal(t, types.HealthOK, report.Status)
+	assert.Equal(t, []string{types.HealthOK, types.HealthOK, types.HealthOK, types.HealthOK, types.HealthOK, types.HealthDisabled},
+		[]string{report.Checks[0].Status, report.Checks[1].Status, report.Checks[2].Status, report.Checks[3].Status, report.Checks[4].Status, report.Checks[5].Status})
+
+	time.Sleep(60 * time.Millisecond)
+	listener.Close()
+	report = health.Ready(context.Background())
+	assert.Equal(t, types.HealthDegraded, report.Status)
+	assert.Equal(t, "topic:/mower/status", report.Checks[3].Name)
+	assert.Equal(t, "last message is too old", report.Checks[3].Message)
+	assert.Equal(t, "mqtt", report.Checks[4].Name)
+	assert.Equal(t, types.HealthFailed, report.Checks[4].Status)
+	assert.False(t, report.Checks[4].Critical)
+}
+
+func TestHealthReplay(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	health := NewHealthProvider(ros, fakes.NewDBProvider(map[string]string{
+		"system.health.topics":        "/mower/status",
+		"system.health.maxMessageAge": "10",
+		"system.mqtt.enabled":         "false",
+		"system.homekit.enabled":      "false",
+	}), fakes.NewDockerProvider())
+
+	// the recorded messages don't refresh the topics, which are degraded during the replay
+	ros.Replay(true)
+	ros.Inject("/mower/status", []byte(`{}`))
+	report := health.Ready(context.Background())
+	assert.Equal(t, types.HealthDegraded, report.Status)
+	assert.Equal(t, "a recording is replayed, the live messages are dropped", report.Checks[3].Message)
+
+	ros.Replay(false)
+	report = health.Ready(context.Background())
+	assert.Equal(t, "no message received since "+health.started.Format(time.RFC3339), report.Checks[3].Message)
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{}))
+	assert.Equal(t, types.HealthOK, health.Ready(context.Background()).Status)
+}
@@ -8,12 +8,15 @@ import (
 	log2 "github.com/brutella/hap/log"
 	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
 	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/sirupsen/logrus"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 )
 
+var homekitLog = Logs.Logger("homekit")
+
 type HomeKitProvider struct {
 	rosProvider types2.IRosProvider
 	mower       *accessory.Switch
@@ -49,26 +52,29 @@ func (hc *HomeKitProvider) registerAccessories() *accessory.A {
 			}, &mower_msgs.HighLevelControlSrvRes{})
 		}
 		if err != nil {
-			log.Println(err)
+			homekitLog.Error(err)
 		}
 	})
 	return hc.mower.A
 }
 
 func (hc *HomeKitProvider) launchServer(as *accessory.A) {
-	// Store the data in the "./db" directory.
-	log2.Debug.Enable()
+	// The debug and info output of hap is logged in the homekit subsystem
+	log2.Debug.SetFlags(log.Lshortfile)
+	log2.Debug.SetOutput(homekitLog.WriterLevel(logrus.DebugLevel))
+	log2.Info.SetFlags(log.Lshortfile)
+	log2.Info.SetOutput(homekitLog.Writer())
 	// Create the hap server.
 	server, err := hap.NewServer(hc.db, as)
-	server.Addr = ":8000"
+	server.Addr = homekitAddr
 	pinCode, err := hc.db.Get("system.homekit.pincode")
 	if err != nil {
-		log.Panic(err)
+		homekitLog.Panic(err)
 	}
 	server.Pin = string(pinCode)
 	if err != nil {
 		// stop if an error happens
-		log.Panic(err)
+		homekitLog.Panic(err)
 	}
 
 	// Setup a listener for interrupts and SIGTERM signals
@@ -98,7 +104,7 @@ func (hc *HomeKitProvider) subscribeToRos() {
 		var status mower_msgs.HighLevelStatus
 		err := json.Unmarshal(msg, &status)
 		if err != nil {
-			log.Println(err)
+			homekitLog.Error(err)
 			return
 		}
 		if status.StateName == "MOWING" || status.StateName == "DOCKING" || status.StateName == "UNDOCKING" {
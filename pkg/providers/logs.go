@@ -0,0 +1,349 @@
+package providers
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// rootSubsystem is the subsystem of the entries logged with the standard logrus logger
+const rootSubsystem = "gui"
+
+const defaultLogBufferSize = 1000
+
+// Logs holds the loggers of the subsystems, providers and routes get their logger with Logs.Logger
+var Logs = NewLogRegistry(logrus.StandardLogger())
+
+// LogProvider configures the levels and the buffer size of the Logs registry from the DB: system.logs.level is the
+// level of every subsystem unless it is overridden in system.logs.levels. The standard log package, used by some
+// libraries, is redirected to the gui subsystem.
+type LogProvider struct {
+	dbProvider types.IDBProvider
+	registry   *LogRegistry
+}
+
+func NewLogProvider(dbProvider types.IDBProvider) *LogProvider {
+	l := &LogProvider{
+		dbProvider: dbProvider,
+		registry:   Logs,
+	}
+	size, err := GetInt(dbProvider, "system.logs.bufferSize")
+	if err != nil {
+		logrus.Error(xerrors.Errorf("failed to read the log buffer size: %w", err))
+		size = defaultLogBufferSize
+	}
+	l.registry.Resize(size)
+	err = l.registry.SetLevels(l.defaultLevel(), l.overrides())
+	if err != nil {
+		logrus.Error(xerrors.Errorf("failed to apply the log levels: %w", err))
+	}
+	log.SetFlags(0)
+	log.SetOutput(l.registry.Logger(rootSubsystem).Writer())
+	return l
+}
+
+func (l *LogProvider) Entries(query types.LogQuery) ([]types.LogEntry, error) {
+	return l.registry.Entries(query)
+}
+
+func (l *LogProvider) SubscribeLogs(id string, query types.LogQuery, cb func(entry types.LogEntry)) ([]types.LogEntry, error) {
+	return l.registry.Subscribe(id, query, cb)
+}
+
+func (l *LogProvider) UnSubscribeLogs(id string) {
+	l.registry.UnSubscribe(id)
+}
+
+func (l *LogProvider) Levels() map[string]string {
+	return l.registry.Levels()
+}
+
+// SetLevels validates and saves the level overrides, the subsystems without an override use system.logs.level
+func (l *LogProvider) SetLevels(levels map[string]string) error {
+	known := l.registry.Levels()
+	fields := map[string]string{}
+	for subsystem, level := range levels {
+		if _, ok := known[subsystem]; !ok {
+			fields["levels."+subsystem] = "unknown subsystem"
+			continue
+		}
+		if _, err := logrus.ParseLevel(level); err != nil {
+			fields["levels."+subsystem] = err.Error()
+		}
+	}
+	if len(fields) > 0 {
+		return &types.ValidationError{Fields: fields}
+	}
+	value, err := json.Marshal(levels)
+	if err != nil {
+		return err
+	}
+	err = l.dbProvider.Set("system.logs.levels", value)
+	if err != nil {
+		return err
+	}
+	return l.registry.SetLevels(l.defaultLevel(), levels)
+}
+
+func (l *LogProvider) defaultLevel() string {
+	level, err := l.dbProvider.Get("system.logs.level")
+	if err != nil {
+		return logrus.InfoLevel.String()
+	}
+	return string(level)
+}
+
+func (l *LogProvider) overrides() map[string]string {
+	levels := map[string]string{}
+	value, err := l.dbProvider.Get("system.logs.levels")
+	if err != nil {
+		return levels
+	}
+	err = json.Unmarshal(value, &levels)
+	if err != nil {
+		logrus.Error(xerrors.Errorf("failed to read the log levels: %w", err))
+	}
+	return levels
+}
+
+type logRecord struct {
+	level logrus.Level
+	entry types.LogEntry
+}
+
+type logSubscriber struct {
+	subsystem string
+	level     logrus.Level
+	cb        func(entry types.LogEntry)
+}
+
+// LogRegistry creates a logrus logger per subsystem, they share the output and the formatter of the root logger and
+// their entries are kept in a ring buffer
+type LogRegistry struct {
+	mtx         sync.Mutex
+	root        *logrus.Logger
+	loggers     map[string]*logrus.Logger
+	level       logrus.Level
+	overrides   map[string]logrus.Level
+	buffer      []logRecord
+	next        int
+	count       int
+	subscribers map[string]logSubscriber
+}
+
+func NewLogRegistry(root *logrus.Logger) *LogRegistry {
+	r := &LogRegistry{
+		root:        root,
+		loggers:     map[string]*logrus.Logger{rootSubsystem: root},
+		level:       root.GetLevel(),
+		overrides:   make(map[string]logrus.Level),
+		buffer:      make([]logRecord, defaultLogBufferSize),
+		subscribers: make(map[string]logSubscriber),
+	}
+	root.AddHook(&logHook{registry: r})
+	return r
+}
+
+// Logger returns the logger of subsystem, entries are tagged with a subsystem field
+func (r *LogRegistry) Logger(subsystem string) *logrus.Entry {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	logger, ok := r.loggers[subsystem]
+	if !ok {
+		logger = logrus.New()
+		logger.SetOutput(r.root.Out)
+		logger.SetFormatter(r.root.Formatter)
+		logger.SetLevel(r.levelOf(subsystem))
+		logger.AddHook(&logHook{registry: r})
+		r.loggers[subsystem] = logger
+	}
+	return logger.WithField("subsystem", subsystem)
+}
+
+// Levels returns the level of each subsystem
+func (r *LogRegistry) Levels() map[string]string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	levels := make(map[string]string, len(r.loggers))
+	for subsystem, logger := range r.loggers {
+		levels[subsystem] = logger.GetLevel().String()
+	}
+	return levels
+}
+
+// SetLevels sets the level of the subsystems in overrides and defaultLevel to the others, including the subsystems
+// whose logger is created later
+func (r *LogRegistry) SetLevels(defaultLevel string, overrides map[string]string) error {
+	level, err := logrus.ParseLevel(defaultLevel)
+	if err != nil {
+		return err
+	}
+	parsed := make(map[string]logrus.Level, len(overrides))
+	for subsystem, override := range overrides {
+		parsed[subsystem], err = logrus.ParseLevel(override)
+		if err != nil {
+			return xerrors.Errorf("invalid level of %s: %w", subsystem, err)
+		}
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.level = level
+	r.overrides = parsed
+	for subsystem, logger := range r.loggers {
+		logger.SetLevel(r.levelOf(subsystem))
+	}
+	return nil
+}
+
+// levelOf returns the configured level of subsystem, r.mtx must be held
+func (r *LogRegistry) levelOf(subsystem string) logrus.Level {
+	if level, ok := r.overrides[subsystem]; ok {
+		return level
+	}
+	return r.level
+}
+
+// Resize changes the number of entries kept, the most recent entries are preserved
+func (r *LogRegistry) Resize(size int) {
+	if size < 1 {
+		size = 1
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	records := r.records()
+	if len(records) > size {
+		records = records[len(records)-size:]
+	}
+	r.buffer = make([]logRecord, size)
+	copy(r.buffer, records)
+	r.count = len(records)
+	r.next = len(records) % size
+}
+
+func (r *LogRegistry) Entries(query types.LogQuery) ([]types.LogEntry, error) {
+	subscriber, err := newLogSubscriber(query, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.matching(subscriber, query.Limit), nil
+}
+
+func (r *LogRegistry) Subscribe(id string, query types.LogQuery, cb func(entry types.LogEntry)) ([]types.LogEntry, error) {
+	subscriber, err := newLogSubscriber(query, cb)
+	if err != nil {
+		return nil, err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.subscribers[id] = subscriber
+	return r.matching(subscriber, query.Limit), nil
+}
+
+func (r *LogRegistry) UnSubscribe(id string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.subscribers, id)
+}
+
+func (r *LogRegistry) append(record logRecord) {
+	r.mtx.Lock()
+	r.buffer[r.next] = record
+	r.next = (r.next + 1) % len(r.buffer)
+	if r.count < len(r.buffer) {
+		r.count++
+	}
+	var callbacks []func(entry types.LogEntry)
+	for _, subscriber := range r.subscribers {
+		if subscriber.matches(record) {
+			callbacks = append(callbacks, subscriber.cb)
+		}
+	}
+	r.mtx.Unlock()
+	for _, cb := range callbacks {
+		cb(record.entry)
+	}
+}
+
+// records returns the buffered records from the oldest to the most recent, r.mtx must be held
+func (r *LogRegistry) records() []logRecord {
+	records := make([]logRecord, 0, r.count)
+	start := (r.next - r.count + len(r.buffer)) % len(r.buffer)
+	for i := 0; i < r.count; i++ {
+		records = append(records, r.buffer[(start+i)%len(r.buffer)])
+	}
+	return records
+}
+
+// matching returns the last limit entries matching subscriber, r.mtx must be held
+func (r *LogRegistry) matching(subscriber logSubscriber, limit int) []types.LogEntry {
+	entries := []types.LogEntry{}
+	for _, record := range r.records() {
+		if subscriber.matches(record) {
+			entries = append(entries, record.entry)
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+func newLogSubscriber(query types.LogQuery, cb func(entry types.LogEntry)) (logSubscriber, error) {
+	subscriber := logSubscriber{subsystem: query.Subsystem, level: logrus.TraceLevel, cb: cb}
+	if query.Level != "" {
+		level, err := logrus.ParseLevel(query.Level)
+		if err != nil {
+			return subscriber, &types.ValidationError{Fields: map[string]string{"level": err.Error()}}
+		}
+		subscriber.level = level
+	}
+	return subscriber, nil
+}
+
+func (s logSubscriber) matches(record logRecord) bool {
+	return record.level <= s.level && (s.subsystem == "" || s.subsystem == record.entry.Subsystem)
+}
+
+// logHook copies the entries of the subsystem loggers to the registry
+type logHook struct {
+	registry *LogRegistry
+}
+
+func (h *logHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *logHook) Fire(entry *logrus.Entry) error {
+	record := logRecord{
+		level: entry.Level,
+		entry: types.LogEntry{
+			Time:      entry.Time,
+			Level:     entry.Level.String(),
+			Subsystem: rootSubsystem,
+			Message:   entry.Message,
+		},
+	}
+	for key, value := range entry.Data {
+		if key == "subsystem" {
+			if subsystem, ok := value.(string); ok {
+				record.entry.Subsystem = subsystem
+			}
+			continue
+		}
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		if record.entry.Fields == nil {
+			record.entry.Fields = map[string]any{}
+		}
+		record.entry.Fields[key] = value
+	}
+	h.registry.append(record)
+	return nil
+}
@@ -0,0 +1,99 @@
+package providers
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogRegistry() *LogRegistry {
+	root := logrus.New()
+	root.SetOutput(io.Discard)
+	return NewLogRegistry(root)
+}
+
+func messages(entries []types.LogEntry) []string {
+	return lo.Map(entries, func(entry types.LogEntry, _ int) string {
+		return entry.Subsystem + ":" + entry.Message
+	})
+}
+
+func TestLogRegistryLevelsAndBuffer(t *testing.T) {
+	registry := newTestLogRegistry()
+	registry.Resize(3)
+	ros := registry.Logger("ros")
+	mqtt := registry.Logger("mqtt")
+	require.NoError(t, registry.SetLevels("warning", map[string]string{"ros": "debug"}))
+	assert.Equal(t, map[string]string{"gui": "warning", "ros": "debug", "mqtt": "warning"}, registry.Levels())
+
+	ros.Debug("connecting")
+	mqtt.Info("client connected")
+	mqtt.WithError(errors.New("broken pipe")).Error("publish failed")
+	registry.root.Warn("low battery")
+	ros.Error("disconnected")
+
+	entries, err := registry.Entries(types.LogQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mqtt:publish failed", "gui:low battery", "ros:disconnected"}, messages(entries))
+	assert.Equal(t, map[string]any{"error": "broken pipe"}, entries[0].Fields)
+	entries, err = registry.Entries(types.LogQuery{Level: "error", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ros:disconnected"}, messages(entries))
+
+	registry.Resize(2)
+	entries, err = registry.Entries(types.LogQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gui:low battery", "ros:disconnected"}, messages(entries))
+	_, err = registry.Entries(types.LogQuery{Level: "loud"})
+	var validationErr *types.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+}
+
+func TestLogRegistrySubscribe(t *testing.T) {
+	registry := newTestLogRegistry()
+	ros := registry.Logger("ros")
+	ros.Info("started")
+	registry.Logger("docker").Info("listing containers")
+
+	var received []types.LogEntry
+	recent, err := registry.Subscribe("test", types.LogQuery{Subsystem: "ros"}, func(entry types.LogEntry) {
+		received = append(received, entry)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ros:started"}, messages(recent))
+	ros.Warn("reconnecting")
+	registry.Logger("docker").Warn("socket closed")
+	registry.UnSubscribe("test")
+	ros.Info("connected")
+	assert.Equal(t, []string{"ros:reconnecting"}, messages(received))
+}
+
+func TestLogProviderSetLevels(t *testing.T) {
+	db := fakes.NewDBProvider(map[string]string{"system.logs.level": "error"})
+	registry := newTestLogRegistry()
+	registry.Logger("ros")
+	provider := &LogProvider{dbProvider: db, registry: registry}
+
+	err := provider.SetLevels(map[string]string{"ros": "loud", "unknown": "info"})
+	var validationErr *types.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	fields := lo.Keys(validationErr.Fields)
+	sort.Strings(fields)
+	assert.Equal(t, []string{"levels.ros", "levels.unknown"}, fields)
+
+	require.NoError(t, provider.SetLevels(map[string]string{"ros": "trace"}))
+	assert.Equal(t, map[string]string{"gui": "error", "ros": "trace"}, provider.Levels())
+	saved, err := db.Get("system.logs.levels")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ros":"trace"}`, string(saved))
+	registry.Logger("mqtt")
+	assert.Equal(t, "error", provider.Levels()["mqtt"])
+}
@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgproc"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/diagnostic_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/nav_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/sensor_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/std_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/visualization_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/dynamic_reconfigure"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"golang.org/x/xerrors"
+)
+
+// messageTypes are the ROS message types known by the GUI indexed by their ROS name, e.g. geometry_msgs/Twist
+var messageTypes = registerMessageTypes(
+	&std_msgs.Bool{}, &std_msgs.Empty{}, &std_msgs.Float32{}, &std_msgs.Float64{}, &std_msgs.Int8{},
+	&std_msgs.Int16{}, &std_msgs.Int32{}, &std_msgs.Int64{}, &std_msgs.UInt8{}, &std_msgs.UInt16{},
+	&std_msgs.UInt32{}, &std_msgs.UInt64{}, &std_msgs.String{},
+	&geometry_msgs.Point{}, &geometry_msgs.PointStamped{}, &geometry_msgs.Pose{}, &geometry_msgs.Pose2D{},
+	&geometry_msgs.PoseStamped{}, &geometry_msgs.PoseWithCovarianceStamped{}, &geometry_msgs.Polygon{},
+	&geometry_msgs.PolygonStamped{}, &geometry_msgs.Quaternion{}, &geometry_msgs.Twist{},
+	&geometry_msgs.TwistStamped{}, &geometry_msgs.Vector3{},
+	&nav_msgs.Odometry{}, &nav_msgs.Path{},
+	&sensor_msgs.Imu{}, &sensor_msgs.NavSatFix{},
+	&diagnostic_msgs.DiagnosticArray{},
+	&visualization_msgs.Marker{}, &visualization_msgs.MarkerArray{},
+	&dynamic_reconfigure.Config{}, &dynamic_reconfigure.ConfigDescription{},
+	&mower_map.MapArea{}, &mower_map.MapAreas{},
+	&mower_msgs.ESCStatus{}, &mower_msgs.HighLevelStatus{}, &mower_msgs.ImuRaw{}, &mower_msgs.Perimeter{},
+	&mower_msgs.Status{},
+	&xbot_msgs.AbsolutePose{}, &xbot_msgs.ActionInfo{}, &xbot_msgs.Map{}, &xbot_msgs.MapArea{},
+	&xbot_msgs.MapOverlay{}, &xbot_msgs.MapOverlayPolygon{}, &xbot_msgs.RobotState{},
+	&xbot_msgs.SensorDataDouble{}, &xbot_msgs.SensorDataString{}, &xbot_msgs.SensorInfo{}, &xbot_msgs.WheelTick{},
+)
+
+func registerMessageTypes(msgs ...any) map[string]reflect.Type {
+	registry := make(map[string]reflect.Type)
+	for _, msg := range msgs {
+		name, err := msgproc.Type(reflect.ValueOf(msg).Elem().Interface())
+		if err != nil {
+			panic(err)
+		}
+		registry[name] = reflect.TypeOf(msg)
+	}
+	return registry
+}
+
+// NewMessage returns a pointer to a new message of the given ROS type
+func NewMessage(name string) (any, error) {
+	msgType, ok := messageTypes[name]
+	if !ok {
+		return nil, xerrors.Errorf("unknown message type %s", name)
+	}
+	return reflect.New(msgType.Elem()).Interface(), nil
+}
+
+// MessageTypes returns the names of the known ROS message types
+func MessageTypes() []string {
+	var names []string
+	for name := range messageTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
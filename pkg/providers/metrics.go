@@ -0,0 +1,321 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+var metricsLog = Logs.Logger("metrics")
+
+// MetricsProvider exports the mower status, high level status and pose as gauges along with the process metrics of
+// the GUI. The other GUI metrics are updated where they happen through the Metrics registry. The gauges of the mower
+// keep their live values while a recording is replayed.
+type MetricsProvider struct {
+	rosProvider types.IRosProvider
+	registry    *MetricsRegistry
+}
+
+func NewMetricsProvider(rosProvider types.IRosProvider) *MetricsProvider {
+	m := &MetricsProvider{
+		rosProvider: rosProvider,
+		registry:    Metrics,
+	}
+	start := float64(time.Now().Unix())
+	m.registry.GaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", func() float64 {
+		return start
+	})
+	m.registry.GaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	m.registry.GaugeFunc("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", func() float64 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return float64(stats.Alloc)
+	})
+	subscriptions := map[string]func(msg []byte){
+		"/mower/status":              m.onStatus,
+		"/mower_logic/current_state": m.onHighLevelStatus,
+		"/xbot_positioning/xb_pose":  m.onPose,
+	}
+	for topic, cb := range subscriptions {
+		cb := cb
+		err := rosProvider.Subscribe(topic, "metrics", func(msg []byte) {
+			if !m.rosProvider.Replaying() {
+				cb(msg)
+			}
+		})
+		if err != nil {
+			metricsLog.Error(xerrors.Errorf("failed to subscribe to %s: %w", topic, err))
+		}
+	}
+	return m
+}
+
+func (m *MetricsProvider) WriteMetrics(w io.Writer) error {
+	return m.registry.WriteText(w)
+}
+
+func (m *MetricsProvider) onStatus(msg []byte) {
+	var status mower_msgs.Status
+	err := json.Unmarshal(msg, &status)
+	if err != nil {
+		return
+	}
+	r := m.registry
+	r.SetGauge("openmower_battery_voltage_volts", "Battery voltage.", float32Gauge(status.VBattery))
+	r.SetGauge("openmower_charge_voltage_volts", "Charge voltage.", float32Gauge(status.VCharge))
+	r.SetGauge("openmower_charge_current_amperes", "Charge current.", float32Gauge(status.ChargeCurrent))
+	r.SetGauge("openmower_rain_detected", "1 if rain is detected.", boolGauge(status.RainDetected))
+	r.SetGauge("openmower_emergency", "1 if the low level emergency is active.", boolGauge(status.Emergency))
+	r.SetGauge("openmower_mow_enabled", "1 if the mow motor is enabled.", boolGauge(status.MowEnabled))
+	escs := map[string]mower_msgs.ESCStatus{"left": status.LeftEscStatus, "right": status.RightEscStatus, "mow": status.MowEscStatus}
+	for name, esc := range escs {
+		r.SetGauge("openmower_esc_status", "ESC status code, 200 is OK and 201 is running.", float64(esc.Status), "esc", name)
+		r.SetGauge("openmower_esc_current_amperes", "ESC current.", float32Gauge(esc.Current), "esc", name)
+		r.SetGauge("openmower_esc_rpm", "ESC motor speed in rotations per minute.", float64(esc.Rpm), "esc", name)
+		r.SetGauge("openmower_esc_motor_temperature_celsius", "ESC motor temperature.", float32Gauge(esc.TemperatureMotor), "esc", name)
+		r.SetGauge("openmower_esc_pcb_temperature_celsius", "ESC PCB temperature.", float32Gauge(esc.TemperaturePcb), "esc", name)
+	}
+}
+
+func (m *MetricsProvider) onHighLevelStatus(msg []byte) {
+	var status mower_msgs.HighLevelStatus
+	err := json.Unmarshal(msg, &status)
+	if err != nil {
+		return
+	}
+	r := m.registry
+	r.SetEnum("openmower_state", "1 for the current high level state of the mower.", "state", status.StateName)
+	r.SetGauge("openmower_battery_level_ratio", "Battery level between 0 and 1.", float32Gauge(status.BatteryPercent))
+	r.SetGauge("openmower_gps_quality_ratio", "GPS quality between 0 and 1.", float32Gauge(status.GpsQualityPercent))
+	r.SetGauge("openmower_charging", "1 if the mower is charging.", boolGauge(status.IsCharging))
+	r.SetGauge("openmower_high_level_emergency", "1 if the high level emergency is active.", boolGauge(status.Emergency))
+	r.SetGauge("openmower_current_area", "Index of the area being mowed.", float64(status.CurrentArea))
+}
+
+func (m *MetricsProvider) onPose(msg []byte) {
+	var pose xbot_msgs.AbsolutePose
+	err := json.Unmarshal(msg, &pose)
+	if err != nil {
+		return
+	}
+	m.registry.SetGauge("openmower_pose_position_accuracy_meters", "Accuracy of the estimated position.", float32Gauge(pose.PositionAccuracy))
+	m.registry.SetGauge("openmower_pose_orientation_accuracy_radians", "Accuracy of the estimated orientation.", float32Gauge(pose.OrientationAccuracy))
+}
+
+// float32Gauge converts a message value without the float32 rounding noise, e.g. 0.9 instead of 0.8999999761581421
+func float32Gauge(value float32) float64 {
+	converted, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'g', -1, 32), 64)
+	return converted
+}
+
+func boolGauge(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// Metrics is the registry of the metrics exported on /metrics, providers and routes update it directly
+var Metrics = NewMetricsRegistry()
+
+// durationBuckets are the upper bounds in seconds of the duration histograms
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metricFamily struct {
+	help    string
+	kind    string
+	samples map[string]*metricSample
+}
+
+type metricSample struct {
+	value   float64
+	fn      func() float64
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// MetricsRegistry holds gauges, counters and histograms and writes them in the Prometheus text format. Labels are
+// given as name, value pairs.
+type MetricsRegistry struct {
+	mtx      sync.Mutex
+	families map[string]*metricFamily
+}
+
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{families: make(map[string]*metricFamily)}
+}
+
+func (m *MetricsRegistry) SetGauge(name string, help string, value float64, labels ...string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.sample(name, help, "gauge", labels).value = value
+}
+
+func (m *MetricsRegistry) AddGauge(name string, help string, delta float64, labels ...string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.sample(name, help, "gauge", labels).value += delta
+}
+
+// SetEnum sets the gauge of the label value to 1 and the gauges of the other values seen so far to 0
+func (m *MetricsRegistry) SetEnum(name string, help string, label string, value string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	current := m.sample(name, help, "gauge", []string{label, value})
+	for _, sample := range m.families[name].samples {
+		sample.value = 0
+	}
+	current.value = 1
+}
+
+// GaugeFunc registers a gauge whose value is read when the metrics are written
+func (m *MetricsRegistry) GaugeFunc(name string, help string, fn func() float64, labels ...string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.sample(name, help, "gauge", labels).fn = fn
+}
+
+func (m *MetricsRegistry) AddCounter(name string, help string, delta float64, labels ...string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.sample(name, help, "counter", labels).value += delta
+}
+
+// ObserveDuration adds a duration to a histogram with the durationBuckets
+func (m *MetricsRegistry) ObserveDuration(name string, help string, duration time.Duration, labels ...string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	sample := m.sample(name, help, "histogram", labels)
+	if sample.buckets == nil {
+		sample.buckets = make([]uint64, len(durationBuckets))
+	}
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			sample.buckets[i]++
+		}
+	}
+	sample.sum += seconds
+	sample.count++
+}
+
+// sample returns the sample of the labels, m.mtx must be held
+func (m *MetricsRegistry) sample(name string, help string, kind string, labels []string) *metricSample {
+	family, ok := m.families[name]
+	if !ok {
+		family = &metricFamily{help: help, kind: kind, samples: make(map[string]*metricSample)}
+		m.families[name] = family
+	}
+	key := formatLabels(labels)
+	sample, ok := family.samples[key]
+	if !ok {
+		sample = &metricSample{}
+		family.samples[key] = sample
+	}
+	return sample
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format
+func (m *MetricsRegistry) WriteText(w io.Writer) error {
+	// gauge functions may call other providers, they are evaluated without holding m.mtx
+	m.mtx.Lock()
+	var samples []*metricSample
+	var fns []func() float64
+	for _, family := range m.families {
+		for _, sample := range family.samples {
+			if sample.fn != nil {
+				samples = append(samples, sample)
+				fns = append(fns, sample.fn)
+			}
+		}
+	}
+	m.mtx.Unlock()
+	values := make([]float64, len(fns))
+	for i, fn := range fns {
+		values[i] = fn()
+	}
+
+	m.mtx.Lock()
+	for i, sample := range samples {
+		sample.value = values[i]
+	}
+	var builder strings.Builder
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		family := m.families[name]
+		fmt.Fprintf(&builder, "# HELP %s %s\n# TYPE %s %s\n", name, family.help, name, family.kind)
+		keys := make([]string, 0, len(family.samples))
+		for key := range family.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			sample := family.samples[key]
+			if family.kind != "histogram" {
+				fmt.Fprintf(&builder, "%s%s %s\n", name, key, formatFloat(sample.value))
+				continue
+			}
+			for i, bound := range durationBuckets {
+				fmt.Fprintf(&builder, "%s_bucket%s %d\n", name, withLabel(key, "le", formatFloat(bound)), sample.buckets[i])
+			}
+			fmt.Fprintf(&builder, "%s_bucket%s %d\n", name, withLabel(key, "le", "+Inf"), sample.count)
+			fmt.Fprintf(&builder, "%s_sum%s %s\n", name, key, formatFloat(sample.sum))
+			fmt.Fprintf(&builder, "%s_count%s %d\n", name, key, sample.count)
+		}
+	}
+	m.mtx.Unlock()
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+"=\""+labelEscaper.Replace(labels[i+1])+"\"")
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func withLabel(key string, name string, value string) string {
+	label := name + "=\"" + labelEscaper.Replace(value) + "\""
+	if key == "" {
+		return "{" + label + "}"
+	}
+	return strings.TrimSuffix(key, "}") + "," + label + "}"
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
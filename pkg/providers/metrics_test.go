@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	registry := NewMetricsRegistry()
+	registry.SetGauge("mower_voltage", "Voltage.", 24.5)
+	registry.AddCounter("calls_total", "Calls.", 1, "service", `/a"b`)
+	registry.AddCounter("calls_total", "Calls.", 2, "service", `/a"b`)
+	registry.GaugeFunc("clients", "Clients.", func() float64 { return 3 })
+	registry.ObserveDuration("call_seconds", "Call duration.", 30*time.Millisecond, "service", "/a")
+	registry.ObserveDuration("call_seconds", "Call duration.", 2*time.Second, "service", "/a")
+
+	var builder strings.Builder
+	require.NoError(t, registry.WriteText(&builder))
+	assert.Equal(t, `# HELP call_seconds Call duration.
+# TYPE call_seconds histogram
+call_seconds_bucket{service="/a",le="0.005"} 0
+call_seconds_bucket{service="/a",le="0.01"} 0
+call_seconds_bucket{service="/a",le="0.025"} 0
+call_seconds_bucket{service="/a",le="0.05"} 1
+call_seconds_bucket{service="/a",le="0.1"} 1
+call_seconds_bucket{service="/a",le="0.25"} 1
+call_seconds_bucket{service="/a",le="0.5"} 1
+call_seconds_bucket{service="/a",le="1"} 1
+call_seconds_bucket{service="/a",le="2.5"} 2
+call_seconds_bucket{service="/a",le="5"} 2
+call_seconds_bucket{service="/a",le="10"} 2
+call_seconds_bucket{service="/a",le="+Inf"} 2
+call_seconds_sum{service="/a"} 2.03
+call_seconds_count{service="/a"} 2
+# HELP calls_total Calls.
+# TYPE calls_total counter
+calls_total{service="/a\"b"} 3
+# HELP clients Clients.
+# TYPE clients gauge
+clients 3
+# HELP mower_voltage Voltage.
+# TYPE mower_voltage gauge
+mower_voltage 24.5
+`, builder.String())
+}
+
+func TestMetricsReplay(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	metrics := NewMetricsProvider(ros)
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{VBattery: 27.5}))
+
+	// the gauges keep the live values during a replay
+	ros.Replay(true)
+	ros.Inject("/mower/status", []byte(`{"VBattery":12}`))
+	var builder strings.Builder
+	require.NoError(t, metrics.WriteMetrics(&builder))
+	assert.Contains(t, builder.String(), "openmower_battery_voltage_volts 27.5\n")
+}
@@ -0,0 +1,308 @@
+package providers
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/std_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+var monitoringLog = Logs.Logger("monitoring")
+
+const (
+	robotStateTopic        = "/xbot_monitoring/robot_state"
+	actionTopic            = "/xbot/action"
+	registerActionsService = "/xbot/register_actions"
+	sensorTopicPrefix      = "/xbot_monitoring/sensors/"
+	sensorDiscoveryPeriod  = 10 * time.Second
+)
+
+func sensorInfoTopic(id string) string {
+	return sensorTopicPrefix + id + "/info"
+}
+
+func sensorDataTopic(id string) string {
+	return sensorTopicPrefix + id + "/data"
+}
+
+var sensorDescriptions = map[uint8]string{
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_UNKNOWN:      "unknown",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_TEMPERATURE:  "temperature",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_VELOCITY:     "velocity",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_ACCELERATION: "acceleration",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_VOLTAGE:      "voltage",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_CURRENT:      "current",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_PERCENT:      "percent",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_DISTANCE:     "distance",
+	xbot_msgs.SensorInfo_VALUE_DESCRIPTION_RPM:          "rpm",
+}
+
+// MonitoringProvider tracks the sensors published by xbot_monitoring and the actions registered by the nodes.
+// Sensors are discovered from the /xbot_monitoring/sensors/<id>/info topics of the ROS graph. Actions are triggered on
+// /xbot/action.
+//
+// The nodes register their actions by calling /xbot/register_actions, which xbot_monitoring provides and doesn't
+// expose to other nodes. The actions are therefore only known when system.monitoring.registerActions is enabled (or in
+// simulation): the GUI then takes over /xbot/register_actions from xbot_monitoring, which stops seeing the actions
+// registered afterwards. The actions registered before the GUI started are not known until their nodes register them
+// again, so the list of actions is empty by default on a real robot.
+type MonitoringProvider struct {
+	rosProvider      types.IRosProvider
+	dbProvider       types.IDBProvider
+	mtx              sync.Mutex
+	sensors          map[string]*types.Sensor
+	actions          map[string]types.Action
+	subscribers      map[string]func(sensor types.Sensor)
+	actionsPublisher types.IRosPublisher
+}
+
+func NewMonitoringProvider(rosProvider types.IRosProvider, dbProvider types.IDBProvider) *MonitoringProvider {
+	m := &MonitoringProvider{
+		rosProvider: rosProvider,
+		dbProvider:  dbProvider,
+		sensors:     make(map[string]*types.Sensor),
+		actions:     make(map[string]types.Action),
+		subscribers: make(map[string]func(sensor types.Sensor)),
+	}
+	err := rosProvider.RegisterTopic(robotStateTopic, &xbot_msgs.RobotState{})
+	if err != nil {
+		monitoringLog.Error(xerrors.Errorf("failed to register %s: %w", robotStateTopic, err))
+	}
+	if m.registerActionsEnabled() {
+		err = rosProvider.AdvertiseService(registerActionsService, &xbot_msgs.RegisterActionsSrv{}, m.onRegisterActions)
+		if err != nil {
+			monitoringLog.Error(xerrors.Errorf("failed to advertise %s: %w", registerActionsService, err))
+		} else if simulation, _ := dbProvider.Get("system.ros.simulation"); string(simulation) != "true" {
+			monitoringLog.Warn(registerActionsService + " is provided by the GUI instead of xbot_monitoring, " +
+				"the actions registered before are unknown until their nodes register them again")
+		}
+	}
+	go func() {
+		m.discoverSensors()
+		for range time.Tick(sensorDiscoveryPeriod) {
+			m.discoverSensors()
+		}
+	}()
+	return m
+}
+
+func (m *MonitoringProvider) registerActionsEnabled() bool {
+	for _, key := range []string{"system.monitoring.registerActions", "system.ros.simulation"} {
+		value, err := m.dbProvider.Get(key)
+		if err == nil && string(value) == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MonitoringProvider) Sensors() []types.Sensor {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	var sensors []types.Sensor
+	for _, sensor := range m.sensors {
+		sensors = append(sensors, *sensor)
+	}
+	sort.Slice(sensors, func(i, j int) bool {
+		return sensors[i].ID < sensors[j].ID
+	})
+	return sensors
+}
+
+func (m *MonitoringProvider) SubscribeSensors(id string, cb func(sensor types.Sensor)) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.subscribers[id] = cb
+}
+
+func (m *MonitoringProvider) UnSubscribeSensors(id string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.subscribers, id)
+}
+
+func (m *MonitoringProvider) Actions() []types.Action {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	var actions []types.Action
+	for _, action := range m.actions {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].ID < actions[j].ID
+	})
+	return actions
+}
+
+func (m *MonitoringProvider) TriggerAction(id string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	action, ok := m.actions[id]
+	if !ok {
+		return xerrors.Errorf("%s: %w", id, types.ErrUnknownAction)
+	}
+	if !action.Enabled {
+		return xerrors.Errorf("%s: %w", id, types.ErrActionDisabled)
+	}
+	// the publisher of the provider follows the reconnections to ROS, it can be kept for the lifetime of the provider
+	if m.actionsPublisher == nil {
+		publisher, err := m.rosProvider.Publisher(actionTopic, &std_msgs.String{})
+		if err != nil {
+			return err
+		}
+		m.actionsPublisher = publisher
+	}
+	m.actionsPublisher.Write(&std_msgs.String{Data: id})
+	monitoringLog.Info("Triggered action " + id)
+	return nil
+}
+
+// onRegisterActions handles the register actions service, the actions of a node replace its previous actions
+func (m *MonitoringProvider) onRegisterActions(req any) (any, error) {
+	request, ok := req.(*xbot_msgs.RegisterActionsSrvReq)
+	if !ok {
+		return nil, xerrors.Errorf("invalid register actions request %T", req)
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for id, action := range m.actions {
+		if action.Node == request.NodePrefix {
+			delete(m.actions, id)
+		}
+	}
+	for _, info := range request.Actions {
+		id := request.NodePrefix + "/" + info.ActionId
+		m.actions[id] = types.Action{
+			ID:      id,
+			Node:    request.NodePrefix,
+			Name:    info.ActionName,
+			Enabled: info.Enabled,
+		}
+	}
+	return &xbot_msgs.RegisterActionsSrvRes{}, nil
+}
+
+// discoverSensors subscribes to the info topics of the sensors not known yet
+func (m *MonitoringProvider) discoverSensors() {
+	topics, err := m.rosProvider.Topics()
+	if err != nil {
+		monitoringLog.Debug(xerrors.Errorf("failed to discover sensors: %w", err))
+		return
+	}
+	for _, topic := range topics {
+		if !strings.HasPrefix(topic.Name, sensorTopicPrefix) || !strings.HasSuffix(topic.Name, "/info") {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(topic.Name, sensorTopicPrefix), "/info")
+		m.mtx.Lock()
+		_, known := m.sensors[id]
+		if !known {
+			m.sensors[id] = &types.Sensor{ID: id}
+		}
+		m.mtx.Unlock()
+		if known {
+			continue
+		}
+		err = m.rosProvider.RegisterTopic(topic.Name, &xbot_msgs.SensorInfo{})
+		if err == nil {
+			err = m.rosProvider.Subscribe(topic.Name, "monitoring", m.onSensorInfo)
+		}
+		if err != nil {
+			monitoringLog.Error(xerrors.Errorf("failed to subscribe to sensor %s: %w", id, err))
+		}
+	}
+}
+
+func (m *MonitoringProvider) onSensorInfo(msg []byte) {
+	var info xbot_msgs.SensorInfo
+	err := json.Unmarshal(msg, &info)
+	if err != nil || info.SensorId == "" {
+		return
+	}
+	sensor := types.Sensor{
+		ID:          info.SensorId,
+		Name:        info.SensorName,
+		Type:        "double",
+		Description: sensorDescriptions[info.ValueDescription],
+		Unit:        info.Unit,
+	}
+	var data any = &xbot_msgs.SensorDataDouble{}
+	if info.ValueType == xbot_msgs.SensorInfo_TYPE_STRING {
+		sensor.Type = "string"
+		data = &xbot_msgs.SensorDataString{}
+	}
+	if sensor.Description == "" {
+		sensor.Description = "unknown"
+	}
+	if info.HasMinMax {
+		sensor.Min, sensor.Max = &info.MinValue, &info.MaxValue
+	}
+	if info.HasCriticalLow {
+		sensor.LowerCritical = &info.LowerCriticalValue
+	}
+	if info.HasCriticalHigh {
+		sensor.UpperCritical = &info.UpperCriticalValue
+	}
+	m.mtx.Lock()
+	if previous, ok := m.sensors[sensor.ID]; ok {
+		sensor.Value, sensor.Stamp = previous.Value, previous.Stamp
+		sensor.Critical = critical(sensor, previous.Value)
+	}
+	m.sensors[sensor.ID] = &sensor
+	m.mtx.Unlock()
+	topic := sensorDataTopic(sensor.ID)
+	err = m.rosProvider.RegisterTopic(topic, data)
+	if err == nil {
+		err = m.rosProvider.Subscribe(topic, "monitoring", func(msg []byte) {
+			m.onSensorData(sensor.ID, msg)
+		})
+	}
+	if err != nil {
+		monitoringLog.Error(xerrors.Errorf("failed to subscribe to sensor %s data: %w", sensor.ID, err))
+	}
+}
+
+func (m *MonitoringProvider) onSensorData(id string, msg []byte) {
+	var data struct {
+		Stamp time.Time
+		Data  any
+	}
+	err := json.Unmarshal(msg, &data)
+	if err != nil {
+		return
+	}
+	m.mtx.Lock()
+	sensor, ok := m.sensors[id]
+	if !ok {
+		m.mtx.Unlock()
+		return
+	}
+	sensor.Value = data.Data
+	sensor.Stamp = data.Stamp
+	sensor.Critical = critical(*sensor, data.Data)
+	update := *sensor
+	var callbacks []func(sensor types.Sensor)
+	for _, cb := range m.subscribers {
+		callbacks = append(callbacks, cb)
+	}
+	m.mtx.Unlock()
+	for _, cb := range callbacks {
+		cb(update)
+	}
+}
+
+// critical returns true when value is outside the critical thresholds of the sensor
+func critical(sensor types.Sensor, value any) bool {
+	number, ok := value.(float64)
+	if !ok {
+		return false
+	}
+	return (sensor.LowerCritical != nil && number < *sensor.LowerCritical) ||
+		(sensor.UpperCritical != nil && number > *sensor.UpperCritical)
+}
@@ -0,0 +1,85 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/std_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitoringSensors(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	ros.SetGraph(nil, []types.RosTopic{
+		{Name: "/xbot_monitoring/sensors/om_v_battery/info", Type: "xbot_msgs/SensorInfo"},
+		{Name: "/xbot_monitoring/sensors/om_v_battery/data", Type: "xbot_msgs/SensorDataDouble"},
+	}, nil)
+	monitoring := NewMonitoringProvider(ros, fakes.NewDBProvider(map[string]string{}))
+	monitoring.discoverSensors()
+	var updates []types.Sensor
+	monitoring.SubscribeSensors("test", func(sensor types.Sensor) {
+		updates = append(updates, sensor)
+	})
+
+	require.NoError(t, ros.Publish(sensorInfoTopic("om_v_battery"), &xbot_msgs.SensorInfo{
+		SensorId: "om_v_battery", SensorName: "Battery Voltage", ValueType: xbot_msgs.SensorInfo_TYPE_DOUBLE,
+		ValueDescription: xbot_msgs.SensorInfo_VALUE_DESCRIPTION_VOLTAGE, Unit: "V",
+		HasCriticalLow: true, LowerCriticalValue: 21.7,
+	}))
+	stamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, ros.Publish(sensorDataTopic("om_v_battery"), &xbot_msgs.SensorDataDouble{Stamp: stamp, Data: 20}))
+
+	lowerCritical := 21.7
+	expected := types.Sensor{
+		ID: "om_v_battery", Name: "Battery Voltage", Type: "double", Description: "voltage", Unit: "V",
+		LowerCritical: &lowerCritical, Value: 20.0, Stamp: stamp, Critical: true,
+	}
+	assert.Equal(t, []types.Sensor{expected}, monitoring.Sensors())
+	assert.Equal(t, []types.Sensor{expected}, updates)
+
+	require.NoError(t, ros.Publish(sensorDataTopic("om_v_battery"), &xbot_msgs.SensorDataDouble{Stamp: stamp, Data: 25}))
+	assert.False(t, monitoring.Sensors()[0].Critical)
+	monitoring.UnSubscribeSensors("test")
+	require.NoError(t, ros.Publish(sensorDataTopic("om_v_battery"), &xbot_msgs.SensorDataDouble{Stamp: stamp, Data: 26}))
+	assert.Len(t, updates, 2)
+}
+
+func TestMonitoringActions(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	monitoring := NewMonitoringProvider(ros, fakes.NewDBProvider(map[string]string{
+		"system.monitoring.registerActions": "true",
+	}))
+
+	register := func(prefix string, actions ...xbot_msgs.ActionInfo) {
+		_, err := ros.CallAdvertised(registerActionsService, &xbot_msgs.RegisterActionsSrvReq{NodePrefix: prefix, Actions: actions})
+		require.NoError(t, err)
+	}
+	register("mower_logic:idle", xbot_msgs.ActionInfo{ActionId: "start_mowing", ActionName: "Start mowing", Enabled: true})
+	register("mower_logic:mowing", xbot_msgs.ActionInfo{ActionId: "abort_mowing", ActionName: "Stop mowing"})
+	assert.Equal(t, []types.Action{
+		{ID: "mower_logic:idle/start_mowing", Node: "mower_logic:idle", Name: "Start mowing", Enabled: true},
+		{ID: "mower_logic:mowing/abort_mowing", Node: "mower_logic:mowing", Name: "Stop mowing"},
+	}, monitoring.Actions())
+
+	assert.True(t, errors.Is(monitoring.TriggerAction("mower_logic:mowing/abort_mowing"), types.ErrActionDisabled))
+	assert.True(t, errors.Is(monitoring.TriggerAction("mower_logic:idle/unknown"), types.ErrUnknownAction))
+	assert.NoError(t, monitoring.TriggerAction("mower_logic:idle/start_mowing"))
+	assert.Equal(t, []any{&std_msgs.String{Data: "mower_logic:idle/start_mowing"}}, ros.Published(actionTopic))
+
+	// a new registration replaces the actions of the node
+	register("mower_logic:idle", xbot_msgs.ActionInfo{ActionId: "start_area_recording", ActionName: "Start area recording", Enabled: true})
+	assert.Equal(t, []string{"mower_logic:idle/start_area_recording", "mower_logic:mowing/abort_mowing"},
+		[]string{monitoring.Actions()[0].ID, monitoring.Actions()[1].ID})
+}
+
+func TestMonitoringActionsDisabled(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	NewMonitoringProvider(ros, fakes.NewDBProvider(map[string]string{"system.monitoring.registerActions": "false"}))
+	_, err := ros.CallAdvertised(registerActionsService, &xbot_msgs.RegisterActionsSrvReq{})
+	assert.True(t, errors.Is(err, types.ErrUnknownService))
+}
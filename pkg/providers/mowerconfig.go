@@ -0,0 +1,290 @@
+package providers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"golang.org/x/xerrors"
+)
+
+var (
+	mowerConfigAssignment = regexp.MustCompile(`^(\s*(?:export\s+)?)([A-Za-z_][A-Za-z0-9_]*)=`)
+	mowerConfigKey        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// mowerConfigBareValue are the values that can be written without quotes
+	mowerConfigBareValue = regexp.MustCompile(`^[A-Za-z0-9_./:,+@%=-]+$`)
+)
+
+// MowerConfig is a shell environment file such as mower_config.sh. The lines are kept as they are read and only the
+// assignments of the changed keys are rewritten, so that the comments, blank lines, other commands, order and quoting
+// of the file are preserved.
+type MowerConfig struct {
+	lines []mowerConfigLine
+	// newline tells if the file ends with a newline
+	newline bool
+}
+
+// mowerConfigLine is a line of the file, or the lines of an assignment whose quoted value spans several lines. For an
+// assignment, raw is prefix, key, =, the quoted value and suffix, and value is the unquoted value.
+type mowerConfigLine struct {
+	raw    string
+	key    string
+	prefix string
+	value  string
+	quote  byte
+	suffix string
+}
+
+// ParseMowerConfig parses the assignments of a file, written KEY=value with an optional export, the values can be
+// quoted with single or double quotes like in a shell
+func ParseMowerConfig(data []byte) (*MowerConfig, error) {
+	config := &MowerConfig{newline: true}
+	if len(data) == 0 {
+		return config, nil
+	}
+	lines := strings.Split(string(data), "\n")
+	config.newline = lines[len(lines)-1] == ""
+	if config.newline {
+		lines = lines[:len(lines)-1]
+	}
+	for i := 0; i < len(lines); i++ {
+		match := mowerConfigAssignment.FindStringSubmatch(lines[i])
+		if match == nil {
+			config.lines = append(config.lines, mowerConfigLine{raw: lines[i]})
+			continue
+		}
+		start := i
+		raw := lines[i]
+		for {
+			value, quote, end, complete := parseShellWord(raw[len(match[0]):])
+			if complete {
+				config.lines = append(config.lines, mowerConfigLine{
+					raw:    raw,
+					key:    match[2],
+					prefix: match[1],
+					value:  value,
+					quote:  quote,
+					suffix: raw[len(match[0])+end:],
+				})
+				break
+			}
+			// the quoted value or the line continuation goes on on the next line
+			if i+1 >= len(lines) {
+				return nil, xerrors.Errorf("unterminated value of %s at line %d", match[2], start+1)
+			}
+			i++
+			raw += "\n" + lines[i]
+		}
+	}
+	return config, nil
+}
+
+// parseShellWord reads a word, it returns the word without its quotes and escapes, its quote style, the index of its
+// end and false if the word goes on on the next line
+func parseShellWord(s string) (string, byte, int, bool) {
+	var word strings.Builder
+	var quote byte
+	if s != "" && (s[0] == '\'' || s[0] == '"') {
+		quote = s[0]
+	}
+	i := 0
+	for i < len(s) {
+		switch c := s[i]; c {
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return "", 0, 0, false
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 2
+		case '"':
+			i++
+			for {
+				if i >= len(s) {
+					return "", 0, 0, false
+				}
+				if s[i] == '"' {
+					i++
+					break
+				}
+				// in double quotes, the backslash only escapes $, `, ", \ and newlines
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					if s[i+1] != '\n' {
+						word.WriteByte(s[i+1])
+					}
+					i += 2
+					continue
+				}
+				word.WriteByte(s[i])
+				i++
+			}
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, 0, false
+			}
+			if s[i+1] != '\n' {
+				word.WriteByte(s[i+1])
+			}
+			i += 2
+		case ' ', '\t', '\r', ';':
+			return word.String(), quote, i, true
+		default:
+			word.WriteByte(c)
+			i++
+		}
+	}
+	return word.String(), quote, i, true
+}
+
+// quoteShellWord quotes a value with the given quote style, falling back to double quotes when the style cannot
+// represent the value
+func quoteShellWord(value string, quote byte) string {
+	switch {
+	case quote == 0 && mowerConfigBareValue.MatchString(value):
+		return value
+	case quote == '\'' && !strings.Contains(value, "'"):
+		return "'" + value + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value) + `"`
+}
+
+// Get returns the value of a key, the last assignment wins like in a shell
+func (m *MowerConfig) Get(key string) (string, bool) {
+	for i := len(m.lines) - 1; i >= 0; i-- {
+		if m.lines[i].key == key {
+			return m.lines[i].value, true
+		}
+	}
+	return "", false
+}
+
+// Values returns the value of each key
+func (m *MowerConfig) Values() map[string]string {
+	values := map[string]string{}
+	for _, line := range m.lines {
+		if line.key != "" {
+			values[line.key] = line.value
+		}
+	}
+	return values
+}
+
+// Set changes the last assignment of a key, keeping its quote style, or appends an exported assignment with a double
+// quoted value if the key is not set. The file is not changed if the key already has this value.
+func (m *MowerConfig) Set(key string, value string) error {
+	if !mowerConfigKey.MatchString(key) {
+		return xerrors.Errorf("invalid variable name %s", key)
+	}
+	for i := len(m.lines) - 1; i >= 0; i-- {
+		line := &m.lines[i]
+		if line.key != key {
+			continue
+		}
+		if line.value != value {
+			line.value = value
+			line.raw = line.prefix + key + "=" + quoteShellWord(value, line.quote) + line.suffix
+		}
+		return nil
+	}
+	m.lines = append(m.lines, mowerConfigLine{
+		raw:    "export " + key + "=" + quoteShellWord(value, '"'),
+		key:    key,
+		prefix: "export ",
+		value:  value,
+		quote:  '"',
+	})
+	return nil
+}
+
+// Delete removes the assignments of a key
+func (m *MowerConfig) Delete(key string) {
+	var lines []mowerConfigLine
+	for _, line := range m.lines {
+		if line.key != key {
+			lines = append(lines, line)
+		}
+	}
+	m.lines = lines
+}
+
+func (m *MowerConfig) Bytes() []byte {
+	var b strings.Builder
+	for i, line := range m.lines {
+		b.WriteString(line.raw)
+		if i < len(m.lines)-1 || m.newline {
+			b.WriteByte('\n')
+		}
+	}
+	return []byte(b.String())
+}
+
+// ReadMowerConfig reads a file, a missing file is an empty configuration
+func ReadMowerConfig(path string) (*MowerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return ParseMowerConfig(data)
+}
+
+func WriteMowerConfig(path string, config *MowerConfig) error {
+	return writeFileAtomic(path, config.Bytes(), 0644)
+}
+
+// writeFileAtomic writes a temporary file next to path, syncs it and renames it to path so that path is never left
+// half written. A file bind mounted in a container cannot be replaced, it is rewritten in place instead.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EXDEV) {
+		return writeFileInPlace(path, data, perm)
+	}
+	if err != nil {
+		return err
+	}
+	parent, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+	return parent.Sync()
+}
+
+func writeFileInPlace(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMowerConfig = `#!/bin/bash
+# Mower settings
+
+export OM_MOWER="YardForce500"   # the model
+export OM_DATUM_LAT=48.1
+  OM_NTRIP_PASSWORD='pa"ss'
+export OM_NTRIP_ENDPOINT="line one
+line two"
+export OM_ESCAPED="a \"quoted\" \$value"
+if [ -f /boot/extra.sh ]; then source /boot/extra.sh; fi
+export OM_DATUM_LAT=48.2
+export OM_NO_NEWLINE=end`
+
+func TestParseMowerConfig(t *testing.T) {
+	config, err := ParseMowerConfig([]byte(testMowerConfig))
+	require.NoError(t, err)
+	assert.Equal(t, testMowerConfig, string(config.Bytes()))
+	assert.Equal(t, map[string]string{
+		"OM_MOWER":          "YardForce500",
+		"OM_DATUM_LAT":      "48.2",
+		"OM_NTRIP_PASSWORD": `pa"ss`,
+		"OM_NTRIP_ENDPOINT": "line one\nline two",
+		"OM_ESCAPED":        `a "quoted" $value`,
+		"OM_NO_NEWLINE":     "end",
+	}, config.Values())
+
+	_, err = ParseMowerConfig([]byte("export OM_MOWER=\"YardForce500\n"))
+	assert.EqualError(t, err, "unterminated value of OM_MOWER at line 1")
+}
+
+func TestMowerConfigSet(t *testing.T) {
+	config, err := ParseMowerConfig([]byte(testMowerConfig))
+	require.NoError(t, err)
+	require.NoError(t, config.Set("OM_MOWER", "YardForce500"))
+	assert.Equal(t, testMowerConfig, string(config.Bytes()))
+
+	require.NoError(t, config.Set("OM_MOWER", "Sabo"))
+	require.NoError(t, config.Set("OM_DATUM_LAT", "48 N"))
+	require.NoError(t, config.Set("OM_NTRIP_PASSWORD", "it's"))
+	require.NoError(t, config.Set("OM_NEW", "$HOME"))
+	config.Delete("OM_ESCAPED")
+	assert.EqualError(t, config.Set("OM-BAD", "value"), "invalid variable name OM-BAD")
+	assert.Equal(t, `#!/bin/bash
+# Mower settings
+
+export OM_MOWER="Sabo"   # the model
+export OM_DATUM_LAT=48.1
+  OM_NTRIP_PASSWORD="it's"
+export OM_NTRIP_ENDPOINT="line one
+line two"
+if [ -f /boot/extra.sh ]; then source /boot/extra.sh; fi
+export OM_DATUM_LAT="48 N"
+export OM_NO_NEWLINE=end
+export OM_NEW="\$HOME"`, string(config.Bytes()))
+
+	reparsed, err := ParseMowerConfig(config.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, config.Values(), reparsed.Values())
+}
+
+func TestWriteMowerConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mower_config.sh")
+	config, err := ReadMowerConfig(path)
+	require.NoError(t, err)
+	require.NoError(t, config.Set("OM_MOWER", "YardForce500"))
+	require.NoError(t, WriteMowerConfig(path, config))
+	require.NoError(t, os.Chmod(path, 0600))
+
+	require.NoError(t, config.Set("OM_MOWER", "Sabo"))
+	require.NoError(t, WriteMowerConfig(path, config))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export OM_MOWER=\"Sabo\"\n", string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	// the temporary file is renamed
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
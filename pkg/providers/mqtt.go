@@ -4,24 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/brutella/hap/accessory"
-	"github.com/cedbossneo/openmower-gui/pkg/msgs/dynamic_reconfigure"
-	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
 	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
 	mqtt "github.com/mochi-mqtt/server/v2"
 	"github.com/mochi-mqtt/server/v2/hooks/auth"
 	"github.com/mochi-mqtt/server/v2/listeners"
 	"github.com/mochi-mqtt/server/v2/packets"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
-	"reflect"
 	"time"
 
-	"log"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 )
 
+var mqttLog = Logs.Logger("mqtt")
+
 type MqttProvider struct {
 	rosProvider types2.IRosProvider
 	mower       *accessory.Switch
@@ -44,7 +42,7 @@ func (hc *MqttProvider) Init() {
 	if err == nil {
 		hc.prefix = string(dbPrefix)
 	} else {
-		logrus.Error(xerrors.Errorf("Failed to get system.mqtt.prefix: %w", err))
+		mqttLog.Error(xerrors.Errorf("Failed to get system.mqtt.prefix: %w", err))
 	}
 	hc.launchServer()
 	hc.subscribeToRos()
@@ -68,22 +66,26 @@ func (hc *MqttProvider) launchServer() {
 
 	// Allow all connections.
 	_ = hc.server.AddHook(new(auth.AllowHook), nil)
+	server := hc.server
+	Metrics.GaugeFunc("openmower_gui_mqtt_clients", "Number of clients connected to the MQTT server.", func() float64 {
+		return float64(atomic.LoadInt64(&server.Info.ClientsConnected))
+	})
 
 	// Create a TCP listener on a standard port.
 	port, err := hc.dbProvider.Get("system.mqtt.host")
 	if err != nil {
-		log.Fatal(err)
+		mqttLog.Fatal(err)
 	}
 	tcp := listeners.NewTCP("t1", string(port), nil)
 	err = hc.server.AddListener(tcp)
 	if err != nil {
-		log.Fatal(err)
+		mqttLog.Fatal(err)
 	}
 
 	go func() {
 		err := hc.server.Serve()
 		if err != nil {
-			log.Fatal(err)
+			mqttLog.Fatal(err)
 		}
 	}()
 }
@@ -106,37 +108,46 @@ func (hc *MqttProvider) subscribeToRosTopic(topic string, id string) {
 		time.Sleep(500 * time.Millisecond)
 		err := hc.server.Publish(hc.prefix+topic, msg, true, 0)
 		if err != nil {
-			logrus.Error(xerrors.Errorf("Failed to publish to %s: %w", topic, err))
+			mqttLog.Error(xerrors.Errorf("Failed to publish to %s: %w", topic, err))
 		}
 	})
 	if err != nil {
-		logrus.Error(xerrors.Errorf("Failed to subscribe to %s: %w", topic, err))
+		mqttLog.Error(xerrors.Errorf("Failed to subscribe to %s: %w", topic, err))
 	}
 }
 
 func (hc *MqttProvider) subscribeToMqtt() {
-	subscribeToMqttCall(hc.server, hc.rosProvider, hc.prefix, "/mower_service/high_level_control", &mower_msgs.HighLevelControlSrv{}, &mower_msgs.HighLevelControlSrvReq{}, &mower_msgs.HighLevelControlSrvRes{})
-	subscribeToMqttCall(hc.server, hc.rosProvider, hc.prefix, "/mower_service/emergency", &mower_msgs.EmergencyStopSrv{}, &mower_msgs.EmergencyStopSrvReq{}, &mower_msgs.EmergencyStopSrvRes{})
-	subscribeToMqttCall(hc.server, hc.rosProvider, hc.prefix, "/mower_logic/set_parameters", &dynamic_reconfigure.Reconfigure{}, &dynamic_reconfigure.ReconfigureReq{}, &dynamic_reconfigure.ReconfigureRes{})
-	subscribeToMqttCall(hc.server, hc.rosProvider, hc.prefix, "/mower_service/mow_enabled", &mower_msgs.MowerControlSrv{}, &mower_msgs.MowerControlSrvReq{}, &mower_msgs.MowerControlSrvRes{})
-	subscribeToMqttCall(hc.server, hc.rosProvider, hc.prefix, "/mower_service/start_in_area", &mower_msgs.StartInAreaSrv{}, &mower_msgs.StartInAreaSrvReq{}, &mower_msgs.StartInAreaSrvRes{})
+	for _, service := range ServiceNames() {
+		subscribeToMqttCall(hc.server, hc.rosProvider, hc.prefix, service)
+	}
 }
 
-func subscribeToMqttCall[SRV any, REQ any, RES any](server *mqtt.Server, rosProvider types2.IRosProvider, prefix, topic string, srv SRV, req REQ, res RES) {
-	err := server.Subscribe(prefix+"/call"+topic, 1, func(cl *mqtt.Client, sub packets.Subscription, pk packets.Packet) {
-		logrus.Info("Received " + topic)
-		var newReq = reflect.New(reflect.TypeOf(req).Elem()).Interface()
-		err := json.Unmarshal(pk.Payload, newReq)
+// subscribeToMqttCall calls service when a request is received on prefix/call/service, the response is published on
+// prefix/response/service
+func subscribeToMqttCall(server *mqtt.Server, rosProvider types2.IRosProvider, prefix, service string) {
+	err := server.Subscribe(prefix+"/call"+service, 1, func(cl *mqtt.Client, sub packets.Subscription, pk packets.Packet) {
+		mqttLog.Info("Received " + service)
+		res, err := CallRegisteredService(context.Background(), rosProvider, service, func(req any) error {
+			if len(pk.Payload) == 0 {
+				return nil
+			}
+			return json.Unmarshal(pk.Payload, req)
+		})
+		if err != nil {
+			mqttLog.Error(xerrors.Errorf("Failed to call %s: %w", service, err))
+			return
+		}
+		resJson, err := json.Marshal(res)
 		if err != nil {
-			logrus.Error(xerrors.Errorf("Failed to unmarshal %s: %w", topic, err))
+			mqttLog.Error(xerrors.Errorf("Failed to marshal %s response: %w", service, err))
 			return
 		}
-		err = rosProvider.CallService(context.Background(), topic, srv, newReq, res)
+		err = server.Publish(prefix+"/response"+service, resJson, false, 0)
 		if err != nil {
-			logrus.Error(xerrors.Errorf("Failed to call %s: %w", topic, err))
+			mqttLog.Error(xerrors.Errorf("Failed to publish %s response: %w", service, err))
 		}
 	})
 	if err != nil {
-		logrus.Error(xerrors.Errorf("Failed to subscribe to %s: %w", topic, err))
+		mqttLog.Error(xerrors.Errorf("Failed to subscribe to %s: %w", service, err))
 	}
 }
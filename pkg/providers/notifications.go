@@ -0,0 +1,404 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+var notificationsLog = Logs.Logger("notifications")
+
+// NotificationEvents are the event types that can be routed to a channel
+var NotificationEvents = []string{
+	"alert.raised", "alert.cleared", "state.changed", "mowing.started", "mowing.finished", "test",
+}
+
+// notifiers send an event to a channel, indexed by channel type
+var notifiers = map[string]func(ctx context.Context, channel types.NotificationChannel, event types.NotificationEvent) error{
+	"webhook": sendWebhook,
+	"smtp":    sendEmail,
+	"ntfy":    sendNtfy,
+	"gotify":  sendGotify,
+}
+
+const maxDeliveries = 100
+
+// NotificationProvider delivers the alert and mower state events to the channels stored as JSON in
+// system.notifications.channels. Failed deliveries are retried system.notifications.retries times, waiting
+// system.notifications.retryDelay milliseconds doubled after each attempt. The last deliveries are kept in memory.
+type NotificationProvider struct {
+	rosProvider types.IRosProvider
+	dbProvider  types.IDBProvider
+	mtx         sync.Mutex
+	channels    []types.NotificationChannel
+	deliveries  []types.NotificationDelivery
+	state       string
+}
+
+func NewNotificationProvider(rosProvider types.IRosProvider, dbProvider types.IDBProvider, alertProvider types.IAlertProvider) *NotificationProvider {
+	n := &NotificationProvider{
+		rosProvider: rosProvider,
+		dbProvider:  dbProvider,
+	}
+	value, err := dbProvider.Get("system.notifications.channels")
+	if err == nil {
+		err = json.Unmarshal(value, &n.channels)
+		if err != nil {
+			notificationsLog.Error(xerrors.Errorf("failed to read notification channels: %w", err))
+		}
+	}
+	alertProvider.SubscribeAlerts("notifications", n.onAlert)
+	err = rosProvider.Subscribe("/mower_logic/current_state", "notifications", n.onStatus)
+	if err != nil {
+		notificationsLog.Error(xerrors.Errorf("failed to subscribe to /mower_logic/current_state: %w", err))
+	}
+	return n
+}
+
+func (n *NotificationProvider) Channels() ([]types.NotificationChannel, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return append([]types.NotificationChannel{}, n.channels...), nil
+}
+
+func (n *NotificationProvider) SetChannels(channels []types.NotificationChannel) error {
+	err := validateNotificationChannels(channels)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(channels)
+	if err != nil {
+		return err
+	}
+	err = n.dbProvider.Set("system.notifications.channels", value)
+	if err != nil {
+		return err
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.channels = channels
+	return nil
+}
+
+// Notify delivers event in the background to the enabled channels routing its type
+func (n *NotificationProvider) Notify(event types.NotificationEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	n.mtx.Lock()
+	channels := lo.Filter(n.channels, func(channel types.NotificationChannel, _ int) bool {
+		return channel.Enabled && (len(channel.Events) == 0 || lo.Contains(channel.Events, event.Type))
+	})
+	n.mtx.Unlock()
+	if len(channels) == 0 {
+		return
+	}
+	retries, err := GetInt(n.dbProvider, "system.notifications.retries")
+	if err != nil {
+		notificationsLog.Error(xerrors.Errorf("failed to read notification retries: %w", err))
+	}
+	retryDelay, err := GetInt(n.dbProvider, "system.notifications.retryDelay")
+	if err != nil {
+		notificationsLog.Error(xerrors.Errorf("failed to read notification retry delay: %w", err))
+	}
+	for _, channel := range channels {
+		go n.deliver(channel, event, retries, time.Duration(retryDelay)*time.Millisecond)
+	}
+}
+
+// Test sends a test event to the channel without retrying
+func (n *NotificationProvider) Test(channelID string) (types.NotificationDelivery, error) {
+	n.mtx.Lock()
+	channel, ok := lo.Find(n.channels, func(channel types.NotificationChannel) bool {
+		return channel.ID == channelID
+	})
+	n.mtx.Unlock()
+	if !ok {
+		return types.NotificationDelivery{}, xerrors.Errorf("%s: %w", channelID, types.ErrUnknownChannel)
+	}
+	return n.deliver(channel, types.NotificationEvent{
+		Type:     "test",
+		Title:    "OpenMower test notification",
+		Message:  "This is a test notification from the OpenMower GUI",
+		Severity: "info",
+		Time:     time.Now(),
+	}, 0, 0), nil
+}
+
+// Deliveries returns the last deliveries, newest first
+func (n *NotificationProvider) Deliveries() []types.NotificationDelivery {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	deliveries := make([]types.NotificationDelivery, len(n.deliveries))
+	for i, delivery := range n.deliveries {
+		deliveries[len(n.deliveries)-1-i] = delivery
+	}
+	return deliveries
+}
+
+func (n *NotificationProvider) deliver(channel types.NotificationChannel, event types.NotificationEvent, retries int, retryDelay time.Duration) types.NotificationDelivery {
+	delivery := types.NotificationDelivery{Channel: channel.ID, Event: event.Type, Time: time.Now()}
+	var err error
+	for delivery.Attempts <= retries {
+		if delivery.Attempts > 0 {
+			time.Sleep(retryDelay)
+			retryDelay *= 2
+		}
+		delivery.Attempts++
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = notifiers[channel.Type](ctx, channel, event)
+		cancel()
+		if err == nil {
+			break
+		}
+	}
+	delivery.Success = err == nil
+	if err != nil {
+		delivery.Error = err.Error()
+		notificationsLog.Error(xerrors.Errorf("failed to send %s notification to %s: %w", event.Type, channel.ID, err))
+	}
+	n.mtx.Lock()
+	n.deliveries = append(n.deliveries, delivery)
+	if len(n.deliveries) > maxDeliveries {
+		n.deliveries = n.deliveries[len(n.deliveries)-maxDeliveries:]
+	}
+	n.mtx.Unlock()
+	return delivery
+}
+
+func (n *NotificationProvider) onAlert(event types.AlertEvent) {
+	if event.Type == types.AlertAcknowledged {
+		return
+	}
+	message := event.Alert.Name
+	if event.Type == types.AlertCleared {
+		message += " (cleared)"
+	}
+	n.Notify(types.NotificationEvent{
+		Type:     "alert." + event.Type,
+		Title:    "OpenMower alert",
+		Message:  message,
+		Severity: event.Alert.Severity,
+		Data:     event.Alert,
+	})
+}
+
+// onStatus notifies the state changes of the mower, the replayed states are ignored
+func (n *NotificationProvider) onStatus(msg []byte) {
+	if n.rosProvider.Replaying() {
+		return
+	}
+	var status mower_msgs.HighLevelStatus
+	err := json.Unmarshal(msg, &status)
+	if err != nil {
+		return
+	}
+	n.mtx.Lock()
+	previous := n.state
+	n.state = status.StateName
+	n.mtx.Unlock()
+	if previous == "" || previous == status.StateName {
+		return
+	}
+	data := map[string]string{"previous": previous, "state": status.StateName}
+	n.Notify(types.NotificationEvent{
+		Type:     "state.changed",
+		Title:    "OpenMower state changed",
+		Message:  "The mower is now " + status.StateName,
+		Severity: "info",
+		Data:     data,
+	})
+	switch {
+	case status.StateName == "MOWING" && previous != "MOWING":
+		n.Notify(types.NotificationEvent{
+			Type: "mowing.started", Title: "OpenMower started mowing", Message: "The mower started mowing",
+			Severity: "info", Data: data,
+		})
+	case previous == "MOWING" && !status.Emergency:
+		n.Notify(types.NotificationEvent{
+			Type: "mowing.finished", Title: "OpenMower finished mowing", Message: "The mower stopped mowing and is now " + status.StateName,
+			Severity: "info", Data: data,
+		})
+	}
+}
+
+// renderNotificationBody renders the body template of the channel, the json function encodes a value as JSON
+func renderNotificationBody(channel types.NotificationChannel, event types.NotificationEvent) ([]byte, error) {
+	body := channel.Body
+	if body == "" {
+		body = "{{json .}}"
+	}
+	tmpl, err := template.New(channel.ID).Funcs(template.FuncMap{
+		"json": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+	}).Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, event)
+	return buffer.Bytes(), err
+}
+
+func sendWebhook(ctx context.Context, channel types.NotificationChannel, event types.NotificationEvent) error {
+	body, err := renderNotificationBody(channel, event)
+	if err != nil {
+		return err
+	}
+	method := channel.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	for key, value := range channel.Headers {
+		headers[key] = value
+	}
+	return sendHTTP(ctx, method, channel.URL, headers, body)
+}
+
+func sendNtfy(ctx context.Context, channel types.NotificationChannel, event types.NotificationEvent) error {
+	headers := map[string]string{"Title": event.Title, "Tags": event.Type}
+	if channel.Priority > 0 {
+		headers["Priority"] = strconv.Itoa(channel.Priority)
+	}
+	if channel.Token != "" {
+		headers["Authorization"] = "Bearer " + channel.Token
+	}
+	return sendHTTP(ctx, http.MethodPost, channel.URL, headers, []byte(event.Message))
+}
+
+func sendGotify(ctx context.Context, channel types.NotificationChannel, event types.NotificationEvent) error {
+	body, err := json.Marshal(map[string]any{
+		"title":    event.Title,
+		"message":  event.Message,
+		"priority": channel.Priority,
+	})
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Content-Type": "application/json", "X-Gotify-Key": channel.Token}
+	return sendHTTP(ctx, http.MethodPost, strings.TrimSuffix(channel.URL, "/")+"/message", headers, body)
+}
+
+func sendHTTP(ctx context.Context, method string, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		content, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return xerrors.Errorf("%s %s returned %d: %s", method, url, res.StatusCode, strings.TrimSpace(string(content)))
+	}
+	return nil
+}
+
+func sendEmail(ctx context.Context, channel types.NotificationChannel, event types.NotificationEvent) error {
+	body := []byte(event.Message)
+	if channel.Body != "" {
+		var err error
+		body, err = renderNotificationBody(channel, event)
+		if err != nil {
+			return err
+		}
+	}
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", channel.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(channel.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", event.Title)
+	fmt.Fprintf(&message, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	message.Write(body)
+	var auth smtp.Auth
+	if channel.Username != "" {
+		auth = smtp.PlainAuth("", channel.Username, channel.Password, channel.Host)
+	}
+	addr := channel.Host + ":" + strconv.Itoa(channel.Port)
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, channel.From, channel.To, message.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func validateNotificationChannels(channels []types.NotificationChannel) error {
+	fields := map[string]string{}
+	ids := map[string]bool{}
+	for i, channel := range channels {
+		prefix := fmt.Sprintf("channels[%d].", i)
+		if channel.ID == "" {
+			fields[prefix+"id"] = "is required"
+		} else if ids[channel.ID] {
+			fields[prefix+"id"] = "is duplicated"
+		}
+		ids[channel.ID] = true
+		if _, ok := notifiers[channel.Type]; !ok {
+			fields[prefix+"type"] = "must be one of webhook, smtp, ntfy, gotify"
+		}
+		for _, event := range channel.Events {
+			if !lo.Contains(NotificationEvents, event) {
+				fields[prefix+"events"] = "unknown event " + event
+			}
+		}
+		switch channel.Type {
+		case "smtp":
+			if channel.Host == "" {
+				fields[prefix+"host"] = "is required"
+			}
+			if channel.Port <= 0 {
+				fields[prefix+"port"] = "is required"
+			}
+			if channel.From == "" {
+				fields[prefix+"from"] = "is required"
+			}
+			if len(channel.To) == 0 {
+				fields[prefix+"to"] = "is required"
+			}
+		case "webhook", "ntfy", "gotify":
+			if !strings.HasPrefix(channel.URL, "http://") && !strings.HasPrefix(channel.URL, "https://") {
+				fields[prefix+"url"] = "must be an http or https URL"
+			}
+		}
+		if channel.Type == "gotify" && channel.Token == "" {
+			fields[prefix+"token"] = "is required"
+		}
+		if channel.Body != "" {
+			_, err := template.New("").Funcs(template.FuncMap{"json": func(any) string { return "" }}).Parse(channel.Body)
+			if err != nil {
+				fields[prefix+"body"] = err.Error()
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return &types.ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package providers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	path    string
+	headers http.Header
+	body    string
+}
+
+// newNotificationServer records the requests it receives, the first failures requests are answered with a 500
+func newNotificationServer(t *testing.T, failures int) (*httptest.Server, func() []recordedRequest) {
+	var mtx sync.Mutex
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mtx.Lock()
+		defer mtx.Unlock()
+		requests = append(requests, recordedRequest{path: r.URL.Path, headers: r.Header, body: string(body)})
+		if len(requests) <= failures {
+			w.WriteHeader(500)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []recordedRequest {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]recordedRequest{}, requests...)
+	}
+}
+
+func newNotificationProvider(ros *fakes.RosProvider, channels ...types.NotificationChannel) (*NotificationProvider, error) {
+	db := fakes.NewDBProvider(map[string]string{
+		"system.notifications.retries":    "2",
+		"system.notifications.retryDelay": "1",
+	})
+	notifications := NewNotificationProvider(ros, db, NewAlertProvider(ros, db))
+	return notifications, notifications.SetChannels(channels)
+}
+
+func TestNotificationWebhookRetries(t *testing.T) {
+	server, requests := newNotificationServer(t, 2)
+	ros := fakes.NewRosProvider()
+	notifications, err := newNotificationProvider(ros, types.NotificationChannel{
+		ID: "hook", Type: "webhook", Enabled: true, URL: server.URL + "/hook", Events: []string{"mowing.finished"},
+		Headers: map[string]string{"X-Token": "secret"},
+		Body:    `{"text":{{json .Message}},"state":{{json .Data.state}}}`,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "MOWING"}))
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "DOCKING"}))
+	require.Eventually(t, func() bool {
+		return len(notifications.Deliveries()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	delivery := notifications.Deliveries()[0]
+	assert.Equal(t, "hook", delivery.Channel)
+	assert.Equal(t, "mowing.finished", delivery.Event)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.True(t, delivery.Success)
+	received := requests()
+	require.Len(t, received, 3)
+	assert.Equal(t, "/hook", received[2].path)
+	assert.Equal(t, "secret", received[2].headers.Get("X-Token"))
+	assert.JSONEq(t, `{"text":"The mower stopped mowing and is now DOCKING","state":"DOCKING"}`, received[2].body)
+}
+
+func TestNotificationPushChannels(t *testing.T) {
+	server, requests := newNotificationServer(t, 0)
+	notifications, err := newNotificationProvider(fakes.NewRosProvider(),
+		types.NotificationChannel{ID: "ntfy", Type: "ntfy", URL: server.URL + "/mower", Token: "tk", Priority: 4},
+		types.NotificationChannel{ID: "gotify", Type: "gotify", URL: server.URL + "/", Token: "app", Priority: 5},
+	)
+	require.NoError(t, err)
+
+	delivery, err := notifications.Test("ntfy")
+	require.NoError(t, err)
+	assert.True(t, delivery.Success, delivery.Error)
+	delivery, err = notifications.Test("gotify")
+	require.NoError(t, err)
+	assert.True(t, delivery.Success, delivery.Error)
+	_, err = notifications.Test("unknown")
+	assert.True(t, errors.Is(err, types.ErrUnknownChannel))
+
+	received := requests()
+	require.Len(t, received, 2)
+	assert.Equal(t, "/mower", received[0].path)
+	assert.Equal(t, "OpenMower test notification", received[0].headers.Get("Title"))
+	assert.Equal(t, "4", received[0].headers.Get("Priority"))
+	assert.Equal(t, "Bearer tk", received[0].headers.Get("Authorization"))
+	assert.Equal(t, "This is a test notification from the OpenMower GUI", received[0].body)
+	assert.Equal(t, "/message", received[1].path)
+	assert.Equal(t, "app", received[1].headers.Get("X-Gotify-Key"))
+	assert.JSONEq(t, `{"title":"OpenMower test notification","message":"This is a test notification from the OpenMower GUI","priority":5}`, received[1].body)
+}
+
+// serveSMTP answers a single SMTP session and returns the received message
+func serveSMTP(t *testing.T) (string, int, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		write := func(line string) {
+			_, _ = conn.Write([]byte(line + "\r\n"))
+		}
+		write("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				write("250 localhost")
+			case command == "DATA":
+				write("354 end data with <CR><LF>.<CR><LF>")
+				for {
+					line, err = reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				write("250 OK")
+			case command == "QUIT":
+				write("221 bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func TestNotificationEmail(t *testing.T) {
+	host, port, messages := serveSMTP(t)
+	notifications, err := newNotificationProvider(fakes.NewRosProvider(), types.NotificationChannel{
+		ID: "mail", Type: "smtp", Host: host, Port: port, From: "mower@example.com", To: []string{"me@example.com"},
+	})
+	require.NoError(t, err)
+
+	delivery, err := notifications.Test("mail")
+	require.NoError(t, err)
+	assert.True(t, delivery.Success, delivery.Error)
+	message := <-messages
+	assert.Contains(t, message, "To: me@example.com\r\n")
+	assert.Contains(t, message, "Subject: OpenMower test notification\r\n")
+	assert.True(t, strings.HasSuffix(message, "\r\n\r\nThis is a test notification from the OpenMower GUI\r\n"))
+}
+
+func TestNotificationChannelsValidation(t *testing.T) {
+	_, err := newNotificationProvider(fakes.NewRosProvider(),
+		types.NotificationChannel{ID: "a", Type: "pager"},
+		types.NotificationChannel{ID: "a", Type: "webhook", URL: "ftp://host", Events: []string{"lunch"}, Body: "{{"},
+		types.NotificationChannel{ID: "b", Type: "smtp"},
+	)
+	var validationErr *types.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	fields := lo.Keys(validationErr.Fields)
+	sort.Strings(fields)
+	assert.Equal(t, []string{
+		"channels[0].type", "channels[1].body", "channels[1].events", "channels[1].id", "channels[1].url",
+		"channels[2].from", "channels[2].host", "channels[2].port", "channels[2].to",
+	}, fields)
+}
+
+func TestNotificationReplay(t *testing.T) {
+	server, requests := newNotificationServer(t, 0)
+	ros := fakes.NewRosProvider()
+	notifications, err := newNotificationProvider(ros, types.NotificationChannel{
+		ID: "hook", Type: "webhook", Enabled: true, URL: server.URL, Events: []string{"state.changed"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "IDLE"}))
+
+	// the recorded states are not notified and don't change the state compared to the live ones
+	ros.Replay(true)
+	ros.Inject("/mower_logic/current_state", []byte(`{"StateName":"MOWING"}`))
+	ros.Replay(false)
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "IDLE"}))
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "DOCKING"}))
+	require.Eventually(t, func() bool {
+		return len(notifications.Deliveries()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, requests(), 1)
+	assert.Equal(t, "state.changed", notifications.Deliveries()[0].Event)
+}
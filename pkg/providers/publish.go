@@ -0,0 +1,149 @@
+package providers
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgproc"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+// PublishProvider publishes messages to the whitelisted topics of system.ros.publish.topics. Entries are either a
+// topic or topic=type, the type of a topic without explicit type is taken from the ROS master. Publish rates are
+// limited per topic by system.ros.publish.rates (topic=messages per second) or system.ros.publish.defaultRate.
+// The teleop topic can't be whitelisted, velocities are only sent there through the teleop session.
+type PublishProvider struct {
+	rosProvider types.IRosProvider
+	dbProvider  types.IDBProvider
+	mtx         sync.Mutex
+	publishers  map[string]types.IRosPublisher
+	lastPublish map[string]time.Time
+	graphTypes  map[string]string
+}
+
+func NewPublishProvider(rosProvider types.IRosProvider, dbProvider types.IDBProvider) *PublishProvider {
+	return &PublishProvider{
+		rosProvider: rosProvider,
+		dbProvider:  dbProvider,
+		publishers:  make(map[string]types.IRosPublisher),
+		lastPublish: make(map[string]time.Time),
+		graphTypes:  make(map[string]string),
+	}
+}
+
+func (p *PublishProvider) Topics() ([]types.PublishTopic, error) {
+	defaultRate, err := GetFloat(p.dbProvider, "system.ros.publish.defaultRate")
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64)
+	for _, entry := range GetOptionalList(p.dbProvider, "system.ros.publish.rates") {
+		topic, rate, _ := strings.Cut(entry, "=")
+		rates[topic], err = strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid publish rate for %s: %w", topic, err)
+		}
+	}
+	var graphTopics []types.RosTopic
+	var topics []types.PublishTopic
+	for _, entry := range GetOptionalList(p.dbProvider, "system.ros.publish.topics") {
+		topic, msgType, _ := strings.Cut(entry, "=")
+		if topic == teleopTopic {
+			continue
+		}
+		if msgType == "" {
+			msgType = p.graphType(topic)
+		}
+		if msgType == "" {
+			if graphTopics == nil {
+				graphTopics, err = p.rosProvider.Topics()
+				if err != nil {
+					return nil, err
+				}
+			}
+			graphTopic, ok := lo.Find(graphTopics, func(t types.RosTopic) bool {
+				return t.Name == topic
+			})
+			if ok {
+				msgType = graphTopic.Type
+				p.mtx.Lock()
+				p.graphTypes[topic] = msgType
+				p.mtx.Unlock()
+			}
+		}
+		rate, ok := rates[topic]
+		if !ok {
+			rate = defaultRate
+		}
+		topics = append(topics, types.PublishTopic{Topic: topic, Type: msgType, Rate: rate})
+	}
+	return topics, nil
+}
+
+func (p *PublishProvider) graphType(topic string) string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.graphTypes[topic]
+}
+
+// Message returns a new message of the type of topic
+func (p *PublishProvider) Message(topic string) (any, error) {
+	publishTopic, err := p.topic(topic)
+	if err != nil {
+		return nil, err
+	}
+	return NewMessage(publishTopic.Type)
+}
+
+func (p *PublishProvider) Publish(topic string, msg any) error {
+	publishTopic, err := p.topic(topic)
+	if err != nil {
+		return err
+	}
+	msgType, err := msgproc.Type(reflect.ValueOf(msg).Elem().Interface())
+	if err != nil {
+		return err
+	}
+	if msgType != publishTopic.Type {
+		return xerrors.Errorf("topic %s expects %s messages, got %s", topic, publishTopic.Type, msgType)
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	now := time.Now()
+	if publishTopic.Rate > 0 && now.Sub(p.lastPublish[topic]) < time.Duration(float64(time.Second)/publishTopic.Rate) {
+		return types.ErrRateLimited
+	}
+	publisher, ok := p.publishers[topic]
+	if !ok {
+		publisher, err = p.rosProvider.Publisher(topic, msg)
+		if err != nil {
+			return err
+		}
+		p.publishers[topic] = publisher
+	}
+	publisher.Write(msg)
+	p.lastPublish[topic] = now
+	return nil
+}
+
+func (p *PublishProvider) topic(topic string) (types.PublishTopic, error) {
+	topics, err := p.Topics()
+	if err != nil {
+		return types.PublishTopic{}, err
+	}
+	publishTopic, ok := lo.Find(topics, func(t types.PublishTopic) bool {
+		return t.Topic == topic
+	})
+	if !ok {
+		return types.PublishTopic{}, xerrors.Errorf("%s: %w", topic, types.ErrTopicNotAllowed)
+	}
+	if publishTopic.Type == "" {
+		return types.PublishTopic{}, xerrors.Errorf("unknown message type for topic %s", topic)
+	}
+	return publishTopic, nil
+}
@@ -0,0 +1,60 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/std_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	ros.SetGraph(nil, []types.RosTopic{{Name: "/cmd_vel", Type: "geometry_msgs/Twist"}}, nil)
+	db := fakes.NewDBProvider(map[string]string{
+		"system.ros.publish.topics":      "/cmd_vel,/ll/_service/enabled=std_msgs/Bool,/unknown,/joy_vel=geometry_msgs/Twist",
+		"system.ros.publish.rates":       "/ll/_service/enabled=0",
+		"system.ros.publish.defaultRate": "1",
+	})
+	publish := NewPublishProvider(ros, db)
+
+	topics, err := publish.Topics()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.PublishTopic{
+		{Topic: "/cmd_vel", Type: "geometry_msgs/Twist", Rate: 1},
+		{Topic: "/ll/_service/enabled", Type: "std_msgs/Bool", Rate: 0},
+		{Topic: "/unknown", Type: "", Rate: 1},
+	}, topics)
+
+	msg, err := publish.Message("/cmd_vel")
+	assert.NoError(t, err)
+	assert.IsType(t, &geometry_msgs.Twist{}, msg)
+	// the teleop topic is never whitelisted
+	_, err = publish.Message("/joy_vel")
+	assert.True(t, errors.Is(err, types.ErrTopicNotAllowed))
+	assert.True(t, errors.Is(publish.Publish("/joy_vel", &geometry_msgs.Twist{}), types.ErrTopicNotAllowed))
+	_, err = publish.Message("/unknown")
+	assert.EqualError(t, err, "unknown message type for topic /unknown")
+
+	assert.NoError(t, publish.Publish("/cmd_vel", &geometry_msgs.Twist{Linear: geometry_msgs.Vector3{X: 1}}))
+	assert.True(t, errors.Is(publish.Publish("/cmd_vel", &geometry_msgs.Twist{}), types.ErrRateLimited))
+	assert.Len(t, ros.Published("/cmd_vel"), 1)
+	assert.EqualError(t, publish.Publish("/cmd_vel", &std_msgs.Bool{}), "topic /cmd_vel expects geometry_msgs/Twist messages, got std_msgs/Bool")
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, publish.Publish("/ll/_service/enabled", &std_msgs.Bool{Data: true}))
+	}
+	assert.Len(t, ros.Published("/ll/_service/enabled"), 3)
+}
+
+func TestMessageTypes(t *testing.T) {
+	msg, err := NewMessage("mower_msgs/HighLevelStatus")
+	assert.NoError(t, err)
+	assert.NotNil(t, msg)
+	_, err = NewMessage("mower_msgs/Unknown")
+	assert.EqualError(t, err, "unknown message type mower_msgs/Unknown")
+	assert.Contains(t, MessageTypes(), "xbot_msgs/AbsolutePose")
+}
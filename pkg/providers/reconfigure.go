@@ -0,0 +1,467 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/dynamic_reconfigure"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+var reconfigureLog = Logs.Logger("reconfigure")
+
+type ReconfigureProvider struct {
+	rosProvider  types.IRosProvider
+	mtx          sync.Mutex
+	watched      map[string]bool
+	descriptions map[string]*dynamic_reconfigure.ConfigDescription
+	configs      map[string]*dynamic_reconfigure.Config
+}
+
+func NewReconfigureProvider(rosProvider types.IRosProvider) *ReconfigureProvider {
+	return &ReconfigureProvider{
+		rosProvider:  rosProvider,
+		watched:      make(map[string]bool),
+		descriptions: make(map[string]*dynamic_reconfigure.ConfigDescription),
+		configs:      make(map[string]*dynamic_reconfigure.Config),
+	}
+}
+
+// watch subscribes to the parameter_descriptions and parameter_updates topics of the node, both are latched so the
+// last published values are received right after subscribing
+func (r *ReconfigureProvider) watch(node string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.watched[node] {
+		return nil
+	}
+	err := r.rosProvider.RegisterTopic(node+"/parameter_descriptions", &dynamic_reconfigure.ConfigDescription{})
+	if err != nil {
+		return err
+	}
+	err = r.rosProvider.RegisterTopic(node+"/parameter_updates", &dynamic_reconfigure.Config{})
+	if err != nil {
+		return err
+	}
+	err = r.rosProvider.Subscribe(node+"/parameter_descriptions", "reconfigure", func(msg []byte) {
+		var description dynamic_reconfigure.ConfigDescription
+		err := json.Unmarshal(msg, &description)
+		if err != nil {
+			reconfigureLog.Error(xerrors.Errorf("failed to unmarshal parameter descriptions of %s: %w", node, err))
+			return
+		}
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		r.descriptions[node] = &description
+	})
+	if err != nil {
+		return err
+	}
+	err = r.rosProvider.Subscribe(node+"/parameter_updates", "reconfigure", func(msg []byte) {
+		var config dynamic_reconfigure.Config
+		err := json.Unmarshal(msg, &config)
+		if err != nil {
+			reconfigureLog.Error(xerrors.Errorf("failed to unmarshal parameter updates of %s: %w", node, err))
+			return
+		}
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		r.configs[node] = &config
+	})
+	if err != nil {
+		return err
+	}
+	r.watched[node] = true
+	return nil
+}
+
+func (r *ReconfigureProvider) Parameters(ctx context.Context, node string) ([]types.ReconfigureParameter, error) {
+	node = normalizeNodeName(node)
+	err := r.watch(node)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for {
+		r.mtx.Lock()
+		description, hasDescription := r.descriptions[node]
+		config := r.configs[node]
+		r.mtx.Unlock()
+		if hasDescription {
+			return buildParameters(description, config), nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, xerrors.Errorf("no parameter descriptions received from %s", node)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (r *ReconfigureProvider) SetParameters(ctx context.Context, node string, values map[string]any) ([]types.ReconfigureParameter, error) {
+	node = normalizeNodeName(node)
+	parameters, err := r.Parameters(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	var config dynamic_reconfigure.Config
+	fields := map[string]string{}
+	for name, value := range values {
+		parameter, found := lo.Find(parameters, func(p types.ReconfigureParameter) bool {
+			return p.Name == name
+		})
+		if !found {
+			fields[name] = "unknown parameter"
+			continue
+		}
+		errMsg := validateParameter(parameter, value)
+		if errMsg != "" {
+			fields[name] = errMsg
+			continue
+		}
+		switch parameter.Type {
+		case "bool":
+			config.Bools = append(config.Bools, dynamic_reconfigure.BoolParameter{Name: name, Value: value.(bool)})
+		case "int":
+			config.Ints = append(config.Ints, dynamic_reconfigure.IntParameter{Name: name, Value: int32(value.(float64))})
+		case "double":
+			config.Doubles = append(config.Doubles, dynamic_reconfigure.DoubleParameter{Name: name, Value: value.(float64)})
+		case "str":
+			config.Strs = append(config.Strs, dynamic_reconfigure.StrParameter{Name: name, Value: value.(string)})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &types.ValidationError{Fields: fields}
+	}
+	var res dynamic_reconfigure.ReconfigureRes
+	err = r.rosProvider.CallService(ctx, node+"/set_parameters", &dynamic_reconfigure.Reconfigure{}, &dynamic_reconfigure.ReconfigureReq{Config: config}, &res)
+	if err != nil {
+		return nil, err
+	}
+	r.mtx.Lock()
+	r.configs[node] = &res.Config
+	description := r.descriptions[node]
+	r.mtx.Unlock()
+	return buildParameters(description, &res.Config), nil
+}
+
+func validateParameter(parameter types.ReconfigureParameter, value any) string {
+	switch parameter.Type {
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	case "int", "double":
+		number, ok := value.(float64)
+		if !ok {
+			return "must be a number"
+		}
+		if parameter.Type == "int" && number != math.Trunc(number) {
+			return "must be an integer"
+		}
+		if parameter.Min != nil && number < toFloat(parameter.Min) {
+			return fmt.Sprintf("must be greater than or equal to %v", parameter.Min)
+		}
+		if parameter.Max != nil && number > toFloat(parameter.Max) {
+			return fmt.Sprintf("must be less than or equal to %v", parameter.Max)
+		}
+	case "str":
+		if _, ok := value.(string); !ok {
+			return "must be a string"
+		}
+	}
+	if len(parameter.Enum) > 0 && !lo.ContainsBy(parameter.Enum, func(e types.ReconfigureEnumValue) bool {
+		return fmt.Sprint(e.Value) == fmt.Sprint(value)
+	}) {
+		return "must be one of " + strings.Join(lo.Map(parameter.Enum, func(e types.ReconfigureEnumValue, _ int) string {
+			return fmt.Sprint(e.Value)
+		}), ", ")
+	}
+	return ""
+}
+
+func buildParameters(description *dynamic_reconfigure.ConfigDescription, config *dynamic_reconfigure.Config) []types.ReconfigureParameter {
+	var parameters []types.ReconfigureParameter
+	for _, group := range description.Groups {
+		for _, param := range group.Parameters {
+			parameter := types.ReconfigureParameter{
+				Name:        param.Name,
+				Type:        param.Type,
+				Group:       group.Name,
+				Description: param.Description,
+				Level:       param.Level,
+				Min:         configValue(&description.Min, param.Type, param.Name),
+				Max:         configValue(&description.Max, param.Type, param.Name),
+				Default:     configValue(&description.Dflt, param.Type, param.Name),
+				Enum:        parseEnum(param.EditMethod),
+			}
+			if param.Type != "int" && param.Type != "double" {
+				parameter.Min = nil
+				parameter.Max = nil
+			}
+			parameter.Value = parameter.Default
+			if config != nil {
+				if value := configValue(config, param.Type, param.Name); value != nil {
+					parameter.Value = value
+				}
+			}
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters
+}
+
+func configValue(config *dynamic_reconfigure.Config, paramType string, name string) any {
+	switch paramType {
+	case "bool":
+		if p, ok := lo.Find(config.Bools, func(p dynamic_reconfigure.BoolParameter) bool { return p.Name == name }); ok {
+			return p.Value
+		}
+	case "int":
+		if p, ok := lo.Find(config.Ints, func(p dynamic_reconfigure.IntParameter) bool { return p.Name == name }); ok {
+			return p.Value
+		}
+	case "double":
+		if p, ok := lo.Find(config.Doubles, func(p dynamic_reconfigure.DoubleParameter) bool { return p.Name == name }); ok {
+			return p.Value
+		}
+	case "str":
+		if p, ok := lo.Find(config.Strs, func(p dynamic_reconfigure.StrParameter) bool { return p.Name == name }); ok {
+			return p.Value
+		}
+	}
+	return nil
+}
+
+// parseEnum parses the edit_method of a parameter, it is a python dict repr like
+// {'enum': [{'name': 'Small', 'value': 0, 'description': 'A small constant', ...}], 'enum_description': '...'}
+func parseEnum(editMethod string) []types.ReconfigureEnumValue {
+	if editMethod == "" {
+		return nil
+	}
+	parser := &pythonLiteralParser{input: editMethod}
+	value, err := parser.parse()
+	if err != nil {
+		reconfigureLog.Warn(xerrors.Errorf("failed to parse edit method %s: %w", editMethod, err))
+		return nil
+	}
+	// the literal is converted to JSON to decode it like the other messages
+	editMethodJson, err := json.Marshal(value)
+	if err != nil {
+		reconfigureLog.Warn(xerrors.Errorf("failed to parse edit method %s: %w", editMethod, err))
+		return nil
+	}
+	var parsed struct {
+		Enum []types.ReconfigureEnumValue `json:"enum"`
+	}
+	err = json.Unmarshal(editMethodJson, &parsed)
+	if err != nil {
+		reconfigureLog.Warn(xerrors.Errorf("failed to parse edit method %s: %w", editMethod, err))
+		return nil
+	}
+	return parsed.Enum
+}
+
+// pythonLiteralParser parses the python literals written by repr: dicts with string keys, lists, tuples, strings,
+// numbers, booleans and None
+type pythonLiteralParser struct {
+	input string
+	pos   int
+}
+
+func (p *pythonLiteralParser) parse() (any, error) {
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.input) {
+		return nil, xerrors.Errorf("unexpected %q at %d", p.input[p.pos], p.pos)
+	}
+	return value, nil
+}
+
+func (p *pythonLiteralParser) skipSpaces() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\r\n", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *pythonLiteralParser) value() (any, error) {
+	p.skipSpaces()
+	if p.pos == len(p.input) {
+		return nil, xerrors.New("unexpected end of literal")
+	}
+	switch c := p.input[p.pos]; {
+	case c == '{':
+		return p.dict()
+	case c == '[':
+		return p.sequence(']')
+	case c == '(':
+		return p.sequence(')')
+	case c == '\'' || c == '"':
+		return p.string()
+	case (c == 'u' || c == 'b') && p.pos+1 < len(p.input) && (p.input[p.pos+1] == '\'' || p.input[p.pos+1] == '"'):
+		p.pos++
+		return p.string()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+	for literal, value := range map[string]any{"True": true, "False": false, "None": nil} {
+		if strings.HasPrefix(p.input[p.pos:], literal) {
+			p.pos += len(literal)
+			return value, nil
+		}
+	}
+	return nil, xerrors.Errorf("unexpected %q at %d", p.input[p.pos], p.pos)
+}
+
+func (p *pythonLiteralParser) dict() (any, error) {
+	dict := map[string]any{}
+	p.pos++
+	for {
+		p.skipSpaces()
+		if p.pos < len(p.input) && p.input[p.pos] == '}' {
+			p.pos++
+			return dict, nil
+		}
+		key, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, xerrors.Errorf("dict key %v is not a string", key)
+		}
+		err = p.expect(':')
+		if err != nil {
+			return nil, err
+		}
+		dict[keyString], err = p.value()
+		if err != nil {
+			return nil, err
+		}
+		if !p.separator('}') {
+			return nil, xerrors.Errorf("expected , or } at %d", p.pos)
+		}
+	}
+}
+
+func (p *pythonLiteralParser) sequence(end byte) (any, error) {
+	sequence := []any{}
+	p.pos++
+	for {
+		p.skipSpaces()
+		if p.pos < len(p.input) && p.input[p.pos] == end {
+			p.pos++
+			return sequence, nil
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, item)
+		if !p.separator(end) {
+			return nil, xerrors.Errorf("expected , or %c at %d", end, p.pos)
+		}
+	}
+}
+
+// separator consumes the comma between two items, the end of the container is left to the caller
+func (p *pythonLiteralParser) separator(end byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == ',' {
+		p.pos++
+		return true
+	}
+	return p.pos < len(p.input) && p.input[p.pos] == end
+}
+
+func (p *pythonLiteralParser) expect(c byte) error {
+	p.skipSpaces()
+	if p.pos == len(p.input) || p.input[p.pos] != c {
+		return xerrors.Errorf("expected %c at %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+// string parses a quoted string, repr quotes it with " when it contains a ' and escapes the other quote
+func (p *pythonLiteralParser) string() (any, error) {
+	quote := p.input[p.pos]
+	p.pos++
+	var builder strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return builder.String(), nil
+		case c != '\\':
+			builder.WriteByte(c)
+		case p.pos == len(p.input):
+			return nil, xerrors.New("unterminated string")
+		default:
+			escaped := p.input[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'r':
+				builder.WriteByte('\r')
+			case 'x', 'u', 'U':
+				size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[escaped]
+				if p.pos+size > len(p.input) {
+					return nil, xerrors.New("unterminated string")
+				}
+				code, err := strconv.ParseUint(p.input[p.pos:p.pos+size], 16, 32)
+				if err != nil {
+					return nil, xerrors.Errorf("invalid escape at %d: %w", p.pos, err)
+				}
+				builder.WriteRune(rune(code))
+				p.pos += size
+			default:
+				// \\, \' and \"
+				builder.WriteByte(escaped)
+			}
+		}
+	}
+	return nil, xerrors.New("unterminated string")
+}
+
+func (p *pythonLiteralParser) number() (any, error) {
+	start := p.pos
+	for p.pos < len(p.input) && strings.ContainsRune("+-.0123456789eEL", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	number, err := strconv.ParseFloat(strings.TrimSuffix(p.input[start:p.pos], "L"), 64)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid number at %d: %w", start, err)
+	}
+	return number, nil
+}
+
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case int32:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func normalizeNodeName(node string) string {
+	return "/" + strings.Trim(node, "/")
+}
@@ -0,0 +1,37 @@
+package providers
+
+import (
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseEnum(t *testing.T) {
+	enum := parseEnum("{'enum': [{'name': 'Small', 'type': 'int', 'value': 0, 'srcline': 7, 'description': 'A small constant', 'srcfile': 'cfg/Test.cfg', 'cconsttype': 'const int', 'ctype': 'int'}, {'name': 'Large', 'type': 'int', 'value': 1, 'srcline': 8, 'description': 'A large constant', 'srcfile': 'cfg/Test.cfg', 'cconsttype': 'const int', 'ctype': 'int'}], 'enum_description': 'Size'}")
+	assert.Equal(t, []types.ReconfigureEnumValue{
+		{Name: "Small", Value: float64(0), Description: "A small constant"},
+		{Name: "Large", Value: float64(1), Description: "A large constant"},
+	}, enum)
+	assert.Nil(t, parseEnum(""))
+
+	// repr quotes the strings containing an apostrophe with " and escapes the quotes of the strings containing both
+	enum = parseEnum(`{'enum': [{'name': 'Fast', 'value': 'fast', 'description': "The mower's top speed", 'enabled': True}, ` +
+		`{'name': u'Slow', 'value': -1.5, 'description': 'It\'s "slow"\n', 'previous': None, 'range': (0, 1L)}], 'enum_description': ''}`)
+	assert.Equal(t, []types.ReconfigureEnumValue{
+		{Name: "Fast", Value: "fast", Description: "The mower's top speed"},
+		{Name: "Slow", Value: -1.5, Description: "It's \"slow\"\n"},
+	}, enum)
+	assert.Nil(t, parseEnum("{'enum': [{'name': 'Broken}]}"))
+}
+
+func TestValidateParameter(t *testing.T) {
+	intParam := types.ReconfigureParameter{Name: "size", Type: "int", Min: int32(0), Max: int32(10)}
+	assert.Equal(t, "", validateParameter(intParam, float64(5)))
+	assert.Equal(t, "must be an integer", validateParameter(intParam, 5.5))
+	assert.Equal(t, "must be less than or equal to 10", validateParameter(intParam, float64(11)))
+	assert.Equal(t, "must be a number", validateParameter(intParam, "5"))
+	boolParam := types.ReconfigureParameter{Name: "enabled", Type: "bool"}
+	assert.Equal(t, "must be a boolean", validateParameter(boolParam, "true"))
+	enumParam := types.ReconfigureParameter{Name: "mode", Type: "int", Enum: []types.ReconfigureEnumValue{{Value: float64(0)}, {Value: float64(1)}}}
+	assert.Equal(t, "must be one of 0, 1", validateParameter(enumParam, float64(2)))
+}
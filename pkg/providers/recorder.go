@@ -0,0 +1,397 @@
+package providers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+var recorderLog = Logs.Logger("recorder")
+
+var recordingNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+\.bag$`)
+
+type recordingSession struct {
+	meta    types.Recording
+	writer  *bagWriter
+	maxSize int64
+	timer   *time.Timer
+}
+
+type bufferedMessage struct {
+	topic string
+	time  time.Time
+	msg   any
+}
+
+type RecorderProvider struct {
+	rosProvider types.IRosProvider
+	dbProvider  types.IDBProvider
+	mtx         sync.Mutex
+	current     *recordingSession
+	buffer      []bufferedMessage
+	emergency   bool
+	// stopping is the session being stopped, List shows it until its metadata is saved
+	stopping *recordingSession
+}
+
+func NewRecorderProvider(rosProvider types.IRosProvider, dbProvider types.IDBProvider) *RecorderProvider {
+	r := &RecorderProvider{
+		rosProvider: rosProvider,
+		dbProvider:  dbProvider,
+	}
+	enabled, err := dbProvider.Get("system.recorder.emergency.enabled")
+	if err != nil {
+		recorderLog.Error(xerrors.Errorf("failed to get system.recorder.emergency.enabled: %w", err))
+	} else if string(enabled) == "true" {
+		r.watchEmergency()
+	}
+	return r
+}
+
+func (r *RecorderProvider) Start(name string, topics []string) (types.Recording, error) {
+	maxDuration, err := GetInt(r.dbProvider, "system.recorder.maxDuration")
+	if err != nil {
+		return types.Recording{}, err
+	}
+	return r.start(name, topics, "manual", time.Duration(maxDuration)*time.Second, nil)
+}
+
+func (r *RecorderProvider) start(name string, topics []string, trigger string, maxDuration time.Duration, seed []bufferedMessage) (types.Recording, error) {
+	var err error
+	if len(topics) == 0 {
+		topics, err = GetList(r.dbProvider, "system.recorder.topics")
+		if err != nil {
+			return types.Recording{}, err
+		}
+	}
+	if name == "" {
+		name = trigger + "_" + time.Now().Format("2006-01-02_15-04-05")
+	}
+	if !strings.HasSuffix(name, ".bag") {
+		name += ".bag"
+	}
+	if !recordingNameRegexp.MatchString(name) {
+		return types.Recording{}, xerrors.Errorf("invalid recording name %s", name)
+	}
+	maxSizeMB, err := GetInt(r.dbProvider, "system.recorder.maxSizeMB")
+	if err != nil {
+		return types.Recording{}, err
+	}
+	directory, err := r.directory()
+	if err != nil {
+		return types.Recording{}, err
+	}
+	for _, topic := range topics {
+		_, err := r.rosProvider.MessageType(topic)
+		if err != nil {
+			return types.Recording{}, err
+		}
+	}
+
+	r.mtx.Lock()
+	if r.current != nil {
+		r.mtx.Unlock()
+		return types.Recording{}, xerrors.Errorf("recording %s is already running", r.current.meta.Name)
+	}
+	if _, err := os.Stat(filepath.Join(directory, name)); err == nil {
+		r.mtx.Unlock()
+		return types.Recording{}, xerrors.Errorf("recording %s already exists", name)
+	}
+	writer, err := newBagWriter(filepath.Join(directory, name))
+	if err != nil {
+		r.mtx.Unlock()
+		return types.Recording{}, err
+	}
+	session := &recordingSession{
+		meta: types.Recording{
+			Name:      name,
+			Topics:    topics,
+			Trigger:   trigger,
+			StartedAt: time.Now(),
+			Active:    true,
+		},
+		writer:  writer,
+		maxSize: int64(maxSizeMB) * 1024 * 1024,
+	}
+	for _, message := range seed {
+		err := writer.Write(message.topic, message.time, message.msg)
+		if err != nil {
+			recorderLog.Error(xerrors.Errorf("failed to write buffered message of %s: %w", message.topic, err))
+		}
+	}
+	r.current = session
+	r.mtx.Unlock()
+
+	// every message is recorded, the topics published faster than the subscribers poll are not thinned
+	for _, topic := range topics {
+		topic := topic
+		err := r.rosProvider.SubscribeQueued(topic, "recorder", func(msg []byte) {
+			r.record(session, topic, msg)
+		})
+		if err != nil {
+			recorderLog.Error(xerrors.Errorf("failed to subscribe to %s: %w", topic, err))
+		}
+	}
+	session.timer = time.AfterFunc(maxDuration, func() {
+		r.stop(session)
+	})
+	recorderLog.Info("Started recording " + name)
+	return session.meta, nil
+}
+
+func (r *RecorderProvider) record(session *recordingSession, topic string, msg []byte) {
+	instance, err := r.rosProvider.MessageType(topic)
+	if err != nil {
+		recorderLog.Error(err)
+		return
+	}
+	err = json.Unmarshal(msg, instance)
+	if err != nil {
+		recorderLog.Error(xerrors.Errorf("failed to unmarshal message of %s: %w", topic, err))
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.current != session {
+		return
+	}
+	err = session.writer.Write(topic, time.Now(), instance)
+	if err != nil {
+		recorderLog.Error(xerrors.Errorf("failed to record message of %s: %w", topic, err))
+		return
+	}
+	if session.writer.Size() > session.maxSize {
+		recorderLog.Info("Recording " + session.meta.Name + " reached its maximum size")
+		// stop unsubscribes the callback we are running in, it can't be called synchronously
+		go r.stop(session)
+	}
+}
+
+func (r *RecorderProvider) Stop() (types.Recording, error) {
+	r.mtx.Lock()
+	session := r.current
+	r.mtx.Unlock()
+	if session == nil {
+		return types.Recording{}, xerrors.New("no recording is running")
+	}
+	return r.stop(session)
+}
+
+func (r *RecorderProvider) stop(session *recordingSession) (types.Recording, error) {
+	r.mtx.Lock()
+	if r.current != session {
+		r.mtx.Unlock()
+		return types.Recording{}, xerrors.Errorf("recording %s is not running", session.meta.Name)
+	}
+	r.current = nil
+	r.stopping = session
+	r.mtx.Unlock()
+	defer func() {
+		r.mtx.Lock()
+		r.stopping = nil
+		r.mtx.Unlock()
+	}()
+
+	if session.timer != nil {
+		session.timer.Stop()
+	}
+	for _, topic := range session.meta.Topics {
+		r.rosProvider.UnSubscribe(topic, "recorder")
+	}
+	r.mtx.Lock()
+	session.meta.Active = false
+	session.meta.Duration = time.Since(session.meta.StartedAt).Seconds()
+	session.meta.Size = session.writer.Size()
+	meta := session.meta
+	r.mtx.Unlock()
+	err := session.writer.Close()
+	if err != nil {
+		return meta, xerrors.Errorf("failed to close recording %s: %w", meta.Name, err)
+	}
+	metaJson, err := json.Marshal(meta)
+	if err != nil {
+		return meta, err
+	}
+	err = r.dbProvider.Set("gui.recorder."+meta.Name, metaJson)
+	if err != nil {
+		return meta, err
+	}
+	recorderLog.Info("Stopped recording " + meta.Name)
+	return meta, nil
+}
+
+func (r *RecorderProvider) List() ([]types.Recording, error) {
+	directory, err := r.directory()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	// the metadata is copied under the lock, stop updates it once the session is no longer current. The session being
+	// stopped is shown until its metadata is saved.
+	var current *types.Recording
+	r.mtx.Lock()
+	if session := lo.Ternary(r.current != nil, r.current, r.stopping); session != nil {
+		meta := session.meta
+		current = &meta
+	}
+	r.mtx.Unlock()
+	var recordings []types.Recording
+	for _, entry := range entries {
+		if entry.IsDir() || !recordingNameRegexp.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		recording := types.Recording{
+			Name:      entry.Name(),
+			StartedAt: info.ModTime(),
+			Trigger:   "unknown",
+		}
+		metaJson, err := r.dbProvider.Get("gui.recorder." + entry.Name())
+		if err == nil {
+			_ = json.Unmarshal(metaJson, &recording)
+		}
+		if current != nil && current.Name == entry.Name() {
+			recording = *current
+			if recording.Active {
+				recording.Duration = time.Since(recording.StartedAt).Seconds()
+			}
+		}
+		recording.Size = info.Size()
+		recordings = append(recordings, recording)
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+func (r *RecorderProvider) Path(name string) (string, error) {
+	if !recordingNameRegexp.MatchString(name) {
+		return "", xerrors.Errorf("invalid recording name %s", name)
+	}
+	directory, err := r.directory()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(directory, name)
+	_, err = os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (r *RecorderProvider) Delete(name string) error {
+	path, err := r.Path(name)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	active := r.current != nil && r.current.meta.Name == name
+	r.mtx.Unlock()
+	if active {
+		return xerrors.Errorf("recording %s is running", name)
+	}
+	err = os.Remove(path)
+	if err != nil {
+		return err
+	}
+	_ = r.dbProvider.Delete("gui.recorder." + name)
+	return nil
+}
+
+func (r *RecorderProvider) directory() (string, error) {
+	directory, err := r.dbProvider.Get("system.recorder.directory")
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(string(directory), 0755)
+	if err != nil {
+		return "", err
+	}
+	return string(directory), nil
+}
+
+// watchEmergency keeps the last messages of the recorded topics in memory and starts a recording containing them
+// when the mower enters emergency
+func (r *RecorderProvider) watchEmergency() {
+	topics, err := GetList(r.dbProvider, "system.recorder.topics")
+	if err != nil {
+		recorderLog.Error(err)
+		return
+	}
+	before, err := GetInt(r.dbProvider, "system.recorder.emergency.before")
+	if err != nil {
+		recorderLog.Error(err)
+		return
+	}
+	after, err := GetInt(r.dbProvider, "system.recorder.emergency.after")
+	if err != nil {
+		recorderLog.Error(err)
+		return
+	}
+	for _, topic := range topics {
+		topic := topic
+		err := r.rosProvider.SubscribeQueued(topic, "recorder-buffer", func(msg []byte) {
+			instance, err := r.rosProvider.MessageType(topic)
+			if err != nil {
+				return
+			}
+			err = json.Unmarshal(msg, instance)
+			if err != nil {
+				return
+			}
+			now := time.Now()
+			r.mtx.Lock()
+			defer r.mtx.Unlock()
+			r.buffer = append(r.buffer, bufferedMessage{topic: topic, time: now, msg: instance})
+			limit := now.Add(-time.Duration(before) * time.Second)
+			for len(r.buffer) > 0 && r.buffer[0].time.Before(limit) {
+				r.buffer = r.buffer[1:]
+			}
+		})
+		if err != nil {
+			recorderLog.Error(xerrors.Errorf("failed to subscribe to %s: %w", topic, err))
+		}
+	}
+	err = r.rosProvider.Subscribe("/mower_logic/current_state", "recorder-emergency", func(msg []byte) {
+		var status mower_msgs.HighLevelStatus
+		err := json.Unmarshal(msg, &status)
+		if err != nil {
+			return
+		}
+		r.mtx.Lock()
+		triggered := status.Emergency && !r.emergency
+		r.emergency = status.Emergency
+		seed := append([]bufferedMessage{}, r.buffer...)
+		r.mtx.Unlock()
+		if !triggered {
+			return
+		}
+		go func() {
+			_, err := r.start("", topics, "emergency", time.Duration(after)*time.Second, seed)
+			if err != nil {
+				recorderLog.Error(xerrors.Errorf("failed to start emergency recording: %w", err))
+			}
+		}()
+	})
+	if err != nil {
+		recorderLog.Error(xerrors.Errorf("failed to subscribe to /mower_logic/current_state: %w", err))
+	}
+}
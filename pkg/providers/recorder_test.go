@@ -0,0 +1,141 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/protocommon"
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRecorder(t *testing.T, config map[string]string) (*RecorderProvider, *fakes.RosProvider, types.IDBProvider) {
+	values := map[string]string{
+		"system.recorder.directory":         t.TempDir(),
+		"system.recorder.topics":            "/mower/status",
+		"system.recorder.maxSizeMB":         "100",
+		"system.recorder.maxDuration":       "600",
+		"system.recorder.emergency.enabled": "false",
+		"system.recorder.emergency.before":  "30",
+		"system.recorder.emergency.after":   "30",
+	}
+	for key, value := range config {
+		values[key] = value
+	}
+	ros := fakes.NewRosProvider()
+	require.NoError(t, ros.RegisterTopic("/mower/status", &mower_msgs.Status{}))
+	require.NoError(t, ros.RegisterTopic("/mower_logic/current_state", &mower_msgs.HighLevelStatus{}))
+	db := fakes.NewDBProvider(values)
+	return NewRecorderProvider(ros, db), ros, db
+}
+
+// waitRecording waits until the recording stopped by itself and returns its metadata
+func waitRecording(t *testing.T, db types.IDBProvider, name string, timeout time.Duration) types.Recording {
+	var recording types.Recording
+	require.Eventually(t, func() bool {
+		metaJson, err := db.Get("gui.recorder." + name)
+		return err == nil && json.Unmarshal(metaJson, &recording) == nil
+	}, timeout, 10*time.Millisecond)
+	return recording
+}
+
+func readRecordedStatuses(t *testing.T, recorder *RecorderProvider, name string) []float32 {
+	path, err := recorder.Path(name)
+	require.NoError(t, err)
+	_, messages, err := readBag(path)
+	require.NoError(t, err)
+	var batteries []float32
+	for _, message := range messages {
+		var status mower_msgs.Status
+		require.NoError(t, protocommon.MessageDecode(bytes.NewReader(message.data), &status))
+		batteries = append(batteries, status.VBattery)
+	}
+	return batteries
+}
+
+func TestRecorderMaxSize(t *testing.T) {
+	recorder, ros, db := newTestRecorder(t, map[string]string{
+		"system.recorder.maxSizeMB": "1",
+		"system.recorder.topics":    "/mower_logic/current_state",
+	})
+	_, err := recorder.Start("size", nil)
+	require.NoError(t, err)
+
+	// each message is 300kB, the recording stops after the fourth one went past 1MB
+	for i := 0; i < 4; i++ {
+		require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: strings.Repeat("x", 300*1024)}))
+	}
+	recording := waitRecording(t, db, "size.bag", 5*time.Second)
+	assert.False(t, recording.Active)
+	assert.Greater(t, recording.Size, int64(1024*1024))
+	assert.Less(t, recording.Size, int64(1300*1024))
+	_, err = recorder.Stop()
+	assert.EqualError(t, err, "no recording is running")
+	assert.Zero(t, ros.Subscribers("/mower_logic/current_state"))
+
+	path, err := recorder.Path("size.bag")
+	require.NoError(t, err)
+	_, messages, err := readBag(path)
+	require.NoError(t, err)
+	assert.Len(t, messages, 4)
+}
+
+func TestRecorderMaxDuration(t *testing.T) {
+	recorder, ros, db := newTestRecorder(t, map[string]string{"system.recorder.maxDuration": "1"})
+	_, err := recorder.Start("duration", nil)
+	require.NoError(t, err)
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{VBattery: 28}))
+
+	recording := waitRecording(t, db, "duration.bag", 5*time.Second)
+	assert.False(t, recording.Active)
+	assert.InDelta(t, 1, recording.Duration, 0.5)
+	assert.Equal(t, "manual", recording.Trigger)
+	assert.Zero(t, ros.Subscribers("/mower/status"))
+	assert.Equal(t, []float32{28}, readRecordedStatuses(t, recorder, "duration.bag"))
+}
+
+func TestRecorderEmergency(t *testing.T) {
+	recorder, ros, _ := newTestRecorder(t, map[string]string{
+		"system.recorder.emergency.enabled": "true",
+		"system.recorder.emergency.before":  "1",
+		"system.recorder.emergency.after":   "1",
+	})
+
+	// the first message is older than the window kept before the emergency
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{VBattery: 1}))
+	time.Sleep(1200 * time.Millisecond)
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{VBattery: 2}))
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{Emergency: false}))
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{Emergency: true}))
+
+	// the recording subscribes next to the buffer
+	require.Eventually(t, func() bool {
+		return ros.Subscribers("/mower/status") == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, ros.Publish("/mower/status", &mower_msgs.Status{VBattery: 3}))
+	// an emergency still going on doesn't start another recording
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{Emergency: true}))
+
+	var recordings []types.Recording
+	require.Eventually(t, func() bool {
+		var err error
+		recordings, err = recorder.List()
+		return err == nil && len(recordings) == 1 && !recordings[0].Active
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "emergency", recordings[0].Trigger)
+	assert.True(t, strings.HasPrefix(recordings[0].Name, "emergency_"))
+	assert.InDelta(t, 1, recordings[0].Duration, 0.5)
+	assert.Equal(t, 1, ros.Subscribers("/mower/status"))
+
+	// the fake provider delivers the last message again on subscription, as ROS does with latched topics
+	batteries := readRecordedStatuses(t, recorder, recordings[0].Name)
+	assert.NotContains(t, batteries, float32(1))
+	assert.Equal(t, float32(2), batteries[0])
+	assert.Equal(t, float32(3), batteries[len(batteries)-1])
+}
@@ -0,0 +1,167 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"golang.org/x/xerrors"
+)
+
+// manifestMediaTypes are the manifests accepted from the registries, the index of a multi-platform image is preferred
+// because its digest is the one Docker keeps in the repo digests of the pulled image
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+var bearerChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// registryDigest returns the digest of the manifest of the image tag in its registry. Anonymous bearer tokens are
+// requested when the registry asks for them. Like Docker, registries on a loopback address are reached over HTTP.
+func registryDigest(ctx context.Context, client *http.Client, image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	named = reference.TagNameOnly(named)
+	tagged, ok := named.(reference.Tagged)
+	if !ok {
+		return "", xerrors.Errorf("image %s has no tag", image)
+	}
+	host := reference.Domain(named)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if isLoopbackHost(host) {
+		scheme = "http"
+	}
+	manifestURL := scheme + "://" + host + "/v2/" + reference.Path(named) + "/manifests/" + tagged.Tag()
+
+	res, err := headManifest(ctx, client, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		token, err := registryToken(ctx, client, res.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		res, err = headManifest(ctx, client, manifestURL, token)
+		if err != nil {
+			return "", err
+		}
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", xerrors.Errorf("registry answered %s for %s", res.Status, image)
+	}
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", xerrors.Errorf("registry did not return the digest of %s", image)
+	}
+	return digest, nil
+}
+
+func headManifest(ctx context.Context, client *http.Client, manifestURL string, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	return res, nil
+}
+
+// registryToken requests an anonymous token from the realm of a challenge such as
+// Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:owner/image:pull"
+func registryToken(ctx context.Context, client *http.Client, challenge string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", xerrors.Errorf("unsupported registry authentication: %s", challenge)
+	}
+	params := map[string]string{}
+	for _, match := range bearerChallengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", xerrors.Errorf("invalid registry authentication realm: %s", challenge)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", xerrors.Errorf("registry token request answered %s", res.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+func isLoopbackHost(host string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if hostname == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+// repoDigest returns the digest of image among the repo digests of a local image, e.g.
+// ghcr.io/owner/image@sha256:... for ghcr.io/owner/image:tag
+func repoDigest(image string, repoDigests []string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	for _, repoDigest := range repoDigests {
+		digested, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil {
+			continue
+		}
+		canonical, ok := digested.(reference.Canonical)
+		if ok && digested.Name() == named.Name() {
+			return canonical.Digest().String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,262 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgproc"
+	"github.com/bluenviron/goroslib/v2/pkg/protocommon"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+var replayLog = Logs.Logger("replay")
+
+const replayTick = 20 * time.Millisecond
+
+type replayMessage struct {
+	topic  string
+	offset time.Duration
+	data   []byte
+}
+
+type ReplayProvider struct {
+	rosProvider      types.IRosProvider
+	recorderProvider types.IRecorderProvider
+	mtx              sync.Mutex
+	name             string
+	topics           []string
+	messages         []replayMessage
+	duration         time.Duration
+	position         time.Duration
+	index            int
+	playing          bool
+	speed            float64
+	stop             chan struct{}
+}
+
+func NewReplayProvider(rosProvider types.IRosProvider, recorderProvider types.IRecorderProvider) *ReplayProvider {
+	return &ReplayProvider{
+		rosProvider:      rosProvider,
+		recorderProvider: recorderProvider,
+		speed:            1,
+	}
+}
+
+// Load reads a recording and switches the ROS provider to replay mode, the replay is paused at the beginning
+func (r *ReplayProvider) Load(name string) (types.ReplayStatus, error) {
+	path, err := r.recorderProvider.Path(name)
+	if err != nil {
+		return types.ReplayStatus{}, err
+	}
+	connections, bagMessages, err := readBag(path)
+	if err != nil {
+		return types.ReplayStatus{}, err
+	}
+	var topics []string
+	supported := make(map[*bagConnection]bool)
+	for _, conn := range connections {
+		instance, err := r.rosProvider.MessageType(conn.topic)
+		if err != nil {
+			replayLog.Warn(xerrors.Errorf("skipping topic %s of %s: %w", conn.topic, name, err))
+			continue
+		}
+		md5sum, err := msgproc.MD5(reflect.ValueOf(instance).Elem().Interface())
+		if err != nil || md5sum != conn.md5sum {
+			replayLog.Warn("skipping topic " + conn.topic + " of " + name + ": message type mismatch")
+			continue
+		}
+		supported[conn] = true
+		topics = append(topics, conn.topic)
+	}
+	var messages []replayMessage
+	for _, message := range bagMessages {
+		if !supported[message.conn] {
+			continue
+		}
+		messages = append(messages, replayMessage{
+			topic:  message.conn.topic,
+			offset: message.time.Sub(bagMessages[0].time),
+			data:   message.data,
+		})
+	}
+	if len(messages) == 0 {
+		return types.ReplayStatus{}, xerrors.Errorf("recording %s has no message to replay", name)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.name = name
+	r.topics = topics
+	r.messages = messages
+	r.duration = messages[len(messages)-1].offset
+	r.playing = false
+	if r.stop == nil {
+		r.stop = make(chan struct{})
+		go r.run(r.stop)
+	}
+	r.rosProvider.Replay(true)
+	r.seek(0)
+	replayLog.Info("Loaded replay of " + name)
+	return r.status(), nil
+}
+
+func (r *ReplayProvider) Play() (types.ReplayStatus, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.name == "" {
+		return types.ReplayStatus{}, xerrors.New("no recording loaded")
+	}
+	if r.index >= len(r.messages) {
+		r.seek(0)
+	}
+	r.playing = true
+	return r.status(), nil
+}
+
+func (r *ReplayProvider) Pause() (types.ReplayStatus, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.name == "" {
+		return types.ReplayStatus{}, xerrors.New("no recording loaded")
+	}
+	r.playing = false
+	return r.status(), nil
+}
+
+func (r *ReplayProvider) Seek(position float64) (types.ReplayStatus, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.name == "" {
+		return types.ReplayStatus{}, xerrors.New("no recording loaded")
+	}
+	r.seek(time.Duration(position * float64(time.Second)))
+	return r.status(), nil
+}
+
+func (r *ReplayProvider) Speed(speed float64) (types.ReplayStatus, error) {
+	if speed <= 0 || speed > 20 {
+		return types.ReplayStatus{}, xerrors.Errorf("speed must be greater than 0 and less than or equal to 20")
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.speed = speed
+	return r.status(), nil
+}
+
+// Stop ends the replay and switches the ROS provider back to live data
+func (r *ReplayProvider) Stop() (types.ReplayStatus, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.name == "" {
+		return types.ReplayStatus{}, xerrors.New("no recording loaded")
+	}
+	close(r.stop)
+	r.stop = nil
+	r.name = ""
+	r.topics = nil
+	r.messages = nil
+	r.duration = 0
+	r.position = 0
+	r.index = 0
+	r.playing = false
+	r.rosProvider.Replay(false)
+	replayLog.Info("Stopped replay")
+	return r.status(), nil
+}
+
+func (r *ReplayProvider) Status() types.ReplayStatus {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.status()
+}
+
+func (r *ReplayProvider) run(stop chan struct{}) {
+	ticker := time.NewTicker(replayTick)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			elapsed := now.Sub(last)
+			last = now
+			r.mtx.Lock()
+			if r.playing {
+				r.position += time.Duration(float64(elapsed) * r.speed)
+				for r.index < len(r.messages) && r.messages[r.index].offset <= r.position {
+					r.inject(r.messages[r.index])
+					r.index++
+				}
+				if r.index >= len(r.messages) {
+					r.playing = false
+					r.position = r.duration
+				}
+			}
+			r.mtx.Unlock()
+		}
+	}
+}
+
+// seek moves the replay to position and sends the last message of each topic before it, so subscribers get the
+// state of the mower at that time. r.mtx must be held.
+func (r *ReplayProvider) seek(position time.Duration) {
+	if position < 0 {
+		position = 0
+	}
+	if position > r.duration {
+		position = r.duration
+	}
+	r.position = position
+	r.index = sort.Search(len(r.messages), func(i int) bool {
+		return r.messages[i].offset > position
+	})
+	var latest []replayMessage
+	seen := make(map[string]bool)
+	for i := r.index - 1; i >= 0; i-- {
+		if seen[r.messages[i].topic] {
+			continue
+		}
+		seen[r.messages[i].topic] = true
+		latest = append(latest, r.messages[i])
+	}
+	for i := len(latest) - 1; i >= 0; i-- {
+		r.inject(latest[i])
+	}
+}
+
+func (r *ReplayProvider) inject(message replayMessage) {
+	instance, err := r.rosProvider.MessageType(message.topic)
+	if err != nil {
+		replayLog.Error(err)
+		return
+	}
+	err = protocommon.MessageDecode(bytes.NewReader(message.data), instance)
+	if err != nil {
+		replayLog.Error(xerrors.Errorf("failed to decode message of %s: %w", message.topic, err))
+		return
+	}
+	msgJson, err := json.Marshal(instance)
+	if err != nil {
+		replayLog.Error(xerrors.Errorf("failed to marshal message of %s: %w", message.topic, err))
+		return
+	}
+	r.rosProvider.Inject(message.topic, msgJson)
+}
+
+func (r *ReplayProvider) status() types.ReplayStatus {
+	return types.ReplayStatus{
+		Active:   r.name != "",
+		Name:     r.name,
+		Playing:  r.playing,
+		Speed:    r.speed,
+		Position: r.position.Seconds(),
+		Duration: r.duration.Seconds(),
+		Topics:   r.topics,
+	}
+}
@@ -0,0 +1,134 @@
+package providers
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReplay writes a recording with a state at 0, 1 and 2 seconds and a status at 0.5 second
+func newTestReplay(t *testing.T) (*ReplayProvider, *fakes.RosProvider) {
+	recorder, ros, _ := newTestRecorder(t, nil)
+	directory, err := recorder.directory()
+	require.NoError(t, err)
+	writer, err := newBagWriter(filepath.Join(directory, "test.bag"))
+	require.NoError(t, err)
+	start := time.Unix(1700000000, 0)
+	for i, state := range []string{"IDLE", "UNDOCKING", "MOWING"} {
+		require.NoError(t, writer.Write("/mower_logic/current_state", start.Add(time.Duration(i)*time.Second), &mower_msgs.HighLevelStatus{StateName: state}))
+	}
+	require.NoError(t, writer.Write("/mower/status", start.Add(500*time.Millisecond), &mower_msgs.Status{VBattery: 28}))
+	require.NoError(t, writer.Close())
+	return NewReplayProvider(ros, recorder), ros
+}
+
+// subscribeStates returns the states received by a subscriber of /mower_logic/current_state
+func subscribeStates(t *testing.T, ros *fakes.RosProvider, id string) func() []string {
+	var mtx sync.Mutex
+	var states []string
+	require.NoError(t, ros.Subscribe("/mower_logic/current_state", id, func(msg []byte) {
+		var status mower_msgs.HighLevelStatus
+		require.NoError(t, json.Unmarshal(msg, &status))
+		mtx.Lock()
+		defer mtx.Unlock()
+		states = append(states, status.StateName)
+	}))
+	return func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]string{}, states...)
+	}
+}
+
+func TestReplayLoadAndSeek(t *testing.T) {
+	replay, ros := newTestReplay(t)
+	states := subscribeStates(t, ros, "test")
+
+	_, err := replay.Load("unknown.bag")
+	assert.Error(t, err)
+	status, err := replay.Load("test.bag")
+	require.NoError(t, err)
+	assert.True(t, status.Active)
+	assert.False(t, status.Playing)
+	assert.Equal(t, 2.0, status.Duration)
+	assert.ElementsMatch(t, []string{"/mower_logic/current_state", "/mower/status"}, status.Topics)
+	assert.True(t, ros.Replaying())
+	// the replay starts with the messages at the beginning of the recording
+	assert.Equal(t, []string{"IDLE"}, states())
+
+	// the live messages are dropped
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "LIVE"}))
+	assert.Equal(t, []string{"IDLE"}, states())
+
+	// seeking sends the last message of each topic before the position
+	status, err = replay.Seek(1.5)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, status.Position)
+	assert.Equal(t, []string{"IDLE", "UNDOCKING"}, states())
+	status, err = replay.Seek(10)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, status.Position)
+	assert.Equal(t, "MOWING", states()[2])
+}
+
+func TestReplayPlaySpeed(t *testing.T) {
+	replay, ros := newTestReplay(t)
+	states := subscribeStates(t, ros, "test")
+	_, err := replay.Play()
+	assert.EqualError(t, err, "no recording loaded")
+	_, err = replay.Load("test.bag")
+	require.NoError(t, err)
+
+	_, err = replay.Speed(0)
+	assert.Error(t, err)
+	_, err = replay.Speed(21)
+	assert.Error(t, err)
+	status, err := replay.Speed(20)
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, status.Speed)
+
+	// the 2 seconds of the recording are replayed in 100ms, the replay then pauses at the end
+	status, err = replay.Play()
+	require.NoError(t, err)
+	assert.True(t, status.Playing)
+	require.Eventually(t, func() bool {
+		return !replay.Status().Playing
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2.0, replay.Status().Position)
+	assert.Equal(t, []string{"IDLE", "UNDOCKING", "MOWING"}, states())
+
+	// playing again restarts from the beginning
+	_, err = replay.Play()
+	require.NoError(t, err)
+	_, err = replay.Pause()
+	require.NoError(t, err)
+	assert.Equal(t, "IDLE", states()[3])
+}
+
+func TestReplayStop(t *testing.T) {
+	replay, ros := newTestReplay(t)
+	_, err := replay.Stop()
+	assert.EqualError(t, err, "no recording loaded")
+	_, err = replay.Load("test.bag")
+	require.NoError(t, err)
+
+	status, err := replay.Stop()
+	require.NoError(t, err)
+	assert.False(t, status.Active)
+	assert.False(t, ros.Replaying())
+
+	// the recorded messages are not delivered to the new subscribers, the live ones are
+	states := subscribeStates(t, ros, "test")
+	assert.Empty(t, states())
+	require.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "LIVE"}))
+	assert.Equal(t, []string{"LIVE"}, states())
+	_, err = replay.Seek(1)
+	assert.EqualError(t, err, "no recording loaded")
+}
@@ -8,18 +8,24 @@ import (
 	"github.com/bluenviron/goroslib/v2/pkg/msgs/nav_msgs"
 	"github.com/bluenviron/goroslib/v2/pkg/msgs/sensor_msgs"
 	"github.com/bluenviron/goroslib/v2/pkg/msgs/visualization_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/prototcp"
+	"github.com/bluenviron/goroslib/v2/pkg/serviceproc"
 	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
 	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
 	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/simplify"
 	"github.com/samber/lo"
-	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
+	"net"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 )
 
+var rosLog = Logs.Logger("ros")
+
 type RosSubscriber struct {
 	Topic       string
 	Id          string
@@ -27,6 +33,10 @@ type RosSubscriber struct {
 	cb          func(msg []byte)
 	nextMessage []byte
 	close       chan bool
+	// queue holds the messages of the queued subscribers, notify wakes them up when a message is queued
+	queued bool
+	queue  [][]byte
+	notify chan struct{}
 }
 
 func NewRosSubscriber(topic, id string, cb func(msg []byte)) *RosSubscriber {
@@ -41,9 +51,32 @@ func NewRosSubscriber(topic, id string, cb func(msg []byte)) *RosSubscriber {
 	return r
 }
 
+// NewRosQueueSubscriber creates a subscriber calling cb with every message in order, instead of the latest one
+func NewRosQueueSubscriber(topic, id string, cb func(msg []byte)) *RosSubscriber {
+	r := &RosSubscriber{
+		cb:     cb,
+		Topic:  topic,
+		Id:     id,
+		mtx:    &sync.Mutex{},
+		close:  make(chan bool),
+		queued: true,
+		notify: make(chan struct{}, 1),
+	}
+	go r.runQueue()
+	return r
+}
+
 func (r *RosSubscriber) Publish(msg []byte) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
+	if r.queued {
+		r.queue = append(r.queue, msg)
+		select {
+		case r.notify <- struct{}{}:
+		default:
+		}
+		return
+	}
 	r.nextMessage = msg
 }
 
@@ -66,6 +99,32 @@ func (r *RosSubscriber) Run() {
 	}
 }
 
+func (r *RosSubscriber) runQueue() {
+	for {
+		select {
+		case <-r.close:
+			return
+		case <-r.notify:
+		}
+		for {
+			r.mtx.Lock()
+			if len(r.queue) == 0 {
+				r.mtx.Unlock()
+				break
+			}
+			messageToProcess := r.queue[0]
+			r.queue = r.queue[1:]
+			r.mtx.Unlock()
+			r.cb(messageToProcess)
+			select {
+			case <-r.close:
+				return
+			default:
+			}
+		}
+	}
+}
+
 func (r *RosSubscriber) processMessage(messageToProcess []byte) {
 	if messageToProcess != nil {
 		r.cb(messageToProcess)
@@ -74,6 +133,24 @@ func (r *RosSubscriber) processMessage(messageToProcess []byte) {
 	}
 }
 
+// topicMessageTypes are the message types of the topics subscribed by default
+var topicMessageTypes = map[string]any{
+	"/mower/status":                              &mower_msgs.Status{},
+	"/mower_logic/current_state":                 &mower_msgs.HighLevelStatus{},
+	"/xbot_driver_gps/xb_pose":                   &xbot_msgs.AbsolutePose{},
+	"/xbot_positioning/xb_pose":                  &xbot_msgs.AbsolutePose{},
+	"/imu/data_raw":                              &sensor_msgs.Imu{},
+	"/mower/wheel_ticks":                         &xbot_msgs.WheelTick{},
+	"/xbot_monitoring/map":                       &xbot_msgs.Map{},
+	"/slic3r_coverage_planner/path_marker_array": &visualization_msgs.MarkerArray{},
+	"/move_base_flex/FTCPlanner/global_plan":     &nav_msgs.Path{},
+}
+
+type advertisedService struct {
+	srv any
+	cb  func(req any) (any, error)
+}
+
 type RosProvider struct {
 	node                      *goroslib.Node
 	mtx                       sync.Mutex
@@ -86,12 +163,20 @@ type RosProvider struct {
 	pathSubscriber            *goroslib.Subscriber
 	currentPathSubscriber     *goroslib.Subscriber
 	poseSubscriber            *goroslib.Subscriber
+	topicTypes                map[string]reflect.Type
+	topicSubscribers          map[string]*goroslib.Subscriber
+	advertisedServices        map[string]advertisedService
+	serviceProviders          map[string]*goroslib.ServiceProvider
 	subscribers               map[string]map[string]*RosSubscriber
+	replaying                 bool
 	lastMessage               map[string][]byte
 	mowingPaths               []*nav_msgs.Path
 	mowingPath                *nav_msgs.Path
 	mowingPathOrigin          orb.LineString
 	dbProvider                types2.IDBProvider
+	// subscribersMtx serializes initSubscribers and resetSubscribers, p.mtx can't be held while the goroslib
+	// subscribers are created or closed as their callbacks lock it
+	subscribersMtx sync.Mutex
 }
 
 func (p *RosProvider) getNode() (*goroslib.Node, error) {
@@ -125,39 +210,47 @@ func (p *RosProvider) getNode() (*goroslib.Node, error) {
 
 }
 
+const (
+	rosReconnectsMetric = "openmower_gui_ros_reconnects_total"
+	rosReconnectsHelp   = "Number of times the ROS node was restarted after losing the master."
+)
+
 func NewRosProvider(dbProvider types2.IDBProvider) types2.IRosProvider {
 	r := &RosProvider{
-		dbProvider: dbProvider,
+		dbProvider:  dbProvider,
+		subscribers: make(map[string]map[string]*RosSubscriber),
+		lastMessage: make(map[string][]byte),
 	}
+	Metrics.AddCounter(rosReconnectsMetric, rosReconnectsHelp, 0)
 	err := r.initSubscribers()
 	if err != nil {
-		logrus.Error(err)
+		rosLog.Error(err)
 		return r
 	}
 	err = r.initMowingPathSubscriber()
 	if err != nil {
-		logrus.Error(err)
+		rosLog.Error(err)
 		return r
 	}
 	go func() {
 		for range time.Tick(20 * time.Second) {
 			node, err := r.getNode()
 			if err != nil {
-				logrus.Error(xerrors.Errorf("failed to get node: %w", err))
+				rosLog.Error(xerrors.Errorf("failed to get node: %w", err))
 				continue
 			}
 			_, err = node.NodePing("rosout")
 			if err != nil {
-				logrus.Error(xerrors.Errorf("failed to ping node: %w, restarting node", err))
+				rosLog.Error(xerrors.Errorf("failed to ping node: %w, restarting node", err))
 				r.resetSubscribers()
 			} else {
 				err = r.initSubscribers()
 				if err != nil {
-					logrus.Error(xerrors.Errorf("failed to init subscribers: %w", err))
+					rosLog.Error(xerrors.Errorf("failed to init subscribers: %w", err))
 				}
 				err = r.initMowingPathSubscriber()
 				if err != nil {
-					logrus.Error(xerrors.Errorf("failed to init mowing path subscriber: %w", err))
+					rosLog.Error(xerrors.Errorf("failed to init mowing path subscriber: %w", err))
 				}
 			}
 		}
@@ -166,8 +259,15 @@ func NewRosProvider(dbProvider types2.IDBProvider) types2.IRosProvider {
 }
 
 func (p *RosProvider) resetSubscribers() {
-	if p.node != nil {
-		p.node.Close()
+	p.subscribersMtx.Lock()
+	defer p.subscribersMtx.Unlock()
+	Metrics.AddCounter(rosReconnectsMetric, rosReconnectsHelp, 1)
+	p.mtx.Lock()
+	node := p.node
+	p.node = nil
+	p.mtx.Unlock()
+	if node != nil {
+		node.Close()
 	}
 	p.currentPathSubscriber.Close()
 	p.gpsSubscriber.Close()
@@ -178,7 +278,6 @@ func (p *RosProvider) resetSubscribers() {
 	p.statusSubscriber.Close()
 	p.ticksSubscriber.Close()
 	p.poseSubscriber.Close()
-	p.node = nil
 	p.currentPathSubscriber = nil
 	p.gpsSubscriber = nil
 	p.highLevelStatusSubscriber = nil
@@ -188,6 +287,16 @@ func (p *RosProvider) resetSubscribers() {
 	p.statusSubscriber = nil
 	p.ticksSubscriber = nil
 	p.poseSubscriber = nil
+	for topic, subscriber := range p.topicSubscribers {
+		subscriber.Close()
+		delete(p.topicSubscribers, topic)
+	}
+	for name, provider := range p.serviceProviders {
+		provider.Close()
+		delete(p.serviceProviders, name)
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
 	p.mowingPaths = []*nav_msgs.Path{}
 	p.mowingPath = nil
 	p.mowingPathOrigin = nil
@@ -206,7 +315,7 @@ func (p *RosProvider) initMowingPathSubscriber() error {
 		var pose xbot_msgs.AbsolutePose
 		err := json.Unmarshal(msg, &pose)
 		if err != nil {
-			logrus.Error(xerrors.Errorf("failed to unmarshal pose: %w", err))
+			rosLog.Error(xerrors.Errorf("failed to unmarshal pose: %w", err))
 			return
 		}
 		hlsLastMessage, ok := p.lastMessage["/mower_logic/current_state"]
@@ -214,7 +323,7 @@ func (p *RosProvider) initMowingPathSubscriber() error {
 			var highLevelStatus mower_msgs.HighLevelStatus
 			err := json.Unmarshal(hlsLastMessage, &highLevelStatus)
 			if err != nil {
-				logrus.Error(xerrors.Errorf("failed to unmarshal high level status: %w", err))
+				rosLog.Error(xerrors.Errorf("failed to unmarshal high level status: %w", err))
 				return
 			}
 			switch highLevelStatus.StateName {
@@ -224,7 +333,7 @@ func (p *RosProvider) initMowingPathSubscriber() error {
 					var status mower_msgs.Status
 					err := json.Unmarshal(sLastMessage, &status)
 					if err != nil {
-						logrus.Error(xerrors.Errorf("failed to unmarshal status: %w", err))
+						rosLog.Error(xerrors.Errorf("failed to unmarshal status: %w", err))
 						return
 					}
 					if status.MowEscStatus.Tacho > 0 {
@@ -279,21 +388,38 @@ func (p *RosProvider) CallService(ctx context.Context, srvName string, srv any,
 	if err != nil {
 		return err
 	}
+	start := time.Now()
 	serviceClient, err := goroslib.NewServiceClient(goroslib.ServiceClientConf{
 		Node: rosNode,
 		Name: srvName,
 		Srv:  srv,
 	})
-	err = serviceClient.CallContext(ctx, req, res)
+	if err == nil {
+		defer serviceClient.Close()
+		err = serviceClient.CallContext(ctx, req, res)
+	}
+	Metrics.ObserveDuration("openmower_gui_ros_service_call_duration_seconds", "Duration of the ROS service calls.", time.Since(start), "service", srvName)
 	if err != nil {
+		Metrics.AddCounter("openmower_gui_ros_service_call_errors_total", "Number of failed ROS service calls.", 1, "service", srvName)
 		return err
 	}
 	return nil
 }
 
 func (p *RosProvider) Subscribe(topic string, id string, cb func(msg []byte)) error {
+	return p.subscribe(topic, id, cb, NewRosSubscriber)
+}
+
+func (p *RosProvider) SubscribeQueued(topic string, id string, cb func(msg []byte)) error {
+	return p.subscribe(topic, id, cb, NewRosQueueSubscriber)
+}
+
+func (p *RosProvider) subscribe(topic string, id string, cb func(msg []byte), newSubscriber func(topic, id string, cb func(msg []byte)) *RosSubscriber) error {
 	err := p.initSubscribers()
-	if err != nil {
+	p.mtx.Lock()
+	replaying := p.replaying
+	p.mtx.Unlock()
+	if err != nil && !replaying {
 		return err
 	}
 	p.mtx.Lock()
@@ -305,7 +431,7 @@ func (p *RosProvider) Subscribe(topic string, id string, cb func(msg []byte)) er
 	}
 	_, hasCallback := subscriber[id]
 	if !hasCallback {
-		subscriber[id] = NewRosSubscriber(topic, id, cb)
+		subscriber[id] = newSubscriber(topic, id, cb)
 	}
 	lastMessage, hasLastMessage := p.lastMessage[topic]
 	if hasLastMessage {
@@ -314,17 +440,76 @@ func (p *RosProvider) Subscribe(topic string, id string, cb func(msg []byte)) er
 	return nil
 }
 
-func (p *RosProvider) Publisher(topic string, obj interface{}) (*goroslib.Publisher, error) {
-	rosNode, err := p.getNode()
+func (p *RosProvider) Publisher(topic string, obj interface{}) (types2.IRosPublisher, error) {
+	publisher := &rosPublisher{provider: p, topic: topic, msg: obj}
+	err := publisher.renew()
 	if err != nil {
 		return nil, err
 	}
+	return publisher, nil
+}
+
+// rosPublisher publishes on the current node of the provider. The publishers of a node are closed with it and drop
+// the messages, so the goroslib publisher is created again when the node was restarted after losing the master.
+type rosPublisher struct {
+	provider  *RosProvider
+	topic     string
+	msg       any
+	mtx       sync.Mutex
+	node      *goroslib.Node
+	publisher *goroslib.Publisher
+	closed    bool
+}
+
+// renew creates the goroslib publisher on the current node if it changed, p.mtx must be held
+func (p *rosPublisher) renew() error {
+	node, err := p.provider.getNode()
+	if err != nil {
+		return err
+	}
+	if node == p.node {
+		return nil
+	}
+	if p.publisher != nil {
+		p.publisher.Close()
+		p.publisher = nil
+		p.node = nil
+	}
 	publisher, err := goroslib.NewPublisher(goroslib.PublisherConf{
-		Node:  rosNode,
-		Topic: topic,
-		Msg:   obj,
+		Node:  node,
+		Topic: p.topic,
+		Msg:   p.msg,
 	})
-	return publisher, nil
+	if err != nil {
+		return err
+	}
+	p.node = node
+	p.publisher = publisher
+	return nil
+}
+
+func (p *rosPublisher) Write(msg interface{}) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.closed {
+		return
+	}
+	err := p.renew()
+	if err != nil {
+		rosLog.Error(xerrors.Errorf("failed to publish on %s: %w", p.topic, err))
+		return
+	}
+	p.publisher.Write(msg)
+}
+
+func (p *rosPublisher) Close() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.closed = true
+	if p.publisher != nil {
+		p.publisher.Close()
+		p.publisher = nil
+	}
 }
 
 func (p *RosProvider) UnSubscribe(topic string, id string) {
@@ -338,6 +523,8 @@ func (p *RosProvider) UnSubscribe(topic string, id string) {
 }
 
 func (p *RosProvider) initSubscribers() error {
+	p.subscribersMtx.Lock()
+	defer p.subscribersMtx.Unlock()
 	node, err := p.getNode()
 	if err != nil {
 		return err
@@ -348,6 +535,12 @@ func (p *RosProvider) initSubscribers() error {
 	if p.lastMessage == nil {
 		p.lastMessage = make(map[string][]byte)
 	}
+	if p.topicSubscribers == nil {
+		p.topicSubscribers = make(map[string]*goroslib.Subscriber)
+	}
+	if p.serviceProviders == nil {
+		p.serviceProviders = make(map[string]*goroslib.ServiceProvider)
+	}
 	if p.statusSubscriber == nil {
 		p.statusSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
 			Node:      node,
@@ -355,7 +548,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*mower_msgs.Status](p, "/mower/status"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /mower/status")
+		rosLog.Info("Subscribed to /mower/status")
 	}
 	if p.highLevelStatusSubscriber == nil {
 		p.highLevelStatusSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -364,7 +557,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*mower_msgs.HighLevelStatus](p, "/mower_logic/current_state"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /mower_logic/current_state")
+		rosLog.Info("Subscribed to /mower_logic/current_state")
 	}
 	if p.gpsSubscriber == nil {
 		p.gpsSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -373,7 +566,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*xbot_msgs.AbsolutePose](p, "/xbot_driver_gps/xb_pose"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /xbot_driver_gps/xb_pose")
+		rosLog.Info("Subscribed to /xbot_driver_gps/xb_pose")
 	}
 	if p.poseSubscriber == nil {
 		p.poseSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -382,7 +575,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*xbot_msgs.AbsolutePose](p, "/xbot_positioning/xb_pose"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /xbot_positioning/xb_pose")
+		rosLog.Info("Subscribed to /xbot_positioning/xb_pose")
 	}
 	if p.imuSubscriber == nil {
 		p.imuSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -391,7 +584,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*sensor_msgs.Imu](p, "/imu/data_raw"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /imu/data_raw")
+		rosLog.Info("Subscribed to /imu/data_raw")
 	}
 	if p.ticksSubscriber == nil {
 		p.ticksSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -400,7 +593,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*xbot_msgs.WheelTick](p, "/mower/wheel_ticks"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /mower/wheel_ticks")
+		rosLog.Info("Subscribed to /mower/wheel_ticks")
 	}
 	if p.mapSubscriber == nil {
 		p.mapSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -409,7 +602,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*xbot_msgs.Map](p, "/xbot_monitoring/map"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /xbot_monitoring/map")
+		rosLog.Info("Subscribed to /xbot_monitoring/map")
 	}
 	if p.pathSubscriber == nil {
 		p.pathSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -418,7 +611,7 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*visualization_msgs.MarkerArray](p, "/slic3r_coverage_planner/path_marker_array"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /slic3r_coverage_planner/path_marker_array")
+		rosLog.Info("Subscribed to /slic3r_coverage_planner/path_marker_array")
 	}
 	if p.currentPathSubscriber == nil {
 		p.currentPathSubscriber, err = goroslib.NewSubscriber(goroslib.SubscriberConf{
@@ -427,26 +620,348 @@ func (p *RosProvider) initSubscribers() error {
 			Callback:  cbHandler[*nav_msgs.Path](p, "/move_base_flex/FTCPlanner/global_plan"),
 			QueueSize: 1,
 		})
-		logrus.Info("Subscribed to /move_base_flex/FTCPlanner/global_plan")
+		rosLog.Info("Subscribed to /move_base_flex/FTCPlanner/global_plan")
+	}
+	p.mtx.Lock()
+	topicTypes := lo.Assign(p.topicTypes)
+	p.mtx.Unlock()
+	for topic, msgType := range topicTypes {
+		if p.topicSubscribers[topic] == nil {
+			subscriber, err := goroslib.NewSubscriber(goroslib.SubscriberConf{
+				Node:      node,
+				Topic:     topic,
+				Callback:  dynamicCbHandler(p, topic, msgType),
+				QueueSize: 1,
+			})
+			if err != nil {
+				rosLog.Error(xerrors.Errorf("failed to subscribe to %s: %w", topic, err))
+				continue
+			}
+			p.topicSubscribers[topic] = subscriber
+			rosLog.Info("Subscribed to " + topic)
+		}
+	}
+	p.mtx.Lock()
+	advertisedServices := lo.Assign(p.advertisedServices)
+	p.mtx.Unlock()
+	for name, service := range advertisedServices {
+		if p.serviceProviders[name] == nil {
+			callback, err := dynamicServiceHandler(service.srv, service.cb)
+			if err != nil {
+				rosLog.Error(xerrors.Errorf("failed to advertise %s: %w", name, err))
+				continue
+			}
+			provider, err := goroslib.NewServiceProvider(goroslib.ServiceProviderConf{
+				Node:     node,
+				Name:     name,
+				Srv:      service.srv,
+				Callback: callback,
+			})
+			if err != nil {
+				rosLog.Error(xerrors.Errorf("failed to advertise %s: %w", name, err))
+				continue
+			}
+			p.serviceProviders[name] = provider
+			rosLog.Info("Advertised " + name)
+		}
 	}
 	return nil
 }
 
+// RegisterTopic subscribes to a topic which is not part of the default subscribers, msg is a pointer to the message type
+// of the topic. Messages are then dispatched to Subscribe callbacks like any other topic.
+func (p *RosProvider) RegisterTopic(topic string, msg any) error {
+	msgType := reflect.TypeOf(msg)
+	if msgType == nil || msgType.Kind() != reflect.Ptr {
+		return xerrors.Errorf("message type of %s must be a pointer", topic)
+	}
+	p.mtx.Lock()
+	if p.topicTypes == nil {
+		p.topicTypes = make(map[string]reflect.Type)
+	}
+	p.topicTypes[topic] = msgType
+	p.mtx.Unlock()
+	return p.initSubscribers()
+}
+
 func cbHandler[T any](p *RosProvider, topic string) func(msg T) {
 	return func(msg T) {
 		p.mtx.Lock()
 		defer p.mtx.Unlock()
+		if p.replaying {
+			return
+		}
 		msgJson, err := json.Marshal(msg)
 		if err != nil {
-			logrus.Error(xerrors.Errorf("failed to marshal message: %w", err))
+			rosLog.Error(xerrors.Errorf("failed to marshal message: %w", err))
 			return
 		}
-		p.lastMessage[topic] = msgJson
-		subscribers, hasSubscriber := p.subscribers[topic]
-		if hasSubscriber {
-			for _, cb := range subscribers {
-				cb.Publish(msgJson)
-			}
+		p.dispatch(topic, msgJson)
+	}
+}
+
+// dispatch stores the message as the last message of the topic and sends it to the subscribers, p.mtx must be held
+func (p *RosProvider) dispatch(topic string, msgJson []byte) {
+	p.lastMessage[topic] = msgJson
+	subscribers, hasSubscriber := p.subscribers[topic]
+	if hasSubscriber {
+		for _, cb := range subscribers {
+			cb.Publish(msgJson)
+		}
+	}
+}
+
+// Replay switches the provider between live and replay mode. In replay mode, messages received from ROS are dropped
+// and subscribers only receive the messages sent with Inject.
+func (p *RosProvider) Replay(enabled bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.replaying = enabled
+	p.lastMessage = make(map[string][]byte)
+	p.mowingPaths = []*nav_msgs.Path{}
+	p.mowingPath = nil
+	p.mowingPathOrigin = nil
+}
+
+func (p *RosProvider) Replaying() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.replaying
+}
+
+// Inject sends a message to the subscribers of a topic as if it was received from ROS
+func (p *RosProvider) Inject(topic string, msgJson []byte) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.dispatch(topic, msgJson)
+}
+
+// MessageType returns a new instance of the message type of a subscribed topic
+func (p *RosProvider) MessageType(topic string) (any, error) {
+	p.mtx.Lock()
+	msgType, ok := p.topicTypes[topic]
+	p.mtx.Unlock()
+	if !ok {
+		msg, ok := topicMessageTypes[topic]
+		if !ok {
+			return nil, xerrors.Errorf("unknown message type for topic %s", topic)
+		}
+		msgType = reflect.TypeOf(msg)
+	}
+	return reflect.New(msgType.Elem()).Interface(), nil
+}
+
+// AdvertiseService provides a service to the other nodes, srv is a pointer to the service type. cb receives a pointer
+// to the request and returns a pointer to the response. Like topics registered with RegisterTopic, the service is
+// advertised again when the node reconnects.
+func (p *RosProvider) AdvertiseService(name string, srv any, cb func(req any) (any, error)) error {
+	if reflect.TypeOf(srv) == nil || reflect.TypeOf(srv).Kind() != reflect.Ptr {
+		return xerrors.Errorf("service type of %s must be a pointer", name)
+	}
+	p.mtx.Lock()
+	if p.advertisedServices == nil {
+		p.advertisedServices = make(map[string]advertisedService)
+	}
+	p.advertisedServices[name] = advertisedService{srv: srv, cb: cb}
+	p.mtx.Unlock()
+	return p.initSubscribers()
+}
+
+// dynamicServiceHandler builds the func(*Req) (*Res, bool) callback expected by goroslib for srv
+func dynamicServiceHandler(srv any, cb func(req any) (any, error)) (any, error) {
+	req, res, err := serviceproc.RequestResponse(reflect.ValueOf(srv).Elem().Interface())
+	if err != nil {
+		return nil, err
+	}
+	resType := reflect.PointerTo(reflect.TypeOf(res))
+	fnType := reflect.FuncOf([]reflect.Type{reflect.PointerTo(reflect.TypeOf(req))}, []reflect.Type{resType, reflect.TypeOf(true)}, false)
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		out, err := cb(args[0].Interface())
+		if err != nil {
+			rosLog.Error(xerrors.Errorf("service call failed: %w", err))
+			return []reflect.Value{reflect.Zero(resType), reflect.ValueOf(false)}
 		}
+		return []reflect.Value{reflect.ValueOf(out), reflect.ValueOf(true)}
+	}).Interface(), nil
+}
+
+func dynamicCbHandler(p *RosProvider, topic string, msgType reflect.Type) any {
+	handler := cbHandler[any](p, topic)
+	return reflect.MakeFunc(reflect.FuncOf([]reflect.Type{msgType}, nil, false), func(args []reflect.Value) []reflect.Value {
+		handler(args[0].Interface())
+		return nil
+	}).Interface()
+}
+
+func (p *RosProvider) Nodes() ([]types2.RosNode, error) {
+	node, err := p.getNode()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := node.MasterGetNodes()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get nodes: %w", err)
+	}
+	var result []types2.RosNode
+	for name, info := range nodes {
+		result = append(result, types2.RosNode{
+			Name:          name,
+			Address:       info.Address,
+			Publications:  sortedKeys(info.PublishedTopics),
+			Subscriptions: sortedKeys(info.SubscribedTopics),
+			Services:      sortedKeys(info.ProvidedServices),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (p *RosProvider) Topics() ([]types2.RosTopic, error) {
+	node, err := p.getNode()
+	if err != nil {
+		return nil, err
+	}
+	topics, err := node.MasterGetTopics()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get topics: %w", err)
+	}
+	var result []types2.RosTopic
+	for name, info := range topics {
+		result = append(result, types2.RosTopic{
+			Name:        name,
+			Type:        info.Type,
+			Publishers:  sortedKeys(info.Publishers),
+			Subscribers: sortedKeys(info.Subscribers),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// Services lists the services of the master, their types are probed in parallel until ctx is done or for
+// serviceProbeTimeout, the type of a service that could not be probed is empty
+func (p *RosProvider) Services(ctx context.Context) ([]types2.RosService, error) {
+	node, err := p.getNode()
+	if err != nil {
+		return nil, err
+	}
+	nodeName, err := p.dbProvider.Get("system.ros.nodeName")
+	if err != nil {
+		return nil, err
+	}
+	services, err := node.MasterGetServices()
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get services: %w", err)
+	}
+	var result []types2.RosService
+	for name, info := range services {
+		result = append(result, types2.RosService{
+			Name:      name,
+			Address:   info.Address,
+			Providers: sortedKeys(info.Providers),
+		})
+	}
+	probeServiceTypes(ctx, result, "/"+string(nodeName))
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (p *RosProvider) NodePing(nodeName string) (time.Duration, error) {
+	node, err := p.getNode()
+	if err != nil {
+		return 0, err
+	}
+	return node.NodePing(nodeName)
+}
+
+// serviceProbeWorkers is the number of services probed at the same time
+const serviceProbeWorkers = 8
+
+// serviceProbeDeadline bounds the probe of a service and serviceProbeTimeout the probes of all the services
+var (
+	serviceProbeDeadline = 2 * time.Second
+	serviceProbeTimeout  = 5 * time.Second
+)
+
+// serviceProbeHeader is the TCPROS header used by rosservice to query the type of a service without calling it
+type serviceProbeHeader struct {
+	Callerid string
+	Md5sum   string
+	Service  string
+	Probe    int
+}
+
+// probeServiceTypes sets the types of the services with serviceProbeWorkers probes at the same time, until ctx is done
+// or for serviceProbeTimeout
+func probeServiceTypes(ctx context.Context, services []types2.RosService, callerId string) {
+	ctx, cancel := context.WithTimeout(ctx, serviceProbeTimeout)
+	defer cancel()
+	// each worker probes the next service until there is none left
+	jobs := make(chan *types2.RosService)
+	var wg sync.WaitGroup
+	for i := 0; i < lo.Min([]int{serviceProbeWorkers, len(services)}); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for service := range jobs {
+				var err error
+				service.Type, err = probeServiceType(ctx, service.Address, service.Name, callerId)
+				if err != nil {
+					rosLog.Warn(xerrors.Errorf("failed to probe service %s: %w", service.Name, err))
+				}
+			}
+		}()
 	}
+	for i := range services {
+		jobs <- &services[i]
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// probeServiceType asks the provider of a service for its type, the probe lasts serviceProbeDeadline at most
+func probeServiceType(ctx context.Context, address string, service string, callerId string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, serviceProbeDeadline)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	tcpConn := prototcp.NewConn(conn)
+	err = tcpConn.WriteHeader(&serviceProbeHeader{
+		Callerid: callerId,
+		Md5sum:   "*",
+		Service:  service,
+		Probe:    1,
+	})
+	if err != nil {
+		return "", err
+	}
+	header, err := tcpConn.ReadHeaderRaw()
+	if err != nil {
+		return "", err
+	}
+	if errMsg, ok := header["error"]; ok {
+		return "", xerrors.New(errMsg)
+	}
+	return header["type"], nil
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return keys
 }
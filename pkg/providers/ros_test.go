@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/prototcp"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProbeServer answers the service probes with srvType, or never answers when srvType is empty
+func newProbeServer(t *testing.T, srvType string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tcpConn := prototcp.NewConn(conn)
+				if _, err := tcpConn.ReadHeaderRaw(); err != nil || srvType == "" {
+					// the connection is held until the prober gives up
+					_, _ = conn.Read(make([]byte, 1))
+					return
+				}
+				_ = tcpConn.WriteHeader(&struct {
+					Callerid string
+					Md5sum   string
+					Type     string
+				}{Callerid: "/mower_service", Md5sum: "*", Type: srvType})
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestProbeServiceTypes(t *testing.T) {
+	deadline := serviceProbeDeadline
+	t.Cleanup(func() {
+		serviceProbeDeadline = deadline
+	})
+	serviceProbeDeadline = 100 * time.Millisecond
+	silent := newProbeServer(t, "")
+	services := []types.RosService{{Name: "/mower_service/emergency", Address: newProbeServer(t, "mower_msgs/EmergencyStopSrv")}}
+	for i := 0; i < 3*serviceProbeWorkers; i++ {
+		services = append(services, types.RosService{Name: "/silent", Address: silent})
+	}
+
+	// the silent services are probed in parallel
+	start := time.Now()
+	probeServiceTypes(context.Background(), services, "/openmower_gui")
+	assert.Less(t, time.Since(start), time.Duration(len(services))*serviceProbeDeadline/2)
+	assert.Equal(t, "mower_msgs/EmergencyStopSrv", services[0].Type)
+	assert.Empty(t, services[1].Type)
+
+	// the probes stop when the request is done
+	serviceProbeDeadline = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	probeServiceTypes(ctx, services[1:], "/openmower_gui")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRosQueueSubscriber(t *testing.T) {
+	var mtx sync.Mutex
+	var received []string
+	subscriber := NewRosQueueSubscriber("/topic", "test", func(msg []byte) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		received = append(received, string(msg))
+	})
+	defer subscriber.Close()
+
+	var published []string
+	for i := 0; i < 100; i++ {
+		published = append(published, strconv.Itoa(i))
+		subscriber.Publish([]byte(published[i]))
+	}
+	assert.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return assert.ObjectsAreEqual(published, received)
+	}, time.Second, 10*time.Millisecond)
+}
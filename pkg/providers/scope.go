@@ -0,0 +1,149 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/docker/api/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+// ScopedContainerProvider restricts a container backend to the containers in the management scope: the containers
+// having all the labels of system.containers.labels, written key or key=value, and a name matching one of the patterns
+// of system.containers.names, e.g. openmower*. An empty list does not restrict the scope. The other containers are
+// hidden and the calls with their ID fail with types.ErrUnknownContainer. The containers can be given by name, the
+// backend receives their ID.
+type ScopedContainerProvider struct {
+	provider   types2.IDockerProvider
+	dbProvider types2.IDBProvider
+}
+
+func NewScopedContainerProvider(provider types2.IDockerProvider, dbProvider types2.IDBProvider) *ScopedContainerProvider {
+	return &ScopedContainerProvider{provider: provider, dbProvider: dbProvider}
+}
+
+// inScope returns a function telling if a container is in the scope, or an error if the scope is invalid
+func (s *ScopedContainerProvider) inScope() (func(container types.Container) bool, error) {
+	labels := GetOptionalList(s.dbProvider, "system.containers.labels")
+	patterns := GetOptionalList(s.dbProvider, "system.containers.names")
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, xerrors.Errorf("invalid system.containers.names pattern %s: %w", pattern, err)
+		}
+	}
+	return func(container types.Container) bool {
+		for _, label := range labels {
+			key, value, withValue := strings.Cut(label, "=")
+			actual, ok := container.Labels[key]
+			if !ok || (withValue && actual != value) {
+				return false
+			}
+		}
+		if len(patterns) == 0 {
+			return true
+		}
+		return lo.SomeBy(container.Names, func(name string) bool {
+			return lo.SomeBy(patterns, func(pattern string) bool {
+				matched, _ := path.Match(pattern, strings.TrimPrefix(name, "/"))
+				return matched
+			})
+		})
+	}, nil
+}
+
+func (s *ScopedContainerProvider) ContainerList(ctx context.Context) ([]types.Container, error) {
+	inScope, err := s.inScope()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := s.provider.ContainerList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(containers, func(container types.Container, idx int) bool {
+		return inScope(container)
+	}), nil
+}
+
+// resolve returns the ID of a container given by ID or name, or types.ErrUnknownContainer if it is not in the scope
+func (s *ScopedContainerProvider) resolve(ctx context.Context, containerID string) (string, error) {
+	containers, err := s.ContainerList(ctx)
+	if err != nil {
+		return "", err
+	}
+	container, found := lo.Find(containers, func(container types.Container) bool {
+		return container.ID == containerID || lo.Contains(container.Names, "/"+strings.TrimPrefix(containerID, "/"))
+	})
+	if !found {
+		return "", xerrors.Errorf("%s: %w", containerID, types2.ErrUnknownContainer)
+	}
+	return container.ID, nil
+}
+
+func (s *ScopedContainerProvider) ContainerLogs(ctx context.Context, containerID string, query types2.ContainerLogsQuery, cb func(line types2.ContainerLogLine) error) error {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return s.provider.ContainerLogs(ctx, containerID, query, cb)
+}
+
+func (s *ScopedContainerProvider) ContainerStats(ctx context.Context, containerID string, stream bool, cb func(stats types2.ContainerStats) error) error {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return s.provider.ContainerStats(ctx, containerID, stream, cb)
+}
+
+func (s *ScopedContainerProvider) CheckImageUpdate(ctx context.Context, containerID string) (types2.ImageUpdateStatus, error) {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return types2.ImageUpdateStatus{}, err
+	}
+	return s.provider.CheckImageUpdate(ctx, containerID)
+}
+
+func (s *ScopedContainerProvider) UpdateContainer(ctx context.Context, containerID string, progress func(event types2.ImageUpdateEvent)) (types2.ImageUpdateResult, error) {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return types2.ImageUpdateResult{}, err
+	}
+	return s.provider.UpdateContainer(ctx, containerID, progress)
+}
+
+func (s *ScopedContainerProvider) ContainerExec(ctx context.Context, containerID string, options types2.ExecOptions, stdin io.Reader, stdout io.Writer, resize <-chan types2.TerminalSize) (int, error) {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return -1, err
+	}
+	return s.provider.ContainerExec(ctx, containerID, options, stdin, stdout, resize)
+}
+
+func (s *ScopedContainerProvider) ContainerStart(ctx context.Context, containerID string) error {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return s.provider.ContainerStart(ctx, containerID)
+}
+
+func (s *ScopedContainerProvider) ContainerStop(ctx context.Context, containerID string) error {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return s.provider.ContainerStop(ctx, containerID)
+}
+
+func (s *ScopedContainerProvider) ContainerRestart(ctx context.Context, containerID string) error {
+	containerID, err := s.resolve(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return s.provider.ContainerRestart(ctx, containerID)
+}
@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/docker/docker/api/types"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedContainerProvider(t *testing.T) {
+	docker := fakes.NewDockerProvider(
+		types.Container{ID: "om", Names: []string{"/openmower"}, Labels: map[string]string{"project": "openmower"}},
+		types.Container{ID: "gui", Names: []string{"/openmower-gui"}, Labels: map[string]string{"project": "openmower", "role": "gui"}},
+		types.Container{ID: "db", Names: []string{"/postgres"}, Labels: map[string]string{"project": "openmower"}},
+		types.Container{ID: "other", Names: []string{"/openmower-test"}},
+	)
+	db := fakes.NewDBProvider(map[string]string{"system.containers.names": "openmower*", "system.containers.labels": "project=openmower"})
+	provider := NewScopedContainerProvider(docker, db)
+	ctx := context.Background()
+
+	containers, err := provider.ContainerList(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"om", "gui"}, lo.Map(containers, func(container types.Container, idx int) string {
+		return container.ID
+	}))
+
+	require.NoError(t, provider.ContainerRestart(ctx, "om"))
+	require.NoError(t, provider.ContainerStop(ctx, "openmower-gui"))
+	err = provider.ContainerStop(ctx, "db")
+	assert.ErrorIs(t, err, types2.ErrUnknownContainer)
+	assert.EqualError(t, err, "db: no such container")
+	assert.ErrorIs(t, provider.ContainerStart(ctx, "other"), types2.ErrUnknownContainer)
+	assert.Equal(t, []string{"restart om", "stop gui"}, docker.History())
+
+	// a label without value only has to be present
+	require.NoError(t, db.Set("system.containers.labels", []byte("role")))
+	require.NoError(t, db.Set("system.containers.names", []byte("*")))
+	containers, err = provider.ContainerList(ctx)
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, "gui", containers[0].ID)
+
+	require.NoError(t, db.Set("system.containers.names", []byte("openmower[")))
+	_, err = provider.ContainerList(ctx)
+	assert.EqualError(t, err, "invalid system.containers.names pattern openmower[: syntax error in pattern")
+}
@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/bluenviron/goroslib/v2/pkg/serviceproc"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/dynamic_reconfigure"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+// serviceTypes are the ROS services that can be called through the API and MQTT indexed by their name
+var serviceTypes = map[string]any{
+	"/mower_service/high_level_control":             &mower_msgs.HighLevelControlSrv{},
+	"/mower_service/emergency":                      &mower_msgs.EmergencyStopSrv{},
+	"/mower_service/mow_enabled":                    &mower_msgs.MowerControlSrv{},
+	"/mower_service/start_in_area":                  &mower_msgs.StartInAreaSrv{},
+	"/mower_logic/set_parameters":                   &dynamic_reconfigure.Reconfigure{},
+	"/mower_map_service/add_mowing_area":            &mower_map.AddMowingAreaSrv{},
+	"/mower_map_service/clear_map":                  &mower_map.ClearMapSrv{},
+	"/mower_map_service/delete_mowing_area":         &mower_map.DeleteMowingAreaSrv{},
+	"/mower_map_service/convert_to_navigation_area": &mower_map.ConvertToNavigationAreaSrv{},
+	"/mower_map_service/get_mowing_area":            &mower_map.GetMowingAreaSrv{},
+	"/mower_map_service/set_docking_point":          &mower_map.SetDockingPointSrv{},
+	"/mower_map_service/get_docking_point":          &mower_map.GetDockingPointSrv{},
+	"/mower_map_service/set_nav_point":              &mower_map.SetNavPointSrv{},
+	"/mower_map_service/clear_nav_point":            &mower_map.ClearNavPointSrv{},
+	"/mower_map_service/append_map":                 &mower_map.AppendMapSrv{},
+}
+
+// ServiceNames returns the names of the services that can be called with CallRegisteredService
+func ServiceNames() []string {
+	var names []string
+	for name := range serviceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CallRegisteredService calls a service of the registry. decode fills the request, a pointer to the generated
+// request type, and the response is returned as a pointer to the generated response type.
+func CallRegisteredService(ctx context.Context, rosProvider types.IRosProvider, name string, decode func(req any) error) (any, error) {
+	srv, ok := serviceTypes[name]
+	if !ok {
+		return nil, xerrors.Errorf("%s: %w", name, types.ErrUnknownService)
+	}
+	req, res, err := serviceproc.RequestResponse(reflect.ValueOf(srv).Elem().Interface())
+	if err != nil {
+		return nil, err
+	}
+	reqPtr := reflect.New(reflect.TypeOf(req)).Interface()
+	resPtr := reflect.New(reflect.TypeOf(res)).Interface()
+	err = decode(reqPtr)
+	if err != nil {
+		return nil, err
+	}
+	err = rosProvider.CallService(ctx, name, srv, reqPtr, resPtr)
+	if err != nil {
+		return nil, err
+	}
+	return resPtr, nil
+}
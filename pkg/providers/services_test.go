@@ -0,0 +1,37 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallRegisteredService(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	for _, service := range ServiceNames() {
+		_, err := CallRegisteredService(context.Background(), ros, service, func(req any) error {
+			return nil
+		})
+		assert.NoError(t, err, service)
+	}
+
+	ros.ServiceResponses["/mower_map_service/get_mowing_area"] = mower_map.GetMowingAreaSrvRes{Area: mower_map.MapArea{Name: "front"}}
+	res, err := CallRegisteredService(context.Background(), ros, "/mower_map_service/get_mowing_area", func(req any) error {
+		req.(*mower_map.GetMowingAreaSrvReq).Index = 2
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "front", res.(*mower_map.GetMowingAreaSrvRes).Area.Name)
+	calls := ros.Calls()
+	assert.Equal(t, mower_map.GetMowingAreaSrvReq{Index: 2}, calls[len(calls)-1].Req)
+
+	_, err = CallRegisteredService(context.Background(), ros, "/unknown", func(req any) error {
+		return nil
+	})
+	assert.True(t, errors.Is(err, types.ErrUnknownService))
+}
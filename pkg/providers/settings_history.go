@@ -0,0 +1,271 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+var settingsLog = Logs.Logger("settings")
+
+const settingsRevisionPrefix = "gui.settings.revisions."
+
+// CurrentRevision is the revision ID of the current content of mower_config.sh
+const CurrentRevision = "current"
+
+type settingsRevisionRecord struct {
+	types.SettingsRevision
+	Content []byte `json:"content"`
+}
+
+// SettingsHistory writes mower_config.sh and saves its previous content as a revision in the DB, keeping the last
+// system.settings.historySize revisions. The revision IDs are the write times in nanoseconds so they sort in order.
+type SettingsHistory struct {
+	dbProvider types.IDBProvider
+	mtx        sync.Mutex
+}
+
+func NewSettingsHistory(dbProvider types.IDBProvider) *SettingsHistory {
+	return &SettingsHistory{dbProvider: dbProvider}
+}
+
+// Write replaces the content of mower_config.sh, the previous content is saved as a revision made by author. Nothing
+// is written if the content does not change.
+func (h *SettingsHistory) Write(data []byte, author string) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.write(data, author)
+}
+
+// Update reads mower_config.sh, changes it with update and writes it as Write does. The whole read-modify-write holds
+// the lock so that concurrent changes are not lost, nothing is written if update fails.
+func (h *SettingsHistory) Update(update func(config *MowerConfig) error, author string) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	path, err := h.dbProvider.Get("system.mower.configFile")
+	if err != nil {
+		return err
+	}
+	config, err := ReadMowerConfig(string(path))
+	if err != nil {
+		return err
+	}
+	err = update(config)
+	if err != nil {
+		return err
+	}
+	return h.write(config.Bytes(), author)
+}
+
+func (h *SettingsHistory) write(data []byte, author string) error {
+	path, err := h.dbProvider.Get("system.mower.configFile")
+	if err != nil {
+		return err
+	}
+	previous, err := os.ReadFile(string(path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil && bytes.Equal(previous, data) {
+		return nil
+	}
+	var key string
+	if err == nil {
+		// a missing file has nothing to restore
+		key, err = h.save(previous, data, author)
+		if err != nil {
+			return err
+		}
+	}
+	err = writeFileAtomic(string(path), data, 0644)
+	if err != nil && key != "" {
+		if deleteErr := h.dbProvider.Delete(key); deleteErr != nil {
+			settingsLog.Error(xerrors.Errorf("failed to delete settings revision %s: %w", key, deleteErr))
+		}
+	}
+	return err
+}
+
+// save stores the previous content as a revision and drops the oldest revisions, it returns the key of the revision
+func (h *SettingsHistory) save(previous []byte, data []byte, author string) (string, error) {
+	changes, err := diffMowerConfigs(previous, data)
+	if err != nil {
+		return "", err
+	}
+	keys, err := h.keys()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	id := now.UnixNano()
+	if len(keys) > 0 {
+		last, _ := strconv.ParseInt(keys[len(keys)-1][len(settingsRevisionPrefix):], 10, 64)
+		id = lo.Max([]int64{id, last + 1})
+	}
+	record := settingsRevisionRecord{
+		SettingsRevision: types.SettingsRevision{
+			ID:     strconv.FormatInt(id, 10),
+			Time:   now,
+			Author: author,
+			Changes: lo.Map(changes, func(change types.SettingChange, idx int) string {
+				return change.Key
+			}),
+		},
+		Content: previous,
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	key := settingsRevisionPrefix + record.ID
+	err = h.dbProvider.Set(key, value)
+	if err != nil {
+		return "", err
+	}
+	size, err := GetInt(h.dbProvider, "system.settings.historySize")
+	if err != nil {
+		settingsLog.Error(xerrors.Errorf("failed to get system.settings.historySize: %w", err))
+		return key, nil
+	}
+	keys = append(keys, key)
+	for len(keys) > size && len(keys) > 1 {
+		if err := h.dbProvider.Delete(keys[0]); err != nil {
+			settingsLog.Error(xerrors.Errorf("failed to delete settings revision %s: %w", keys[0], err))
+		}
+		keys = keys[1:]
+	}
+	return key, nil
+}
+
+// keys returns the keys of the revisions, oldest first
+func (h *SettingsHistory) keys() ([]string, error) {
+	keys, err := h.dbProvider.KeysWithSuffix(settingsRevisionPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys, nil
+}
+
+func (h *SettingsHistory) get(id string) (settingsRevisionRecord, error) {
+	var record settingsRevisionRecord
+	value, err := h.dbProvider.Get(settingsRevisionPrefix + id)
+	if err != nil || len(value) == 0 {
+		return record, xerrors.Errorf("%s: %w", id, types.ErrUnknownRevision)
+	}
+	err = json.Unmarshal(value, &record)
+	return record, err
+}
+
+// content returns the content of a revision, CurrentRevision is the content of mower_config.sh
+func (h *SettingsHistory) content(id string) ([]byte, error) {
+	if id == CurrentRevision {
+		path, err := h.dbProvider.Get("system.mower.configFile")
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(string(path))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return data, nil
+	}
+	record, err := h.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return record.Content, nil
+}
+
+// Revisions returns the revisions, newest first
+func (h *SettingsHistory) Revisions() ([]types.SettingsRevision, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	keys, err := h.keys()
+	if err != nil {
+		return nil, err
+	}
+	revisions := []types.SettingsRevision{}
+	for i := len(keys) - 1; i >= 0; i-- {
+		record, err := h.get(keys[i][len(settingsRevisionPrefix):])
+		if err != nil {
+			settingsLog.Error(xerrors.Errorf("failed to read settings revision %s: %w", keys[i], err))
+			continue
+		}
+		revisions = append(revisions, record.SettingsRevision)
+	}
+	return revisions, nil
+}
+
+// Diff returns the settings changed from a revision to another, either can be CurrentRevision
+func (h *SettingsHistory) Diff(from string, to string) ([]types.SettingChange, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	fromContent, err := h.content(from)
+	if err != nil {
+		return nil, err
+	}
+	toContent, err := h.content(to)
+	if err != nil {
+		return nil, err
+	}
+	return diffMowerConfigs(fromContent, toContent)
+}
+
+// Restore writes the content of a revision to mower_config.sh, the replaced content is saved as a new revision so that
+// the restore can be undone
+func (h *SettingsHistory) Restore(id string, author string) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	record, err := h.get(id)
+	if err != nil {
+		return err
+	}
+	return h.write(record.Content, author)
+}
+
+// diffMowerConfigs returns the changes of the values from a file to another, sorted by key
+func diffMowerConfigs(from []byte, to []byte) ([]types.SettingChange, error) {
+	fromConfig, err := ParseMowerConfig(from)
+	if err != nil {
+		return nil, err
+	}
+	toConfig, err := ParseMowerConfig(to)
+	if err != nil {
+		return nil, err
+	}
+	fromValues, toValues := fromConfig.Values(), toConfig.Values()
+	changes := []types.SettingChange{}
+	for key, old := range fromValues {
+		value, ok := toValues[key]
+		switch {
+		case !ok:
+			changes = append(changes, types.SettingChange{Key: key, Change: "removed", Old: old})
+		case value != old:
+			changes = append(changes, types.SettingChange{Key: key, Change: "changed", Old: old, New: value})
+		}
+	}
+	for key, value := range toValues {
+		if _, ok := fromValues[key]; !ok {
+			changes = append(changes, types.SettingChange{Key: key, Change: "added", New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
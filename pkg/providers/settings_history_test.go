@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mower_config.sh")
+	history := NewSettingsHistory(fakes.NewDBProvider(map[string]string{
+		"system.mower.configFile":     path,
+		"system.settings.historySize": "2",
+	}))
+
+	// a missing file has no revision
+	require.NoError(t, history.Write([]byte("export OM_TOOL_WIDTH=0.13\n"), "alice"))
+	revisions, err := history.Revisions()
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	require.NoError(t, history.Write([]byte("export OM_TOOL_WIDTH=0.14\nexport OM_USE_NTRIP=False\n"), "bob"))
+	require.NoError(t, history.Write([]byte("export OM_TOOL_WIDTH=0.14\nexport OM_USE_NTRIP=False\n"), "bob"))
+	require.NoError(t, history.Write([]byte("export OM_USE_NTRIP=True\n"), "carol"))
+	revisions, err = history.Revisions()
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "carol", revisions[0].Author)
+	assert.Equal(t, []string{"OM_TOOL_WIDTH", "OM_USE_NTRIP"}, revisions[0].Changes)
+	assert.Equal(t, "bob", revisions[1].Author)
+	assert.Equal(t, []string{"OM_TOOL_WIDTH", "OM_USE_NTRIP"}, revisions[1].Changes)
+
+	changes, err := history.Diff(revisions[1].ID, CurrentRevision)
+	require.NoError(t, err)
+	assert.Equal(t, []types.SettingChange{
+		{Key: "OM_TOOL_WIDTH", Change: "removed", Old: "0.13"},
+		{Key: "OM_USE_NTRIP", Change: "added", New: "True"},
+	}, changes)
+	_, err = history.Diff("1", CurrentRevision)
+	assert.ErrorIs(t, err, types.ErrUnknownRevision)
+
+	require.NoError(t, history.Restore(revisions[0].ID, "dave"))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "export OM_TOOL_WIDTH=0.14\nexport OM_USE_NTRIP=False\n", string(content))
+
+	// the oldest revision is dropped, the restored content can be restored back
+	revisions, err = history.Revisions()
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "dave", revisions[0].Author)
+	assert.Equal(t, "carol", revisions[1].Author)
+	assert.ErrorIs(t, history.Restore("1", "dave"), types.ErrUnknownRevision)
+}
+
+func TestSettingsHistoryUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mower_config.sh")
+	history := NewSettingsHistory(fakes.NewDBProvider(map[string]string{
+		"system.mower.configFile":     path,
+		"system.settings.historySize": "50",
+	}))
+
+	// the concurrent updates of different keys are all kept
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, history.Update(func(config *MowerConfig) error {
+				return config.Set(fmt.Sprintf("OM_KEY_%d", i), "True")
+			}, "alice"))
+		}(i)
+	}
+	wg.Wait()
+	config, err := ReadMowerConfig(path)
+	require.NoError(t, err)
+	assert.Len(t, config.Values(), 10)
+
+	// nothing is written when the update fails
+	err = history.Update(func(config *MowerConfig) error {
+		config.Delete("OM_KEY_0")
+		return errors.New("invalid")
+	}, "bob")
+	assert.EqualError(t, err, "invalid")
+	config, err = ReadMowerConfig(path)
+	require.NoError(t, err)
+	assert.Len(t, config.Values(), 10)
+}
@@ -0,0 +1,169 @@
+package providers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+)
+
+func settingBound(value float64) *float64 {
+	return &value
+}
+
+var ntripEnabled = &types.SettingCondition{Key: "OM_USE_NTRIP", Value: "True"}
+var mqttEnabled = &types.SettingCondition{Key: "OM_MQTT_ENABLE", Value: "True"}
+
+// MowerSettingsSchema are the known settings of mower_config.sh, the settings page is built from it in this order
+var MowerSettingsSchema = []types.SettingSchema{
+	{Key: "OM_DATUM_LAT", Section: "Datum", Type: types.SettingLat, Default: "43.0", Min: settingBound(-90), Max: settingBound(90), Description: "Latitude of the datum point", Help: "This will be your map origin!"},
+	{Key: "OM_DATUM_LONG", Section: "Datum", Type: types.SettingLon, Default: "2.0", Min: settingBound(-180), Max: settingBound(180), Description: "Longitude of the datum point", Help: "This will be your map origin!"},
+	{Key: "OM_USE_NTRIP", Section: "NTRIP", Type: types.SettingBoolean, Default: "True", Description: "Use NTRIP to get RTK corrections", Help: "Set to False if using external radio plugged into the Ardusimple board."},
+	{Key: "OM_NTRIP_HOSTNAME", Section: "NTRIP", Type: types.SettingString, Default: "192.168.178.55", Description: "NTRIP server hostname", RequiredWhen: ntripEnabled},
+	{Key: "OM_NTRIP_PORT", Section: "NTRIP", Type: types.SettingInt, Default: "2101", Min: settingBound(1), Max: settingBound(65535), Description: "NTRIP server port", RequiredWhen: ntripEnabled},
+	{Key: "OM_NTRIP_USER", Section: "NTRIP", Type: types.SettingString, Default: "gps", Description: "NTRIP server username"},
+	{Key: "OM_NTRIP_PASSWORD", Section: "NTRIP", Type: types.SettingString, Default: "gps", Description: "NTRIP server password"},
+	{Key: "OM_NTRIP_ENDPOINT", Section: "NTRIP", Type: types.SettingString, Default: "BASE1", Description: "NTRIP server endpoint", RequiredWhen: ntripEnabled},
+	{Key: "OM_MOWER_GAMEPAD", Section: "Mower", Type: types.SettingSelect, Default: "xbox360", Description: "Gamepad type", Options: []types.SettingOption{
+		{ID: "xbox360", Label: "Xbox 360"},
+		{ID: "ps3", Label: "PS3"},
+		{ID: "shield", Label: "Shield"},
+		{ID: "steam_stick", Label: "Steam Stick"},
+		{ID: "steam_touch", Label: "Steam Touch"},
+		{ID: "switch_pro", Label: "Switch Pro"},
+	}},
+	{Key: "OM_ENABLE_RECORDING_ALL", Section: "Recording", Type: types.SettingBoolean, Default: "False", Description: "Enable recording of all topics"},
+	{Key: "OM_PERIMETER_SIGNAL", Section: "Mower", Type: types.SettingBoolean, Default: "False", Description: "Enable perimeter signal"},
+	{Key: "OM_USE_RELATIVE_POSITION", Section: "Positioning", Type: types.SettingBoolean, Default: "False", Description: "Use relative position to datum point", Help: "If set to true, the mower will use the position relative to the datum point. If set to false, the mower will use the absolute position."},
+	{Key: "OM_GPS_PROTOCOL", Section: "Positioning", Type: types.SettingSelect, Default: "UBX", Description: "GPS protocol", Options: []types.SettingOption{
+		{ID: "UBX", Label: "UBX"},
+		{ID: "NMEA", Label: "NMEA"},
+	}},
+	{Key: "OM_GPS_BAUDRATE", Section: "Positioning", Type: types.SettingInt, Default: "921600", Min: settingBound(1), Description: "GPS baudrate"},
+	{Key: "OM_GPS_PORT", Section: "Positioning", Type: types.SettingString, Default: "/dev/serial/by-id/usb-u-blox_AG_-_www.u-blox.com_u-blox_GNSS_receiver-if00", Description: "GPS port (/dev/gps on mowgli)"},
+	{Key: "OM_ANTENNA_OFFSET_X", Section: "Positioning", Type: types.SettingFloat, Default: "0.3", Description: "Antenna offset X"},
+	{Key: "OM_ANTENNA_OFFSET_Y", Section: "Positioning", Type: types.SettingFloat, Default: "0.0", Description: "Antenna offset Y"},
+	{Key: "OM_USE_F9R_SENSOR_FUSION", Section: "Positioning", Type: types.SettingBoolean, Default: "False", Description: "Use F9R sensor fusion", Help: "If you want to use F9R's sensor fusion, set this to true (you will also need to set DATUM_LAT and DATUM_LONG). If you want to use the GPS position, set this to false."},
+	{Key: "OM_DOCKING_DISTANCE", Section: "Docking", Type: types.SettingFloat, Default: "1.0", Min: settingBound(0), Description: "Distance to dock"},
+	{Key: "OM_UNDOCK_DISTANCE", Section: "Docking", Type: types.SettingFloat, Default: "2.0", Min: settingBound(0), Description: "Distance to undock"},
+	{Key: "OM_DOCKING_EXTRA_TIME", Section: "Docking", Type: types.SettingFloat, Default: "0.0", Min: settingBound(0), Description: "Extra time (s) to continue docking after detecting voltage"},
+	{Key: "OM_OUTLINE_COUNT", Section: "Navigation", Type: types.SettingInt, Default: "4", Min: settingBound(0), Description: "Number of points in the outline"},
+	{Key: "OM_MOWING_ANGLE_OFFSET", Section: "Mower", Type: types.SettingFloat, Default: "0", Description: "Mowing angle offset"},
+	{Key: "OM_MOWING_ANGLE_INCREMENT", Section: "Mower", Type: types.SettingFloat, Default: "0.1", Description: "Mowing angle increment"},
+	{Key: "OM_WHEEL_DISTANCE_M", Section: "Mower", Type: types.SettingFloat, Default: "0.325", Min: settingBound(0), Description: "Distance between wheels in m"},
+	{Key: "OM_WHEEL_TICKS_PER_M", Section: "Mower", Type: types.SettingFloat, Default: "1600", Min: settingBound(0), Description: "Wheel ticks per meter"},
+	{Key: "OM_MOWING_ANGLE_OFFSET_IS_ABSOLUTE", Section: "Mower", Type: types.SettingBoolean, Default: "False", Description: "Mowing angle offset is absolute"},
+	{Key: "OM_TOOL_WIDTH", Section: "Mower", Type: types.SettingFloat, Default: "0.13", Min: settingBound(0.01), Description: "Tool width", Help: "Choose it smaller than your actual mowing tool in order to have some overlap."},
+	{Key: "OM_BATTERY_EMPTY_VOLTAGE", Section: "Mower", Type: types.SettingFloat, Default: "25.0", Min: settingBound(0), Description: "Battery empty voltage", Help: "Voltage for battery to be considered empty"},
+	{Key: "OM_BATTERY_FULL_VOLTAGE", Section: "Mower", Type: types.SettingFloat, Default: "28.5", Min: settingBound(0), GreaterThan: "OM_BATTERY_EMPTY_VOLTAGE", Description: "Battery full voltage", Help: "Voltage for battery to be considered full"},
+	{Key: "OM_BATTERY_CAPACITY_MAH", Section: "Mower", Type: types.SettingFloat, Default: "3000.0", Min: settingBound(0), Description: "Battery capacity", Help: "Battery capacity in mAh"},
+	{Key: "OM_MOWING_MOTOR_TEMP_HIGH", Section: "Mower", Type: types.SettingFloat, Default: "80.0", GreaterThan: "OM_MOWING_MOTOR_TEMP_LOW", Description: "Mowing motor temperature high", Help: "If the temperature of the mowing motor is higher than this value, the mower will stop."},
+	{Key: "OM_MOWING_MOTOR_TEMP_LOW", Section: "Mower", Type: types.SettingFloat, Default: "40.0", Description: "Mowing motor temperature low", Help: "If the temperature of the mowing motor is lower than this value, the mower will start again."},
+	{Key: "OM_GPS_WAIT_TIME_SEC", Section: "Positioning", Type: types.SettingFloat, Default: "10.0", Min: settingBound(0), Description: "GPS wait time"},
+	{Key: "OM_GPS_TIMEOUT_SEC", Section: "Positioning", Type: types.SettingFloat, Default: "5.0", Min: settingBound(0), Description: "GPS timeout"},
+	{Key: "OM_ENABLE_MOWER", Section: "Mower", Type: types.SettingBoolean, Default: "True", Description: "Enable mower"},
+	{Key: "OM_AUTOMATIC_MODE", Section: "Mower", Type: types.SettingSelect, Default: "0", Description: "Automatic mode", Options: []types.SettingOption{
+		{ID: "0", Label: "MANUAL - mowing requires manual start"},
+		{ID: "1", Label: "SEMIAUTO - mow the entire map once then wait for manual start again"},
+		{ID: "2", Label: "AUTO - mow whenever possible"},
+	}},
+	{Key: "OM_OUTLINE_OFFSET", Section: "Navigation", Type: types.SettingFloat, Default: "0.05", Description: "Outline offset", Help: "Offset of the outline from the boundary in meters."},
+	{Key: "OM_OUTLINE_OVERLAP_COUNT", Section: "Navigation", Type: types.SettingInt, Default: "0", Min: settingBound(0), Description: "How many outlines should the fill (lanes) overlap", Help: "Number of points in the overlap"},
+	{Key: "OM_MQTT_ENABLE", Section: "OpenMower MQTT", Type: types.SettingBoolean, Default: "False", Description: "Enable OpenMower MQTT"},
+	{Key: "OM_MQTT_HOSTNAME", Section: "OpenMower MQTT", Type: types.SettingString, Default: "your_mqtt_broker", Description: "MQTT broker hostname", RequiredWhen: mqttEnabled},
+	{Key: "OM_MQTT_PORT", Section: "OpenMower MQTT", Type: types.SettingInt, Default: "1883", Min: settingBound(1), Max: settingBound(65535), Description: "MQTT broker port", RequiredWhen: mqttEnabled},
+	{Key: "OM_MQTT_USER", Section: "OpenMower MQTT", Type: types.SettingString, Default: "", Description: "MQTT broker username"},
+	{Key: "OM_MQTT_PASSWORD", Section: "OpenMower MQTT", Type: types.SettingString, Default: "", Description: "MQTT broker password"},
+	{Key: "OM_MQTT_TOPIC_PREFIX", Section: "OpenMower MQTT", Type: types.SettingString, Default: "", Description: "MQTT topic prefix"},
+}
+
+// ValidateMowerSettings checks the values of the changed settings, and of the settings depending on them, against
+// MowerSettingsSchema, it returns a types.ValidationError with the error of each invalid setting. The unknown settings
+// are not checked, neither are the settings left unchanged so that an invalid value already in the file doesn't
+// prevent saving the other settings.
+func ValidateMowerSettings(values map[string]string, changed []string) error {
+	fields := map[string]string{}
+	for _, setting := range MowerSettingsSchema {
+		if !settingAffected(setting, changed) {
+			continue
+		}
+		value, ok := values[setting.Key]
+		if condition := setting.RequiredWhen; condition != nil && value == "" && strings.EqualFold(values[condition.Key], condition.Value) {
+			fields[setting.Key] = "required when " + condition.Key + " is " + condition.Value
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := validateMowerSetting(setting, value, values); err != "" {
+			fields[setting.Key] = err
+		}
+	}
+	if len(fields) > 0 {
+		return &types.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// settingAffected tells if the setting or one of the settings it depends on changed
+func settingAffected(setting types.SettingSchema, changed []string) bool {
+	dependencies := []string{setting.Key}
+	if setting.GreaterThan != "" {
+		dependencies = append(dependencies, setting.GreaterThan)
+	}
+	if setting.RequiredWhen != nil {
+		dependencies = append(dependencies, setting.RequiredWhen.Key)
+	}
+	return lo.Some(changed, dependencies)
+}
+
+// validateMowerSetting returns the error of a value or an empty string if it is valid
+func validateMowerSetting(setting types.SettingSchema, value string, values map[string]string) string {
+	var number float64
+	var err error
+	switch setting.Type {
+	case types.SettingString:
+		return ""
+	case types.SettingBoolean:
+		if !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false") {
+			return "must be True or False"
+		}
+		return ""
+	case types.SettingSelect:
+		if !lo.ContainsBy(setting.Options, func(option types.SettingOption) bool {
+			return option.ID == value
+		}) {
+			return "must be one of " + strings.Join(lo.Map(setting.Options, func(option types.SettingOption, idx int) string {
+				return option.ID
+			}), ", ")
+		}
+		return ""
+	case types.SettingInt:
+		var n int
+		n, err = strconv.Atoi(value)
+		if err != nil {
+			return "must be an integer"
+		}
+		number = float64(n)
+	default:
+		number, err = strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return "must be a number"
+		}
+	}
+	if setting.Min != nil && number < *setting.Min {
+		return "must be at least " + strconv.FormatFloat(*setting.Min, 'f', -1, 64)
+	}
+	if setting.Max != nil && number > *setting.Max {
+		return "must be at most " + strconv.FormatFloat(*setting.Max, 'f', -1, 64)
+	}
+	if setting.GreaterThan != "" {
+		other, err := strconv.ParseFloat(values[setting.GreaterThan], 64)
+		if err == nil && number <= other {
+			return "must be greater than " + setting.GreaterThan
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMowerSettings(t *testing.T) {
+	defaults := map[string]string{}
+	for _, setting := range MowerSettingsSchema {
+		defaults[setting.Key] = setting.Default
+	}
+	assert.NoError(t, ValidateMowerSettings(defaults, lo.Keys(defaults)))
+	assert.NoError(t, ValidateMowerSettings(map[string]string{"OM_USE_NTRIP": "false", "OM_CUSTOM": "x"}, []string{"OM_USE_NTRIP", "OM_CUSTOM"}))
+
+	values := map[string]string{
+		"OM_USE_NTRIP":             "true",
+		"OM_NTRIP_HOSTNAME":        "caster",
+		"OM_NTRIP_PORT":            "21o1",
+		"OM_DATUM_LAT":             "91",
+		"OM_DATUM_LONG":            "-180",
+		"OM_ENABLE_MOWER":          "yes",
+		"OM_AUTOMATIC_MODE":        "3",
+		"OM_BATTERY_EMPTY_VOLTAGE": "28.5",
+		"OM_BATTERY_FULL_VOLTAGE":  "28.5",
+		"OM_TOOL_WIDTH":            "NaN",
+	}
+	err := ValidateMowerSettings(values, lo.Keys(values))
+	var validationErr *types.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, map[string]string{
+		"OM_NTRIP_PORT":           "must be an integer",
+		"OM_NTRIP_ENDPOINT":       "required when OM_USE_NTRIP is True",
+		"OM_DATUM_LAT":            "must be at most 90",
+		"OM_ENABLE_MOWER":         "must be True or False",
+		"OM_AUTOMATIC_MODE":       "must be one of 0, 1, 2",
+		"OM_BATTERY_FULL_VOLTAGE": "must be greater than OM_BATTERY_EMPTY_VOLTAGE",
+		"OM_TOOL_WIDTH":           "must be a number",
+	}, validationErr.Fields)
+
+	// the unchanged settings are only checked when a setting they depend on changed
+	err = ValidateMowerSettings(values, []string{"OM_USE_NTRIP", "OM_BATTERY_EMPTY_VOLTAGE"})
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, map[string]string{
+		"OM_NTRIP_PORT":           "must be an integer",
+		"OM_NTRIP_ENDPOINT":       "required when OM_USE_NTRIP is True",
+		"OM_BATTERY_FULL_VOLTAGE": "must be greater than OM_BATTERY_EMPTY_VOLTAGE",
+	}, validationErr.Fields)
+	assert.NoError(t, ValidateMowerSettings(values, []string{"OM_DATUM_LONG"}))
+}
@@ -0,0 +1,991 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgproc"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/nav_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/sensor_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/std_msgs"
+	"github.com/bluenviron/goroslib/v2/pkg/serviceproc"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/dynamic_reconfigure"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/paulmach/orb"
+	"golang.org/x/xerrors"
+)
+
+const (
+	simTick            = 100 * time.Millisecond
+	simSpeed           = 0.4  // m/s
+	simToolWidth       = 0.3  // m, distance between two coverage lines
+	simUndockDistance  = 1.0  // m
+	simDrainPerSecond  = 0.05 // battery percent
+	simChargePerSecond = 0.2  // battery percent
+	simLowBattery      = 20.0 // battery percent
+)
+
+// simServices are the services answered by SimRosProvider
+var simServices = map[string]any{
+	"/mower_service/high_level_control":             &mower_msgs.HighLevelControlSrv{},
+	"/mower_service/emergency":                      &mower_msgs.EmergencyStopSrv{},
+	"/mower_service/mow_enabled":                    &mower_msgs.MowerControlSrv{},
+	"/mower_service/start_in_area":                  &mower_msgs.StartInAreaSrv{},
+	"/mower_logic/set_parameters":                   &dynamic_reconfigure.Reconfigure{},
+	"/mower_map_service/add_mowing_area":            &mower_map.AddMowingAreaSrv{},
+	"/mower_map_service/clear_map":                  &mower_map.ClearMapSrv{},
+	"/mower_map_service/delete_mowing_area":         &mower_map.DeleteMowingAreaSrv{},
+	"/mower_map_service/convert_to_navigation_area": &mower_map.ConvertToNavigationAreaSrv{},
+	"/mower_map_service/get_mowing_area":            &mower_map.GetMowingAreaSrv{},
+	"/mower_map_service/set_docking_point":          &mower_map.SetDockingPointSrv{},
+	"/mower_map_service/get_docking_point":          &mower_map.GetDockingPointSrv{},
+	"/mower_map_service/set_nav_point":              &mower_map.SetNavPointSrv{},
+	"/mower_map_service/clear_nav_point":            &mower_map.ClearNavPointSrv{},
+	"/mower_map_service/append_map":                 &mower_map.AppendMapSrv{},
+}
+
+// simNodes are the nodes reported by SimRosProvider with the topics they publish
+var simNodes = map[string][]string{
+	"/mower_logic":       {"/mower_logic/current_state", "/move_base_flex/FTCPlanner/global_plan"},
+	"/mower_comms":       {"/mower/status", "/mower/wheel_ticks", "/imu/data_raw"},
+	"/mower_map_service": {},
+	"/xbot_positioning":  {"/xbot_positioning/xb_pose"},
+	"/xbot_driver_gps":   {"/xbot_driver_gps/xb_pose"},
+	"/xbot_monitoring":   {"/xbot_monitoring/map"},
+}
+
+// simSensors are the sensors published by the simulated mower_comms
+var simSensors = []xbot_msgs.SensorInfo{
+	{
+		SensorId: "om_v_battery", SensorName: "Battery Voltage", ValueType: xbot_msgs.SensorInfo_TYPE_DOUBLE,
+		ValueDescription: xbot_msgs.SensorInfo_VALUE_DESCRIPTION_VOLTAGE, Unit: "V",
+		HasMinMax: true, MinValue: 20, MaxValue: 30, HasCriticalLow: true, LowerCriticalValue: 21.7,
+	},
+	{
+		SensorId: "om_charge_current", SensorName: "Charge Current", ValueType: xbot_msgs.SensorInfo_TYPE_DOUBLE,
+		ValueDescription: xbot_msgs.SensorInfo_VALUE_DESCRIPTION_CURRENT, Unit: "A",
+		HasMinMax: true, MinValue: 0, MaxValue: 2,
+	},
+	{
+		SensorId: "om_mow_motor_temp", SensorName: "Mow Motor Temperature", ValueType: xbot_msgs.SensorInfo_TYPE_DOUBLE,
+		ValueDescription: xbot_msgs.SensorInfo_VALUE_DESCRIPTION_TEMPERATURE, Unit: "deg.C",
+		HasMinMax: true, MinValue: 0, MaxValue: 100, HasCriticalHigh: true, UpperCriticalValue: 80,
+	},
+	{
+		SensorId: "om_mow_motor_rpm", SensorName: "Mow Motor Speed", ValueType: xbot_msgs.SensorInfo_TYPE_DOUBLE,
+		ValueDescription: xbot_msgs.SensorInfo_VALUE_DESCRIPTION_RPM, Unit: "rpm",
+	},
+	{
+		SensorId: "om_esc_status", SensorName: "Mow ESC Status", ValueType: xbot_msgs.SensorInfo_TYPE_STRING,
+	},
+}
+
+func init() {
+	simNodes["/mower_logic"] = append(simNodes["/mower_logic"], robotStateTopic)
+	for _, sensor := range simSensors {
+		simNodes["/mower_comms"] = append(simNodes["/mower_comms"], sensorInfoTopic(sensor.SensorId), sensorDataTopic(sensor.SensorId))
+	}
+}
+
+// simClock gives the time of the simulation and calls it on each tick
+type simClock interface {
+	Now() time.Time
+	Every(d time.Duration, f func())
+}
+
+type systemSimClock struct{}
+
+func (systemSimClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemSimClock) Every(d time.Duration, f func()) {
+	go func() {
+		for range time.Tick(d) {
+			f()
+		}
+	}()
+}
+
+// newSimClock returns the clock of a new simulation, tests replace it with a clock they advance themselves
+var newSimClock = func() simClock {
+	return systemSimClock{}
+}
+
+type simMap struct {
+	MowingAreas     []mower_map.MapArea
+	NavigationAreas []mower_map.MapArea
+	DockX           float64
+	DockY           float64
+	DockHeading     float64
+}
+
+type simPublisher struct {
+	sim   *SimRosProvider
+	topic string
+}
+
+func (s *simPublisher) Write(msg interface{}) {
+	s.sim.mtx.Lock()
+	defer s.sim.mtx.Unlock()
+	switch msg := msg.(type) {
+	case *geometry_msgs.Twist:
+		if s.topic == "/joy_vel" {
+			s.sim.twist = *msg
+			s.sim.twistAt = s.sim.clock.Now()
+		}
+	case *std_msgs.String:
+		if s.topic == actionTopic {
+			s.sim.triggerAction(msg.Data)
+		}
+	}
+}
+
+func (s *simPublisher) Close() {
+}
+
+// SimRosProvider simulates a mower and the OpenMower ROS nodes, it is used to develop the GUI without a robot
+type SimRosProvider struct {
+	dbProvider  types.IDBProvider
+	clock       simClock
+	mtx         sync.Mutex
+	subscribers map[string]map[string]*RosSubscriber
+	lastMessage map[string][]byte
+	topicTypes  map[string]reflect.Type
+	replaying   bool
+	services    map[string]func(req any) (any, error)
+
+	registeredActions string
+	stateName         string
+	emergency         bool
+	mowEnabled        bool
+	battery           float64
+	x                 float64
+	y                 float64
+	heading           float64
+	distance          float64
+	currentArea       int
+	coverage          []orb.Point
+	target            *orb.Point
+	mowingPaths       []*nav_msgs.Path
+	twist             geometry_msgs.Twist
+	twistAt           time.Time
+	ticks             uint32
+	tacho             uint32
+	tickCount         int
+	mapData           simMap
+}
+
+func NewSimRosProvider(dbProvider types.IDBProvider) types.IRosProvider {
+	s := &SimRosProvider{
+		dbProvider:  dbProvider,
+		clock:       newSimClock(),
+		subscribers: make(map[string]map[string]*RosSubscriber),
+		lastMessage: make(map[string][]byte),
+		topicTypes:  make(map[string]reflect.Type),
+		services:    make(map[string]func(req any) (any, error)),
+		stateName:   "IDLE",
+		battery:     100,
+	}
+	mapJson, err := dbProvider.Get("gui.sim.map")
+	if err == nil {
+		err = json.Unmarshal(mapJson, &s.mapData)
+		if err != nil {
+			rosLog.Error(xerrors.Errorf("failed to unmarshal simulated map: %w", err))
+		}
+	}
+	s.x = s.mapData.DockX
+	s.y = s.mapData.DockY
+	s.heading = s.mapData.DockHeading
+	s.mtx.Lock()
+	s.publishMap()
+	s.publishSensorInfos()
+	s.mtx.Unlock()
+	s.clock.Every(simTick, func() {
+		s.step(simTick.Seconds())
+		s.registerActions()
+	})
+	rosLog.Info("Using simulated mower")
+	return s
+}
+
+func (s *SimRosProvider) CallService(ctx context.Context, srvName string, srv any, req any, res any) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	switch srvName {
+	case "/mower_service/high_level_control":
+		r, ok := req.(*mower_msgs.HighLevelControlSrvReq)
+		if !ok {
+			break
+		}
+		switch r.Command {
+		case mower_msgs.HighLevelControlSrvReq_COMMAND_START:
+			s.start()
+		case mower_msgs.HighLevelControlSrvReq_COMMAND_HOME:
+			if s.stateName == "MOWING" || s.stateName == "UNDOCKING" {
+				s.setState("DOCKING")
+			}
+		case mower_msgs.HighLevelControlSrvReq_COMMAND_S1:
+			if s.stateName == "IDLE" {
+				s.setState("AREA_RECORDING")
+			} else if s.stateName == "AREA_RECORDING" {
+				s.setState("IDLE")
+			}
+		case mower_msgs.HighLevelControlSrvReq_COMMAND_S2:
+			if s.stateName == "MOWING" {
+				s.nextArea()
+			}
+		case mower_msgs.HighLevelControlSrvReq_COMMAND_RESET_EMERGENCY:
+			s.emergency = false
+		case mower_msgs.HighLevelControlSrvReq_COMMAND_DELETE_MAPS:
+			s.mapData.MowingAreas = nil
+			s.mapData.NavigationAreas = nil
+			return s.saveMap()
+		}
+		return nil
+	case "/mower_service/emergency":
+		r, ok := req.(*mower_msgs.EmergencyStopSrvReq)
+		if !ok {
+			break
+		}
+		s.emergency = r.Emergency != 0
+		if s.emergency {
+			s.setState("IDLE")
+		}
+		return nil
+	case "/mower_service/mow_enabled":
+		r, ok := req.(*mower_msgs.MowerControlSrvReq)
+		if !ok {
+			break
+		}
+		s.mowEnabled = r.MowEnabled != 0
+		return nil
+	case "/mower_service/start_in_area":
+		r, ok := req.(*mower_msgs.StartInAreaSrvReq)
+		if !ok {
+			break
+		}
+		if int(r.Area) >= len(s.mapData.MowingAreas) {
+			return xerrors.Errorf("area %d does not exist", r.Area)
+		}
+		s.currentArea = int(r.Area)
+		s.coverage = nil
+		s.start()
+		return nil
+	case "/mower_logic/set_parameters":
+		r, ok := req.(*dynamic_reconfigure.ReconfigureReq)
+		if !ok {
+			break
+		}
+		if out, ok := res.(*dynamic_reconfigure.ReconfigureRes); ok {
+			out.Config = r.Config
+		}
+		return nil
+	case "/mower_map_service/add_mowing_area":
+		r, ok := req.(*mower_map.AddMowingAreaSrvReq)
+		if !ok {
+			break
+		}
+		if r.IsNavigationArea {
+			s.mapData.NavigationAreas = append(s.mapData.NavigationAreas, r.Area)
+		} else {
+			s.mapData.MowingAreas = append(s.mapData.MowingAreas, r.Area)
+		}
+		return s.saveMap()
+	case "/mower_map_service/clear_map":
+		s.mapData.MowingAreas = nil
+		s.mapData.NavigationAreas = nil
+		return s.saveMap()
+	case "/mower_map_service/delete_mowing_area":
+		r, ok := req.(*mower_map.DeleteMowingAreaSrvReq)
+		if !ok {
+			break
+		}
+		if int(r.Index) >= len(s.mapData.MowingAreas) {
+			return xerrors.Errorf("area %d does not exist", r.Index)
+		}
+		s.mapData.MowingAreas = append(s.mapData.MowingAreas[:r.Index], s.mapData.MowingAreas[r.Index+1:]...)
+		return s.saveMap()
+	case "/mower_map_service/convert_to_navigation_area":
+		r, ok := req.(*mower_map.ConvertToNavigationAreaSrvReq)
+		if !ok {
+			break
+		}
+		if int(r.Index) >= len(s.mapData.MowingAreas) {
+			return xerrors.Errorf("area %d does not exist", r.Index)
+		}
+		s.mapData.NavigationAreas = append(s.mapData.NavigationAreas, s.mapData.MowingAreas[r.Index])
+		s.mapData.MowingAreas = append(s.mapData.MowingAreas[:r.Index], s.mapData.MowingAreas[r.Index+1:]...)
+		return s.saveMap()
+	case "/mower_map_service/get_mowing_area":
+		r, ok := req.(*mower_map.GetMowingAreaSrvReq)
+		if !ok {
+			break
+		}
+		if int(r.Index) >= len(s.mapData.MowingAreas) {
+			return xerrors.Errorf("area %d does not exist", r.Index)
+		}
+		if out, ok := res.(*mower_map.GetMowingAreaSrvRes); ok {
+			out.Area = s.mapData.MowingAreas[r.Index]
+		}
+		return nil
+	case "/mower_map_service/set_docking_point":
+		r, ok := req.(*mower_map.SetDockingPointSrvReq)
+		if !ok {
+			break
+		}
+		s.mapData.DockX = r.DockingPose.Position.X
+		s.mapData.DockY = r.DockingPose.Position.Y
+		s.mapData.DockHeading = quaternionToYaw(r.DockingPose.Orientation)
+		return s.saveMap()
+	case "/mower_map_service/get_docking_point":
+		if out, ok := res.(*mower_map.GetDockingPointSrvRes); ok {
+			out.DockingPose = geometry_msgs.Pose{
+				Position:    geometry_msgs.Point{X: s.mapData.DockX, Y: s.mapData.DockY},
+				Orientation: yawToQuaternion(s.mapData.DockHeading),
+			}
+		}
+		return nil
+	case "/mower_map_service/set_nav_point", "/mower_map_service/clear_nav_point", "/mower_map_service/append_map":
+		return nil
+	default:
+		return xerrors.Errorf("service %s is not available in simulation", srvName)
+	}
+	return xerrors.Errorf("invalid request type %T for service %s", req, srvName)
+}
+
+func (s *SimRosProvider) Subscribe(topic string, id string, cb func(msg []byte)) error {
+	return s.subscribe(topic, id, cb, NewRosSubscriber)
+}
+
+func (s *SimRosProvider) SubscribeQueued(topic string, id string, cb func(msg []byte)) error {
+	return s.subscribe(topic, id, cb, NewRosQueueSubscriber)
+}
+
+func (s *SimRosProvider) subscribe(topic string, id string, cb func(msg []byte), newSubscriber func(topic, id string, cb func(msg []byte)) *RosSubscriber) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	subscriber, hasSubscriber := s.subscribers[topic]
+	if !hasSubscriber {
+		subscriber = make(map[string]*RosSubscriber)
+		s.subscribers[topic] = subscriber
+	}
+	if _, hasCallback := subscriber[id]; !hasCallback {
+		subscriber[id] = newSubscriber(topic, id, cb)
+	}
+	if lastMessage, hasLastMessage := s.lastMessage[topic]; hasLastMessage {
+		subscriber[id].Publish(lastMessage)
+	}
+	return nil
+}
+
+func (s *SimRosProvider) UnSubscribe(topic string, id string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if subscriber, hasSubscriber := s.subscribers[topic][id]; hasSubscriber {
+		subscriber.Close()
+		delete(s.subscribers[topic], id)
+	}
+}
+
+func (s *SimRosProvider) Publisher(topic string, obj interface{}) (types.IRosPublisher, error) {
+	return &simPublisher{sim: s, topic: topic}, nil
+}
+
+// AdvertiseService stores the handler of the service, the simulated nodes call it like the real nodes would
+func (s *SimRosProvider) AdvertiseService(name string, srv any, cb func(req any) (any, error)) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.services[name] = cb
+	return nil
+}
+
+func (s *SimRosProvider) RegisterTopic(topic string, msg any) error {
+	msgType := reflect.TypeOf(msg)
+	if msgType == nil || msgType.Kind() != reflect.Ptr {
+		return xerrors.Errorf("message type of %s must be a pointer", topic)
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.topicTypes[topic] = msgType
+	return nil
+}
+
+func (s *SimRosProvider) MessageType(topic string) (any, error) {
+	s.mtx.Lock()
+	msgType, ok := s.topicTypes[topic]
+	s.mtx.Unlock()
+	if !ok {
+		msg, ok := simTopicType(topic)
+		if !ok {
+			return nil, xerrors.Errorf("unknown message type for topic %s", topic)
+		}
+		msgType = reflect.TypeOf(msg)
+	}
+	return reflect.New(msgType.Elem()).Interface(), nil
+}
+
+func (s *SimRosProvider) Replay(enabled bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.replaying = enabled
+	s.lastMessage = make(map[string][]byte)
+	if !enabled {
+		s.publishMap()
+		s.publishSensorInfos()
+	}
+}
+
+func (s *SimRosProvider) Replaying() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.replaying
+}
+
+func (s *SimRosProvider) Inject(topic string, msg []byte) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.dispatch(topic, msg)
+}
+
+func (s *SimRosProvider) Nodes() ([]types.RosNode, error) {
+	var nodes []types.RosNode
+	for name, publications := range simNodes {
+		var services []string
+		for service := range simServices {
+			if len(service) > len(name) && service[:len(name)+1] == name+"/" {
+				services = append(services, service)
+			}
+		}
+		if name == "/mower_logic" {
+			services = append(services, "/mower_service/high_level_control", "/mower_service/emergency", "/mower_service/mow_enabled", "/mower_service/start_in_area")
+		}
+		sort.Strings(services)
+		nodes = append(nodes, types.RosNode{
+			Name:         name,
+			Address:      "localhost:0",
+			Publications: publications,
+			Services:     services,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+func (s *SimRosProvider) Topics() ([]types.RosTopic, error) {
+	var topics []types.RosTopic
+	for name, publications := range simNodes {
+		for _, topic := range publications {
+			msg, _ := s.MessageType(topic)
+			msgType, _ := msgproc.Type(reflect.ValueOf(msg).Elem().Interface())
+			topics = append(topics, types.RosTopic{
+				Name:       topic,
+				Type:       msgType,
+				Publishers: []string{name},
+			})
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics, nil
+}
+
+func (s *SimRosProvider) Services(ctx context.Context) ([]types.RosService, error) {
+	var services []types.RosService
+	for name, srv := range simServices {
+		srvType, _ := serviceproc.Type(reflect.ValueOf(srv).Elem().Interface())
+		services = append(services, types.RosService{
+			Name:    name,
+			Type:    srvType,
+			Address: "localhost:0",
+		})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
+
+func (s *SimRosProvider) NodePing(node string) (time.Duration, error) {
+	if _, ok := simNodes[node]; !ok {
+		return 0, xerrors.Errorf("node %s not found", node)
+	}
+	return time.Millisecond, nil
+}
+
+// simTopicType returns the message type of a topic published by the simulation
+func simTopicType(topic string) (any, bool) {
+	if msg, ok := topicMessageTypes[topic]; ok {
+		return msg, true
+	}
+	if topic == robotStateTopic {
+		return &xbot_msgs.RobotState{}, true
+	}
+	for _, sensor := range simSensors {
+		switch topic {
+		case sensorInfoTopic(sensor.SensorId):
+			return &xbot_msgs.SensorInfo{}, true
+		case sensorDataTopic(sensor.SensorId):
+			if sensor.ValueType == xbot_msgs.SensorInfo_TYPE_STRING {
+				return &xbot_msgs.SensorDataString{}, true
+			}
+			return &xbot_msgs.SensorDataDouble{}, true
+		}
+	}
+	return nil, false
+}
+
+// actions returns the actions of the simulated mower_logic for the current state, s.mtx must be held
+func (s *SimRosProvider) actions() []xbot_msgs.RegisterActionsSrvReq {
+	idle := s.stateName == "IDLE" && !s.emergency
+	return []xbot_msgs.RegisterActionsSrvReq{
+		{NodePrefix: "mower_logic:idle", Actions: []xbot_msgs.ActionInfo{
+			{ActionId: "start_mowing", ActionName: "Start mowing", Enabled: idle && len(s.mapData.MowingAreas) > 0},
+			{ActionId: "start_area_recording", ActionName: "Start area recording", Enabled: idle},
+		}},
+		{NodePrefix: "mower_logic:mowing", Actions: []xbot_msgs.ActionInfo{
+			{ActionId: "abort_mowing", ActionName: "Stop mowing", Enabled: s.stateName == "MOWING" || s.stateName == "UNDOCKING"},
+			{ActionId: "skip_area", ActionName: "Skip area", Enabled: s.stateName == "MOWING"},
+		}},
+		{NodePrefix: "mower_logic:area_recording", Actions: []xbot_msgs.ActionInfo{
+			{ActionId: "exit_recording_mode", ActionName: "Exit recording mode", Enabled: s.stateName == "AREA_RECORDING"},
+		}},
+	}
+}
+
+// registerActions calls the advertised register actions service when the actions changed, like mower_logic does on
+// each state change
+func (s *SimRosProvider) registerActions() {
+	s.mtx.Lock()
+	handler, ok := s.services[registerActionsService]
+	requests := s.actions()
+	key, _ := json.Marshal(requests)
+	changed := ok && string(key) != s.registeredActions
+	if changed {
+		s.registeredActions = string(key)
+	}
+	s.mtx.Unlock()
+	if !changed {
+		return
+	}
+	for i := range requests {
+		_, err := handler(&requests[i])
+		if err != nil {
+			rosLog.Error(xerrors.Errorf("simulation: failed to register actions: %w", err))
+		}
+	}
+}
+
+// triggerAction executes an action received on the action topic, s.mtx must be held
+func (s *SimRosProvider) triggerAction(id string) {
+	switch id {
+	case "mower_logic:idle/start_mowing":
+		s.start()
+	case "mower_logic:idle/start_area_recording":
+		if s.stateName == "IDLE" && !s.emergency {
+			s.setState("AREA_RECORDING")
+		}
+	case "mower_logic:mowing/abort_mowing":
+		if s.stateName == "MOWING" || s.stateName == "UNDOCKING" {
+			s.setState("DOCKING")
+		}
+	case "mower_logic:mowing/skip_area":
+		if s.stateName == "MOWING" {
+			s.nextArea()
+		}
+	case "mower_logic:area_recording/exit_recording_mode":
+		if s.stateName == "AREA_RECORDING" {
+			s.setState("IDLE")
+		}
+	default:
+		rosLog.Warn("simulation: unknown action " + id)
+	}
+}
+
+// start leaves the dock to mow the current area, s.mtx must be held
+func (s *SimRosProvider) start() {
+	if s.emergency || (s.stateName != "IDLE" && s.stateName != "DOCKING") {
+		return
+	}
+	if len(s.mapData.MowingAreas) == 0 {
+		rosLog.Warn("simulation: no mowing area to mow")
+		return
+	}
+	s.distance = 0
+	s.setState("UNDOCKING")
+}
+
+func (s *SimRosProvider) setState(stateName string) {
+	s.stateName = stateName
+	s.target = nil
+	if stateName != "MOWING" {
+		s.coverage = nil
+	}
+}
+
+// nextArea moves to the next mowing area or docks when all areas are mowed, s.mtx must be held
+func (s *SimRosProvider) nextArea() {
+	s.coverage = nil
+	s.currentArea++
+	if s.currentArea >= len(s.mapData.MowingAreas) {
+		s.currentArea = 0
+		s.setState("DOCKING")
+	}
+}
+
+func (s *SimRosProvider) step(dt float64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var linear, angular float64
+	switch {
+	case s.emergency:
+	case s.stateName == "UNDOCKING":
+		linear = -simSpeed
+		s.distance += simSpeed * dt
+		if s.distance >= simUndockDistance {
+			s.setState("MOWING")
+		}
+	case s.stateName == "MOWING":
+		if s.battery < simLowBattery {
+			s.setState("DOCKING")
+			break
+		}
+		if s.coverage == nil {
+			if s.currentArea >= len(s.mapData.MowingAreas) {
+				s.nextArea()
+				break
+			}
+			s.coverage = coverageLines(s.mapData.MowingAreas[s.currentArea], simToolWidth)
+			s.mowingPaths = append(s.mowingPaths, &nav_msgs.Path{})
+			s.publishPlan()
+		}
+		if len(s.coverage) == 0 {
+			s.nextArea()
+			break
+		}
+		if s.moveTo(s.coverage[0], dt) {
+			s.coverage = s.coverage[1:]
+			s.publishPlan()
+		}
+		if s.mowEnabled {
+			path := s.mowingPaths[len(s.mowingPaths)-1]
+			path.Poses = append(path.Poses, geometry_msgs.PoseStamped{
+				Pose: geometry_msgs.Pose{Position: geometry_msgs.Point{X: s.x, Y: s.y}},
+			})
+		}
+	case s.stateName == "DOCKING":
+		if s.moveTo(orb.Point{s.mapData.DockX, s.mapData.DockY}, dt) {
+			s.heading = s.mapData.DockHeading
+			s.mowingPaths = nil
+			s.setState("IDLE")
+		}
+	case s.clock.Now().Sub(s.twistAt) < 500*time.Millisecond:
+		linear = s.twist.Linear.X
+		angular = s.twist.Angular.Z
+	}
+	if linear != 0 || angular != 0 {
+		s.heading += angular * dt
+		s.x += math.Cos(s.heading) * linear * dt
+		s.y += math.Sin(s.heading) * linear * dt
+		s.ticks += uint32(math.Abs(linear) * dt * 1000)
+	}
+
+	docked := s.stateName == "IDLE" && math.Hypot(s.x-s.mapData.DockX, s.y-s.mapData.DockY) < 0.1
+	if docked {
+		s.battery = math.Min(100, s.battery+simChargePerSecond*dt)
+	} else {
+		s.battery = math.Max(0, s.battery-simDrainPerSecond*dt)
+	}
+	mowing := s.stateName == "MOWING" && s.mowEnabled && !s.emergency
+	if mowing {
+		s.tacho += uint32(dt * 50)
+	}
+	s.tickCount++
+	if s.replaying {
+		return
+	}
+	s.publishPose()
+	if s.tickCount%5 == 0 {
+		s.publishStatus(docked, mowing)
+	}
+}
+
+// moveTo drives the mower toward target and returns true once it is reached, s.mtx must be held
+func (s *SimRosProvider) moveTo(target orb.Point, dt float64) bool {
+	dx := target[0] - s.x
+	dy := target[1] - s.y
+	remaining := math.Hypot(dx, dy)
+	step := simSpeed * dt
+	if remaining > 0 {
+		s.heading = math.Atan2(dy, dx)
+	}
+	s.ticks += uint32(math.Min(step, remaining) * 1000)
+	if remaining <= step {
+		s.x = target[0]
+		s.y = target[1]
+		return true
+	}
+	s.x += dx / remaining * step
+	s.y += dy / remaining * step
+	return false
+}
+
+func (s *SimRosProvider) publishPose() {
+	now := s.clock.Now()
+	pose := &xbot_msgs.AbsolutePose{
+		Header:              std_msgs.Header{Stamp: now, FrameId: "map"},
+		Source:              xbot_msgs.AbsolutePose_SOURCE_GPS,
+		Flags:               xbot_msgs.AbsolutePose_FLAG_GPS_RTK | xbot_msgs.AbsolutePose_FLAG_GPS_RTK_FIXED,
+		OrientationValid:    1,
+		MotionVectorValid:   1,
+		PositionAccuracy:    0.02,
+		OrientationAccuracy: 0.01,
+		Pose: geometry_msgs.PoseWithCovariance{
+			Pose: geometry_msgs.Pose{
+				Position:    geometry_msgs.Point{X: s.x, Y: s.y},
+				Orientation: yawToQuaternion(s.heading),
+			},
+		},
+		VehicleHeading: s.heading,
+		MotionHeading:  s.heading,
+	}
+	s.publish("/xbot_driver_gps/xb_pose", pose)
+	pose.Source = xbot_msgs.AbsolutePose_SOURCE_SENSOR_FUSION
+	pose.Flags = xbot_msgs.AbsolutePose_FLAG_SENSOR_FUSION_RECENT_ABSOLUTE_POSE
+	s.publish("/xbot_positioning/xb_pose", pose)
+	s.publish("/imu/data_raw", &sensor_msgs.Imu{
+		Header:             std_msgs.Header{Stamp: now, FrameId: "base_link"},
+		Orientation:        yawToQuaternion(s.heading),
+		LinearAcceleration: geometry_msgs.Vector3{Z: 9.81},
+	})
+	s.publish("/mower/wheel_ticks", &xbot_msgs.WheelTick{
+		Stamp:           now,
+		WheelTickFactor: 1000,
+		ValidWheels:     xbot_msgs.WheelTick_WHEEL_VALID_RL | xbot_msgs.WheelTick_WHEEL_VALID_RR,
+		WheelTicksRl:    s.ticks,
+		WheelTicksRr:    s.ticks,
+	})
+	if len(s.mowingPaths) > 0 {
+		msgJson, err := json.Marshal(s.mowingPaths)
+		if err == nil {
+			s.dispatch("/mowing_path", msgJson)
+		}
+	}
+}
+
+func (s *SimRosProvider) publishStatus(docked bool, mowing bool) {
+	state := mower_msgs.HighLevelStatus_HIGH_LEVEL_STATE_IDLE
+	switch s.stateName {
+	case "UNDOCKING", "MOWING", "DOCKING":
+		state = mower_msgs.HighLevelStatus_HIGH_LEVEL_STATE_AUTONOMOUS
+	case "AREA_RECORDING":
+		state = mower_msgs.HighLevelStatus_HIGH_LEVEL_STATE_RECORDING
+	}
+	s.publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{
+		State:             state,
+		StateName:         s.stateName,
+		CurrentArea:       int16(s.currentArea),
+		GpsQualityPercent: 1,
+		BatteryPercent:    float32(s.battery / 100),
+		IsCharging:        docked,
+		Emergency:         s.emergency,
+	})
+	escStatus := mower_msgs.ESCStatus{Status: mower_msgs.ESCStatus_ESC_STATUS_OK, TemperatureMotor: 30, TemperaturePcb: 30}
+	mowEscStatus := escStatus
+	if mowing {
+		mowEscStatus.Status = mower_msgs.ESCStatus_ESC_STATUS_RUNNING
+		mowEscStatus.Rpm = 3000
+		mowEscStatus.Current = 1.5
+	}
+	mowEscStatus.Tacho = s.tacho
+	var chargeCurrent, vCharge float32
+	if docked {
+		vCharge = 29
+		if s.battery < 100 {
+			chargeCurrent = 1
+		}
+	}
+	s.publish(robotStateTopic, &xbot_msgs.RobotState{
+		BatteryPercentage: float32(s.battery / 100),
+		Emergency:         s.emergency,
+		IsCharging:        docked,
+		GpsPercentage:     1,
+		CurrentState:      s.stateName,
+		CurrentArea:       int16(s.currentArea),
+		RobotPose: xbot_msgs.AbsolutePose{
+			Pose: geometry_msgs.PoseWithCovariance{Pose: geometry_msgs.Pose{
+				Position:    geometry_msgs.Point{X: s.x, Y: s.y},
+				Orientation: yawToQuaternion(s.heading),
+			}},
+			VehicleHeading: s.heading,
+		},
+	})
+	escState := "OK"
+	if mowing {
+		escState = "RUNNING"
+	}
+	now := s.clock.Now()
+	sensorValues := map[string]any{
+		"om_v_battery":      24 + 4*s.battery/100,
+		"om_charge_current": float64(chargeCurrent),
+		"om_mow_motor_temp": float64(mowEscStatus.TemperatureMotor),
+		"om_mow_motor_rpm":  float64(mowEscStatus.Rpm),
+		"om_esc_status":     escState,
+	}
+	for id, value := range sensorValues {
+		switch value := value.(type) {
+		case float64:
+			s.publish(sensorDataTopic(id), &xbot_msgs.SensorDataDouble{Stamp: now, Data: value})
+		case string:
+			s.publish(sensorDataTopic(id), &xbot_msgs.SensorDataString{Stamp: now, Data: value})
+		}
+	}
+	s.publish("/mower/status", &mower_msgs.Status{
+		Stamp:            now,
+		MowerStatus:      mower_msgs.Status_MOWER_STATUS_OK,
+		RaspberryPiPower: true,
+		GpsPower:         true,
+		EscPower:         true,
+		Emergency:        s.emergency,
+		VCharge:          vCharge,
+		VBattery:         float32(24 + 4*s.battery/100),
+		ChargeCurrent:    chargeCurrent,
+		MowEnabled:       s.mowEnabled,
+		LeftEscStatus:    escStatus,
+		RightEscStatus:   escStatus,
+		MowEscStatus:     mowEscStatus,
+	})
+}
+
+func (s *SimRosProvider) publishSensorInfos() {
+	for i := range simSensors {
+		s.publish(sensorInfoTopic(simSensors[i].SensorId), &simSensors[i])
+	}
+}
+
+func (s *SimRosProvider) publishPlan() {
+	plan := &nav_msgs.Path{Header: std_msgs.Header{Stamp: s.clock.Now(), FrameId: "map"}}
+	for _, point := range s.coverage {
+		plan.Poses = append(plan.Poses, geometry_msgs.PoseStamped{
+			Pose: geometry_msgs.Pose{Position: geometry_msgs.Point{X: point[0], Y: point[1]}},
+		})
+	}
+	s.publish("/move_base_flex/FTCPlanner/global_plan", plan)
+}
+
+func (s *SimRosProvider) publishMap() {
+	toXbot := func(areas []mower_map.MapArea) []xbot_msgs.MapArea {
+		var result []xbot_msgs.MapArea
+		for _, area := range areas {
+			result = append(result, xbot_msgs.MapArea{Name: area.Name, Area: area.Area, Obstacles: area.Obstacles})
+		}
+		return result
+	}
+	mapMsg := &xbot_msgs.Map{
+		NavigationAreas: toXbot(s.mapData.NavigationAreas),
+		WorkingArea:     toXbot(s.mapData.MowingAreas),
+		DockX:           s.mapData.DockX,
+		DockY:           s.mapData.DockY,
+		DockHeading:     s.mapData.DockHeading,
+	}
+	var bound orb.Bound
+	first := true
+	for _, area := range append(s.mapData.NavigationAreas, s.mapData.MowingAreas...) {
+		for _, point := range area.Area.Points {
+			if first {
+				bound = orb.Point{float64(point.X), float64(point.Y)}.Bound()
+				first = false
+			}
+			bound = bound.Extend(orb.Point{float64(point.X), float64(point.Y)})
+		}
+	}
+	if !first {
+		mapMsg.MapWidth = bound.Max[0] - bound.Min[0]
+		mapMsg.MapHeight = bound.Max[1] - bound.Min[1]
+		mapMsg.MapCenterX = bound.Center()[0]
+		mapMsg.MapCenterY = bound.Center()[1]
+	}
+	s.publish("/xbot_monitoring/map", mapMsg)
+}
+
+// saveMap persists the simulated map and publishes it, s.mtx must be held
+func (s *SimRosProvider) saveMap() error {
+	if !s.replaying {
+		s.publishMap()
+	}
+	mapJson, err := json.Marshal(s.mapData)
+	if err != nil {
+		return err
+	}
+	return s.dbProvider.Set("gui.sim.map", mapJson)
+}
+
+func (s *SimRosProvider) publish(topic string, msg any) {
+	msgJson, err := json.Marshal(msg)
+	if err != nil {
+		rosLog.Error(xerrors.Errorf("failed to marshal message: %w", err))
+		return
+	}
+	s.dispatch(topic, msgJson)
+}
+
+// dispatch stores the message as the last message of the topic and sends it to the subscribers, s.mtx must be held
+func (s *SimRosProvider) dispatch(topic string, msgJson []byte) {
+	s.lastMessage[topic] = msgJson
+	for _, cb := range s.subscribers[topic] {
+		cb.Publish(msgJson)
+	}
+}
+
+// coverageLines computes back and forth lines covering the area, spaced by width. Obstacles are holes of the
+// polygon, so the even-odd intersections of each line with the area and obstacle edges give the segments to mow.
+func coverageLines(area mower_map.MapArea, width float64) []orb.Point {
+	polygons := append([]geometry_msgs.Polygon{area.Area}, area.Obstacles...)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, point := range area.Area.Points {
+		minY = math.Min(minY, float64(point.Y))
+		maxY = math.Max(maxY, float64(point.Y))
+	}
+	var points []orb.Point
+	row := 0
+	for y := minY + width/2; y < maxY; y += width {
+		var xs []float64
+		for _, polygon := range polygons {
+			for i := range polygon.Points {
+				a := polygon.Points[i]
+				b := polygon.Points[(i+1)%len(polygon.Points)]
+				ay, by := float64(a.Y), float64(b.Y)
+				if (ay <= y && by > y) || (by <= y && ay > y) {
+					xs = append(xs, float64(a.X)+(y-ay)/(by-ay)*float64(b.X-a.X))
+				}
+			}
+		}
+		sort.Float64s(xs)
+		var segments []orb.Point
+		for i := 0; i+1 < len(xs); i += 2 {
+			segments = append(segments, orb.Point{xs[i], y}, orb.Point{xs[i+1], y})
+		}
+		if row%2 == 1 {
+			for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+				segments[i], segments[j] = segments[j], segments[i]
+			}
+		}
+		points = append(points, segments...)
+		row++
+	}
+	return points
+}
+
+func yawToQuaternion(yaw float64) geometry_msgs.Quaternion {
+	return geometry_msgs.Quaternion{Z: math.Sin(yaw / 2), W: math.Cos(yaw / 2)}
+}
+
+func quaternionToYaw(q geometry_msgs.Quaternion) float64 {
+	return math.Atan2(2*(q.W*q.Z+q.X*q.Y), 1-2*(q.Y*q.Y+q.Z*q.Z))
+}
@@ -0,0 +1,230 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/dynamic_reconfigure"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_map"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/xbot_msgs"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func simSquare(minX, minY, maxX, maxY float32) geometry_msgs.Polygon {
+	return geometry_msgs.Polygon{Points: []geometry_msgs.Point32{
+		{X: minX, Y: minY}, {X: maxX, Y: minY}, {X: maxX, Y: maxY}, {X: minX, Y: maxY},
+	}}
+}
+
+func TestCoverageLines(t *testing.T) {
+	points := coverageLines(mower_map.MapArea{Area: simSquare(0, 0, 4, 1)}, 0.5)
+	assert.Equal(t, []orb.Point{{0, 0.25}, {4, 0.25}, {4, 0.75}, {0, 0.75}}, points)
+
+	points = coverageLines(mower_map.MapArea{
+		Area:      simSquare(0, 0, 4, 1),
+		Obstacles: []geometry_msgs.Polygon{simSquare(1, 0.5, 2, 1)},
+	}, 0.5)
+	assert.Equal(t, []orb.Point{{0, 0.25}, {4, 0.25}, {4, 0.75}, {2, 0.75}, {1, 0.75}, {0, 0.75}}, points)
+}
+
+// testSimClock is a simClock running the ticks of the simulation synchronously when it is advanced
+type testSimClock struct {
+	now  time.Time
+	tick time.Duration
+	f    func()
+}
+
+func newTestSimClock(t *testing.T) *testSimClock {
+	clock := &testSimClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	newClock := newSimClock
+	newSimClock = func() simClock {
+		return clock
+	}
+	t.Cleanup(func() {
+		newSimClock = newClock
+	})
+	return clock
+}
+
+func (c *testSimClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testSimClock) Every(d time.Duration, f func()) {
+	c.tick = d
+	c.f = f
+}
+
+func (c *testSimClock) advance(d time.Duration) {
+	for end := c.now.Add(d); c.now.Before(end); {
+		c.now = c.now.Add(c.tick)
+		c.f()
+	}
+}
+
+// advanceUntil advances the clock until the simulated mower is in state and returns the time it took
+func (c *testSimClock) advanceUntil(t *testing.T, s *SimRosProvider, state string, timeout time.Duration) time.Duration {
+	start := c.now
+	for s.state() != state {
+		require.Less(t, c.now.Sub(start), timeout, "waiting for "+state)
+		c.advance(c.tick)
+	}
+	return c.now.Sub(start)
+}
+
+func (s *SimRosProvider) state() string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.stateName
+}
+
+func (s *SimRosProvider) batteryPercent() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.battery
+}
+
+// simPublished decodes the last message published by the simulation on topic
+func simPublished(t *testing.T, s *SimRosProvider, topic string, msg any) {
+	s.mtx.Lock()
+	msgJson, ok := s.lastMessage[topic]
+	s.mtx.Unlock()
+	require.True(t, ok, "nothing published on "+topic)
+	require.NoError(t, json.Unmarshal(msgJson, msg))
+}
+
+func TestSimServices(t *testing.T) {
+	newTestSimClock(t)
+	db := fakes.NewDBProvider(map[string]string{})
+	s := NewSimRosProvider(db).(*SimRosProvider)
+	ctx := context.Background()
+
+	dock := geometry_msgs.Pose{Position: geometry_msgs.Point{X: 1, Y: 2}, Orientation: yawToQuaternion(0.5)}
+	require.NoError(t, s.CallService(ctx, "/mower_map_service/set_docking_point", nil, &mower_map.SetDockingPointSrvReq{DockingPose: dock}, &mower_map.SetDockingPointSrvRes{}))
+	var dockRes mower_map.GetDockingPointSrvRes
+	require.NoError(t, s.CallService(ctx, "/mower_map_service/get_docking_point", nil, &mower_map.GetDockingPointSrvReq{}, &dockRes))
+	assert.Equal(t, dock.Position, dockRes.DockingPose.Position)
+	assert.InDelta(t, 0.5, quaternionToYaw(dockRes.DockingPose.Orientation), 1e-9)
+
+	area := mower_map.MapArea{Name: "front", Area: simSquare(0, 0, 2, 1)}
+	require.NoError(t, s.CallService(ctx, "/mower_map_service/add_mowing_area", nil, &mower_map.AddMowingAreaSrvReq{Area: area}, &mower_map.AddMowingAreaSrvRes{}))
+	require.NoError(t, s.CallService(ctx, "/mower_map_service/add_mowing_area", nil, &mower_map.AddMowingAreaSrvReq{Area: area, IsNavigationArea: true}, &mower_map.AddMowingAreaSrvRes{}))
+	var areaRes mower_map.GetMowingAreaSrvRes
+	require.NoError(t, s.CallService(ctx, "/mower_map_service/get_mowing_area", nil, &mower_map.GetMowingAreaSrvReq{Index: 0}, &areaRes))
+	assert.Equal(t, area, areaRes.Area)
+	assert.EqualError(t, s.CallService(ctx, "/mower_map_service/get_mowing_area", nil, &mower_map.GetMowingAreaSrvReq{Index: 1}, &areaRes), "area 1 does not exist")
+	assert.EqualError(t, s.CallService(ctx, "/mower_service/start_in_area", nil, &mower_msgs.StartInAreaSrvReq{Area: 1}, &mower_msgs.StartInAreaSrvRes{}), "area 1 does not exist")
+
+	// the map is saved and published
+	var saved simMap
+	mapJson, err := db.Get("gui.sim.map")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(mapJson, &saved))
+	assert.Len(t, saved.MowingAreas, 1)
+	assert.Len(t, saved.NavigationAreas, 1)
+	var mapMsg xbot_msgs.Map
+	simPublished(t, s, "/xbot_monitoring/map", &mapMsg)
+	assert.Len(t, mapMsg.WorkingArea, 1)
+	assert.Equal(t, 1.0, mapMsg.DockX)
+	assert.Equal(t, 2.0, mapMsg.MapWidth)
+
+	config := dynamic_reconfigure.Config{Doubles: []dynamic_reconfigure.DoubleParameter{{Name: "tool_width", Value: 0.14}}}
+	var configRes dynamic_reconfigure.ReconfigureRes
+	require.NoError(t, s.CallService(ctx, "/mower_logic/set_parameters", nil, &dynamic_reconfigure.ReconfigureReq{Config: config}, &configRes))
+	assert.Equal(t, config, configRes.Config)
+
+	assert.EqualError(t, s.CallService(ctx, "/mower_service/emergency", nil, &mower_msgs.MowerControlSrvReq{}, &mower_msgs.EmergencyStopSrvRes{}), "invalid request type *mower_msgs.MowerControlSrvReq for service /mower_service/emergency")
+	assert.EqualError(t, s.CallService(ctx, "/unknown", nil, nil, nil), "service /unknown is not available in simulation")
+
+	require.NoError(t, s.CallService(ctx, "/mower_map_service/clear_map", nil, &mower_map.ClearMapSrvReq{}, &mower_map.ClearMapSrvRes{}))
+	simPublished(t, s, "/xbot_monitoring/map", &mapMsg)
+	assert.Empty(t, mapMsg.WorkingArea)
+}
+
+func TestSimMowingCycle(t *testing.T) {
+	clock := newTestSimClock(t)
+	s := NewSimRosProvider(fakes.NewDBProvider(map[string]string{})).(*SimRosProvider)
+	ctx := context.Background()
+	call := func(srvName string, req any) {
+		t.Helper()
+		require.NoError(t, s.CallService(ctx, srvName, nil, req, nil))
+	}
+	call("/mower_map_service/add_mowing_area", &mower_map.AddMowingAreaSrvReq{Area: mower_map.MapArea{Area: simSquare(1, -0.3, 3, 0.3)}})
+
+	// docked and full, the battery stays at 100%
+	clock.advance(time.Second)
+	var status mower_msgs.HighLevelStatus
+	simPublished(t, s, "/mower_logic/current_state", &status)
+	assert.Equal(t, "IDLE", status.StateName)
+	assert.Equal(t, mower_msgs.HighLevelStatus_HIGH_LEVEL_STATE_IDLE, status.State)
+	assert.True(t, status.IsCharging)
+	assert.Equal(t, float32(1), status.BatteryPercent)
+
+	call("/mower_service/mow_enabled", &mower_msgs.MowerControlSrvReq{MowEnabled: 1})
+	call("/mower_service/high_level_control", &mower_msgs.HighLevelControlSrvReq{Command: mower_msgs.HighLevelControlSrvReq_COMMAND_START})
+	assert.Equal(t, "UNDOCKING", s.state())
+	clock.advance(500 * time.Millisecond)
+	simPublished(t, s, "/mower_logic/current_state", &status)
+	assert.Equal(t, "UNDOCKING", status.StateName)
+	assert.Equal(t, mower_msgs.HighLevelStatus_HIGH_LEVEL_STATE_AUTONOMOUS, status.State)
+	assert.False(t, status.IsCharging)
+
+	// the mower backs off the dock then mows the two lines covering the area
+	undocking := clock.advanceUntil(t, s, "MOWING", time.Minute)
+	assert.InDelta(t, simUndockDistance/simSpeed, (500*time.Millisecond + undocking).Seconds(), simTick.Seconds())
+	var pose xbot_msgs.AbsolutePose
+	simPublished(t, s, "/xbot_positioning/xb_pose", &pose)
+	assert.InDelta(t, -simUndockDistance, pose.Pose.Pose.Position.X, 0.05)
+	assert.Equal(t, clock.now, pose.Header.Stamp.UTC())
+	clock.advance(5 * time.Second)
+	var mowerStatus mower_msgs.Status
+	simPublished(t, s, "/mower/status", &mowerStatus)
+	assert.True(t, mowerStatus.MowEnabled)
+	assert.Equal(t, mower_msgs.ESCStatus_ESC_STATUS_RUNNING, mowerStatus.MowEscStatus.Status)
+	var plan struct{ Poses []geometry_msgs.PoseStamped }
+	simPublished(t, s, "/move_base_flex/FTCPlanner/global_plan", &plan)
+	assert.NotEmpty(t, plan.Poses)
+
+	clock.advanceUntil(t, s, "DOCKING", time.Minute)
+	clock.advanceUntil(t, s, "IDLE", time.Minute)
+	simPublished(t, s, "/xbot_positioning/xb_pose", &pose)
+	assert.InDelta(t, 0, pose.Pose.Pose.Position.X, 1e-9)
+	assert.InDelta(t, 0, pose.Pose.Pose.Position.Y, 1e-9)
+
+	// the battery drained while away from the dock and charges once docked, from the tick the mower is back
+	away := clock.now.Sub(time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC)) - simTick
+	battery := s.batteryPercent()
+	assert.InDelta(t, 100-simDrainPerSecond*away.Seconds()+simChargePerSecond*simTick.Seconds(), battery, 1e-6)
+	clock.advance(2 * time.Second)
+	assert.InDelta(t, battery+2*simChargePerSecond, s.batteryPercent(), 1e-6)
+	simPublished(t, s, "/mower/status", &mowerStatus)
+	assert.Equal(t, float32(1), mowerStatus.ChargeCurrent)
+	assert.Equal(t, mower_msgs.ESCStatus_ESC_STATUS_OK, mowerStatus.MowEscStatus.Status)
+
+	// a low battery sends the mower back to the dock
+	call("/mower_service/high_level_control", &mower_msgs.HighLevelControlSrvReq{Command: mower_msgs.HighLevelControlSrvReq_COMMAND_START})
+	clock.advanceUntil(t, s, "MOWING", time.Minute)
+	s.mtx.Lock()
+	s.battery = simLowBattery + simDrainPerSecond*simTick.Seconds()/2
+	s.mtx.Unlock()
+	clock.advance(2 * simTick)
+	assert.Equal(t, "DOCKING", s.state())
+
+	// an emergency stops the mower until it is reset
+	call("/mower_service/emergency", &mower_msgs.EmergencyStopSrvReq{Emergency: 1})
+	call("/mower_service/high_level_control", &mower_msgs.HighLevelControlSrvReq{Command: mower_msgs.HighLevelControlSrvReq_COMMAND_START})
+	clock.advance(time.Second)
+	simPublished(t, s, "/mower_logic/current_state", &status)
+	assert.Equal(t, "IDLE", status.StateName)
+	assert.True(t, status.Emergency)
+	call("/mower_service/high_level_control", &mower_msgs.HighLevelControlSrvReq{Command: mower_msgs.HighLevelControlSrvReq_COMMAND_RESET_EMERGENCY})
+	call("/mower_service/high_level_control", &mower_msgs.HighLevelControlSrvReq{Command: mower_msgs.HighLevelControlSrvReq_COMMAND_START})
+	assert.Equal(t, "UNDOCKING", s.state())
+}
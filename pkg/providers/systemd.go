@@ -0,0 +1,521 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	types2 "github.com/cedbossneo/openmower-gui/pkg/types"
+	sddbus "github.com/coreos/go-systemd/v22/dbus"
+	"github.com/docker/docker/api/types"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/godbus/dbus/v5"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+var systemdLog = Logs.Logger("systemd")
+
+// systemdDetectTimeout bounds the detection of the systemd units when the backend is auto
+var systemdDetectTimeout = 5 * time.Second
+
+// containerStatsInterval is the interval between two samples of the resource usage of a unit
+var containerStatsInterval = time.Second
+
+// systemdConn is the part of the go-systemd D-Bus connection used to manage the units
+type systemdConn interface {
+	GetUnitPropertiesContext(ctx context.Context, unit string) (map[string]interface{}, error)
+	GetUnitTypePropertiesContext(ctx context.Context, unit string, unitType string) (map[string]interface{}, error)
+	StartUnitContext(ctx context.Context, name string, mode string, ch chan<- string) (int, error)
+	StopUnitContext(ctx context.Context, name string, mode string, ch chan<- string) (int, error)
+	RestartUnitContext(ctx context.Context, name string, mode string, ch chan<- string) (int, error)
+	Connected() bool
+	Close()
+}
+
+// dialSystemd connects to systemd through the bus at address, it is replaced in the tests
+var dialSystemd = func(address string) (systemdConn, error) {
+	return sddbus.NewConnection(func() (*dbus.Conn, error) {
+		return dbus.Connect(address, dbus.WithAuth(dbus.AuthExternal(strconv.Itoa(os.Getuid()))))
+	})
+}
+
+// journalCommand runs journalctl, it is replaced in the tests
+var journalCommand = func(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "journalctl", args...)
+}
+
+// SystemdProvider manages the containers run by systemd units, as podman does on OpenMowerOS. The units are started,
+// stopped and restarted through systemd over D-Bus so that their restart policy is respected, and the logs are read
+// from the journal. The container ID is the name of the unit. The commands are executed in the containers through the
+// Docker API of podman, with the container names.
+type SystemdProvider struct {
+	dbProvider types2.IDBProvider
+	docker     types2.IDockerProvider
+	address    string
+	mtx        sync.Mutex
+	conn       systemdConn
+}
+
+// NewContainerProvider returns the container backend selected by system.containers.backend: docker, systemd or auto
+// to use systemd when the units of system.systemd.units are loaded and docker otherwise. The backend is restricted to
+// the management scope.
+func NewContainerProvider(dbProvider types2.IDBProvider) types2.IDockerProvider {
+	return NewScopedContainerProvider(newContainerBackend(dbProvider), dbProvider)
+}
+
+func newContainerBackend(dbProvider types2.IDBProvider) types2.IDockerProvider {
+	backend, err := dbProvider.Get("system.containers.backend")
+	if err != nil {
+		systemdLog.Error(xerrors.Errorf("failed to get system.containers.backend: %w", err))
+	}
+	switch string(backend) {
+	case "systemd":
+		return NewSystemdProvider(dbProvider)
+	case "docker":
+		return NewDockerProvider(dbProvider)
+	}
+	systemd := NewSystemdProvider(dbProvider)
+	ctx, cancel := context.WithTimeout(context.Background(), systemdDetectTimeout)
+	defer cancel()
+	err = systemd.detect(ctx)
+	if err != nil {
+		systemdLog.Infof("using the docker backend: %s", err)
+		return NewDockerProvider(dbProvider)
+	}
+	systemdLog.Info("using the systemd backend")
+	return systemd
+}
+
+func NewSystemdProvider(dbProvider types2.IDBProvider) *SystemdProvider {
+	address, err := dbProvider.Get("system.systemd.busAddress")
+	if err != nil {
+		systemdLog.Error(xerrors.Errorf("failed to get system.systemd.busAddress: %w", err))
+	}
+	return &SystemdProvider{dbProvider: dbProvider, docker: NewDockerProvider(dbProvider), address: string(address)}
+}
+
+// detect checks that systemd is reachable and that at least one of the units is loaded
+func (s *SystemdProvider) detect(ctx context.Context) error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+	for _, unit := range units {
+		properties, err := s.properties(ctx, unit.unit, "")
+		if err != nil {
+			return err
+		}
+		if properties["LoadState"] == "loaded" {
+			return nil
+		}
+	}
+	return errors.New("no systemd unit is loaded")
+}
+
+type systemdUnitName struct {
+	container string
+	unit      string
+}
+
+// units parses system.systemd.units, a list of container=unit
+func (s *SystemdProvider) units() ([]systemdUnitName, error) {
+	list, err := GetList(s.dbProvider, "system.systemd.units")
+	if err != nil {
+		return nil, err
+	}
+	var units []systemdUnitName
+	for _, item := range list {
+		container, unit, found := strings.Cut(item, "=")
+		if !found || container == "" || unit == "" {
+			return nil, xerrors.Errorf("invalid system.systemd.units entry %s, expected container=unit", item)
+		}
+		units = append(units, systemdUnitName{container: container, unit: unit})
+	}
+	return units, nil
+}
+
+// unit finds the unit of a container ID, which is the unit name, or of a container name
+func (s *SystemdProvider) unit(containerID string) (systemdUnitName, error) {
+	units, err := s.units()
+	if err != nil {
+		return systemdUnitName{}, err
+	}
+	for _, unit := range units {
+		if unit.unit == containerID || unit.container == strings.TrimPrefix(containerID, "/") {
+			return unit, nil
+		}
+	}
+	return systemdUnitName{}, xerrors.Errorf("%s: %w", containerID, types2.ErrUnknownContainer)
+}
+
+// connection returns the connection to systemd, it is opened on the first call and again once it is lost. The dial
+// is not done under s.mtx so that a bus that does not answer only blocks the callers until their ctx is done.
+func (s *SystemdProvider) connection(ctx context.Context) (systemdConn, error) {
+	s.mtx.Lock()
+	conn := s.conn
+	s.mtx.Unlock()
+	if conn != nil && conn.Connected() {
+		return conn, nil
+	}
+	type dialResult struct {
+		conn systemdConn
+		err  error
+	}
+	result := make(chan dialResult, 1)
+	dial := dialSystemd
+	go func() {
+		conn, err := dial(s.address)
+		result <- dialResult{conn, err}
+	}()
+	select {
+	case <-ctx.Done():
+		go func() {
+			if r := <-result; r.err == nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	case r := <-result:
+		if r.err != nil {
+			return nil, xerrors.Errorf("failed to connect to systemd: %w", r.err)
+		}
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		if s.conn != nil && s.conn.Connected() {
+			// another caller reconnected first
+			r.conn.Close()
+			return s.conn, nil
+		}
+		if s.conn != nil {
+			s.conn.Close()
+		}
+		s.conn = r.conn
+		return r.conn, nil
+	}
+}
+
+// properties returns the properties of a unit, or of its unit type interface such as Service when unitType is set
+func (s *SystemdProvider) properties(ctx context.Context, unit string, unitType string) (map[string]any, error) {
+	conn, err := s.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if unitType != "" {
+		return conn.GetUnitTypePropertiesContext(ctx, unit, unitType)
+	}
+	return conn.GetUnitPropertiesContext(ctx, unit)
+}
+
+func (s *SystemdProvider) ContainerList(ctx context.Context) ([]types.Container, error) {
+	units, err := s.units()
+	if err != nil {
+		return nil, err
+	}
+	var containers []types.Container
+	for _, unit := range units {
+		properties, err := s.properties(ctx, unit.unit, "")
+		if err != nil {
+			return nil, err
+		}
+		if properties["LoadState"] != "loaded" {
+			continue
+		}
+		activeState, _ := properties["ActiveState"].(string)
+		subState, _ := properties["SubState"].(string)
+		description, _ := properties["Description"].(string)
+		containers = append(containers, types.Container{
+			ID:      unit.unit,
+			Names:   []string{"/" + unit.container},
+			Image:   description,
+			Created: int64(systemdUint(properties["StateChangeTimestamp"]) / 1e6),
+			State:   systemdContainerState(activeState),
+			Status:  activeState + " (" + subState + ")",
+			Labels:  map[string]string{"systemd.unit": unit.unit},
+		})
+	}
+	return containers, nil
+}
+
+// systemdContainerState maps the active state of a unit to the state of a container
+func systemdContainerState(activeState string) string {
+	switch activeState {
+	case "active", "reloading":
+		return "running"
+	case "activating":
+		return "restarting"
+	case "failed":
+		return "dead"
+	}
+	return "exited"
+}
+
+func (s *SystemdProvider) ContainerStart(ctx context.Context, containerID string) error {
+	return s.job(ctx, containerID, "StartUnit")
+}
+
+func (s *SystemdProvider) ContainerStop(ctx context.Context, containerID string) error {
+	return s.job(ctx, containerID, "StopUnit")
+}
+
+func (s *SystemdProvider) ContainerRestart(ctx context.Context, containerID string) error {
+	return s.job(ctx, containerID, "RestartUnit")
+}
+
+// job queues a start, stop or restart job for the unit of the container and waits for systemd to report its result
+func (s *SystemdProvider) job(ctx context.Context, containerID string, method string) error {
+	unit, err := s.unit(containerID)
+	if err != nil {
+		return err
+	}
+	conn, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+	// the result is sent once, the channel is buffered so that it is not lost when ctx is done first
+	done := make(chan string, 1)
+	switch method {
+	case "StartUnit":
+		_, err = conn.StartUnitContext(ctx, unit.unit, "replace", done)
+	case "StopUnit":
+		_, err = conn.StopUnitContext(ctx, unit.unit, "replace", done)
+	case "RestartUnit":
+		_, err = conn.RestartUnitContext(ctx, unit.unit, "replace", done)
+	}
+	if err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-done:
+		// done, canceled, timeout, failed, dependency or skipped
+		if result != "done" {
+			return xerrors.Errorf("%s of %s: %s", method, unit.unit, result)
+		}
+		return nil
+	}
+}
+
+// systemdSample is the state and resource accounting of a unit at a time
+type systemdSample struct {
+	time    time.Time
+	unit    map[string]any
+	service map[string]any
+}
+
+func (s *SystemdProvider) sample(ctx context.Context, unit string) (systemdSample, error) {
+	sample := systemdSample{time: time.Now()}
+	var err error
+	sample.unit, err = s.properties(ctx, unit, "")
+	if err != nil {
+		return sample, err
+	}
+	sample.service, err = s.properties(ctx, unit, "Service")
+	return sample, err
+}
+
+func (s *SystemdProvider) ContainerStats(ctx context.Context, containerID string, stream bool, cb func(stats types2.ContainerStats) error) error {
+	unit, err := s.unit(containerID)
+	if err != nil {
+		return err
+	}
+	// the CPU usage is measured between two samples
+	previous, err := s.sample(ctx, unit.unit)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(containerStatsInterval):
+		}
+		current, err := s.sample(ctx, unit.unit)
+		if err != nil {
+			return err
+		}
+		err = cb(systemdStats(unit, previous, current))
+		if err != nil || !stream {
+			return err
+		}
+		previous = current
+	}
+}
+
+// systemdStats computes the usage of a unit from its accounting properties, the CPU percent is relative to a single
+// CPU and the counters that are not enabled for the unit are 0
+func systemdStats(unit systemdUnitName, previous systemdSample, current systemdSample) types2.ContainerStats {
+	activeState, _ := current.unit["ActiveState"].(string)
+	result := types2.ContainerStats{
+		ID:           unit.unit,
+		Name:         unit.container,
+		Time:         current.time,
+		State:        systemdContainerState(activeState),
+		Health:       "none",
+		RestartCount: int(systemdUint(current.service["NRestarts"])),
+		MemoryUsage:  systemdUint(current.service["MemoryCurrent"]),
+		MemoryLimit:  systemdUint(current.service["MemoryMax"]),
+		NetworkRx:    systemdUint(current.service["IPIngressBytes"]),
+		NetworkTx:    systemdUint(current.service["IPEgressBytes"]),
+		BlockRead:    systemdUint(current.service["IOReadBytes"]),
+		BlockWrite:   systemdUint(current.service["IOWriteBytes"]),
+	}
+	if enteredAt := systemdUint(current.unit["ActiveEnterTimestamp"]); activeState == "active" && enteredAt > 0 {
+		result.StartedAt = time.UnixMicro(int64(enteredAt))
+		result.UptimeSeconds = current.time.Sub(result.StartedAt).Seconds()
+	}
+	cpuDelta := float64(systemdUint(current.service["CPUUsageNSec"])) - float64(systemdUint(previous.service["CPUUsageNSec"]))
+	elapsed := float64(current.time.Sub(previous.time).Nanoseconds())
+	if cpuDelta > 0 && elapsed > 0 {
+		result.CPUPercent = cpuDelta / elapsed * 100
+	}
+	if result.MemoryLimit > 0 {
+		result.MemoryPercent = float64(result.MemoryUsage) / float64(result.MemoryLimit) * 100
+	}
+	return result
+}
+
+// systemdUint returns an uint property, systemd uses the max value for the properties that are not set
+func systemdUint(value any) uint64 {
+	var n uint64
+	switch v := value.(type) {
+	case uint64:
+		n = v
+	case uint32:
+		n = uint64(v)
+	}
+	if n == math.MaxUint64 {
+		return 0
+	}
+	return n
+}
+
+func (s *SystemdProvider) ContainerLogs(ctx context.Context, containerID string, query types2.ContainerLogsQuery, cb func(line types2.ContainerLogLine) error) error {
+	unit, err := s.unit(containerID)
+	if err != nil {
+		return err
+	}
+	args, err := journalArgs(unit.unit, query, time.Now())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := journalCommand(ctx, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err = cmd.Start()
+	if err != nil {
+		return xerrors.Errorf("failed to run journalctl: %w", err)
+	}
+	err = readJournal(stdout, query.Stream, cb)
+	// stop following the journal when cb failed
+	cancel()
+	waitErr := cmd.Wait()
+	if err == nil && waitErr != nil && !query.Follow {
+		return xerrors.Errorf("journalctl failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+// journalArgs returns the journalctl arguments selecting the log lines of a unit
+func journalArgs(unit string, query types2.ContainerLogsQuery, now time.Time) ([]string, error) {
+	args := []string{"--unit", unit, "--output", "json", "--no-pager"}
+	for _, option := range []struct {
+		flag  string
+		value string
+	}{{"--since", query.Since}, {"--until", query.Until}} {
+		if option.value == "" {
+			continue
+		}
+		timestamp, err := timetypes.GetTimestamp(option.value, now)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, option.flag, "@"+timestamp)
+	}
+	if query.Tail != "" && query.Tail != "all" {
+		args = append(args, "--lines", query.Tail)
+	}
+	if query.Follow {
+		args = append(args, "--follow")
+	}
+	return args, nil
+}
+
+// journalEntry is an entry of journalctl --output json, MESSAGE is a string or an array of bytes when it is not valid
+// UTF-8 and the numbers are strings
+type journalEntry struct {
+	Message           json.RawMessage `json:"MESSAGE"`
+	Priority          string          `json:"PRIORITY"`
+	RealtimeTimestamp string          `json:"__REALTIME_TIMESTAMP"`
+}
+
+// readJournal calls cb for each entry, the entries with an error priority or above are on the stderr stream
+func readJournal(reader io.Reader, stream string, cb func(line types2.ContainerLogLine) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return xerrors.Errorf("invalid journal entry: %w", err)
+		}
+		line := types2.ContainerLogLine{Stream: "stdout"}
+		if priority, err := strconv.Atoi(entry.Priority); err == nil && priority <= 3 {
+			line.Stream = "stderr"
+		}
+		if stream != "" && stream != line.Stream {
+			continue
+		}
+		if usec, err := strconv.ParseInt(entry.RealtimeTimestamp, 10, 64); err == nil {
+			line.Timestamp = time.UnixMicro(usec).UTC()
+		}
+		var bytes []int
+		if json.Unmarshal(entry.Message, &line.Message) != nil && json.Unmarshal(entry.Message, &bytes) == nil {
+			line.Message = string(lo.Map(bytes, func(b int, idx int) byte {
+				return byte(b)
+			}))
+		}
+		err = cb(line)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *SystemdProvider) ContainerExec(ctx context.Context, containerID string, options types2.ExecOptions, stdin io.Reader, stdout io.Writer, resize <-chan types2.TerminalSize) (int, error) {
+	unit, err := s.unit(containerID)
+	if err != nil {
+		return -1, err
+	}
+	return s.docker.ContainerExec(ctx, unit.container, options, stdin, stdout, resize)
+}
+
+func (s *SystemdProvider) CheckImageUpdate(ctx context.Context, containerID string) (types2.ImageUpdateStatus, error) {
+	return types2.ImageUpdateStatus{}, errSystemdUpdate(containerID)
+}
+
+func (s *SystemdProvider) UpdateContainer(ctx context.Context, containerID string, progress func(event types2.ImageUpdateEvent)) (types2.ImageUpdateResult, error) {
+	return types2.ImageUpdateResult{}, errSystemdUpdate(containerID)
+}
+
+// errSystemdUpdate is returned for image updates, podman auto-update pulls the images and restarts the units
+func errSystemdUpdate(containerID string) error {
+	return xerrors.Errorf("the image of %s is managed by systemd, use podman auto-update to update it", containerID)
+}
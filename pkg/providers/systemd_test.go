@@ -0,0 +1,270 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSystemd is a systemd stand-in answering with the properties of units, the jobs report the result of the
+// next entry of results, done when it is empty
+type testSystemd struct {
+	mtx     sync.Mutex
+	units   map[string]map[string]map[string]any
+	calls   []string
+	results []string
+	conns   []*testSystemdConn
+}
+
+// testSystemdConn is a connection to testSystemd
+type testSystemdConn struct {
+	*testSystemd
+	closed bool
+}
+
+func newTestSystemd(t *testing.T, units map[string]map[string]map[string]any) *testSystemd {
+	systemd := &testSystemd{units: units}
+	dial := dialSystemd
+	dialSystemd = func(address string) (systemdConn, error) {
+		if address != testBusAddress {
+			return nil, errors.New("dial unix: no such file or directory")
+		}
+		systemd.mtx.Lock()
+		defer systemd.mtx.Unlock()
+		conn := &testSystemdConn{testSystemd: systemd}
+		systemd.conns = append(systemd.conns, conn)
+		return conn, nil
+	}
+	t.Cleanup(func() {
+		dialSystemd = dial
+	})
+	return systemd
+}
+
+func (b *testSystemd) GetUnitPropertiesContext(ctx context.Context, unit string) (map[string]interface{}, error) {
+	return b.GetUnitTypePropertiesContext(ctx, unit, "Unit")
+}
+
+func (b *testSystemd) GetUnitTypePropertiesContext(ctx context.Context, unit string, unitType string) (map[string]interface{}, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	properties, ok := b.units[unit][unitType]
+	if !ok {
+		return map[string]interface{}{"LoadState": "not-found"}, nil
+	}
+	return properties, nil
+}
+
+func (b *testSystemd) job(method string, name string, mode string, ch chan<- string) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	unit, ok := b.units[name]
+	if !ok {
+		return 0, errors.New("Unit " + name + " not found.")
+	}
+	b.calls = append(b.calls, method+" "+name+" "+mode)
+	unit["Unit"]["ActiveState"] = map[string]string{"StartUnit": "active", "StopUnit": "inactive", "RestartUnit": "active"}[method]
+	result := "done"
+	if len(b.results) > 0 {
+		result, b.results = b.results[0], b.results[1:]
+	}
+	go func() {
+		ch <- result
+	}()
+	return 7, nil
+}
+
+func (b *testSystemd) StartUnitContext(ctx context.Context, name string, mode string, ch chan<- string) (int, error) {
+	return b.job("StartUnit", name, mode, ch)
+}
+
+func (b *testSystemd) StopUnitContext(ctx context.Context, name string, mode string, ch chan<- string) (int, error) {
+	return b.job("StopUnit", name, mode, ch)
+}
+
+func (b *testSystemd) RestartUnitContext(ctx context.Context, name string, mode string, ch chan<- string) (int, error) {
+	return b.job("RestartUnit", name, mode, ch)
+}
+
+func (c *testSystemdConn) Connected() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return !c.closed
+}
+
+func (c *testSystemdConn) Close() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.closed = true
+}
+
+func newTestUnits() map[string]map[string]map[string]any {
+	return map[string]map[string]map[string]any{
+		"openmower.service": {
+			"Unit": {
+				"LoadState": "loaded", "ActiveState": "active", "SubState": "running", "Description": "Podman container - openmower.service",
+				"StateChangeTimestamp": uint64(1714564800000000), "ActiveEnterTimestamp": uint64(1714564800000000),
+			},
+			"Service": {"NRestarts": uint32(2), "CPUUsageNSec": uint64(1e9), "MemoryCurrent": uint64(64 << 20), "MemoryMax": uint64(math.MaxUint64)},
+		},
+		"gui.service": {
+			"Unit": {
+				"LoadState": "loaded", "ActiveState": "failed", "SubState": "failed", "Description": "Podman container - gui.service",
+				"StateChangeTimestamp": uint64(1714564900000000),
+			},
+		},
+	}
+}
+
+const testBusAddress = "unix:path=/run/dbus/system_bus_socket"
+
+func newTestSystemdProvider() *SystemdProvider {
+	return NewSystemdProvider(fakes.NewDBProvider(map[string]string{
+		"system.systemd.busAddress": testBusAddress,
+		"system.systemd.units":      "openmower=openmower.service,openmower-gui=gui.service,mowgli=mowgli.service",
+	}))
+}
+
+func TestSystemdProvider(t *testing.T) {
+	systemd := newTestSystemd(t, newTestUnits())
+	provider := newTestSystemdProvider()
+	ctx := context.Background()
+
+	containers, err := provider.ContainerList(ctx)
+	require.NoError(t, err)
+	require.Len(t, containers, 2)
+	assert.Equal(t, "openmower.service", containers[0].ID)
+	assert.Equal(t, []string{"/openmower"}, containers[0].Names)
+	assert.Equal(t, "running", containers[0].State)
+	assert.Equal(t, "active (running)", containers[0].Status)
+	assert.Equal(t, int64(1714564800), containers[0].Created)
+	assert.Equal(t, "dead", containers[1].State)
+
+	require.NoError(t, provider.ContainerRestart(ctx, "openmower.service"))
+	require.NoError(t, provider.ContainerStop(ctx, "openmower-gui"))
+	require.NoError(t, provider.ContainerStart(ctx, "gui.service"))
+	assert.Equal(t, []string{
+		"RestartUnit openmower.service replace", "StopUnit gui.service replace", "StartUnit gui.service replace",
+	}, systemd.calls)
+
+	systemd.results = []string{"failed"}
+	assert.EqualError(t, provider.ContainerStart(ctx, "gui.service"), "StartUnit of gui.service: failed")
+	assert.EqualError(t, provider.ContainerStart(ctx, "unknown"), "unknown: no such container")
+	assert.EqualError(t, provider.ContainerStart(ctx, "mowgli"), "Unit mowgli.service not found.")
+
+	// the connection is opened again once it is lost
+	require.Len(t, systemd.conns, 1)
+	systemd.conns[0].Close()
+	_, err = provider.ContainerList(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, systemd.conns, 2)
+}
+
+func TestSystemdProviderDialTimeout(t *testing.T) {
+	dial := dialSystemd
+	unblock := make(chan struct{})
+	dialSystemd = func(address string) (systemdConn, error) {
+		<-unblock
+		return nil, errors.New("closed")
+	}
+	t.Cleanup(func() {
+		close(unblock)
+		dialSystemd = dial
+	})
+	provider := newTestSystemdProvider()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := provider.ContainerList(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNewContainerBackend(t *testing.T) {
+	newTestSystemd(t, newTestUnits())
+	provider := newContainerBackend(fakes.NewDBProvider(map[string]string{
+		"system.containers.backend": "auto",
+		"system.systemd.busAddress": testBusAddress,
+		"system.systemd.units":      "openmower=openmower.service",
+	}))
+	assert.IsType(t, &SystemdProvider{}, provider)
+
+	provider = newContainerBackend(fakes.NewDBProvider(map[string]string{
+		"system.containers.backend": "auto",
+		"system.systemd.busAddress": testBusAddress,
+		"system.systemd.units":      "mowgli=mowgli.service",
+	}))
+	assert.IsType(t, &DockerProvider{}, provider)
+
+	provider = newContainerBackend(fakes.NewDBProvider(map[string]string{
+		"system.containers.backend": "auto",
+		"system.systemd.busAddress": "unix:path=/missing",
+		"system.systemd.units":      "openmower=openmower.service",
+	}))
+	assert.IsType(t, &DockerProvider{}, provider)
+}
+
+func TestSystemdStats(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	unit := systemdUnitName{container: "openmower", unit: "openmower.service"}
+	previous := systemdSample{time: start, service: map[string]any{"CPUUsageNSec": uint64(1e9)}}
+	current := systemdSample{
+		time: start.Add(2 * time.Second),
+		unit: map[string]any{"ActiveState": "active", "ActiveEnterTimestamp": uint64(start.Add(-time.Minute).UnixMicro())},
+		service: map[string]any{
+			"CPUUsageNSec": uint64(2e9), "NRestarts": uint32(3), "MemoryCurrent": uint64(100), "MemoryMax": uint64(400),
+			"IPIngressBytes": uint64(math.MaxUint64), "IOReadBytes": uint64(10), "IOWriteBytes": uint64(20),
+		},
+	}
+	assert.Equal(t, types.ContainerStats{
+		ID: "openmower.service", Name: "openmower", Time: current.time, State: "running", Health: "none", RestartCount: 3,
+		StartedAt: start.Add(-time.Minute).Local(), UptimeSeconds: 62, CPUPercent: 50,
+		MemoryUsage: 100, MemoryLimit: 400, MemoryPercent: 25, BlockRead: 10, BlockWrite: 20,
+	}, systemdStats(unit, previous, current))
+}
+
+func TestJournalArgs(t *testing.T) {
+	now := time.Unix(1714564800, 0)
+	args, err := journalArgs("gui.service", types.ContainerLogsQuery{Since: "10m", Tail: "50", Follow: true}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--unit", "gui.service", "--output", "json", "--no-pager", "--since", "@1714564200", "--lines", "50", "--follow",
+	}, args)
+
+	args, err = journalArgs("gui.service", types.ContainerLogsQuery{Until: "1714564800", Tail: "all"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--unit", "gui.service", "--output", "json", "--no-pager", "--until", "@1714564800"}, args)
+}
+
+func TestReadJournal(t *testing.T) {
+	journal := `{"MESSAGE":"started","PRIORITY":"6","__REALTIME_TIMESTAMP":"1714564800500000"}
+{"MESSAGE":"failed to connect","PRIORITY":"3","__REALTIME_TIMESTAMP":"1714564801000000"}
+{"MESSAGE":[98,105,110,255],"PRIORITY":"6","__REALTIME_TIMESTAMP":"1714564802000000"}
+`
+	var lines []types.ContainerLogLine
+	err := readJournal(strings.NewReader(journal), "", func(line types.ContainerLogLine) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.ContainerLogLine{
+		{Stream: "stdout", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 500000000, time.UTC), Message: "started"},
+		{Stream: "stderr", Timestamp: time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC), Message: "failed to connect"},
+		{Stream: "stdout", Timestamp: time.Date(2024, 5, 1, 12, 0, 2, 0, time.UTC), Message: "bin\xff"},
+	}, lines)
+
+	lines = nil
+	err = readJournal(strings.NewReader(journal), "stderr", func(line types.ContainerLogLine) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, lines, 1)
+}
@@ -0,0 +1,244 @@
+package providers
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/types"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+var teleopLog = Logs.Logger("teleop")
+
+// teleopTopic is the topic of the velocities of the joystick
+const teleopTopic = "/joy_vel"
+
+type teleopConfig struct {
+	timeout       time.Duration
+	maxLinear     float64
+	maxAngular    float64
+	allowedStates []string
+}
+
+// TeleopProvider forwards joystick velocities to /joy_vel for a single driver at a time. The robot is stopped when
+// the driver releases the session, when no velocity is received before the watchdog timeout or when the mower
+// leaves the allowed states. Teleop is refused while a recording is replayed, as the mower state is then the
+// recorded one and not the state of the robot that would be driven.
+type TeleopProvider struct {
+	rosProvider types.IRosProvider
+	dbProvider  types.IDBProvider
+	mtx         sync.Mutex
+	state       string
+	emergency   bool
+	session     *teleopSession
+}
+
+type teleopSession struct {
+	provider  *TeleopProvider
+	driver    string
+	config    teleopConfig
+	publisher types.IRosPublisher
+	timer     *time.Timer
+	moving    bool
+}
+
+func NewTeleopProvider(rosProvider types.IRosProvider, dbProvider types.IDBProvider) *TeleopProvider {
+	t := &TeleopProvider{
+		rosProvider: rosProvider,
+		dbProvider:  dbProvider,
+	}
+	err := rosProvider.Subscribe("/mower_logic/current_state", "teleop", t.onStatus)
+	if err != nil {
+		teleopLog.Error(xerrors.Errorf("failed to subscribe to /mower_logic/current_state: %w", err))
+	}
+	return t
+}
+
+func (t *TeleopProvider) Acquire(driver string) (types.ITeleopSession, error) {
+	config, err := t.config()
+	if err != nil {
+		return nil, err
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.session != nil {
+		return nil, xerrors.Errorf("teleop is already in use by %s", t.session.driver)
+	}
+	if !t.allowed(config) {
+		return nil, t.notAllowedError()
+	}
+	publisher, err := t.rosProvider.Publisher(teleopTopic, &geometry_msgs.Twist{})
+	if err != nil {
+		return nil, err
+	}
+	session := &teleopSession{
+		provider:  t,
+		driver:    driver,
+		config:    config,
+		publisher: publisher,
+	}
+	session.timer = time.AfterFunc(config.timeout, session.expire)
+	t.session = session
+	teleopLog.Info("Teleop acquired by " + driver)
+	return session, nil
+}
+
+func (t *TeleopProvider) Status() types.TeleopStatus {
+	config, err := t.config()
+	if err != nil {
+		teleopLog.Error(xerrors.Errorf("failed to read teleop configuration: %w", err))
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	status := types.TeleopStatus{
+		Active:        t.session != nil,
+		State:         t.state,
+		Allowed:       t.allowed(config),
+		AllowedStates: config.allowedStates,
+		MaxLinear:     config.maxLinear,
+		MaxAngular:    config.maxAngular,
+		Timeout:       int(config.timeout / time.Millisecond),
+	}
+	if t.session != nil {
+		status.Driver = t.session.driver
+	}
+	return status
+}
+
+func (t *TeleopProvider) config() (teleopConfig, error) {
+	var config teleopConfig
+	timeout, err := GetInt(t.dbProvider, "system.teleop.timeout")
+	if err != nil {
+		return config, err
+	}
+	config.timeout = time.Duration(timeout) * time.Millisecond
+	config.maxLinear, err = GetFloat(t.dbProvider, "system.teleop.maxLinear")
+	if err != nil {
+		return config, err
+	}
+	config.maxAngular, err = GetFloat(t.dbProvider, "system.teleop.maxAngular")
+	if err != nil {
+		return config, err
+	}
+	config.allowedStates, err = GetList(t.dbProvider, "system.teleop.allowedStates")
+	return config, err
+}
+
+func (t *TeleopProvider) allowed(config teleopConfig) bool {
+	return !t.rosProvider.Replaying() && !t.emergency && lo.Contains(config.allowedStates, t.state)
+}
+
+func (t *TeleopProvider) notAllowedError() error {
+	if t.rosProvider.Replaying() {
+		return xerrors.New("teleop is not allowed while a recording is replayed")
+	}
+	if t.emergency {
+		return xerrors.New("teleop is not allowed during an emergency")
+	}
+	if t.state == "" {
+		return xerrors.New("teleop is not allowed while the mower state is unknown")
+	}
+	return xerrors.Errorf("teleop is not allowed in state %s", t.state)
+}
+
+func (t *TeleopProvider) onStatus(msg []byte) {
+	var status mower_msgs.HighLevelStatus
+	err := json.Unmarshal(msg, &status)
+	if err != nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.rosProvider.Replaying() {
+		// the recorded state is not the state of the robot, the live state is received again when the replay stops
+		if t.session != nil {
+			t.session.end()
+		}
+		return
+	}
+	t.state = status.StateName
+	t.emergency = status.Emergency
+	if t.session != nil && !t.allowed(t.session.config) {
+		t.session.stop()
+	}
+}
+
+func (s *teleopSession) Drive(linear float64, angular float64) error {
+	t := s.provider
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.session != s {
+		return xerrors.New("teleop session has been released")
+	}
+	if !t.allowed(s.config) {
+		err := t.notAllowedError()
+		if t.rosProvider.Replaying() {
+			s.end()
+		} else {
+			s.stop()
+		}
+		return err
+	}
+	linear = clamp(linear, s.config.maxLinear)
+	angular = clamp(angular, s.config.maxAngular)
+	s.publisher.Write(&geometry_msgs.Twist{
+		Linear:  geometry_msgs.Vector3{X: linear},
+		Angular: geometry_msgs.Vector3{Z: angular},
+	})
+	s.moving = linear != 0 || angular != 0
+	s.timer.Reset(s.config.timeout)
+	return nil
+}
+
+func (s *teleopSession) Release() {
+	t := s.provider
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.session != s {
+		return
+	}
+	s.end()
+	teleopLog.Info("Teleop released by " + s.driver)
+}
+
+// end stops the robot and closes the session, t.mtx must be held
+func (s *teleopSession) end() {
+	s.timer.Stop()
+	s.publisher.Write(&geometry_msgs.Twist{})
+	s.moving = false
+	s.publisher.Close()
+	s.provider.session = nil
+}
+
+// expire is the dead-man watchdog, it stops the robot when the driver did not send a velocity in time
+func (s *teleopSession) expire() {
+	t := s.provider
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.session != s || !s.moving {
+		return
+	}
+	teleopLog.Warn("Teleop watchdog expired for " + s.driver + ", stopping the mower")
+	s.stop()
+}
+
+// stop publishes a zero velocity if the robot was moving, t.mtx must be held
+func (s *teleopSession) stop() {
+	if !s.moving {
+		return
+	}
+	s.publisher.Write(&geometry_msgs.Twist{})
+	s.moving = false
+}
+
+func clamp(value float64, limit float64) float64 {
+	if math.IsNaN(value) {
+		return 0
+	}
+	return math.Max(-limit, math.Min(limit, value))
+}
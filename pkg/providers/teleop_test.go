@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluenviron/goroslib/v2/pkg/msgs/geometry_msgs"
+	"github.com/cedbossneo/openmower-gui/pkg/fakes"
+	"github.com/cedbossneo/openmower-gui/pkg/msgs/mower_msgs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeleop(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	db := fakes.NewDBProvider(map[string]string{
+		"system.teleop.timeout":       "10000",
+		"system.teleop.maxLinear":     "0.5",
+		"system.teleop.maxAngular":    "1",
+		"system.teleop.allowedStates": "AREA_RECORDING",
+	})
+	teleop := NewTeleopProvider(ros, db)
+
+	_, err := teleop.Acquire("driver")
+	assert.EqualError(t, err, "teleop is not allowed while the mower state is unknown")
+	assert.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "MOWING"}))
+	_, err = teleop.Acquire("driver")
+	assert.EqualError(t, err, "teleop is not allowed in state MOWING")
+
+	assert.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "AREA_RECORDING"}))
+	session, err := teleop.Acquire("driver")
+	assert.NoError(t, err)
+	_, err = teleop.Acquire("other")
+	assert.EqualError(t, err, "teleop is already in use by driver")
+
+	assert.NoError(t, session.Drive(-3, 0.25))
+	assert.Equal(t, []any{&geometry_msgs.Twist{
+		Linear:  geometry_msgs.Vector3{X: -0.5},
+		Angular: geometry_msgs.Vector3{Z: 0.25},
+	}}, ros.Published("/joy_vel"))
+
+	// leaving the allowed states stops the mower and rejects further velocities
+	assert.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "AREA_RECORDING", Emergency: true}))
+	assert.Len(t, ros.Published("/joy_vel"), 2)
+	assert.Equal(t, &geometry_msgs.Twist{}, ros.Published("/joy_vel")[1])
+	assert.EqualError(t, session.Drive(0.1, 0), "teleop is not allowed during an emergency")
+
+	session.Release()
+	assert.False(t, teleop.Status().Active)
+	assert.EqualError(t, session.Drive(0.1, 0), "teleop session has been released")
+}
+
+func TestTeleopWatchdog(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	db := fakes.NewDBProvider(map[string]string{
+		"system.teleop.timeout":       "20",
+		"system.teleop.maxLinear":     "0.5",
+		"system.teleop.maxAngular":    "1",
+		"system.teleop.allowedStates": "IDLE",
+	})
+	teleop := NewTeleopProvider(ros, db)
+	assert.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "IDLE"}))
+	session, err := teleop.Acquire("driver")
+	assert.NoError(t, err)
+	defer session.Release()
+
+	assert.NoError(t, session.Drive(0.2, 0))
+	assert.Eventually(t, func() bool {
+		return len(ros.Published("/joy_vel")) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, &geometry_msgs.Twist{}, ros.Published("/joy_vel")[1])
+	assert.True(t, teleop.Status().Active)
+}
+
+func TestTeleopReplay(t *testing.T) {
+	ros := fakes.NewRosProvider()
+	db := fakes.NewDBProvider(map[string]string{
+		"system.teleop.timeout":       "10000",
+		"system.teleop.maxLinear":     "0.5",
+		"system.teleop.maxAngular":    "1",
+		"system.teleop.allowedStates": "IDLE",
+	})
+	teleop := NewTeleopProvider(ros, db)
+	assert.NoError(t, ros.Publish("/mower_logic/current_state", &mower_msgs.HighLevelStatus{StateName: "IDLE"}))
+	session, err := teleop.Acquire("driver")
+	assert.NoError(t, err)
+	assert.NoError(t, session.Drive(0.2, 0))
+
+	// a recorded state ends the session, the robot is stopped
+	ros.Replay(true)
+	ros.Inject("/mower_logic/current_state", []byte(`{"StateName":"IDLE"}`))
+	assert.False(t, teleop.Status().Active)
+	assert.Equal(t, &geometry_msgs.Twist{}, ros.Published("/joy_vel")[1])
+	assert.EqualError(t, session.Drive(0.1, 0), "teleop session has been released")
+	_, err = teleop.Acquire("driver")
+	assert.EqualError(t, err, "teleop is not allowed while a recording is replayed")
+
+	ros.Replay(false)
+	session, err = teleop.Acquire("driver")
+	assert.NoError(t, err)
+	session.Release()
+}
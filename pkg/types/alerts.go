@@ -0,0 +1,58 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownAlert = errors.New("unknown alert")
+
+type IAlertProvider interface {
+	Rules() ([]AlertRule, error)
+	SetRules(rules []AlertRule) error
+	Active() []Alert
+	Acknowledge(id string) error
+	SubscribeAlerts(id string, cb func(event AlertEvent))
+	UnSubscribeAlerts(id string)
+}
+
+// AlertRule raises an alert when Field of the Source message compared to Value with Operator holds for For seconds,
+// e.g. MowEscStatus.TemperatureMotor > 80 for 30s. Source is one of status, highLevelStatus or pose. When States is
+// set the rule only holds while the mower is in one of these states. A numeric alert is cleared once the value is
+// back past Value by Hysteresis.
+type AlertRule struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Severity   string   `json:"severity"`
+	Source     string   `json:"source"`
+	Field      string   `json:"field"`
+	Operator   string   `json:"operator"`
+	Value      any      `json:"value"`
+	For        float64  `json:"for"`
+	Hysteresis float64  `json:"hysteresis"`
+	States     []string `json:"states,omitempty"`
+}
+
+// Alert is raised by a rule, there is at most one active alert per rule and its ID is the rule ID
+type Alert struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Severity       string     `json:"severity"`
+	Value          any        `json:"value"`
+	RaisedAt       time.Time  `json:"raisedAt"`
+	ClearedAt      *time.Time `json:"clearedAt,omitempty"`
+	Acknowledged   bool       `json:"acknowledged"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty"`
+}
+
+// AlertEvent is sent to the subscribers when an alert is raised, acknowledged or cleared
+type AlertEvent struct {
+	Type  string `json:"type"`
+	Alert Alert  `json:"alert"`
+}
+
+const (
+	AlertRaised       = "raised"
+	AlertAcknowledged = "acknowledged"
+	AlertCleared      = "cleared"
+)
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// the parts of a backup archive
+const (
+	BackupDB          = "db"
+	BackupMowerConfig = "mowerConfig"
+	BackupMap         = "map"
+	// BackupFirmwareConfig and BackupHomeKit are the firmware config and the HomeKit identity found in the DB keys
+	BackupFirmwareConfig = "firmwareConfig"
+	BackupHomeKit        = "homekit"
+)
+
+type IBackupProvider interface {
+	// Backup returns an archive of the GUI DB keys, the mower config and the map, encrypted when passphrase is set
+	Backup(ctx context.Context, passphrase string) ([]byte, BackupManifest, error)
+	// Restore validates an archive then applies it, it fails with ErrInvalidBackup if the archive cannot be read. The
+	// replaced state is put back if a part of the archive cannot be applied.
+	Restore(ctx context.Context, archive []byte, passphrase string) (BackupManifest, error)
+}
+
+// BackupManifest describes a backup archive, Contents are the parts it holds
+type BackupManifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Contents  []string  `json:"contents"`
+	Encrypted bool      `json:"encrypted"`
+}
@@ -2,14 +2,109 @@ package types
 
 import (
 	"context"
+	"errors"
 	"github.com/docker/docker/api/types"
 	"io"
+	"time"
 )
 
+// ErrUnknownContainer is returned for the containers that do not exist or are out of the management scope
+var ErrUnknownContainer = errors.New("no such container")
+
 type IDockerProvider interface {
 	ContainerList(ctx context.Context) ([]types.Container, error)
-	ContainerLogs(ctx context.Context, containerID string) (io.ReadCloser, error)
+	// ContainerLogs calls cb for each log line of the container selected by query, it returns when the logs are read,
+	// when ctx is done or when cb returns an error
+	ContainerLogs(ctx context.Context, containerID string, query ContainerLogsQuery, cb func(line ContainerLogLine) error) error
+	// ContainerStats calls cb with the resource usage of the container, every second until ctx is done or cb returns an
+	// error when stream is true and once otherwise
+	ContainerStats(ctx context.Context, containerID string, stream bool, cb func(stats ContainerStats) error) error
+	// CheckImageUpdate compares the digest of the image tag of the container in its registry with the local image
+	CheckImageUpdate(ctx context.Context, containerID string) (ImageUpdateStatus, error)
+	// UpdateContainer pulls the image tag of the container and recreates it with the same configuration, the previous
+	// container is restored if the new one does not become healthy
+	UpdateContainer(ctx context.Context, containerID string, progress func(event ImageUpdateEvent)) (ImageUpdateResult, error)
+	// ContainerExec runs a command with a TTY in the container, stdin is sent to the command, its output is written to
+	// stdout and the TTY is resized to the sizes received from resize. It returns the exit code of the command, the
+	// command is killed when ctx is done.
+	ContainerExec(ctx context.Context, containerID string, options ExecOptions, stdin io.Reader, stdout io.Writer, resize <-chan TerminalSize) (int, error)
 	ContainerStart(ctx context.Context, containerID string) error
 	ContainerStop(ctx context.Context, containerID string) error
 	ContainerRestart(ctx context.Context, containerID string) error
 }
+
+// ContainerLogsQuery selects the log lines of a container. Since and Until are RFC 3339 dates, unix timestamps or
+// durations relative to now such as 10m, Tail is a number of lines or all and Stream is stdout, stderr or empty for
+// both. With Follow the new lines are read until the context is done.
+type ContainerLogsQuery struct {
+	Since  string `form:"since"`
+	Until  string `form:"until"`
+	Tail   string `form:"tail"`
+	Stream string `form:"stream"`
+	Follow bool   `form:"-"`
+}
+
+type ContainerLogLine struct {
+	Stream    string    `json:"stream"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+// ContainerStats is the resource usage of a container, CPUPercent is relative to a single CPU like docker stats and
+// Health is healthy, unhealthy, starting or none when the container has no health check
+type ContainerStats struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Time          time.Time `json:"time"`
+	State         string    `json:"state"`
+	Health        string    `json:"health"`
+	RestartCount  int       `json:"restartCount"`
+	StartedAt     time.Time `json:"startedAt"`
+	UptimeSeconds float64   `json:"uptimeSeconds"`
+	CPUPercent    float64   `json:"cpuPercent"`
+	MemoryUsage   uint64    `json:"memoryUsage"`
+	MemoryLimit   uint64    `json:"memoryLimit"`
+	MemoryPercent float64   `json:"memoryPercent"`
+	NetworkRx     uint64    `json:"networkRx"`
+	NetworkTx     uint64    `json:"networkTx"`
+	BlockRead     uint64    `json:"blockRead"`
+	BlockWrite    uint64    `json:"blockWrite"`
+}
+
+type ImageUpdateStatus struct {
+	ContainerID     string `json:"containerId"`
+	Image           string `json:"image"`
+	LocalDigest     string `json:"localDigest"`
+	RemoteDigest    string `json:"remoteDigest"`
+	UpdateAvailable bool   `json:"updateAvailable"`
+}
+
+// ImageUpdateEvent reports the progress of an update, Step is pull, recreate, health, rollback or done. Layer, Current
+// and Total are the progress of the pull of a layer.
+type ImageUpdateEvent struct {
+	Step    string `json:"step"`
+	Status  string `json:"status"`
+	Layer   string `json:"layer,omitempty"`
+	Current int64  `json:"current,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+}
+
+type ImageUpdateResult struct {
+	ContainerID     string `json:"containerId"`
+	Image           string `json:"image"`
+	PreviousImageID string `json:"previousImageId"`
+	ImageID         string `json:"imageId"`
+	Updated         bool   `json:"updated"`
+	RolledBack      bool   `json:"rolledBack"`
+}
+
+// ExecOptions are the command run by ContainerExec and the initial size of its TTY
+type ExecOptions struct {
+	Cmd  []string
+	Size TerminalSize
+}
+
+type TerminalSize struct {
+	Rows uint `json:"rows"`
+	Cols uint `json:"cols"`
+}
@@ -0,0 +1,32 @@
+package types
+
+import "context"
+
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthFailed   = "failed"
+	HealthDisabled = "disabled"
+)
+
+type IHealthProvider interface {
+	// Live checks the dependencies without which the GUI cannot work
+	Live(ctx context.Context) HealthReport
+	// Ready checks all the dependencies
+	Ready(ctx context.Context) HealthReport
+}
+
+// HealthReport is failed when a critical check failed and degraded when another check did not succeed
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+type HealthCheck struct {
+	Name       string         `json:"name"`
+	Status     string         `json:"status"`
+	Critical   bool           `json:"critical"`
+	Message    string         `json:"message,omitempty"`
+	DurationMs float64        `json:"durationMs"`
+	Details    map[string]any `json:"details,omitempty"`
+}
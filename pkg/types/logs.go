@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// ILogProvider gives access to the logs of the GUI, each subsystem (ros, docker, mqtt...) has its own level
+type ILogProvider interface {
+	Entries(query LogQuery) ([]LogEntry, error)
+	// SubscribeLogs calls cb for each new entry matching query, it returns the most recent entries logged before
+	SubscribeLogs(id string, query LogQuery, cb func(entry LogEntry)) ([]LogEntry, error)
+	UnSubscribeLogs(id string)
+	Levels() map[string]string
+	SetLevels(levels map[string]string) error
+}
+
+type LogEntry struct {
+	Time      time.Time      `json:"time"`
+	Level     string         `json:"level"`
+	Subsystem string         `json:"subsystem"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// LogQuery selects the entries of Subsystem, or of all subsystems when empty, at Level or above. Limit is the
+// maximum number of most recent entries returned, 0 means all the entries.
+type LogQuery struct {
+	Subsystem string `form:"subsystem"`
+	Level     string `form:"level"`
+	Limit     int    `form:"limit"`
+}
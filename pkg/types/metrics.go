@@ -0,0 +1,8 @@
+package types
+
+import "io"
+
+type IMetricsProvider interface {
+	// WriteMetrics writes the metrics in the Prometheus text exposition format
+	WriteMetrics(w io.Writer) error
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownAction  = errors.New("unknown action")
+	ErrActionDisabled = errors.New("action is disabled")
+)
+
+type IMonitoringProvider interface {
+	Sensors() []Sensor
+	SubscribeSensors(id string, cb func(sensor Sensor))
+	UnSubscribeSensors(id string)
+	Actions() []Action
+	TriggerAction(id string) error
+}
+
+// Sensor is a sensor published by xbot_monitoring, thresholds are only set when the sensor defines them
+type Sensor struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Type          string    `json:"type"`
+	Description   string    `json:"description"`
+	Unit          string    `json:"unit"`
+	Min           *float64  `json:"min,omitempty"`
+	Max           *float64  `json:"max,omitempty"`
+	LowerCritical *float64  `json:"lowerCritical,omitempty"`
+	UpperCritical *float64  `json:"upperCritical,omitempty"`
+	Value         any       `json:"value"`
+	Stamp         time.Time `json:"stamp"`
+	Critical      bool      `json:"critical"`
+}
+
+// Action is an action registered by a node, ID is the node prefix and the action id joined by a slash
+type Action struct {
+	ID      string `json:"id"`
+	Node    string `json:"node"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+type INotificationProvider interface {
+	Channels() ([]NotificationChannel, error)
+	SetChannels(channels []NotificationChannel) error
+	Notify(event NotificationEvent)
+	Test(channelID string) (NotificationDelivery, error)
+	Deliveries() []NotificationDelivery
+}
+
+// NotificationChannel delivers events to a webhook, an SMTP server, ntfy or Gotify. Events lists the event types
+// routed to the channel, all events are routed when it is empty.
+type NotificationChannel struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Enabled bool     `json:"enabled"`
+	Events  []string `json:"events,omitempty"`
+	// URL of the webhook, the ntfy topic or the Gotify server
+	URL string `json:"url,omitempty"`
+	// Method of the webhook, defaults to POST
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is a text/template rendered with the event, defaults to the event as JSON
+	Body string `json:"body,omitempty"`
+	// Token is the Gotify application token or the ntfy access token
+	Token    string   `json:"token,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	Host     string   `json:"host,omitempty"`
+	Port     int      `json:"port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+type NotificationEvent struct {
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Severity string    `json:"severity"`
+	Time     time.Time `json:"time"`
+	Data     any       `json:"data,omitempty"`
+}
+
+type NotificationDelivery struct {
+	Channel  string    `json:"channel"`
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	Attempts int       `json:"attempts"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+}
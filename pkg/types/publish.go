@@ -0,0 +1,22 @@
+package types
+
+import "errors"
+
+var (
+	ErrTopicNotAllowed = errors.New("publishing to this topic is not allowed")
+	ErrRateLimited     = errors.New("publish rate limit exceeded")
+)
+
+type IPublishProvider interface {
+	Topics() ([]PublishTopic, error)
+	Message(topic string) (any, error)
+	Publish(topic string, msg any) error
+}
+
+// PublishTopic is a topic the GUI is allowed to publish to, Rate is the maximum number of messages per second, 0 if
+// unlimited
+type PublishTopic struct {
+	Topic string  `json:"topic"`
+	Type  string  `json:"type"`
+	Rate  float64 `json:"rate"`
+}
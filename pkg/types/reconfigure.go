@@ -0,0 +1,45 @@
+package types
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type IReconfigureProvider interface {
+	Parameters(ctx context.Context, node string) ([]ReconfigureParameter, error)
+	SetParameters(ctx context.Context, node string, values map[string]any) ([]ReconfigureParameter, error)
+}
+
+type ReconfigureParameter struct {
+	Name        string                 `json:"name"`
+	Type        string                 `json:"type"`
+	Group       string                 `json:"group"`
+	Description string                 `json:"description"`
+	Level       uint32                 `json:"level"`
+	Min         any                    `json:"min,omitempty"`
+	Max         any                    `json:"max,omitempty"`
+	Default     any                    `json:"default"`
+	Value       any                    `json:"value"`
+	Enum        []ReconfigureEnumValue `json:"enum,omitempty"`
+}
+
+type ReconfigureEnumValue struct {
+	Name        string `json:"name"`
+	Value       any    `json:"value"`
+	Description string `json:"description"`
+}
+
+// ValidationError is returned when a request contains invalid values, Fields maps each invalid field to its error
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (v *ValidationError) Error() string {
+	var errs []string
+	for field, err := range v.Fields {
+		errs = append(errs, field+": "+err)
+	}
+	sort.Strings(errs)
+	return "invalid values: " + strings.Join(errs, ", ")
+}
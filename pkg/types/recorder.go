@@ -0,0 +1,21 @@
+package types
+
+import "time"
+
+type IRecorderProvider interface {
+	Start(name string, topics []string) (Recording, error)
+	Stop() (Recording, error)
+	List() ([]Recording, error)
+	Path(name string) (string, error)
+	Delete(name string) error
+}
+
+type Recording struct {
+	Name      string    `json:"name"`
+	Topics    []string  `json:"topics"`
+	Trigger   string    `json:"trigger"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  float64   `json:"duration"`
+	Size      int64     `json:"size"`
+	Active    bool      `json:"active"`
+}
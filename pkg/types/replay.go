@@ -0,0 +1,21 @@
+package types
+
+type IReplayProvider interface {
+	Load(name string) (ReplayStatus, error)
+	Play() (ReplayStatus, error)
+	Pause() (ReplayStatus, error)
+	Seek(position float64) (ReplayStatus, error)
+	Speed(speed float64) (ReplayStatus, error)
+	Stop() (ReplayStatus, error)
+	Status() ReplayStatus
+}
+
+type ReplayStatus struct {
+	Active   bool     `json:"active"`
+	Name     string   `json:"name"`
+	Playing  bool     `json:"playing"`
+	Speed    float64  `json:"speed"`
+	Position float64  `json:"position"`
+	Duration float64  `json:"duration"`
+	Topics   []string `json:"topics"`
+}
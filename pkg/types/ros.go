@@ -2,12 +2,57 @@ package types
 
 import (
 	"context"
-	"github.com/bluenviron/goroslib/v2"
+	"errors"
+	"time"
 )
 
+var ErrUnknownService = errors.New("unknown service")
+
 type IRosProvider interface {
 	CallService(ctx context.Context, srvName string, srv any, req any, res any) error
 	Subscribe(topic string, id string, cb func(msg []byte)) error
+	// SubscribeQueued is like Subscribe but cb receives every message in order instead of the latest one
+	SubscribeQueued(topic string, id string, cb func(msg []byte)) error
 	UnSubscribe(topic string, id string)
-	Publisher(topic string, obj interface{}) (*goroslib.Publisher, error)
+	Publisher(topic string, obj interface{}) (IRosPublisher, error)
+	AdvertiseService(name string, srv any, cb func(req any) (any, error)) error
+	RegisterTopic(topic string, msg any) error
+	MessageType(topic string) (any, error)
+	Replay(enabled bool)
+	// Replaying reports whether the subscribers receive recorded messages instead of the live ones
+	Replaying() bool
+	Inject(topic string, msg []byte)
+	Nodes() ([]RosNode, error)
+	Topics() ([]RosTopic, error)
+	// Services lists the services, their types are probed until ctx is done
+	Services(ctx context.Context) ([]RosService, error)
+	NodePing(node string) (time.Duration, error)
+}
+
+// IRosPublisher publishes messages on a topic, the publisher stays valid when the provider reconnects to ROS
+type IRosPublisher interface {
+	Write(msg interface{})
+	Close()
+}
+
+type RosNode struct {
+	Name          string   `json:"name"`
+	Address       string   `json:"address"`
+	Publications  []string `json:"publications"`
+	Subscriptions []string `json:"subscriptions"`
+	Services      []string `json:"services"`
+}
+
+type RosTopic struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Publishers  []string `json:"publishers"`
+	Subscribers []string `json:"subscribers"`
+}
+
+type RosService struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Address   string   `json:"address"`
+	Providers []string `json:"providers"`
 }
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownRevision = errors.New("unknown settings revision")
+
+// the types of the settings, the values are stored as strings in mower_config.sh
+const (
+	SettingString  = "string"
+	SettingInt     = "int"
+	SettingFloat   = "float"
+	SettingBoolean = "boolean"
+	SettingLat     = "lat"
+	SettingLon     = "lon"
+	SettingSelect  = "select"
+)
+
+// SettingSchema describes an OpenMower setting of mower_config.sh. Min and Max bound the numbers, Options are the
+// values of a select, GreaterThan is a setting the value must be greater than and RequiredWhen the condition making
+// the setting required.
+type SettingSchema struct {
+	Key          string            `json:"key"`
+	Section      string            `json:"section"`
+	Type         string            `json:"type"`
+	Description  string            `json:"description"`
+	Help         string            `json:"help,omitempty"`
+	Default      string            `json:"default"`
+	Min          *float64          `json:"min,omitempty"`
+	Max          *float64          `json:"max,omitempty"`
+	Options      []SettingOption   `json:"options,omitempty"`
+	GreaterThan  string            `json:"greaterThan,omitempty"`
+	RequiredWhen *SettingCondition `json:"requiredWhen,omitempty"`
+}
+
+type SettingOption struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// SettingCondition is true when the setting Key has the value Value
+type SettingCondition struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SettingsRevision is a previous content of mower_config.sh, saved when Author replaced it at Time. Changes are the
+// keys changed by this write.
+type SettingsRevision struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Author  string    `json:"author"`
+	Changes []string  `json:"changes"`
+}
+
+// SettingChange is the change of a setting between two revisions, Change is added, removed or changed
+type SettingChange struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
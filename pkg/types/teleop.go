@@ -0,0 +1,24 @@
+package types
+
+type ITeleopProvider interface {
+	Acquire(driver string) (ITeleopSession, error)
+	Status() TeleopStatus
+}
+
+// ITeleopSession is held by the active driver, velocities are published until Release is called or the watchdog
+// times out
+type ITeleopSession interface {
+	Drive(linear float64, angular float64) error
+	Release()
+}
+
+type TeleopStatus struct {
+	Active        bool     `json:"active"`
+	Driver        string   `json:"driver"`
+	State         string   `json:"state"`
+	Allowed       bool     `json:"allowed"`
+	AllowedStates []string `json:"allowedStates"`
+	MaxLinear     float64  `json:"maxLinear"`
+	MaxAngular    float64  `json:"maxAngular"`
+	Timeout       int      `json:"timeout"`
+}
@@ -36,6 +36,10 @@ export interface ApiOkResponse {
     ok?: string;
 }
 
+export interface ApiSettingsSchemaResponse {
+    settings?: TypesSettingSchema[];
+}
+
 export interface GeometryMsgsPoint {
     "msg.Package"?: number;
     x?: number;
@@ -97,6 +101,30 @@ export interface MowerMapSetDockingPointSrvReq {
     "msg.Package"?: number;
 }
 
+export interface TypesSettingCondition {
+    key?: string;
+    value?: string;
+}
+
+export interface TypesSettingOption {
+    id?: string;
+    label?: string;
+}
+
+export interface TypesSettingSchema {
+    default?: string;
+    description?: string;
+    greaterThan?: string;
+    help?: string;
+    key?: string;
+    max?: number;
+    min?: number;
+    options?: TypesSettingOption[];
+    requiredWhen?: TypesSettingCondition;
+    section?: string;
+    type?: string;
+}
+
 export interface TypesFirmwareConfig {
     batChargeCutoffVoltage?: number;
     boardType?: string;
@@ -592,6 +620,22 @@ export class Api<SecurityDataType extends unknown> extends HttpClient<SecurityDa
               format: "json",
               ...params,
           }),
+
+      /**
+       * @description returns the type, range, options and dependencies of the known settings of the mower_config.sh file, the settings are checked against it when they are saved
+       *
+       * @tags settings
+       * @name SchemaList
+       * @summary returns the schema of the known settings
+       * @request GET:/settings/schema
+       */
+      schemaList: (params: RequestParams = {}) =>
+          this.request<ApiSettingsSchemaResponse, any>({
+              path: `/settings/schema`,
+              method: "GET",
+              format: "json",
+              ...params,
+          }),
   };
     setup = {
         /**
@@ -130,7 +130,7 @@ import React, {CSSProperties, useEffect, useMemo} from "react";
 import type {Form as FormType} from "@formily/core";
 import {createForm, onFieldValueChange} from "@formily/core";
 
-import {SettingsConfig, SettingsDesc, SettingValueType, useSettings} from "../hooks/useSettings.ts";
+import {SettingsConfig, SettingValueType, useSettings} from "../hooks/useSettings.ts";
 
 const SchemaField = createSchemaField({
     components: {
@@ -187,7 +187,7 @@ export const SettingsComponent: React.FC<{
 
     const guiApi = useApi()
     const {notification} = App.useApp();
-    const {settings, setSettings, loading} = useSettings()
+    const {settings, setSettings, loading, settingsDesc} = useSettings()
     useEffect(() => {
         if (settings && Object.keys(settings).length > 0) {
             form.setInitialValues(settings)
@@ -249,8 +249,8 @@ export const SettingsComponent: React.FC<{
         }
     }
 
-    const sections = Object.keys(SettingsDesc).reduce((acc, key) => {
-        const setting = SettingsDesc[key];
+    const sections = Object.keys(settingsDesc).reduce((acc, key) => {
+        const setting = settingsDesc[key];
         if (!acc[setting.section]) {
             acc[setting.section] = []
         }
@@ -268,7 +268,7 @@ export const SettingsComponent: React.FC<{
                                 <Card key={section} title={section} style={{marginBottom: 16}}>
                                     {
                                         sections[section].map(settingKey => {
-                                            const setting = SettingsDesc[settingKey];
+                                            const setting = settingsDesc[settingKey];
                                             switch (setting.type) {
                                                 case SettingValueType.Lat:
                                                     return (
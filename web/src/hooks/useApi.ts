@@ -1,7 +1,14 @@
 import {Api} from "../api/Api.ts";
 
 export const useApi = () => {
-    const api = new Api();
+    // the admin routes and the system.* config keys require the admin token
+    const adminToken = localStorage.getItem("adminToken");
+    const api = new Api(adminToken ? {
+        customFetch: (input, init) => fetch(input, {
+            ...init,
+            headers: {...(init?.headers as Record<string, string>), Authorization: `Bearer ${adminToken}`},
+        }),
+    } : {});
     api.baseUrl = "/api"
     return api;
-}
\ No newline at end of file
+}
@@ -2,6 +2,7 @@ import {useApi} from "./useApi.ts";
 import {App} from "antd";
 import {useEffect, useState} from "react";
 import {useConfig} from "./useConfig.tsx";
+import {TypesSettingSchema} from "../api/Api.ts";
 
 export enum SettingValueType {
     String = "string",
@@ -41,326 +42,9 @@ export type Setting = { settingType: SettingType, description: string, help?: st
     defaultValue: string,
     options: { id: string, label: string }[],
 })
-export const SettingsDesc: Record<string, Setting> = {
-    "OM_DATUM_LAT": {
-        settingType: SettingType.ConfigFile,
-        section: "Datum",
-        type: SettingValueType.Lat,
-        defaultValue: 43.0,
-        description: "Latitude of the datum point",
-        help: "This will be your map origin!"
-    },
-    "OM_DATUM_LONG": {
-        settingType: SettingType.ConfigFile,
-        section: "Datum",
-        type: SettingValueType.Lon,
-        defaultValue: 2.0,
-        description: "Longitude of the datum point",
-        help: "This will be your map origin!"
-    },
-    "OM_USE_NTRIP": {
-        settingType: SettingType.ConfigFile,
-        section: "NTRIP",
-        type: SettingValueType.Boolean,
-        defaultValue: true,
-        description: "Use NTRIP to get RTK corrections",
-        help: "Set to False if using external radio plugged into the Ardusimple board."
-    },
-    "OM_NTRIP_HOSTNAME": {
-        settingType: SettingType.ConfigFile,
-        section: "NTRIP",
-        type: SettingValueType.String,
-        defaultValue: "192.168.178.55",
-        description: "NTRIP server hostname",
-    },
-    "OM_NTRIP_PORT": {
-        settingType: SettingType.ConfigFile,
-        section: "NTRIP", type: SettingValueType.Int, defaultValue: 2101, description: "NTRIP server port"},
-    "OM_NTRIP_USER": {
-        settingType: SettingType.ConfigFile,
-        section: "NTRIP",
-        type: SettingValueType.String,
-        defaultValue: "gps",
-        description: "NTRIP server username"
-    },
-    "OM_NTRIP_PASSWORD": {
-        settingType: SettingType.ConfigFile,
-        section: "NTRIP",
-        type: SettingValueType.String,
-        defaultValue: "gps",
-        description: "NTRIP server password"
-    },
-    "OM_NTRIP_ENDPOINT": {
-        settingType: SettingType.ConfigFile,
-        section: "NTRIP",
-        type: SettingValueType.String,
-        defaultValue: "BASE1",
-        description: "NTRIP server endpoint"
-    },
-    "OM_MOWER_GAMEPAD": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Select, defaultValue: "xbox360", description: "Gamepad type", options: [
-            {
-                id: "xbox360",
-                label: "Xbox 360"
-            },
-            {
-                id: "ps3",
-                label: "PS3"
-            },
-            {
-                id: "shield",
-                label: "Shield"
-            },
-            {
-                id: "steam_stick",
-                label: "Steam Stick"
-            },
-            {
-                id: "steam_touch",
-                label: "Steam Touch"
-            },
-            {
-                id: "switch_pro",
-                label: "Switch Pro"
-            }
-        ]
-    },
-    "OM_ENABLE_RECORDING_ALL": {
-        settingType: SettingType.ConfigFile,
-        section: "Recording",
-        type: SettingValueType.Boolean,
-        defaultValue: false,
-        description: "Enable recording of all topics"
-    },
-    "OM_PERIMETER_SIGNAL": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Boolean,
-        defaultValue: false,
-        description: "Enable perimeter signal"
-    },
-    "OM_USE_RELATIVE_POSITION": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.Boolean,
-        defaultValue: false,
-        description: "Use relative position to datum point",
-        help: "If set to true, the mower will use the position relative to the datum point. If set to false, the mower will use the absolute position."
-    },
-    "OM_GPS_PROTOCOL": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.Select, defaultValue: "UBX", description: "GPS protocol", options: [
-            {
-                id: "UBX",
-                label: "UBX"
-            },
-            {
-                id: "NMEA",
-                label: "NMEA"
-            },
-        ]
-    },
-    "OM_GPS_BAUDRATE": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.Int,
-        defaultValue: 921600,
-        description: "GPS baudrate"
-    },
-    "OM_GPS_PORT": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.String,
-        defaultValue: "/dev/serial/by-id/usb-u-blox_AG_-_www.u-blox.com_u-blox_GNSS_receiver-if00",
-        description: "GPS port (/dev/gps on mowgli)"
-    },
-    "OM_ANTENNA_OFFSET_X": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.Float,
-        defaultValue: 0.3,
-        description: "Antenna offset X"
-    },
-    "OM_ANTENNA_OFFSET_Y": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.Float,
-        defaultValue: 0.0,
-        description: "Antenna offset Y"
-    },
-    "OM_USE_F9R_SENSOR_FUSION": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        help: "If you want to use F9R's sensor fusion, set this to true (you will also need to set DATUM_LAT and DATUM_LONG). If you want to use the GPS position, set this to false.",
-        type: SettingValueType.Boolean, defaultValue: false, description: "Use F9R sensor fusion"
-    },
-    "OM_DOCKING_DISTANCE": {
-        settingType: SettingType.ConfigFile,
-        section: "Docking",
-        type: SettingValueType.Float, defaultValue: 1.0, description: "Distance to dock"
-    },
-    "OM_UNDOCK_DISTANCE": {
-        settingType: SettingType.ConfigFile,
-        section: "Docking",
-        type: SettingValueType.Float, defaultValue: 2.0, description: "Distance to undock"
-    },
-    "OM_DOCKING_EXTRA_TIME": {
-        settingType: SettingType.ConfigFile,
-        section: "Docking",
-        type: SettingValueType.Float, defaultValue: 0.0, description: "Extra time (s) to continue docking after detecting voltage"
-    },
-    "OM_OUTLINE_COUNT": {
-        settingType: SettingType.ConfigFile,
-        section: "Navigation",
-        type: SettingValueType.Int, defaultValue: 4, description: "Number of points in the outline"
-    },
-    "OM_MOWING_ANGLE_OFFSET": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Float, defaultValue: 0, description: "Mowing angle offset"
-    },
-    "OM_MOWING_ANGLE_INCREMENT": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Float, defaultValue: 0.1, description: "Mowing angle increment"
-    },
-    "OM_WHEEL_DISTANCE_M": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Float, defaultValue: 0.325, description: "Distance between wheels in m"
-    },
-    "OM_WHEEL_TICKS_PER_M": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Float, defaultValue: 1600, description: "Wheel ticks per meter"
-    },
-    "OM_MOWING_ANGLE_OFFSET_IS_ABSOLUTE": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Boolean,
-        defaultValue: false,
-        description: "Mowing angle offset is absolute"
-    },
-    "OM_TOOL_WIDTH": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        help: "Choose it smaller than your actual mowing tool in order to have some overlap.",
-        type: SettingValueType.Float, defaultValue: 0.13, description: "Tool width"
-    },
-    "OM_BATTERY_EMPTY_VOLTAGE": {
-        settingType: SettingType.ConfigFile,
-        help: "Voltage for battery to be considered empty",
-        section: "Mower",
-        type: SettingValueType.Float, defaultValue: 25.0, description: "Battery empty voltage"
-    },
-    "OM_BATTERY_FULL_VOLTAGE": {
-        settingType: SettingType.ConfigFile,
-        help: "Voltage for battery to be considered full",
-        section: "Mower",
-        type: SettingValueType.Float, defaultValue: 28.5, description: "Battery full voltage"
-    },
-    "OM_BATTERY_CAPACITY_MAH": {
-        settingType: SettingType.ConfigFile,
-        help: "Battery capacity in mAh",
-        section: "Mower",
-        type: SettingValueType.Float, defaultValue: 3000.0, description: "Battery capacity"
-    },
-    "OM_MOWING_MOTOR_TEMP_HIGH": {
-        settingType: SettingType.ConfigFile,
-        help: "If the temperature of the mowing motor is higher than this value, the mower will stop.",
-        section: "Mower",
-        type: SettingValueType.Float,
-        defaultValue: 80.0,
-        description: "Mowing motor temperature high"
-    },
-    "OM_MOWING_MOTOR_TEMP_LOW": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        help: "If the temperature of the mowing motor is lower than this value, the mower will start again.",
-        type: SettingValueType.Float,
-        defaultValue: 40.0,
-        description: "Mowing motor temperature low"
-    },
-    "OM_GPS_WAIT_TIME_SEC": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.Float, defaultValue: 10.0, description: "GPS wait time"
-    },
-    "OM_GPS_TIMEOUT_SEC": {
-        settingType: SettingType.ConfigFile,
-        section: "Positioning",
-        type: SettingValueType.Float, defaultValue: 5.0, description: "GPS timeout"
-    },
-    "OM_ENABLE_MOWER": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Boolean, defaultValue: true, description: "Enable mower"
-    },
-    "OM_AUTOMATIC_MODE": {
-        settingType: SettingType.ConfigFile,
-        section: "Mower",
-        type: SettingValueType.Select, defaultValue: "0", description: "Automatic mode", options: [
-            {
-                id: "0",
-                label: "MANUAL - mowing requires manual start"
-            },
-            {
-                id: "1",
-                label: "SEMIAUTO - mow the entire map once then wait for manual start atgain"
-            },
-            {
-                id: "2",
-                label: "AUTO - mow whenever possible"
-            }
-        ]
-    },
-    "OM_OUTLINE_OFFSET": {
-        settingType: SettingType.ConfigFile,
-        section: "Navigation",
-        help: "Offset of the outline from the boundary in meters.",
-        type: SettingValueType.Float, defaultValue: 0.05, description: "Outline offset"
-    },
-    "OM_OUTLINE_OVERLAP_COUNT": {
-        settingType: SettingType.ConfigFile,
-        section: "Navigation",
-        help: "Number of points in the overlap",
-        type: SettingValueType.Int, defaultValue: 0, description: "How many outlines should the fill (lanes) overlap"
-    },
-    "OM_MQTT_ENABLE": {
-        settingType: SettingType.ConfigFile,
-        section: "OpenMower MQTT",
-        type: SettingValueType.Boolean, defaultValue: false, description: "Enable OpenMower MQTT"
-    },
-    "OM_MQTT_HOSTNAME": {
-        settingType: SettingType.ConfigFile,
-        section: "OpenMower MQTT",
-        type: SettingValueType.String,
-        defaultValue: "your_mqtt_broker",
-        description: "MQTT broker hostname"
-    },
-    "OM_MQTT_PORT": {
-        settingType: SettingType.ConfigFile,
-        section: "OpenMower MQTT",
-        type: SettingValueType.Int, defaultValue: 1883, description: "MQTT broker port"
-    },
-    "OM_MQTT_USER": {
-        settingType: SettingType.ConfigFile,
-        section: "OpenMower MQTT",
-        type: SettingValueType.String, defaultValue: "", description: "MQTT broker username"
-    },
-    "OM_MQTT_PASSWORD": {
-        settingType: SettingType.ConfigFile,
-        section: "OpenMower MQTT",
-        type: SettingValueType.String, defaultValue: "", description: "MQTT broker password"
-    },
-    "OM_MQTT_TOPIC_PREFIX=": {
-        settingType: SettingType.ConfigFile,
-        section: "OpenMower MQTT",
-        type: SettingValueType.String, defaultValue: "", description: "MQTT topic prefix"
-    },
+// DbSettingsDesc are the settings of the GUI stored in its DB, the settings of the mower_config.sh file are described by
+// the schema of the API
+const DbSettingsDesc: Record<string, Setting> = {
     "system.api.addr": {
         settingType: SettingType.Db,
         section: "API",
@@ -456,12 +140,35 @@ export const SettingsDesc: Record<string, Setting> = {
         description: "ROS node host",
     }
 }
-export type SettingsConfig = {
-    [P in keyof typeof SettingsDesc]: Record<P, typeof SettingsDesc[P]['defaultValue']>;
+export type SettingsConfig = Record<string, any>
+const SettingKeysFromDB = Object.keys(DbSettingsDesc)
+// settingFromSchema converts a setting of the API schema, whose default is the string written in mower_config.sh
+const settingFromSchema = (schema: TypesSettingSchema): Setting => {
+    const desc = {
+        settingType: SettingType.ConfigFile,
+        section: schema.section ?? "",
+        description: schema.description ?? "",
+        help: schema.help,
+    }
+    switch (schema.type) {
+        case SettingValueType.Boolean:
+            return {...desc, type: SettingValueType.Boolean, defaultValue: schema.default?.toLowerCase() === "true"}
+        case SettingValueType.Int:
+        case SettingValueType.Float:
+        case SettingValueType.Lat:
+        case SettingValueType.Lon:
+            return {...desc, type: schema.type, defaultValue: Number(schema.default ?? 0)}
+        case SettingValueType.Select:
+            return {
+                ...desc,
+                type: SettingValueType.Select,
+                defaultValue: schema.default ?? "",
+                options: (schema.options ?? []).map((option) => ({id: option.id ?? "", label: option.label ?? ""})),
+            }
+        default:
+            return {...desc, type: SettingValueType.String, defaultValue: schema.default ?? ""}
+    }
 }
-const SettingKeysFromDB = Object.keys(SettingsDesc).filter((key) => {
-    return SettingsDesc[key].settingType === SettingType.Db
-})
 const flattenConfig = (newConfig: Record<string, any>): Record<string, any> => {
     const flatConfig: Record<string, any> = {}
     Object.keys(newConfig).forEach((key) => {
@@ -482,12 +189,14 @@ export const useSettings = () => {
     const {notification} = App.useApp();
     const db = useConfig(SettingKeysFromDB)
     const [loading, setLoading] = useState<boolean>(false)
-    const [settings, setSettings] = useState<Record<keyof typeof SettingsDesc, any>>({})
+    const [settings, setSettings] = useState<SettingsConfig>({})
+    // the settings of the mower_config.sh file are added once the schema is loaded
+    const [settingsDesc, setSettingsDesc] = useState<Record<string, Setting>>(DbSettingsDesc)
     useEffect(() => {
         if (db.config) {
             const newSettings: Record<string, any> = {}
             Object.keys(db.config).forEach((key) => {
-                if (SettingsDesc[key]?.type === SettingValueType.Boolean) {
+                if (DbSettingsDesc[key]?.type === SettingValueType.Boolean) {
                     if (db.config[key] === "true") {
                         newSettings[key] = true
                     } else if (db.config[key] === "false") {
@@ -506,6 +215,17 @@ export const useSettings = () => {
         (async () => {
             try {
                 setLoading(true)
+                const schema = await guiApi.settings.schemaList()
+                if (schema.error) {
+                    throw new Error(schema.error.error)
+                }
+                const configFileDesc: Record<string, Setting> = {}
+                schema.data.settings?.forEach((setting) => {
+                    if (setting.key) {
+                        configFileDesc[setting.key] = settingFromSchema(setting)
+                    }
+                })
+                setSettingsDesc({...configFileDesc, ...DbSettingsDesc})
                 const settingsList = await guiApi.settings.settingsList()
                 if (settingsList.error) {
                     throw new Error(settingsList.error.error)
@@ -514,7 +234,7 @@ export const useSettings = () => {
                 const fetchedSettings = settingsList.data.settings ?? {};
                 const newSettings: Record<string, any> = {}
                 Object.keys(fetchedSettings).forEach((key) => {
-                    if (SettingsDesc[key]?.type === SettingValueType.Boolean) {
+                    if (configFileDesc[key]?.type === SettingValueType.Boolean) {
                         if (fetchedSettings[key] === "True" || fetchedSettings[key] == "1") {
                             newSettings[key] = true
                         } else if (fetchedSettings[key] === "False" || fetchedSettings[key] == "0") {
@@ -541,14 +261,14 @@ export const useSettings = () => {
             newConfig = flattenConfig(newConfig)
             setLoading(true)
             const configFiltered = Object.keys(newConfig).reduce((acc, key) => {
-                if (SettingsDesc[key]?.settingType === SettingType.ConfigFile) {
+                if (settingsDesc[key]?.settingType === SettingType.ConfigFile) {
                     acc[key] = newConfig[key]
                 }
                 return acc
             }, {} as SettingsConfig)
             const dbFiltered = Object.keys(newConfig).reduce((acc, key) => {
-                if (SettingsDesc[key]?.settingType === SettingType.Db) {
-                    if (SettingsDesc[key]?.type === SettingValueType.Boolean) {
+                if (settingsDesc[key]?.settingType === SettingType.Db) {
+                    if (settingsDesc[key]?.type === SettingValueType.Boolean) {
                         acc[key] = newConfig[key].toString()
                     } else {
                         acc[key] = newConfig[key]
@@ -573,5 +293,5 @@ export const useSettings = () => {
             setLoading(false)
         }
     };
-    return {settings, setSettings: handleSetConfig, loading}
+    return {settings, setSettings: handleSetConfig, loading, settingsDesc}
 }